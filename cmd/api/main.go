@@ -59,7 +59,10 @@ func main() {
 	slog.Info("Database connected", "url", cfg.DBUrl)
 
 	// 3. Wiring Layers
-	queries := database.New(db)
+	// Queries run in the transaction of their context, so services and
+	// batches can group writes with the TxManager
+	queries := database.New(repository.NewContextDB(db))
+	txManager := repository.NewSQLTxManager(db)
 
 	// Repositories
 	userRepo := repository.NewSQLUserRepository(queries)
@@ -71,40 +74,93 @@ func main() {
 	sessionPauseRepo := repository.NewSQLSessionPauseRepository(queries)
 	exerciseLogRepo := repository.NewSQLExerciseLogRepository(queries)
 	analyticsRepo := repository.NewSQLAnalyticsRepository(queries)
+	tokenRepo := repository.NewSQLPersonalAccessTokenRepository(queries)
+	goalRepo := repository.NewSQLGoalRepository(queries)
+	recommendationRepo := repository.NewSQLRecommendationRepository(queries)
+	dashboardRepo := repository.NewSQLDashboardRepository(db, queries)
+	exportRepo := repository.NewSQLExportRepository(queries)
+	backupRepo := repository.NewSQLBackupRepository(db, queries)
+	accountDeletionRepo := repository.NewSQLAccountDeletionRepository(db, queries)
+	trashRepo := repository.NewSQLTrashRepository(db, queries)
+	mergeRepo := repository.NewSQLMergeRepository(db, queries)
+	idempotencyRepo := repository.NewSQLIdempotencyRepository(queries)
+	syncRepo := repository.NewSQLSyncRepository(db, queries)
 
 	// Services
-	userService := service.NewUserManager(userRepo)
+	userService := service.NewUserManager(userRepo).WithLockoutPolicy(service.LockoutPolicy{
+		Threshold:    cfg.LockoutThreshold,
+		BaseDuration: cfg.LockoutDuration,
+		MaxDuration:  service.DefaultLockoutPolicy.MaxDuration,
+	})
 	subjectService := service.NewSubjectManager(subjectRepo)
 	topicService := service.NewTopicManager(topicRepo)
-	studyCycleService := service.NewStudyCycleManager(studyCycleRepo)
+	studyCycleService := service.NewStudyCycleManager(studyCycleRepo, txManager)
 	cycleItemService := service.NewCycleItemManager(cycleItemRepo)
-	studySessionService := service.NewStudySessionManager(studySessionRepo)
+	studySessionService := service.NewStudySessionManager(studySessionRepo, txManager)
 	sessionPauseService := service.NewSessionPauseManager(sessionPauseRepo)
 	exerciseLogService := service.NewExerciseLogManager(exerciseLogRepo)
 	analyticsService := service.NewAnalyticsManager(analyticsRepo)
+	tokenService := service.NewPersonalAccessTokenManager(tokenRepo)
+	goalService := service.NewGoalManager(goalRepo, analyticsService)
+	recommendationService := service.NewRecommendationManager(recommendationRepo, analyticsService)
+	dashboardService := service.NewDashboardManager(dashboardRepo)
+	exportService := service.NewExportManager(exportRepo, analyticsService)
+	backupService := service.NewBackupManager(backupRepo)
+	accountDeletionService := service.NewAccountDeletionManager(accountDeletionRepo).WithDefaultGrace(cfg.AccountDeletionGrace)
+	trashService := service.NewTrashManager(trashRepo).WithRetention(cfg.TrashRetention)
+	mergeService := service.NewMergeManager(mergeRepo)
+	idempotencyService := service.NewIdempotencyManager(idempotencyRepo).WithTTL(cfg.IdempotencyTTL)
+	syncService := service.NewSyncManager(syncRepo).WithRetention(cfg.SyncRetention)
 
 	// Handlers
 	authHandler := handler.NewAuthHandler(userService, cfg)
 	userHandler := handler.NewUserHandler(userService)
-	subjectHandler := handler.NewSubjectHandler(subjectService)
-	topicHandler := handler.NewTopicHandler(topicService)
+	subjectHandler := handler.NewSubjectHandler(subjectService).WithExports(exportService)
+	topicHandler := handler.NewTopicHandler(topicService).WithExports(exportService)
 	studyCycleHandler := handler.NewStudyCycleHandler(studyCycleService)
-	cycleItemHandler := handler.NewCycleItemHandler(cycleItemService)
+	cycleItemHandler := handler.NewCycleItemHandler(cycleItemService).WithExports(exportService)
 	studySessionHandler := handler.NewStudySessionHandler(studySessionService)
 	sessionPauseHandler := handler.NewSessionPauseHandler(sessionPauseService)
 	exerciseLogHandler := handler.NewExerciseLogHandler(exerciseLogService)
-	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService).WithExports(exportService)
+	tokenHandler := handler.NewTokenHandler(tokenService)
+	goalHandler := handler.NewGoalHandler(goalService).WithExports(exportService)
+	recommendationHandler := handler.NewRecommendationHandler(recommendationService)
+	dashboardHandler := handler.NewDashboardHandler(dashboardService)
+	exportHandler := handler.NewExportHandler(exportService)
+	backupHandler := handler.NewBackupHandler(backupService)
+	accountDeletionHandler := handler.NewAccountDeletionHandler(accountDeletionService)
+	trashHandler := handler.NewTrashHandler(trashService)
+	mergeHandler := handler.NewMergeHandler(mergeService)
+	syncHandler := handler.NewSyncHandler(syncService)
 
 	// 4. Router Setup
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(customMiddleware.RealIP(cfg.TrustedProxies))
 	r.Use(customMiddleware.RequestLogger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 
-	// Middleware Initialization (JWT)
-	jwtAuth := customMiddleware.NewJWTAuthMiddleware(cfg)
+	// Middleware Initialization (JWT or personal access token)
+	jwtAuth := customMiddleware.NewJWTAuthMiddleware(cfg, tokenService).WithUsers(userService)
+
+	// Rate Limiting (token buckets keyed by IP, account and user)
+	rateStore := customMiddleware.NewMemoryStore()
+	loginByIP := customMiddleware.NewRateLimiter(rateStore, "login-ip", customMiddleware.PerMinute(cfg.LoginRateLimit), customMiddleware.KeyByIP)
+	loginByAccount := customMiddleware.NewRateLimiter(rateStore, "login-account", customMiddleware.PerMinute(cfg.LoginRateLimit), customMiddleware.KeyByAccount)
+	apiLimit := customMiddleware.NewRateLimiter(rateStore, "api", customMiddleware.PerMinute(cfg.APIRateLimit), customMiddleware.KeyByUser)
+
+	// Optimistic concurrency: versioned updates may have to send If-Match
+	requireIfMatch := customMiddleware.RequireIfMatch(cfg.RequireIfMatch)
+
+	// Retried POSTs with an Idempotency-Key replay the first response. Not on
+	// /tokens: the response holds a secret that mustn't be stored.
+	idempotency := customMiddleware.NewIdempotencyMiddleware(idempotencyService)
+
+	// Batches are replayed through this router, so the handler is only
+	// built once it exists
+	batchHandler := handler.NewBatchHandler(r, txManager)
 
 	// Documentation Routes
 	r.Get("/swagger/doc.json", func(w http.ResponseWriter, r *http.Request) {
@@ -119,60 +175,89 @@ func main() {
 	// --- Public Routes ---
 
 	// Authentication (Added this line to fix the error)
-	r.Post("/login", authHandler.Login)
+	r.With(loginByIP.Throttle, loginByAccount.Throttle).Post("/login", authHandler.Login)
+
+	// Deletion reports, authorized by their receipt so they outlive the account
+	r.Get("/account-deletions/{id}", accountDeletionHandler.GetDeletionReport)
 
 	r.Route("/users", func(r chi.Router) {
-		r.Post("/", userHandler.CreateUser)
-		r.Get("/{email}", userHandler.GetUser)
+		r.With(loginByIP.Throttle).Post("/", userHandler.CreateUser)
 
-		// Protected route
+		// Protected routes
 		r.Group(func(r chi.Router) {
-			r.Use(jwtAuth.Protected)
+			r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("account"))
+			r.Get("/{email}", userHandler.GetUser)
 			r.Put("/password", userHandler.ChangePassword)
 		})
 	})
 
 	// --- Protected Routes (Require Valid JWT) ---
 
+	r.Route("/me", func(r chi.Router) {
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("account"), idempotency.Handle)
+		r.Get("/", userHandler.GetMe)
+		r.Patch("/", userHandler.UpdateMe)
+		// Backups hold all study data, so tokens need every scope it touches
+		// as well, like /sync
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.RequireScope("subjects"), customMiddleware.RequireScope("cycles"),
+				customMiddleware.RequireScope("sessions"))
+			r.Get("/export", backupHandler.ExportAccount)
+			r.Post("/import", backupHandler.ImportAccount)
+		})
+		r.Delete("/", accountDeletionHandler.DeleteMe)
+		r.Get("/deletion", accountDeletionHandler.GetMyDeletion)
+		r.Post("/restore", accountDeletionHandler.RestoreMe)
+	})
+
 	r.Route("/subjects", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("subjects"), idempotency.Handle)
 		r.Post("/", subjectHandler.CreateSubject)
 		r.Get("/", subjectHandler.ListSubjects)
 		r.Get("/{id}", subjectHandler.GetSubject)
-		r.Put("/{id}", subjectHandler.UpdateSubject)
+		r.With(requireIfMatch).Put("/{id}", subjectHandler.UpdateSubject)
+		r.With(requireIfMatch).Patch("/{id}", subjectHandler.PatchSubject)
 		r.Delete("/{id}", subjectHandler.DeleteSubject)
+		r.Post("/{id}/restore", trashHandler.RestoreSubject)
+		r.Post("/{id}/merge", mergeHandler.MergeSubjects)
 		r.Post("/{id}/topics", topicHandler.CreateTopic)
 		r.Get("/{id}/topics", topicHandler.ListTopics)
 	})
 
 	r.Route("/topics", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("subjects"), idempotency.Handle)
 		r.Get("/{id}", topicHandler.GetTopic)
-		r.Put("/{id}", topicHandler.UpdateTopic)
+		r.With(requireIfMatch).Put("/{id}", topicHandler.UpdateTopic)
+		r.With(requireIfMatch).Patch("/{id}", topicHandler.PatchTopic)
 		r.Delete("/{id}", topicHandler.DeleteTopic)
+		r.Post("/{id}/restore", trashHandler.RestoreTopic)
+		r.Post("/{id}/merge", mergeHandler.MergeTopics)
 	})
 
 	r.Route("/study-cycles", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("cycles"), idempotency.Handle)
 		r.Post("/", studyCycleHandler.CreateStudyCycle)
 		r.Get("/active", studyCycleHandler.GetActiveStudyCycle)
 		r.Get("/active/items", studyCycleHandler.GetActiveCycleWithItems)
 		r.Get("/{id}", studyCycleHandler.GetStudyCycle)
-		r.Put("/{id}", studyCycleHandler.UpdateStudyCycle)
+		r.With(requireIfMatch).Put("/{id}", studyCycleHandler.UpdateStudyCycle)
+		r.With(requireIfMatch).Patch("/{id}", studyCycleHandler.PatchStudyCycle)
 		r.Delete("/{id}", studyCycleHandler.DeleteStudyCycle)
+		r.Post("/{id}/restore", trashHandler.RestoreStudyCycle)
 		r.Post("/{id}/items", cycleItemHandler.CreateCycleItem)
 		r.Get("/{id}/items", cycleItemHandler.ListCycleItems)
 	})
 
 	r.Route("/cycle-items", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("cycles"))
 		r.Get("/{id}", cycleItemHandler.GetCycleItem)
-		r.Put("/{id}", cycleItemHandler.UpdateCycleItem)
+		r.With(requireIfMatch).Put("/{id}", cycleItemHandler.UpdateCycleItem)
+		r.With(requireIfMatch).Patch("/{id}", cycleItemHandler.PatchCycleItem)
 		r.Delete("/{id}", cycleItemHandler.DeleteCycleItem)
 	})
 
 	r.Route("/study-sessions", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("sessions"), idempotency.Handle)
 		r.Post("/", studySessionHandler.CreateStudySession)
 		r.Get("/open", studySessionHandler.GetOpenSession)
 		r.Get("/{id}", studySessionHandler.GetStudySession)
@@ -181,7 +266,7 @@ func main() {
 	})
 
 	r.Route("/session-pauses", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("sessions"), idempotency.Handle)
 		r.Post("/", sessionPauseHandler.CreateSessionPause)
 		r.Get("/{id}", sessionPauseHandler.GetSessionPause)
 		r.Put("/{id}/end", sessionPauseHandler.EndSessionPause)
@@ -189,7 +274,7 @@ func main() {
 	})
 
 	r.Route("/exercise-logs", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("sessions"), idempotency.Handle)
 		r.Post("/", exerciseLogHandler.CreateExerciseLog)
 		r.Get("/{id}", exerciseLogHandler.GetExerciseLog)
 		r.Delete("/{id}", exerciseLogHandler.DeleteExerciseLog)
@@ -197,11 +282,70 @@ func main() {
 
 	// Analytics routes
 	r.Route("/analytics", func(r chi.Router) {
-		r.Use(jwtAuth.Protected)
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("analytics"))
 		r.Get("/time-by-subject", analyticsHandler.GetTimeReport)
 		r.Get("/accuracy-by-subject", analyticsHandler.GetGlobalAccuracy)
 		r.Get("/accuracy-by-topic/{subject_id}", analyticsHandler.GetWeakPoints)
+		r.Get("/weak-points", analyticsHandler.RankWeakPoints)
 		r.Get("/heatmap", analyticsHandler.GetHeatmap)
+		r.Get("/trends", analyticsHandler.GetTrends)
+		r.Get("/focus", analyticsHandler.GetFocus)
+		r.Get("/productivity", analyticsHandler.GetProductivity)
+		r.Get("/balance", analyticsHandler.GetStudyBalance)
+		r.Get("/compare", analyticsHandler.Compare)
+		r.Get("/readiness", analyticsHandler.GetReadiness)
+		r.Get("/streaks", goalHandler.GetStreaks)
+	})
+
+	r.Route("/goals", func(r chi.Router) {
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("goals"), idempotency.Handle)
+		r.Post("/", goalHandler.CreateGoal)
+		r.Get("/", goalHandler.ListGoals)
+		r.Get("/{id}", goalHandler.GetGoal)
+		r.Put("/{id}", goalHandler.UpdateGoal)
+		r.Delete("/{id}", goalHandler.DeleteGoal)
+	})
+
+	// The trash spans subjects and study cycles, so tokens need both scopes
+	r.With(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("subjects"), customMiddleware.RequireScope("cycles")).
+		Get("/trash", trashHandler.ListTrash)
+
+	// Sync spans all study data, so tokens need every scope it touches; goals
+	// are only read
+	r.Route("/sync", func(r chi.Router) {
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("subjects"),
+			customMiddleware.RequireScope("cycles"), customMiddleware.RequireScope("sessions"))
+		r.With(customMiddleware.RequireScope("goals")).Get("/changes", syncHandler.Changes)
+		r.With(idempotency.Handle).Post("/push", syncHandler.Push)
+	})
+
+	// Each operation of a batch goes through its own route's scope check
+	r.With(jwtAuth.Protected, apiLimit.Throttle, idempotency.Handle).Post("/batch", batchHandler.Batch)
+
+	r.Route("/dashboard", func(r chi.Router) {
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("analytics"))
+		r.Get("/summary", dashboardHandler.GetSummary)
+	})
+
+	r.Route("/export", func(r chi.Router) {
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("sessions"))
+		r.Get("/study-sessions", exportHandler.ExportSessions)
+		r.Get("/exercise-logs", exportHandler.ExportExerciseLogs)
+	})
+
+	r.Route("/recommendations", func(r chi.Router) {
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("analytics"))
+		r.Get("/next", recommendationHandler.NextRecommendations)
+		r.Get("/weights", recommendationHandler.GetWeights)
+		r.Put("/weights", recommendationHandler.UpdateWeights)
+	})
+
+	// Personal access tokens
+	r.Route("/tokens", func(r chi.Router) {
+		r.Use(jwtAuth.Protected, apiLimit.Throttle, customMiddleware.RequireScope("tokens"))
+		r.Post("/", tokenHandler.CreateToken)
+		r.Get("/", tokenHandler.ListTokens)
+		r.Delete("/{id}", tokenHandler.RevokeToken)
 	})
 
 	// Serve Static Web Files
@@ -223,12 +367,20 @@ func main() {
 	}()
 	slog.Info("Server is ready to handle requests")
 
+	// Background jobs, stopped on shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	go runPurges(jobsCtx, cfg.PurgeInterval, "deleted accounts", accountDeletionService.PurgeDue)
+	go runPurges(jobsCtx, cfg.PurgeInterval, "trash", trashService.PurgeExpired)
+	go runPurges(jobsCtx, cfg.PurgeInterval, "idempotency keys", idempotencyService.PurgeExpired)
+	go runPurges(jobsCtx, cfg.PurgeInterval, "sync changes", syncService.CompactChanges)
+
 	// 6. Graceful Shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
 	sig := <-quit
 	slog.Info("Shutting down server...", "signal", sig.String())
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -240,6 +392,33 @@ func main() {
 	slog.Info("Server exited properly")
 }
 
+// runPurges runs purge every interval until ctx is cancelled; what names what
+// it purges in the logs. A non-positive interval disables the job.
+func runPurges(ctx context.Context, interval time.Duration, what string, purge func(context.Context, time.Time) (int, error)) {
+	if interval <= 0 {
+		slog.Warn("Purge job disabled", "what", what, "interval", interval)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := purge(ctx, now)
+			if err != nil {
+				slog.Error("Purge failed", "what", what, "error", err)
+				continue
+			}
+			if purged > 0 {
+				slog.Info("Purged", "what", what, "count", purged)
+			}
+		}
+	}
+}
+
 // FileServer convenience helper to serve static files
 func FileServer(r chi.Router, path string, root http.FileSystem) {
 	if strings.ContainsAny(path, "{}*") {
@@ -0,0 +1,185 @@
+// Package backup reads and writes account archives: everything a user studied,
+// as versioned JSON that another instance can import.
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// Kind marks a file as an account archive
+	Kind = "my-api/account"
+	// Version is the archive layout written by this build; older ones still read
+	Version = 1
+
+	ContentTypeJSON = "application/json"
+	ContentTypeZip  = "application/zip"
+
+	// archiveFile is the archive's name inside a zip
+	archiveFile = "account.json"
+)
+
+var (
+	ErrInvalidArchive     = errors.New("not an account archive")
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+)
+
+// Archive is an account's study data. IDs are the exporting instance's and
+// only link rows within the archive; timestamps are kept as stored.
+type Archive struct {
+	Kind          string         `json:"kind"`
+	Version       int            `json:"version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Subjects      []Subject      `json:"subjects"`
+	Topics        []Topic        `json:"topics"`
+	StudyCycles   []StudyCycle   `json:"study_cycles"`
+	CycleItems    []CycleItem    `json:"cycle_items"`
+	StudySessions []StudySession `json:"study_sessions"`
+	SessionPauses []SessionPause `json:"session_pauses"`
+	ExerciseLogs  []ExerciseLog  `json:"exercise_logs"`
+}
+
+type Subject struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	ColorHex   *string  `json:"color_hex,omitempty"`
+	ExamWeight *float64 `json:"exam_weight,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+	DeletedAt  *string  `json:"deleted_at,omitempty"`
+}
+
+type Topic struct {
+	ID        string  `json:"id"`
+	SubjectID string  `json:"subject_id"`
+	Name      string  `json:"name"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+	DeletedAt *string `json:"deleted_at,omitempty"`
+}
+
+type StudyCycle struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	IsActive    bool    `json:"is_active"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+	DeletedAt   *string `json:"deleted_at,omitempty"`
+}
+
+type CycleItem struct {
+	ID                     string `json:"id"`
+	CycleID                string `json:"cycle_id"`
+	SubjectID              string `json:"subject_id"`
+	OrderIndex             int64  `json:"order_index"`
+	PlannedDurationMinutes *int64 `json:"planned_duration_minutes,omitempty"`
+	CreatedAt              string `json:"created_at"`
+	UpdatedAt              string `json:"updated_at"`
+}
+
+type StudySession struct {
+	ID                   string  `json:"id"`
+	SubjectID            string  `json:"subject_id"`
+	CycleItemID          *string `json:"cycle_item_id,omitempty"`
+	StartedAt            string  `json:"started_at"`
+	FinishedAt           *string `json:"finished_at,omitempty"`
+	GrossDurationSeconds int64   `json:"gross_duration_seconds"`
+	NetDurationSeconds   int64   `json:"net_duration_seconds"`
+	Notes                *string `json:"notes,omitempty"`
+	CreatedAt            string  `json:"created_at"`
+	UpdatedAt            string  `json:"updated_at"`
+}
+
+type SessionPause struct {
+	ID        string  `json:"id"`
+	SessionID string  `json:"session_id"`
+	StartedAt string  `json:"started_at"`
+	EndedAt   *string `json:"ended_at,omitempty"`
+}
+
+type ExerciseLog struct {
+	ID             string  `json:"id"`
+	SessionID      *string `json:"session_id,omitempty"`
+	SubjectID      string  `json:"subject_id"`
+	TopicID        *string `json:"topic_id,omitempty"`
+	QuestionsCount int64   `json:"questions_count"`
+	CorrectCount   int64   `json:"correct_count"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+// Write encodes the archive as indented JSON, or as a zip holding that JSON
+func Write(w io.Writer, archive Archive, zipped bool) error {
+	if !zipped {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(archive)
+	}
+
+	zw := zip.NewWriter(w)
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: archiveFile, Method: zip.Deflate, Modified: archive.ExportedAt})
+	if err != nil {
+		return err
+	}
+	if err := Write(f, archive, false); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read decodes an archive written by Write, JSON or zip, reading at most limit
+// bytes. Archives from newer versions are refused rather than half imported.
+func Read(r io.Reader, limit int64) (Archive, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return Archive{}, err
+	}
+	if int64(len(data)) > limit {
+		return Archive{}, fmt.Errorf("%w: larger than %d bytes", ErrInvalidArchive, limit)
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		if data, err = unzip(data, limit); err != nil {
+			return Archive{}, err
+		}
+	}
+
+	var archive Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return Archive{}, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if archive.Kind != Kind {
+		return Archive{}, ErrInvalidArchive
+	}
+	if archive.Version < 1 || archive.Version > Version {
+		return Archive{}, fmt.Errorf("%w: %d (this server reads up to %d)", ErrUnsupportedVersion, archive.Version, Version)
+	}
+	return archive, nil
+}
+
+func unzip(data []byte, limit int64) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	f, err := zr.Open(archiveFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no %s in the zip", ErrInvalidArchive, archiveFile)
+	}
+	defer f.Close()
+
+	data, err = io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrInvalidArchive, limit)
+	}
+	return data, nil
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +17,34 @@ type Config struct {
 	Env       string
 	JWTSecret string
 	Timeout   time.Duration
+
+	// Rate limiting: requests per minute allowed for each client key
+	LoginRateLimit int
+	APIRateLimit   int
+
+	// Progressive lockout after repeated failed logins
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+
+	// Account deletion and trash: how long deleted accounts and items can be
+	// restored, and how often what's past that is purged (never if <= 0)
+	AccountDeletionGrace time.Duration
+	TrashRetention       time.Duration
+	PurgeInterval        time.Duration
+
+	// Updates of versioned rows must send If-Match when set
+	RequireIfMatch bool
+
+	// How long responses are replayed to POSTs retried with an Idempotency-Key
+	IdempotencyTTL time.Duration
+
+	// How long changes superseded by a later change of the same row stay in
+	// the sync feed before the purge jobs compact them away
+	SyncRetention time.Duration
+
+	// Reverse proxies whose X-Forwarded-For and X-Real-IP headers are
+	// believed; requests from anywhere else are known by their own address
+	TrustedProxies []netip.Prefix
 }
 
 func Load() (*Config, error) {
@@ -26,7 +57,26 @@ func Load() (*Config, error) {
 		Env:       getEnv("ENV", "development"),
 		JWTSecret: getEnv("JWT_SECRET", "super-secret-key-change-me"),
 		Timeout:   5 * time.Second,
+
+		LoginRateLimit:   getEnvInt("RATE_LIMIT_LOGIN", 10),
+		APIRateLimit:     getEnvInt("RATE_LIMIT_API", 300),
+		LockoutThreshold: getEnvInt("LOCKOUT_THRESHOLD", 5),
+		LockoutDuration:  getEnvDuration("LOCKOUT_DURATION", time.Minute),
+
+		AccountDeletionGrace: getEnvDuration("ACCOUNT_DELETION_GRACE", 7*24*time.Hour),
+		TrashRetention:       getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval:        getEnvDuration("PURGE_INTERVAL", time.Hour),
+
+		RequireIfMatch: getEnvBool("REQUIRE_IF_MATCH", false),
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		SyncRetention:  getEnvDuration("SYNC_RETENTION", 30*24*time.Hour),
+	}
+
+	trusted, err := parsePrefixes(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
 	}
+	cfg.TrustedProxies = trusted
 
 	// Database Connection Logic
 	if cfg.DBUrl == "" {
@@ -53,3 +103,55 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+// parsePrefixes reads a comma-separated list of IPs and CIDR ranges
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			addr, err := netip.ParseAddr(field)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
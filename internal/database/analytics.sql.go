@@ -23,12 +23,21 @@ SELECT
     ) AS accuracy_percentage
 FROM subjects s
 LEFT JOIN exercise_logs el ON s.id = el.subject_id
-WHERE s.deleted_at IS NULL
+    AND datetime(el.created_at) >= datetime(CAST(?1 AS TEXT))
+    AND datetime(el.created_at) < datetime(CAST(?2 AS TEXT))
+WHERE s.user_id = ?3
+  AND s.deleted_at IS NULL
 GROUP BY s.id, s.name, s.color_hex
 HAVING total_questions > 0
 ORDER BY accuracy_percentage ASC
 `
 
+type GetAccuracyBySubjectParams struct {
+	RangeFrom string `json:"range_from"`
+	RangeTo   string `json:"range_to"`
+	UserID    string `json:"user_id"`
+}
+
 type GetAccuracyBySubjectRow struct {
 	SubjectID          string          `json:"subject_id"`
 	SubjectName        string          `json:"subject_name"`
@@ -38,8 +47,9 @@ type GetAccuracyBySubjectRow struct {
 	AccuracyPercentage float64         `json:"accuracy_percentage"`
 }
 
-func (q *Queries) GetAccuracyBySubject(ctx context.Context) ([]GetAccuracyBySubjectRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAccuracyBySubject)
+// Exercise logs created in [range_from, range_to), bounds as in ListFinishedSessionsInRange
+func (q *Queries) GetAccuracyBySubject(ctx context.Context, arg GetAccuracyBySubjectParams) ([]GetAccuracyBySubjectRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccuracyBySubject, arg.RangeFrom, arg.RangeTo, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -79,14 +89,21 @@ SELECT
         2
     ) AS accuracy_percentage
 FROM topics t
+JOIN subjects s ON s.id = t.subject_id
 LEFT JOIN exercise_logs el ON t.id = el.topic_id
 WHERE t.subject_id = ?
+  AND s.user_id = ?
   AND t.deleted_at IS NULL
 GROUP BY t.id, t.name
 HAVING total_questions > 0
 ORDER BY accuracy_percentage ASC
 `
 
+type GetAccuracyByTopicParams struct {
+	SubjectID string `json:"subject_id"`
+	UserID    string `json:"user_id"`
+}
+
 type GetAccuracyByTopicRow struct {
 	TopicID            string          `json:"topic_id"`
 	TopicName          string          `json:"topic_name"`
@@ -95,8 +112,8 @@ type GetAccuracyByTopicRow struct {
 	AccuracyPercentage float64         `json:"accuracy_percentage"`
 }
 
-func (q *Queries) GetAccuracyByTopic(ctx context.Context, subjectID string) ([]GetAccuracyByTopicRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAccuracyByTopic, subjectID)
+func (q *Queries) GetAccuracyByTopic(ctx context.Context, arg GetAccuracyByTopicParams) ([]GetAccuracyByTopicRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccuracyByTopic, arg.SubjectID, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -124,34 +141,53 @@ func (q *Queries) GetAccuracyByTopic(ctx context.Context, subjectID string) ([]G
 	return items, nil
 }
 
-const getActivityHeatmap = `-- name: GetActivityHeatmap :many
-SELECT 
-    strftime('%Y-%m-%d', started_at) AS study_date,
-    COUNT(DISTINCT id) AS sessions_count,
-    COALESCE(SUM(net_duration_seconds), 0) AS total_seconds
-FROM study_sessions
-WHERE finished_at IS NOT NULL
-  AND datetime(started_at) >= datetime('now', '-' || CAST(?1 AS TEXT) || ' days')
-GROUP BY study_date
-ORDER BY study_date DESC
+const listActiveCyclePlan = `-- name: ListActiveCyclePlan :many
+SELECT
+    sc.id AS cycle_id,
+    sc.name AS cycle_name,
+    ci.subject_id,
+    COUNT(*) AS items_count,
+    COUNT(ci.planned_duration_minutes) AS timed_items_count,
+    CAST(COALESCE(SUM(ci.planned_duration_minutes), 0) AS INTEGER) AS planned_minutes
+FROM cycle_items ci
+JOIN study_cycles sc ON sc.id = ci.cycle_id
+JOIN subjects s ON s.id = ci.subject_id
+WHERE sc.is_active = 1
+  AND sc.deleted_at IS NULL
+  AND s.user_id = ?
+  AND s.deleted_at IS NULL
+GROUP BY sc.id, sc.name, sc.updated_at, ci.subject_id
+ORDER BY sc.updated_at DESC, sc.id
 `
 
-type GetActivityHeatmapRow struct {
-	StudyDate     interface{} `json:"study_date"`
-	SessionsCount int64       `json:"sessions_count"`
-	TotalSeconds  interface{} `json:"total_seconds"`
+type ListActiveCyclePlanRow struct {
+	CycleID         string `json:"cycle_id"`
+	CycleName       string `json:"cycle_name"`
+	SubjectID       string `json:"subject_id"`
+	ItemsCount      int64  `json:"items_count"`
+	TimedItemsCount int64  `json:"timed_items_count"`
+	PlannedMinutes  int64  `json:"planned_minutes"`
 }
 
-func (q *Queries) GetActivityHeatmap(ctx context.Context, daysCount string) ([]GetActivityHeatmapRow, error) {
-	rows, err := q.db.QueryContext(ctx, getActivityHeatmap, daysCount)
+// Planned minutes and slots per subject in the active cycle(s), most recently updated cycle first.
+// Cycles aren't owned by users, so only the user's subjects are considered.
+func (q *Queries) ListActiveCyclePlan(ctx context.Context, userID string) ([]ListActiveCyclePlanRow, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveCyclePlan, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetActivityHeatmapRow
+	var items []ListActiveCyclePlanRow
 	for rows.Next() {
-		var i GetActivityHeatmapRow
-		if err := rows.Scan(&i.StudyDate, &i.SessionsCount, &i.TotalSeconds); err != nil {
+		var i ListActiveCyclePlanRow
+		if err := rows.Scan(
+			&i.CycleID,
+			&i.CycleName,
+			&i.SubjectID,
+			&i.ItemsCount,
+			&i.TimedItemsCount,
+			&i.PlannedMinutes,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -165,54 +201,303 @@ func (q *Queries) GetActivityHeatmap(ctx context.Context, daysCount string) ([]G
 	return items, nil
 }
 
-const getTimeReportBySubject = `-- name: GetTimeReportBySubject :many
+const listExerciseLogsInRange = `-- name: ListExerciseLogsInRange :many
+SELECT
+    el.id,
+    el.subject_id,
+    el.topic_id,
+    el.questions_count,
+    el.correct_count,
+    el.created_at
+FROM exercise_logs el
+JOIN subjects s ON s.id = el.subject_id
+WHERE s.user_id = ?1
+  AND s.deleted_at IS NULL
+  AND datetime(el.created_at) >= datetime(CAST(?2 AS TEXT))
+  AND datetime(el.created_at) < datetime(CAST(?3 AS TEXT))
+ORDER BY datetime(el.created_at)
+`
 
-SELECT 
-    s.id AS subject_id,
+type ListExerciseLogsInRangeParams struct {
+	UserID    string `json:"user_id"`
+	RangeFrom string `json:"range_from"`
+	RangeTo   string `json:"range_to"`
+}
+
+type ListExerciseLogsInRangeRow struct {
+	ID             string         `json:"id"`
+	SubjectID      string         `json:"subject_id"`
+	TopicID        sql.NullString `json:"topic_id"`
+	QuestionsCount int64          `json:"questions_count"`
+	CorrectCount   int64          `json:"correct_count"`
+	CreatedAt      string         `json:"created_at"`
+}
+
+// Exercise logs created in [range_from, range_to), bounds as in ListFinishedSessionsInRange
+func (q *Queries) ListExerciseLogsInRange(ctx context.Context, arg ListExerciseLogsInRangeParams) ([]ListExerciseLogsInRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExerciseLogsInRange, arg.UserID, arg.RangeFrom, arg.RangeTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExerciseLogsInRangeRow
+	for rows.Next() {
+		var i ListExerciseLogsInRangeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubjectID,
+			&i.TopicID,
+			&i.QuestionsCount,
+			&i.CorrectCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFinishedSessionsInRange = `-- name: ListFinishedSessionsInRange :many
+
+SELECT
+    ss.id,
+    ss.subject_id,
     s.name AS subject_name,
     s.color_hex,
-    COUNT(ss.id) AS sessions_count,
-    ROUND(COALESCE(SUM(ss.net_duration_seconds), 0) / 3600.0, 2) AS total_hours_net
-FROM subjects s
-LEFT JOIN study_sessions ss ON s.id = ss.subject_id 
-    AND ss.finished_at IS NOT NULL
-    AND (?1 = '' OR ss.started_at >= ?1)
-    AND (?2 = '' OR ss.started_at <= ?2)
-WHERE s.deleted_at IS NULL
-GROUP BY s.id, s.name, s.color_hex
-HAVING sessions_count > 0
-ORDER BY total_hours_net DESC
+    ss.started_at,
+    ss.finished_at,
+    COALESCE(ss.gross_duration_seconds, 0) AS gross_duration_seconds,
+    COALESCE(ss.net_duration_seconds, 0) AS net_duration_seconds
+FROM study_sessions ss
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = ?1
+  AND s.deleted_at IS NULL
+  AND ss.finished_at IS NOT NULL
+  AND datetime(ss.finished_at) > datetime(CAST(?2 AS TEXT))
+  AND datetime(ss.started_at) < datetime(CAST(?3 AS TEXT))
+ORDER BY datetime(ss.started_at)
 `
 
-type GetTimeReportBySubjectParams struct {
-	StartDateFrom interface{} `json:"start_date_from"`
-	StartDateTo   interface{} `json:"start_date_to"`
+type ListFinishedSessionsInRangeParams struct {
+	UserID    string `json:"user_id"`
+	RangeFrom string `json:"range_from"`
+	RangeTo   string `json:"range_to"`
 }
 
-type GetTimeReportBySubjectRow struct {
-	SubjectID     string         `json:"subject_id"`
-	SubjectName   string         `json:"subject_name"`
-	ColorHex      sql.NullString `json:"color_hex"`
-	SessionsCount int64          `json:"sessions_count"`
-	TotalHoursNet float64        `json:"total_hours_net"`
+type ListFinishedSessionsInRangeRow struct {
+	ID                   string         `json:"id"`
+	SubjectID            string         `json:"subject_id"`
+	SubjectName          string         `json:"subject_name"`
+	ColorHex             sql.NullString `json:"color_hex"`
+	StartedAt            string         `json:"started_at"`
+	FinishedAt           sql.NullString `json:"finished_at"`
+	GrossDurationSeconds int64          `json:"gross_duration_seconds"`
+	NetDurationSeconds   int64          `json:"net_duration_seconds"`
 }
 
 // Analytics Queries for Study App
-func (q *Queries) GetTimeReportBySubject(ctx context.Context, arg GetTimeReportBySubjectParams) ([]GetTimeReportBySubjectRow, error) {
-	rows, err := q.db.QueryContext(ctx, getTimeReportBySubject, arg.StartDateFrom, arg.StartDateTo)
+// Finished sessions overlapping [range_from, range_to). Bounds are UTC 'YYYY-MM-DD HH:MM:SS';
+// day bucketing and splitting happen in the service, in the user's timezone.
+func (q *Queries) ListFinishedSessionsInRange(ctx context.Context, arg ListFinishedSessionsInRangeParams) ([]ListFinishedSessionsInRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFinishedSessionsInRange, arg.UserID, arg.RangeFrom, arg.RangeTo)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetTimeReportBySubjectRow
+	var items []ListFinishedSessionsInRangeRow
 	for rows.Next() {
-		var i GetTimeReportBySubjectRow
+		var i ListFinishedSessionsInRangeRow
 		if err := rows.Scan(
+			&i.ID,
 			&i.SubjectID,
 			&i.SubjectName,
 			&i.ColorHex,
-			&i.SessionsCount,
-			&i.TotalHoursNet,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.GrossDurationSeconds,
+			&i.NetDurationSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionPausesInRange = `-- name: ListSessionPausesInRange :many
+SELECT
+    sp.id,
+    sp.session_id,
+    sp.started_at,
+    sp.ended_at
+FROM session_pauses sp
+JOIN study_sessions ss ON ss.id = sp.session_id
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = ?1
+  AND s.deleted_at IS NULL
+  AND ss.finished_at IS NOT NULL
+  AND sp.ended_at IS NOT NULL
+  AND datetime(ss.finished_at) > datetime(CAST(?2 AS TEXT))
+  AND datetime(ss.started_at) < datetime(CAST(?3 AS TEXT))
+ORDER BY sp.session_id, datetime(sp.started_at)
+`
+
+type ListSessionPausesInRangeParams struct {
+	UserID    string `json:"user_id"`
+	RangeFrom string `json:"range_from"`
+	RangeTo   string `json:"range_to"`
+}
+
+type ListSessionPausesInRangeRow struct {
+	ID        string         `json:"id"`
+	SessionID string         `json:"session_id"`
+	StartedAt string         `json:"started_at"`
+	EndedAt   sql.NullString `json:"ended_at"`
+}
+
+// Ended pauses of the sessions ListFinishedSessionsInRange returns for the same bounds
+func (q *Queries) ListSessionPausesInRange(ctx context.Context, arg ListSessionPausesInRangeParams) ([]ListSessionPausesInRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionPausesInRange, arg.UserID, arg.RangeFrom, arg.RangeTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionPausesInRangeRow
+	for rows.Next() {
+		var i ListSessionPausesInRangeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.StartedAt,
+			&i.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopicExerciseLogs = `-- name: ListTopicExerciseLogs :many
+SELECT
+    el.id,
+    t.id AS topic_id,
+    t.name AS topic_name,
+    s.id AS subject_id,
+    s.name AS subject_name,
+    el.questions_count,
+    el.correct_count,
+    el.created_at
+FROM exercise_logs el
+JOIN topics t ON t.id = el.topic_id
+JOIN subjects s ON s.id = t.subject_id
+WHERE s.user_id = ?
+  AND s.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+ORDER BY datetime(el.created_at)
+`
+
+type ListTopicExerciseLogsRow struct {
+	ID             string `json:"id"`
+	TopicID        string `json:"topic_id"`
+	TopicName      string `json:"topic_name"`
+	SubjectID      string `json:"subject_id"`
+	SubjectName    string `json:"subject_name"`
+	QuestionsCount int64  `json:"questions_count"`
+	CorrectCount   int64  `json:"correct_count"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// Every exercise log tagged with a live topic, for ranking weak points across subjects
+func (q *Queries) ListTopicExerciseLogs(ctx context.Context, userID string) ([]ListTopicExerciseLogsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTopicExerciseLogs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopicExerciseLogsRow
+	for rows.Next() {
+		var i ListTopicExerciseLogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TopicID,
+			&i.TopicName,
+			&i.SubjectID,
+			&i.SubjectName,
+			&i.QuestionsCount,
+			&i.CorrectCount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopicProgress = `-- name: ListTopicProgress :many
+SELECT
+    t.id AS topic_id,
+    t.subject_id,
+    CAST(COALESCE(MIN(datetime(el.created_at)), '') AS TEXT) AS first_practiced_at,
+    CAST(COALESCE(MAX(datetime(el.created_at)), '') AS TEXT) AS last_practiced_at
+FROM topics t
+JOIN subjects s ON s.id = t.subject_id
+LEFT JOIN exercise_logs el ON el.topic_id = t.id
+WHERE s.user_id = ?
+  AND s.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+GROUP BY t.id, t.subject_id
+ORDER BY t.subject_id, t.id
+`
+
+type ListTopicProgressRow struct {
+	TopicID          string `json:"topic_id"`
+	SubjectID        string `json:"subject_id"`
+	FirstPracticedAt string `json:"first_practiced_at"`
+	LastPracticedAt  string `json:"last_practiced_at"`
+}
+
+// Every live topic with its first and last practice (empty if never practiced), for syllabus coverage
+func (q *Queries) ListTopicProgress(ctx context.Context, userID string) ([]ListTopicProgressRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTopicProgress, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopicProgressRow
+	for rows.Next() {
+		var i ListTopicProgressRow
+		if err := rows.Scan(
+			&i.TopicID,
+			&i.SubjectID,
+			&i.FirstPracticedAt,
+			&i.LastPracticedAt,
 		); err != nil {
 			return nil, err
 		}
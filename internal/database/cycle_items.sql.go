@@ -13,7 +13,7 @@ import (
 const createCycleItem = `-- name: CreateCycleItem :one
 INSERT INTO cycle_items (id, cycle_id, subject_id, order_index, planned_duration_minutes)
 VALUES (?, ?, ?, ?, ?)
-RETURNING id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at
+RETURNING id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at, version
 `
 
 type CreateCycleItemParams struct {
@@ -41,6 +41,7 @@ func (q *Queries) CreateCycleItem(ctx context.Context, arg CreateCycleItemParams
 		&i.PlannedDurationMinutes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
@@ -56,7 +57,7 @@ func (q *Queries) DeleteCycleItem(ctx context.Context, id string) error {
 }
 
 const getCycleItem = `-- name: GetCycleItem :one
-SELECT id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at FROM cycle_items
+SELECT id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at, version FROM cycle_items
 WHERE id = ?
 `
 
@@ -71,12 +72,13 @@ func (q *Queries) GetCycleItem(ctx context.Context, id string) (CycleItem, error
 		&i.PlannedDurationMinutes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const listCycleItems = `-- name: ListCycleItems :many
-SELECT id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at FROM cycle_items
+SELECT id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at, version FROM cycle_items
 WHERE cycle_id = ?
 ORDER BY order_index
 `
@@ -98,6 +100,7 @@ func (q *Queries) ListCycleItems(ctx context.Context, cycleID string) ([]CycleIt
 			&i.PlannedDurationMinutes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -112,10 +115,61 @@ func (q *Queries) ListCycleItems(ctx context.Context, cycleID string) ([]CycleIt
 	return items, nil
 }
 
-const updateCycleItem = `-- name: UpdateCycleItem :exec
+const patchCycleItem = `-- name: PatchCycleItem :one
 UPDATE cycle_items
-SET subject_id = ?, order_index = ?, planned_duration_minutes = ?, updated_at = datetime('now')
-WHERE id = ?
+SET subject_id = CASE WHEN ?1 THEN ?2 ELSE subject_id END,
+    order_index = CASE WHEN ?3 THEN ?4 ELSE order_index END,
+    planned_duration_minutes = CASE WHEN ?5 THEN ?6 ELSE planned_duration_minutes END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = ?7
+  AND (?8 IS NULL OR version = ?8)
+RETURNING id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at, version
+`
+
+type PatchCycleItemParams struct {
+	SetSubjectID              bool          `json:"set_subject_id"`
+	SubjectID                 string        `json:"subject_id"`
+	SetOrderIndex             bool          `json:"set_order_index"`
+	OrderIndex                int64         `json:"order_index"`
+	SetPlannedDurationMinutes bool          `json:"set_planned_duration_minutes"`
+	PlannedDurationMinutes    sql.NullInt64 `json:"planned_duration_minutes"`
+	ID                        string        `json:"id"`
+	IfVersion                 sql.NullInt64 `json:"if_version"`
+}
+
+// planned_duration_minutes can be set to 0 or to NULL, see set_planned_duration_minutes
+func (q *Queries) PatchCycleItem(ctx context.Context, arg PatchCycleItemParams) (CycleItem, error) {
+	row := q.db.QueryRowContext(ctx, patchCycleItem,
+		arg.SetSubjectID,
+		arg.SubjectID,
+		arg.SetOrderIndex,
+		arg.OrderIndex,
+		arg.SetPlannedDurationMinutes,
+		arg.PlannedDurationMinutes,
+		arg.ID,
+		arg.IfVersion,
+	)
+	var i CycleItem
+	err := row.Scan(
+		&i.ID,
+		&i.CycleID,
+		&i.SubjectID,
+		&i.OrderIndex,
+		&i.PlannedDurationMinutes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateCycleItem = `-- name: UpdateCycleItem :one
+UPDATE cycle_items
+SET subject_id = ?1, order_index = ?2, planned_duration_minutes = ?3,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = ?4
+  AND (?5 IS NULL OR version = ?5)
+RETURNING id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at, version
 `
 
 type UpdateCycleItemParams struct {
@@ -123,14 +177,28 @@ type UpdateCycleItemParams struct {
 	OrderIndex             int64         `json:"order_index"`
 	PlannedDurationMinutes sql.NullInt64 `json:"planned_duration_minutes"`
 	ID                     string        `json:"id"`
+	IfVersion              sql.NullInt64 `json:"if_version"`
 }
 
-func (q *Queries) UpdateCycleItem(ctx context.Context, arg UpdateCycleItemParams) error {
-	_, err := q.db.ExecContext(ctx, updateCycleItem,
+// Returns no row when if_version is set and no longer matches
+func (q *Queries) UpdateCycleItem(ctx context.Context, arg UpdateCycleItemParams) (CycleItem, error) {
+	row := q.db.QueryRowContext(ctx, updateCycleItem,
 		arg.SubjectID,
 		arg.OrderIndex,
 		arg.PlannedDurationMinutes,
 		arg.ID,
+		arg.IfVersion,
 	)
-	return err
+	var i CycleItem
+	err := row.Scan(
+		&i.ID,
+		&i.CycleID,
+		&i.SubjectID,
+		&i.OrderIndex,
+		&i.PlannedDurationMinutes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
 }
@@ -9,6 +9,17 @@ import (
 	"time"
 )
 
+type AccountDeletion struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"user_id"`
+	ReceiptHash string         `json:"receipt_hash"`
+	RequestedAt string         `json:"requested_at"`
+	PurgeAfter  string         `json:"purge_after"`
+	PurgedAt    sql.NullString `json:"purged_at"`
+	DeletedRows sql.NullString `json:"deleted_rows"`
+	Verified    int64          `json:"verified"`
+}
+
 type CycleItem struct {
 	ID                     string        `json:"id"`
 	CycleID                string        `json:"cycle_id"`
@@ -17,6 +28,7 @@ type CycleItem struct {
 	PlannedDurationMinutes sql.NullInt64 `json:"planned_duration_minutes"`
 	CreatedAt              string        `json:"created_at"`
 	UpdatedAt              string        `json:"updated_at"`
+	Version                int64         `json:"version"`
 }
 
 type ExerciseLog struct {
@@ -29,6 +41,31 @@ type ExerciseLog struct {
 	CreatedAt      string         `json:"created_at"`
 }
 
+type Goal struct {
+	ID        string         `json:"id"`
+	UserID    string         `json:"user_id"`
+	SubjectID sql.NullString `json:"subject_id"`
+	Metric    string         `json:"metric"`
+	Period    string         `json:"period"`
+	Target    int64          `json:"target"`
+	RestDays  int64          `json:"rest_days"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+	DeletedAt sql.NullString `json:"deleted_at"`
+}
+
+type IdempotencyKey struct {
+	UserID          string         `json:"user_id"`
+	IdempotencyKey  string         `json:"idempotency_key"`
+	RequestHash     string         `json:"request_hash"`
+	StatusCode      sql.NullInt64  `json:"status_code"`
+	ContentType     sql.NullString `json:"content_type"`
+	ResponseBody    []byte         `json:"response_body"`
+	CreatedAt       string         `json:"created_at"`
+	ExpiresAt       string         `json:"expires_at"`
+	ResponseHeaders sql.NullString `json:"response_headers"`
+}
+
 type PasswordResetToken struct {
 	TokenHash string       `json:"token_hash"`
 	UserID    string       `json:"user_id"`
@@ -36,6 +73,27 @@ type PasswordResetToken struct {
 	Used      sql.NullBool `json:"used"`
 }
 
+type PersonalAccessToken struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"user_id"`
+	Name        string         `json:"name"`
+	TokenHash   string         `json:"token_hash"`
+	TokenPrefix string         `json:"token_prefix"`
+	Scopes      string         `json:"scopes"`
+	ExpiresAt   sql.NullString `json:"expires_at"`
+	LastUsedAt  sql.NullString `json:"last_used_at"`
+	CreatedAt   string         `json:"created_at"`
+	RevokedAt   sql.NullString `json:"revoked_at"`
+}
+
+type RecommendationWeight struct {
+	UserID         string  `json:"user_id"`
+	CycleWeight    float64 `json:"cycle_weight"`
+	ReviewWeight   float64 `json:"review_weight"`
+	WeaknessWeight float64 `json:"weakness_weight"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
 type SessionPause struct {
 	ID              string         `json:"id"`
 	SessionID       string         `json:"session_id"`
@@ -52,6 +110,7 @@ type StudyCycle struct {
 	CreatedAt   string         `json:"created_at"`
 	UpdatedAt   string         `json:"updated_at"`
 	DeletedAt   sql.NullString `json:"deleted_at"`
+	Version     int64          `json:"version"`
 }
 
 type StudySession struct {
@@ -68,13 +127,15 @@ type StudySession struct {
 }
 
 type Subject struct {
-	ID        string         `json:"id"`
-	Name      string         `json:"name"`
-	ColorHex  sql.NullString `json:"color_hex"`
-	CreatedAt string         `json:"created_at"`
-	UpdatedAt string         `json:"updated_at"`
-	DeletedAt sql.NullString `json:"deleted_at"`
-	UserID    string         `json:"user_id"`
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	ColorHex   sql.NullString  `json:"color_hex"`
+	CreatedAt  string          `json:"created_at"`
+	UpdatedAt  string          `json:"updated_at"`
+	DeletedAt  sql.NullString  `json:"deleted_at"`
+	UserID     string          `json:"user_id"`
+	ExamWeight sql.NullFloat64 `json:"exam_weight"`
+	Version    int64           `json:"version"`
 }
 
 type Topic struct {
@@ -84,12 +145,20 @@ type Topic struct {
 	CreatedAt string         `json:"created_at"`
 	UpdatedAt string         `json:"updated_at"`
 	DeletedAt sql.NullString `json:"deleted_at"`
+	Version   int64          `json:"version"`
 }
 
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	Name         string    `json:"name"`
-	CreatedAt    time.Time `json:"created_at"`
-	PasswordHash string    `json:"password_hash"`
+	ID                  string         `json:"id"`
+	Email               string         `json:"email"`
+	Name                string         `json:"name"`
+	CreatedAt           time.Time      `json:"created_at"`
+	PasswordHash        string         `json:"password_hash"`
+	FailedLoginAttempts int64          `json:"failed_login_attempts"`
+	LockedUntil         sql.NullTime   `json:"locked_until"`
+	Timezone            string         `json:"timezone"`
+	Locale              string         `json:"locale"`
+	DailyGoalMinutes    int64          `json:"daily_goal_minutes"`
+	WeekStartDay        int64          `json:"week_start_day"`
+	ExamDate            sql.NullString `json:"exam_date"`
 }
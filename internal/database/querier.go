@@ -9,8 +9,21 @@ import (
 )
 
 type Querier interface {
+	CancelAccountDeletion(ctx context.Context, userID string) (int64, error)
+	// Claims the key for a request; no row is inserted when it's already taken
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int64, error)
+	// Deletes the changes logged by the cutoff that a later change of the same row supersedes. The feed
+	// only serves a row's last change, so no page after any cursor loses anything.
+	CompactSyncChanges(ctx context.Context, cutoff string) (int64, error)
+	CompleteAccountDeletion(ctx context.Context, arg CompleteAccountDeletionParams) error
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error
+	// Rows the purge of a user deletes, per table. Study cycles count when all their items are the user's.
+	CountAccountRows(ctx context.Context, userID string) (CountAccountRowsRow, error)
+	CreateAccountDeletion(ctx context.Context, arg CreateAccountDeletionParams) (AccountDeletion, error)
 	CreateCycleItem(ctx context.Context, arg CreateCycleItemParams) (CycleItem, error)
 	CreateExerciseLog(ctx context.Context, arg CreateExerciseLogParams) (ExerciseLog, error)
+	CreateGoal(ctx context.Context, arg CreateGoalParams) (Goal, error)
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
 	CreateSessionPause(ctx context.Context, arg CreateSessionPauseParams) (SessionPause, error)
 	CreateStudyCycle(ctx context.Context, arg CreateStudyCycleParams) (StudyCycle, error)
 	CreateStudySession(ctx context.Context, arg CreateStudySessionParams) (StudySession, error)
@@ -19,37 +32,191 @@ type Querier interface {
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteCycleItem(ctx context.Context, id string) error
 	DeleteExerciseLog(ctx context.Context, id string) error
+	DeleteGoal(ctx context.Context, arg DeleteGoalParams) (int64, error)
 	DeleteSessionPause(ctx context.Context, id string) error
 	DeleteStudyCycle(ctx context.Context, id string) error
 	DeleteStudySession(ctx context.Context, id string) error
 	DeleteSubject(ctx context.Context, arg DeleteSubjectParams) error
+	DeleteSyncCycleItem(ctx context.Context, arg DeleteSyncCycleItemParams) (int64, error)
+	DeleteSyncExerciseLog(ctx context.Context, arg DeleteSyncExerciseLogParams) (int64, error)
+	DeleteSyncSessionPause(ctx context.Context, arg DeleteSyncSessionPauseParams) (int64, error)
+	DeleteSyncStudyCycle(ctx context.Context, arg DeleteSyncStudyCycleParams) (int64, error)
+	DeleteSyncStudySession(ctx context.Context, arg DeleteSyncStudySessionParams) (int64, error)
+	DeleteSyncSubject(ctx context.Context, arg DeleteSyncSubjectParams) (int64, error)
+	DeleteSyncTopic(ctx context.Context, arg DeleteSyncTopicParams) (int64, error)
 	DeleteTopic(ctx context.Context, id string) error
+	DetachStudyCycleSessions(ctx context.Context, cycleID string) error
+	// Sessions of other subjects can still point at the subject's cycle items
+	DetachSubjectCycleItemSessions(ctx context.Context, subjectID string) error
+	// Logs of other subjects can still point at the subject's topics
+	DetachSubjectTopicExerciseLogs(ctx context.Context, subjectID string) error
+	DetachTopicExerciseLogs(ctx context.Context, topicID string) error
+	// Ends the pauses of a session still running when it's stopped
+	EndOpenSessionPauses(ctx context.Context, arg EndOpenSessionPausesParams) error
 	EndSessionPause(ctx context.Context, arg EndSessionPauseParams) error
-	GetAccuracyBySubject(ctx context.Context) ([]GetAccuracyBySubjectRow, error)
-	GetAccuracyByTopic(ctx context.Context, subjectID string) ([]GetAccuracyByTopicRow, error)
+	GetAccountDeletion(ctx context.Context, id string) (AccountDeletion, error)
+	// Exercise logs created in [range_from, range_to), bounds as in ListFinishedSessionsInRange
+	GetAccuracyBySubject(ctx context.Context, arg GetAccuracyBySubjectParams) ([]GetAccuracyBySubjectRow, error)
+	GetAccuracyByTopic(ctx context.Context, arg GetAccuracyByTopicParams) ([]GetAccuracyByTopicRow, error)
 	GetActiveCycleWithItems(ctx context.Context) ([]GetActiveCycleWithItemsRow, error)
 	GetActiveStudyCycle(ctx context.Context) (StudyCycle, error)
-	GetActivityHeatmap(ctx context.Context, daysCount string) ([]GetActivityHeatmapRow, error)
 	GetCycleItem(ctx context.Context, id string) (CycleItem, error)
 	GetExerciseLog(ctx context.Context, id string) (ExerciseLog, error)
+	GetGoal(ctx context.Context, arg GetGoalParams) (Goal, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLatestSyncChange(ctx context.Context, arg GetLatestSyncChangeParams) (GetLatestSyncChangeRow, error)
 	GetOpenSession(ctx context.Context) (GetOpenSessionRow, error)
+	GetPendingAccountDeletion(ctx context.Context, userID string) (AccountDeletion, error)
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (GetPersonalAccessTokenByHashRow, error)
+	GetRecommendationWeights(ctx context.Context, userID string) (RecommendationWeight, error)
 	GetSessionPause(ctx context.Context, id string) (SessionPause, error)
 	GetStudyCycle(ctx context.Context, id string) (StudyCycle, error)
 	GetStudySession(ctx context.Context, id string) (StudySession, error)
 	GetSubject(ctx context.Context, arg GetSubjectParams) (Subject, error)
-	// Analytics Queries for Study App
-	GetTimeReportBySubject(ctx context.Context, arg GetTimeReportBySubjectParams) ([]GetTimeReportBySubjectRow, error)
 	GetTopic(ctx context.Context, id string) (Topic, error)
+	GetTrashedTopic(ctx context.Context, arg GetTrashedTopicParams) (Topic, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserByID(ctx context.Context, id string) (User, error)
+	// The user's latest unfinished session, with the seconds of its ended pauses
+	// and the start of the pause in progress (empty if none)
+	GetUserOpenSession(ctx context.Context, userID string) (GetUserOpenSessionRow, error)
+	// Counts a failed login in place, so concurrent failures all count
+	IncrementLoginFailures(ctx context.Context, id string) (int64, error)
+	// Items of the active cycle(s) on the user's subjects, most recently updated cycle first,
+	// in cycle order, with the start of the latest session recorded against each item (empty if none)
+	ListActiveCycleItems(ctx context.Context, userID string) ([]ListActiveCycleItemsRow, error)
+	// Planned minutes and slots per subject in the active cycle(s), most recently updated cycle first.
+	// Cycles aren't owned by users, so only the user's subjects are considered.
+	ListActiveCyclePlan(ctx context.Context, userID string) ([]ListActiveCyclePlanRow, error)
+	ListBackupCycleItems(ctx context.Context, userID string) ([]CycleItem, error)
+	ListBackupExerciseLogs(ctx context.Context, userID string) ([]ExerciseLog, error)
+	ListBackupSessionPauses(ctx context.Context, userID string) ([]ListBackupSessionPausesRow, error)
+	ListBackupStudyCycles(ctx context.Context, userID string) ([]StudyCycle, error)
+	ListBackupStudySessions(ctx context.Context, userID string) ([]StudySession, error)
+	// Account backups: everything a user owns, soft-deleted rows included, and inserts that keep
+	// the archived timestamps. Study cycles have no owner column, so a user's cycles are the ones
+	// holding items on their subjects.
+	ListBackupSubjects(ctx context.Context, userID string) ([]Subject, error)
+	ListBackupTopics(ctx context.Context, userID string) ([]Topic, error)
 	ListCycleItems(ctx context.Context, cycleID string) ([]CycleItem, error)
+	ListDueAccountDeletions(ctx context.Context, now string) ([]AccountDeletion, error)
+	// Exercise logs created in [range_from, range_to), bounds as in ListFinishedSessionsInRange
+	ListExerciseLogsInRange(ctx context.Context, arg ListExerciseLogsInRangeParams) ([]ListExerciseLogsInRangeRow, error)
+	// Exercise logs created in [range_from, range_to), oldest first, bounds as in ListFinishedSessionsInRange
+	ListExerciseLogsPage(ctx context.Context, arg ListExerciseLogsPageParams) ([]ListExerciseLogsPageRow, error)
+	ListExpiredTrashedStudyCycles(ctx context.Context, cutoff string) ([]string, error)
+	ListExpiredTrashedSubjects(ctx context.Context, cutoff string) ([]string, error)
+	ListExpiredTrashedTopics(ctx context.Context, cutoff string) ([]string, error)
+	// Analytics Queries for Study App
+	// Finished sessions overlapping [range_from, range_to). Bounds are UTC 'YYYY-MM-DD HH:MM:SS';
+	// day bucketing and splitting happen in the service, in the user's timezone.
+	ListFinishedSessionsInRange(ctx context.Context, arg ListFinishedSessionsInRangeParams) ([]ListFinishedSessionsInRangeRow, error)
+	ListGoals(ctx context.Context, userID string) ([]Goal, error)
+	ListPersonalAccessTokens(ctx context.Context, userID string) ([]PersonalAccessToken, error)
+	// Study cycles whose items are all on the user's subjects, so they go with the account
+	ListPurgeableStudyCycles(ctx context.Context, userID string) ([]string, error)
+	// Ended pauses of the sessions ListFinishedSessionsInRange returns for the same bounds
+	ListSessionPausesInRange(ctx context.Context, arg ListSessionPausesInRangeParams) ([]ListSessionPausesInRangeRow, error)
+	// Paged reads for CSV and XLSX exports. Pages are keyed on (datetime(started_at/created_at), id):
+	// pass the sort_key and id of the previous page's last row, or empty strings for the first page.
+	// Sessions started in [range_from, range_to), oldest first, bounds as in ListFinishedSessionsInRange
+	ListSessionsPage(ctx context.Context, arg ListSessionsPageParams) ([]ListSessionsPageRow, error)
 	ListSubjects(ctx context.Context, userID string) ([]Subject, error)
+	// The last change of every row the user's feed logged after the cursor, in commit order. Only
+	// upserts carry the row's current state; tombstones have none.
+	ListSyncChanges(ctx context.Context, arg ListSyncChangesParams) ([]ListSyncChangesRow, error)
+	// Every exercise log tagged with a live topic, for ranking weak points across subjects
+	ListTopicExerciseLogs(ctx context.Context, userID string) ([]ListTopicExerciseLogsRow, error)
+	// Every live topic with its first and last practice (empty if never practiced), for syllabus coverage
+	ListTopicProgress(ctx context.Context, userID string) ([]ListTopicProgressRow, error)
 	ListTopicsBySubject(ctx context.Context, subjectID string) ([]Topic, error)
-	UpdateCycleItem(ctx context.Context, arg UpdateCycleItemParams) error
+	// Study cycles have no owner; a user's are those with items on their subjects
+	ListTrashedStudyCycles(ctx context.Context, userID string) ([]ListTrashedStudyCyclesRow, error)
+	// A trashed subject takes its topics, cycle items, sessions, exercise logs and goals with it: they
+	// stay untouched, hidden with the subject, and are purged along with it.
+	ListTrashedSubjects(ctx context.Context, userID string) ([]ListTrashedSubjectsRow, error)
+	ListTrashedTopics(ctx context.Context, userID string) ([]ListTrashedTopicsRow, error)
+	// Only while the failures are still the ones the lock was computed for, so a
+	// slower request can't shorten the lock of a later failure
+	LockUser(ctx context.Context, arg LockUserParams) error
+	MoveSubjectCycleItems(ctx context.Context, arg MoveSubjectCycleItemsParams) (int64, error)
+	MoveSubjectExerciseLogs(ctx context.Context, arg MoveSubjectExerciseLogsParams) (int64, error)
+	MoveSubjectGoals(ctx context.Context, arg MoveSubjectGoalsParams) (int64, error)
+	MoveSubjectStudySessions(ctx context.Context, arg MoveSubjectStudySessionsParams) (int64, error)
+	// Merging a subject moves every row that points at it, trashed topics included
+	MoveSubjectTopics(ctx context.Context, arg MoveSubjectTopicsParams) (int64, error)
+	MoveTopicExerciseLogs(ctx context.Context, arg MoveTopicExerciseLogsParams) (int64, error)
+	// planned_duration_minutes can be set to 0 or to NULL, see set_planned_duration_minutes
+	PatchCycleItem(ctx context.Context, arg PatchCycleItemParams) (CycleItem, error)
+	// Columns without their set_ flag keep their value
+	PatchStudyCycle(ctx context.Context, arg PatchStudyCycleParams) (StudyCycle, error)
+	// Only columns whose set_ flag is true change, so a merge patch can leave a
+	// field alone, null it or set it to a zero value
+	PatchSubject(ctx context.Context, arg PatchSubjectParams) (Subject, error)
+	PatchTopic(ctx context.Context, arg PatchTopicParams) (Topic, error)
+	PurgeExpiredIdempotencyKeys(ctx context.Context, expiresAt string) (int64, error)
+	PurgeStudyCycle(ctx context.Context, id string) (int64, error)
+	PurgeStudyCycleItems(ctx context.Context, cycleID string) error
+	PurgeSubjectCycleItems(ctx context.Context, subjectID string) error
+	PurgeSubjectExerciseLogs(ctx context.Context, subjectID string) error
+	PurgeSubjectGoals(ctx context.Context, subjectID string) error
+	PurgeSubjectSessionPauses(ctx context.Context, subjectID string) error
+	PurgeSubjectStudySessions(ctx context.Context, subjectID string) error
+	PurgeSubjectTopics(ctx context.Context, subjectID string) error
+	PurgeTrashedStudyCycle(ctx context.Context, id string) (int64, error)
+	PurgeTrashedSubject(ctx context.Context, id string) (int64, error)
+	PurgeTrashedTopic(ctx context.Context, id string) (int64, error)
+	PurgeUser(ctx context.Context, id string) (int64, error)
+	PurgeUserCycleItems(ctx context.Context, userID string) (int64, error)
+	PurgeUserExerciseLogs(ctx context.Context, userID string) (int64, error)
+	PurgeUserGoals(ctx context.Context, userID string) (int64, error)
+	PurgeUserIdempotencyKeys(ctx context.Context, userID string) (int64, error)
+	PurgeUserPasswordResetTokens(ctx context.Context, userID string) (int64, error)
+	PurgeUserPersonalAccessTokens(ctx context.Context, userID string) (int64, error)
+	PurgeUserRecommendationWeights(ctx context.Context, userID string) (int64, error)
+	PurgeUserSessionPauses(ctx context.Context, userID string) (int64, error)
+	PurgeUserStudySessions(ctx context.Context, userID string) (int64, error)
+	PurgeUserSubjects(ctx context.Context, userID string) (int64, error)
+	PurgeUserSyncChanges(ctx context.Context, userID string) error
+	PurgeUserTopics(ctx context.Context, userID string) (int64, error)
+	ReleaseExpiredIdempotencyKey(ctx context.Context, arg ReleaseExpiredIdempotencyKeyParams) error
+	// Frees the key of a request that failed so a retry runs it again
+	ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error
+	ResetLoginFailures(ctx context.Context, id string) error
+	RestoreCycleItem(ctx context.Context, arg RestoreCycleItemParams) error
+	RestoreExerciseLog(ctx context.Context, arg RestoreExerciseLogParams) error
+	RestoreSessionPause(ctx context.Context, arg RestoreSessionPauseParams) error
+	RestoreStudyCycle(ctx context.Context, arg RestoreStudyCycleParams) error
+	RestoreStudySession(ctx context.Context, arg RestoreStudySessionParams) error
+	RestoreSubject(ctx context.Context, arg RestoreSubjectParams) error
+	RestoreTopic(ctx context.Context, arg RestoreTopicParams) error
+	RestoreTrashedStudyCycle(ctx context.Context, arg RestoreTrashedStudyCycleParams) (int64, error)
+	RestoreTrashedSubject(ctx context.Context, arg RestoreTrashedSubjectParams) (int64, error)
+	RestoreTrashedTopic(ctx context.Context, id string) (int64, error)
+	RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (int64, error)
+	TouchPersonalAccessToken(ctx context.Context, id string) error
+	// Returns no row when if_version is set and no longer matches
+	UpdateCycleItem(ctx context.Context, arg UpdateCycleItemParams) (CycleItem, error)
+	UpdateGoal(ctx context.Context, arg UpdateGoalParams) (int64, error)
 	UpdateSessionDuration(ctx context.Context, arg UpdateSessionDurationParams) error
-	UpdateStudyCycle(ctx context.Context, arg UpdateStudyCycleParams) error
-	UpdateSubject(ctx context.Context, arg UpdateSubjectParams) error
-	UpdateTopic(ctx context.Context, arg UpdateTopicParams) error
+	// if_version, when set, must match the current version for the update to apply
+	UpdateStudyCycle(ctx context.Context, arg UpdateStudyCycleParams) (StudyCycle, error)
+	// With if_version set the update only applies to that version; no row comes back otherwise
+	UpdateSubject(ctx context.Context, arg UpdateSubjectParams) (Subject, error)
+	// Only updates the version the client read when if_version is set
+	UpdateTopic(ctx context.Context, arg UpdateTopicParams) (Topic, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error
+	UpsertRecommendationWeights(ctx context.Context, arg UpsertRecommendationWeightsParams) (RecommendationWeight, error)
+	UpsertSyncCycleItem(ctx context.Context, arg UpsertSyncCycleItemParams) (int64, error)
+	UpsertSyncExerciseLog(ctx context.Context, arg UpsertSyncExerciseLogParams) (int64, error)
+	UpsertSyncSessionPause(ctx context.Context, arg UpsertSyncSessionPauseParams) (int64, error)
+	// Cycles have no owner; one holding another user's items is theirs too and isn't updated
+	UpsertSyncStudyCycle(ctx context.Context, arg UpsertSyncStudyCycleParams) (int64, error)
+	UpsertSyncStudySession(ctx context.Context, arg UpsertSyncStudySessionParams) (int64, error)
+	// Inserts under the client's ID, or updates the user's live subject; writes nothing otherwise
+	UpsertSyncSubject(ctx context.Context, arg UpsertSyncSubjectParams) (int64, error)
+	UpsertSyncTopic(ctx context.Context, arg UpsertSyncTopicParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
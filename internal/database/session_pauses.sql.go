@@ -45,6 +45,23 @@ func (q *Queries) DeleteSessionPause(ctx context.Context, id string) error {
 	return err
 }
 
+const endOpenSessionPauses = `-- name: EndOpenSessionPauses :exec
+UPDATE session_pauses
+SET ended_at = ?
+WHERE session_id = ? AND ended_at IS NULL
+`
+
+type EndOpenSessionPausesParams struct {
+	EndedAt   sql.NullString `json:"ended_at"`
+	SessionID string         `json:"session_id"`
+}
+
+// Ends the pauses of a session still running when it's stopped
+func (q *Queries) EndOpenSessionPauses(ctx context.Context, arg EndOpenSessionPausesParams) error {
+	_, err := q.db.ExecContext(ctx, endOpenSessionPauses, arg.EndedAt, arg.SessionID)
+	return err
+}
+
 const endSessionPause = `-- name: EndSessionPause :exec
 UPDATE session_pauses
 SET ended_at = ?
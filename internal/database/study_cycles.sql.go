@@ -13,7 +13,7 @@ import (
 const createStudyCycle = `-- name: CreateStudyCycle :one
 INSERT INTO study_cycles (id, name, description, is_active)
 VALUES (?, ?, ?, ?)
-RETURNING id, name, description, is_active, created_at, updated_at, deleted_at
+RETURNING id, name, description, is_active, created_at, updated_at, deleted_at, version
 `
 
 type CreateStudyCycleParams struct {
@@ -39,13 +39,14 @@ func (q *Queries) CreateStudyCycle(ctx context.Context, arg CreateStudyCyclePara
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const deleteStudyCycle = `-- name: DeleteStudyCycle :exec
 UPDATE study_cycles
-SET deleted_at = datetime('now')
+SET deleted_at = datetime('now'), version = version + 1
 WHERE id = ?
 `
 
@@ -110,7 +111,7 @@ func (q *Queries) GetActiveCycleWithItems(ctx context.Context) ([]GetActiveCycle
 }
 
 const getActiveStudyCycle = `-- name: GetActiveStudyCycle :one
-SELECT id, name, description, is_active, created_at, updated_at, deleted_at FROM study_cycles
+SELECT id, name, description, is_active, created_at, updated_at, deleted_at, version FROM study_cycles
 WHERE is_active = 1 AND deleted_at IS NULL
 LIMIT 1
 `
@@ -126,12 +127,13 @@ func (q *Queries) GetActiveStudyCycle(ctx context.Context) (StudyCycle, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getStudyCycle = `-- name: GetStudyCycle :one
-SELECT id, name, description, is_active, created_at, updated_at, deleted_at FROM study_cycles
+SELECT id, name, description, is_active, created_at, updated_at, deleted_at, version FROM study_cycles
 WHERE id = ? AND deleted_at IS NULL
 `
 
@@ -146,14 +148,66 @@ func (q *Queries) GetStudyCycle(ctx context.Context, id string) (StudyCycle, err
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
-const updateStudyCycle = `-- name: UpdateStudyCycle :exec
+const patchStudyCycle = `-- name: PatchStudyCycle :one
 UPDATE study_cycles
-SET name = ?, description = ?, is_active = ?, updated_at = datetime('now')
-WHERE id = ? AND deleted_at IS NULL
+SET name = CASE WHEN ?1 THEN ?2 ELSE name END,
+    description = CASE WHEN ?3 THEN ?4 ELSE description END,
+    is_active = CASE WHEN ?5 THEN ?6 ELSE is_active END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = ?7 AND deleted_at IS NULL
+  AND (?8 IS NULL OR version = ?8)
+RETURNING id, name, description, is_active, created_at, updated_at, deleted_at, version
+`
+
+type PatchStudyCycleParams struct {
+	SetName        bool           `json:"set_name"`
+	Name           string         `json:"name"`
+	SetDescription bool           `json:"set_description"`
+	Description    sql.NullString `json:"description"`
+	SetIsActive    bool           `json:"set_is_active"`
+	IsActive       sql.NullInt64  `json:"is_active"`
+	ID             string         `json:"id"`
+	IfVersion      sql.NullInt64  `json:"if_version"`
+}
+
+// Columns without their set_ flag keep their value
+func (q *Queries) PatchStudyCycle(ctx context.Context, arg PatchStudyCycleParams) (StudyCycle, error) {
+	row := q.db.QueryRowContext(ctx, patchStudyCycle,
+		arg.SetName,
+		arg.Name,
+		arg.SetDescription,
+		arg.Description,
+		arg.SetIsActive,
+		arg.IsActive,
+		arg.ID,
+		arg.IfVersion,
+	)
+	var i StudyCycle
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateStudyCycle = `-- name: UpdateStudyCycle :one
+UPDATE study_cycles
+SET name = ?1, description = ?2, is_active = ?3,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = ?4 AND deleted_at IS NULL
+  AND (?5 IS NULL OR version = ?5)
+RETURNING id, name, description, is_active, created_at, updated_at, deleted_at, version
 `
 
 type UpdateStudyCycleParams struct {
@@ -161,14 +215,28 @@ type UpdateStudyCycleParams struct {
 	Description sql.NullString `json:"description"`
 	IsActive    sql.NullInt64  `json:"is_active"`
 	ID          string         `json:"id"`
+	IfVersion   sql.NullInt64  `json:"if_version"`
 }
 
-func (q *Queries) UpdateStudyCycle(ctx context.Context, arg UpdateStudyCycleParams) error {
-	_, err := q.db.ExecContext(ctx, updateStudyCycle,
+// if_version, when set, must match the current version for the update to apply
+func (q *Queries) UpdateStudyCycle(ctx context.Context, arg UpdateStudyCycleParams) (StudyCycle, error) {
+	row := q.db.QueryRowContext(ctx, updateStudyCycle,
 		arg.Name,
 		arg.Description,
 		arg.IsActive,
 		arg.ID,
+		arg.IfVersion,
 	)
-	return err
+	var i StudyCycle
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
 }
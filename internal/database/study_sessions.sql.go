@@ -117,6 +117,62 @@ func (q *Queries) GetStudySession(ctx context.Context, id string) (StudySession,
 	return i, err
 }
 
+const getUserOpenSession = `-- name: GetUserOpenSession :one
+SELECT
+    ss.id,
+    ss.subject_id,
+    ss.cycle_item_id,
+    ss.started_at,
+    s.name AS subject_name,
+    s.color_hex,
+    CAST(COALESCE((
+        SELECT SUM(ROUND((julianday(sp.ended_at) - julianday(sp.started_at)) * 86400))
+        FROM session_pauses sp
+        WHERE sp.session_id = ss.id AND sp.ended_at IS NOT NULL
+    ), 0) AS INTEGER) AS paused_seconds,
+    CAST(COALESCE((
+        SELECT MAX(sp.started_at)
+        FROM session_pauses sp
+        WHERE sp.session_id = ss.id AND sp.ended_at IS NULL
+    ), '') AS TEXT) AS open_pause_started_at
+FROM study_sessions ss
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = ?
+  AND s.deleted_at IS NULL
+  AND ss.finished_at IS NULL
+ORDER BY datetime(ss.started_at) DESC
+LIMIT 1
+`
+
+type GetUserOpenSessionRow struct {
+	ID                 string         `json:"id"`
+	SubjectID          string         `json:"subject_id"`
+	CycleItemID        sql.NullString `json:"cycle_item_id"`
+	StartedAt          string         `json:"started_at"`
+	SubjectName        string         `json:"subject_name"`
+	ColorHex           sql.NullString `json:"color_hex"`
+	PausedSeconds      int64          `json:"paused_seconds"`
+	OpenPauseStartedAt string         `json:"open_pause_started_at"`
+}
+
+// The user's latest unfinished session, with the seconds of its ended pauses
+// and the start of the pause in progress (empty if none)
+func (q *Queries) GetUserOpenSession(ctx context.Context, userID string) (GetUserOpenSessionRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserOpenSession, userID)
+	var i GetUserOpenSessionRow
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectID,
+		&i.CycleItemID,
+		&i.StartedAt,
+		&i.SubjectName,
+		&i.ColorHex,
+		&i.PausedSeconds,
+		&i.OpenPauseStartedAt,
+	)
+	return i, err
+}
+
 const updateSessionDuration = `-- name: UpdateSessionDuration :exec
 UPDATE study_sessions
 SET finished_at = ?, gross_duration_seconds = ?, net_duration_seconds = ?, notes = ?
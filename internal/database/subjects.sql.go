@@ -11,16 +11,17 @@ import (
 )
 
 const createSubject = `-- name: CreateSubject :one
-INSERT INTO subjects (id, user_id, name, color_hex)
-VALUES (?, ?, ?, ?)
-RETURNING id, name, color_hex, created_at, updated_at, deleted_at, user_id
+INSERT INTO subjects (id, user_id, name, color_hex, exam_weight)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, name, color_hex, created_at, updated_at, deleted_at, user_id, exam_weight, version
 `
 
 type CreateSubjectParams struct {
-	ID       string         `json:"id"`
-	UserID   string         `json:"user_id"`
-	Name     string         `json:"name"`
-	ColorHex sql.NullString `json:"color_hex"`
+	ID         string          `json:"id"`
+	UserID     string          `json:"user_id"`
+	Name       string          `json:"name"`
+	ColorHex   sql.NullString  `json:"color_hex"`
+	ExamWeight sql.NullFloat64 `json:"exam_weight"`
 }
 
 func (q *Queries) CreateSubject(ctx context.Context, arg CreateSubjectParams) (Subject, error) {
@@ -29,6 +30,7 @@ func (q *Queries) CreateSubject(ctx context.Context, arg CreateSubjectParams) (S
 		arg.UserID,
 		arg.Name,
 		arg.ColorHex,
+		arg.ExamWeight,
 	)
 	var i Subject
 	err := row.Scan(
@@ -39,13 +41,15 @@ func (q *Queries) CreateSubject(ctx context.Context, arg CreateSubjectParams) (S
 		&i.UpdatedAt,
 		&i.DeletedAt,
 		&i.UserID,
+		&i.ExamWeight,
+		&i.Version,
 	)
 	return i, err
 }
 
 const deleteSubject = `-- name: DeleteSubject :exec
 UPDATE subjects
-SET deleted_at = datetime('now')
+SET deleted_at = datetime('now'), version = version + 1
 WHERE id = ? AND user_id = ?
 `
 
@@ -60,7 +64,7 @@ func (q *Queries) DeleteSubject(ctx context.Context, arg DeleteSubjectParams) er
 }
 
 const getSubject = `-- name: GetSubject :one
-SELECT id, name, color_hex, created_at, updated_at, deleted_at, user_id FROM subjects
+SELECT id, name, color_hex, created_at, updated_at, deleted_at, user_id, exam_weight, version FROM subjects
 WHERE id = ? AND user_id = ? AND deleted_at IS NULL
 `
 
@@ -80,12 +84,14 @@ func (q *Queries) GetSubject(ctx context.Context, arg GetSubjectParams) (Subject
 		&i.UpdatedAt,
 		&i.DeletedAt,
 		&i.UserID,
+		&i.ExamWeight,
+		&i.Version,
 	)
 	return i, err
 }
 
 const listSubjects = `-- name: ListSubjects :many
-SELECT id, name, color_hex, created_at, updated_at, deleted_at, user_id FROM subjects
+SELECT id, name, color_hex, created_at, updated_at, deleted_at, user_id, exam_weight, version FROM subjects
 WHERE user_id = ? AND deleted_at IS NULL
 ORDER BY name
 `
@@ -107,6 +113,8 @@ func (q *Queries) ListSubjects(ctx context.Context, userID string) ([]Subject, e
 			&i.UpdatedAt,
 			&i.DeletedAt,
 			&i.UserID,
+			&i.ExamWeight,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -121,25 +129,97 @@ func (q *Queries) ListSubjects(ctx context.Context, userID string) ([]Subject, e
 	return items, nil
 }
 
-const updateSubject = `-- name: UpdateSubject :exec
+const patchSubject = `-- name: PatchSubject :one
 UPDATE subjects
-SET name = ?, color_hex = ?, updated_at = datetime('now')
-WHERE id = ? AND user_id = ? AND deleted_at IS NULL
+SET name = CASE WHEN ?1 THEN ?2 ELSE name END,
+    color_hex = CASE WHEN ?3 THEN ?4 ELSE color_hex END,
+    exam_weight = CASE WHEN ?5 THEN ?6 ELSE exam_weight END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = ?7 AND user_id = ?8 AND deleted_at IS NULL
+  AND (?9 IS NULL OR version = ?9)
+RETURNING id, name, color_hex, created_at, updated_at, deleted_at, user_id, exam_weight, version
+`
+
+type PatchSubjectParams struct {
+	SetName       bool            `json:"set_name"`
+	Name          string          `json:"name"`
+	SetColorHex   bool            `json:"set_color_hex"`
+	ColorHex      sql.NullString  `json:"color_hex"`
+	SetExamWeight bool            `json:"set_exam_weight"`
+	ExamWeight    sql.NullFloat64 `json:"exam_weight"`
+	ID            string          `json:"id"`
+	UserID        string          `json:"user_id"`
+	IfVersion     sql.NullInt64   `json:"if_version"`
+}
+
+// Only columns whose set_ flag is true change, so a merge patch can leave a
+// field alone, null it or set it to a zero value
+func (q *Queries) PatchSubject(ctx context.Context, arg PatchSubjectParams) (Subject, error) {
+	row := q.db.QueryRowContext(ctx, patchSubject,
+		arg.SetName,
+		arg.Name,
+		arg.SetColorHex,
+		arg.ColorHex,
+		arg.SetExamWeight,
+		arg.ExamWeight,
+		arg.ID,
+		arg.UserID,
+		arg.IfVersion,
+	)
+	var i Subject
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ColorHex,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.UserID,
+		&i.ExamWeight,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateSubject = `-- name: UpdateSubject :one
+UPDATE subjects
+SET name = ?1, color_hex = ?2, exam_weight = ?3,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = ?4 AND user_id = ?5 AND deleted_at IS NULL
+  AND (?6 IS NULL OR version = ?6)
+RETURNING id, name, color_hex, created_at, updated_at, deleted_at, user_id, exam_weight, version
 `
 
 type UpdateSubjectParams struct {
-	Name     string         `json:"name"`
-	ColorHex sql.NullString `json:"color_hex"`
-	ID       string         `json:"id"`
-	UserID   string         `json:"user_id"`
+	Name       string          `json:"name"`
+	ColorHex   sql.NullString  `json:"color_hex"`
+	ExamWeight sql.NullFloat64 `json:"exam_weight"`
+	ID         string          `json:"id"`
+	UserID     string          `json:"user_id"`
+	IfVersion  sql.NullInt64   `json:"if_version"`
 }
 
-func (q *Queries) UpdateSubject(ctx context.Context, arg UpdateSubjectParams) error {
-	_, err := q.db.ExecContext(ctx, updateSubject,
+// With if_version set the update only applies to that version; no row comes back otherwise
+func (q *Queries) UpdateSubject(ctx context.Context, arg UpdateSubjectParams) (Subject, error) {
+	row := q.db.QueryRowContext(ctx, updateSubject,
 		arg.Name,
 		arg.ColorHex,
+		arg.ExamWeight,
 		arg.ID,
 		arg.UserID,
+		arg.IfVersion,
 	)
-	return err
+	var i Subject
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ColorHex,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.UserID,
+		&i.ExamWeight,
+		&i.Version,
+	)
+	return i, err
 }
@@ -7,12 +7,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createTopic = `-- name: CreateTopic :one
 INSERT INTO topics (id, subject_id, name)
 VALUES (?, ?, ?)
-RETURNING id, subject_id, name, created_at, updated_at, deleted_at
+RETURNING id, subject_id, name, created_at, updated_at, deleted_at, version
 `
 
 type CreateTopicParams struct {
@@ -31,13 +32,14 @@ func (q *Queries) CreateTopic(ctx context.Context, arg CreateTopicParams) (Topic
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const deleteTopic = `-- name: DeleteTopic :exec
 UPDATE topics
-SET deleted_at = datetime('now')
+SET deleted_at = datetime('now'), version = version + 1
 WHERE id = ?
 `
 
@@ -47,7 +49,7 @@ func (q *Queries) DeleteTopic(ctx context.Context, id string) error {
 }
 
 const getTopic = `-- name: GetTopic :one
-SELECT id, subject_id, name, created_at, updated_at, deleted_at FROM topics
+SELECT id, subject_id, name, created_at, updated_at, deleted_at, version FROM topics
 WHERE id = ? AND deleted_at IS NULL
 `
 
@@ -61,12 +63,13 @@ func (q *Queries) GetTopic(ctx context.Context, id string) (Topic, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.Version,
 	)
 	return i, err
 }
 
 const listTopicsBySubject = `-- name: ListTopicsBySubject :many
-SELECT id, subject_id, name, created_at, updated_at, deleted_at FROM topics
+SELECT id, subject_id, name, created_at, updated_at, deleted_at, version FROM topics
 WHERE subject_id = ? AND deleted_at IS NULL
 ORDER BY name
 `
@@ -87,6 +90,7 @@ func (q *Queries) ListTopicsBySubject(ctx context.Context, subjectID string) ([]
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -101,18 +105,68 @@ func (q *Queries) ListTopicsBySubject(ctx context.Context, subjectID string) ([]
 	return items, nil
 }
 
-const updateTopic = `-- name: UpdateTopic :exec
+const patchTopic = `-- name: PatchTopic :one
 UPDATE topics
-SET name = ?, updated_at = datetime('now')
-WHERE id = ? AND deleted_at IS NULL
+SET name = CASE WHEN ?1 THEN ?2 ELSE name END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = ?3 AND deleted_at IS NULL
+  AND (?4 IS NULL OR version = ?4)
+RETURNING id, subject_id, name, created_at, updated_at, deleted_at, version
+`
+
+type PatchTopicParams struct {
+	SetName   bool          `json:"set_name"`
+	Name      string        `json:"name"`
+	ID        string        `json:"id"`
+	IfVersion sql.NullInt64 `json:"if_version"`
+}
+
+func (q *Queries) PatchTopic(ctx context.Context, arg PatchTopicParams) (Topic, error) {
+	row := q.db.QueryRowContext(ctx, patchTopic,
+		arg.SetName,
+		arg.Name,
+		arg.ID,
+		arg.IfVersion,
+	)
+	var i Topic
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateTopic = `-- name: UpdateTopic :one
+UPDATE topics
+SET name = ?1, updated_at = datetime('now'), version = version + 1
+WHERE id = ?2 AND deleted_at IS NULL
+  AND (?3 IS NULL OR version = ?3)
+RETURNING id, subject_id, name, created_at, updated_at, deleted_at, version
 `
 
 type UpdateTopicParams struct {
-	Name string `json:"name"`
-	ID   string `json:"id"`
+	Name      string        `json:"name"`
+	ID        string        `json:"id"`
+	IfVersion sql.NullInt64 `json:"if_version"`
 }
 
-func (q *Queries) UpdateTopic(ctx context.Context, arg UpdateTopicParams) error {
-	_, err := q.db.ExecContext(ctx, updateTopic, arg.Name, arg.ID)
-	return err
+// Only updates the version the client read when if_version is set
+func (q *Queries) UpdateTopic(ctx context.Context, arg UpdateTopicParams) (Topic, error) {
+	row := q.db.QueryRowContext(ctx, updateTopic, arg.Name, arg.ID, arg.IfVersion)
+	var i Topic
+	err := row.Scan(
+		&i.ID,
+		&i.SubjectID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+	)
+	return i, err
 }
@@ -7,12 +7,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, email, name, password_hash)
 VALUES (?, ?, ?, ?)
-RETURNING id, email, name, created_at, password_hash
+RETURNING id, email, name, created_at, password_hash, failed_login_attempts, locked_until, timezone, locale, daily_goal_minutes, week_start_day, exam_date
 `
 
 type CreateUserParams struct {
@@ -36,12 +37,19 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Name,
 		&i.CreatedAt,
 		&i.PasswordHash,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.Timezone,
+		&i.Locale,
+		&i.DailyGoalMinutes,
+		&i.WeekStartDay,
+		&i.ExamDate,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, name, created_at, password_hash FROM users
+SELECT id, email, name, created_at, password_hash, failed_login_attempts, locked_until, timezone, locale, daily_goal_minutes, week_start_day, exam_date FROM users
 WHERE email = ? LIMIT 1
 `
 
@@ -54,10 +62,87 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Name,
 		&i.CreatedAt,
 		&i.PasswordHash,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.Timezone,
+		&i.Locale,
+		&i.DailyGoalMinutes,
+		&i.WeekStartDay,
+		&i.ExamDate,
 	)
 	return i, err
 }
 
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, email, name, created_at, password_hash, failed_login_attempts, locked_until, timezone, locale, daily_goal_minutes, week_start_day, exam_date FROM users
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.PasswordHash,
+		&i.FailedLoginAttempts,
+		&i.LockedUntil,
+		&i.Timezone,
+		&i.Locale,
+		&i.DailyGoalMinutes,
+		&i.WeekStartDay,
+		&i.ExamDate,
+	)
+	return i, err
+}
+
+const incrementLoginFailures = `-- name: IncrementLoginFailures :one
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE id = ?
+RETURNING failed_login_attempts
+`
+
+// Counts a failed login in place, so concurrent failures all count
+func (q *Queries) IncrementLoginFailures(ctx context.Context, id string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, incrementLoginFailures, id)
+	var failed_login_attempts int64
+	err := row.Scan(&failed_login_attempts)
+	return failed_login_attempts, err
+}
+
+const lockUser = `-- name: LockUser :exec
+UPDATE users
+SET locked_until = ?
+WHERE id = ? AND failed_login_attempts = ?
+`
+
+type LockUserParams struct {
+	LockedUntil         sql.NullTime `json:"locked_until"`
+	ID                  string       `json:"id"`
+	FailedLoginAttempts int64        `json:"failed_login_attempts"`
+}
+
+// Only while the failures are still the ones the lock was computed for, so a
+// slower request can't shorten the lock of a later failure
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) error {
+	_, err := q.db.ExecContext(ctx, lockUser, arg.LockedUntil, arg.ID, arg.FailedLoginAttempts)
+	return err
+}
+
+const resetLoginFailures = `-- name: ResetLoginFailures :exec
+UPDATE users
+SET failed_login_attempts = 0, locked_until = NULL
+WHERE id = ?
+`
+
+func (q *Queries) ResetLoginFailures(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, resetLoginFailures, id)
+	return err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
 SET password_hash = ?
@@ -73,3 +158,32 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.PasswordHash, arg.ID)
 	return err
 }
+
+const updateUserProfile = `-- name: UpdateUserProfile :exec
+UPDATE users
+SET name = ?, timezone = ?, locale = ?, daily_goal_minutes = ?, week_start_day = ?, exam_date = ?
+WHERE id = ?
+`
+
+type UpdateUserProfileParams struct {
+	Name             string         `json:"name"`
+	Timezone         string         `json:"timezone"`
+	Locale           string         `json:"locale"`
+	DailyGoalMinutes int64          `json:"daily_goal_minutes"`
+	WeekStartDay     int64          `json:"week_start_day"`
+	ExamDate         sql.NullString `json:"exam_date"`
+	ID               string         `json:"id"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserProfile,
+		arg.Name,
+		arg.Timezone,
+		arg.Locale,
+		arg.DailyGoalMinutes,
+		arg.WeekStartDay,
+		arg.ExamDate,
+		arg.ID,
+	)
+	return err
+}
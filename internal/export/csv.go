@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type csvWriter struct {
+	w        *csv.Writer
+	settings Settings
+}
+
+func newCSVWriter(w io.Writer, settings Settings) (*csvWriter, error) {
+	// Spreadsheets only read the file as UTF-8 with a byte order mark
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = settings.Locale.Delimiter
+	return &csvWriter{w: cw, settings: settings}, nil
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) Write(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = c.format(cellValue(v))
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return escapeFormula(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ""
+		}
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if c.settings.Locale.Decimal != '.' {
+			s = strings.Replace(s, ".", string(c.settings.Locale.Decimal), 1)
+		}
+		return s
+	case bool:
+		return strconv.FormatBool(v)
+	case Date:
+		return v.Format(c.settings.Locale.DateLayout)
+	case time.Time:
+		return v.In(c.settings.Location).Format(c.settings.Locale.DateLayout + " 15:04:05")
+	}
+	return ""
+}
+
+// escapeFormula keeps spreadsheets from running text as a formula, as they
+// do with a cell starting with one of =+-@ (or a tab or carriage return
+// before one): a leading ' makes it plain text.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,122 @@
+// Package export writes tables as CSV or XLSX, localized to a user's profile.
+package export
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeCSV  = "text/csv"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// ContentType is the media type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return ContentTypeCSV + "; charset=utf-8"
+	case FormatXLSX:
+		return ContentTypeXLSX
+	}
+	return ContentTypeJSON
+}
+
+// Settings are how a user's tables are written
+type Settings struct {
+	Locale   Locale
+	Location *time.Location // Timestamps are shown in it; nil for UTC
+}
+
+// Date is a calendar day, written without a time of day or timezone shift
+type Date struct {
+	time.Time
+}
+
+// Writer writes a table row by row, so large exports never sit in memory
+type Writer interface {
+	// WriteHeader writes the column names; call it once, before any row
+	WriteHeader(columns []string) error
+	// Write writes a row. Values are strings, integers, floats, booleans,
+	// time.Time, Date, pointers to them and sql.Null* types; nil is an empty cell.
+	Write(values []any) error
+	// Close flushes the table; it doesn't close the underlying writer
+	Close() error
+}
+
+// NewWriter starts a CSV or XLSX table on w. sheet names the XLSX worksheet.
+func NewWriter(format Format, w io.Writer, settings Settings, sheet string) (Writer, error) {
+	if settings.Location == nil {
+		settings.Location = time.UTC
+	}
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, settings)
+	case FormatXLSX:
+		return newXLSXWriter(w, settings, sheet)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// WriteTable writes rows, a slice of structs, with a header from Columns
+func WriteTable(w Writer, rows any) error {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("export: rows must be a slice, got %T", rows)
+	}
+	if err := w.WriteHeader(Columns(v.Type().Elem())); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := w.Write(Values(v.Index(i).Interface())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cellValue reduces v to nil, string, int64, float64, bool, time.Time or Date
+func cellValue(v any) any {
+	switch v := v.(type) {
+	case nil, string, int64, float64, bool, time.Time, Date:
+		return v
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return nil
+		}
+		return cellValue(value)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		return cellValue(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRow struct {
+	Name     string   `json:"name"`
+	Hours    float64  `json:"hours"`
+	Day      string   `json:"day" export:"date"`
+	Tags     []string `json:"tags"`
+	Secret   string   `json:"-"`
+	Extra    map[string]int
+	Accuracy *float64 `json:"accuracy"`
+	Nested   struct {
+		Count int `json:"count"`
+	} `json:"nested"`
+	At time.Time `json:"at"`
+}
+
+func TestLocaleFor(t *testing.T) {
+	assert.Equal(t, DefaultLocale, LocaleFor(""))
+	assert.Equal(t, DefaultLocale, LocaleFor("xx"))
+
+	br := LocaleFor("pt-BR")
+	assert.Equal(t, byte(','), br.Decimal)
+	assert.Equal(t, ';', br.Delimiter)
+	assert.Equal(t, "02/01/2006", br.DateLayout)
+
+	assert.Equal(t, "01/02/2006", LocaleFor("en-US").DateLayout)
+	assert.Equal(t, "02/01/2006", LocaleFor("en-GB").DateLayout)
+	assert.Equal(t, "02.01.2006", LocaleFor("de").DateLayout)
+}
+
+func TestColumnsAndValues(t *testing.T) {
+	assert.Equal(t, []string{"name", "hours", "day", "tags", "accuracy", "nested_count", "at"}, Columns(reflect.TypeOf(testRow{})))
+
+	row := testRow{Name: "Math", Hours: 1.5, Day: "2024-03-01", Tags: []string{"a", "b"}}
+	row.Nested.Count = 3
+	values := Values(row)
+	assert.Equal(t, "Math", values[0])
+	assert.Equal(t, Date{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, values[2])
+	assert.Equal(t, "a, b", values[3])
+	assert.Nil(t, values[4])
+	assert.Equal(t, 3, values[5])
+}
+
+func TestCSVWriter(t *testing.T) {
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	settings := Settings{Locale: LocaleFor("pt-BR"), Location: loc}
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, settings, "rows")
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteHeader([]string{"name", "hours", "day", "at"}))
+	assert.NoError(t, w.Write([]any{"Math", 1.5, Date{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)}))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "\ufeffname;hours;day;at\nMath;1,5;01/03/2024;01/03/2024 12:00:00\n", buf.String())
+}
+
+func TestCSVWriter_Formulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, Settings{Locale: DefaultLocale, Location: time.UTC}, "rows")
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.Write([]any{"=1+1", "+SUM(A1)", "-2", "@cmd", "\t=HYPERLINK()", "a=b", -1.5, int64(-3)}))
+	assert.NoError(t, w.Close())
+
+	// Text is neutered; numbers stay numbers
+	assert.Equal(t, "\ufeff'=1+1,'+SUM(A1),'-2,'@cmd,'\t=HYPERLINK(),a=b,-1.5,-3\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf, Settings{Locale: LocaleFor("de-DE")}, "Study sessions")
+	assert.NoError(t, err)
+	assert.NoError(t, w.WriteHeader([]string{"name", "hours"}))
+	assert.NoError(t, w.Write([]any{"A & B", 2.25}))
+	assert.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+
+	assert.Contains(t, parts, "[Content_Types].xml")
+	assert.Contains(t, parts["xl/workbook.xml"], `name="Study sessions"`)
+	assert.Contains(t, parts["xl/styles.xml"], `formatCode="dd.mm.yyyy"`)
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, "A &amp; B")
+	assert.Contains(t, sheet, "<v>2.25</v>")
+}
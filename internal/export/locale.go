@@ -0,0 +1,96 @@
+package export
+
+import (
+	"strings"
+)
+
+// Locale is how numbers and dates are written for a profile's language
+type Locale struct {
+	Decimal    byte   // Decimal separator
+	Delimiter  rune   // CSV field separator: ';' where the decimal separator is ','
+	DateLayout string // Go layout of a date
+	DateFormat string // The same date as an Excel number format
+}
+
+// Date orders, as Go layouts and their Excel equivalents
+var (
+	dateISO      = [2]string{"2006-01-02", "yyyy-mm-dd"}
+	dateDMYSlash = [2]string{"02/01/2006", "dd/mm/yyyy"}
+	dateMDYSlash = [2]string{"01/02/2006", "mm/dd/yyyy"}
+	dateDMYDot   = [2]string{"02.01.2006", "dd.mm.yyyy"}
+	dateDMYDash  = [2]string{"02-01-2006", "dd-mm-yyyy"}
+	dateYMDSlash = [2]string{"2006/01/02", "yyyy/mm/dd"}
+)
+
+func newLocale(decimal byte, date [2]string) Locale {
+	l := Locale{Decimal: decimal, Delimiter: ',', DateLayout: date[0], DateFormat: date[1]}
+	if decimal == ',' {
+		l.Delimiter = ';'
+	}
+	return l
+}
+
+// Conventions per language, and the regions that differ from theirs
+var (
+	languageLocales = map[string]Locale{
+		"en": newLocale('.', dateMDYSlash),
+		"pt": newLocale(',', dateDMYSlash),
+		"es": newLocale(',', dateDMYSlash),
+		"fr": newLocale(',', dateDMYSlash),
+		"it": newLocale(',', dateDMYSlash),
+		"ca": newLocale(',', dateDMYSlash),
+		"el": newLocale(',', dateDMYSlash),
+		"id": newLocale(',', dateDMYSlash),
+		"de": newLocale(',', dateDMYDot),
+		"da": newLocale(',', dateDMYDot),
+		"nb": newLocale(',', dateDMYDot),
+		"fi": newLocale(',', dateDMYDot),
+		"cs": newLocale(',', dateDMYDot),
+		"pl": newLocale(',', dateDMYDot),
+		"ro": newLocale(',', dateDMYDot),
+		"ru": newLocale(',', dateDMYDot),
+		"uk": newLocale(',', dateDMYDot),
+		"tr": newLocale(',', dateDMYDot),
+		"nl": newLocale(',', dateDMYDash),
+		"sv": newLocale(',', dateISO),
+		"ja": newLocale('.', dateYMDSlash),
+		"zh": newLocale('.', dateYMDSlash),
+		"ko": newLocale('.', dateISO),
+	}
+	regionLocales = map[string]Locale{
+		"en-GB": newLocale('.', dateDMYSlash),
+		"en-IE": newLocale('.', dateDMYSlash),
+		"en-AU": newLocale('.', dateDMYSlash),
+		"en-NZ": newLocale('.', dateDMYSlash),
+		"en-IN": newLocale('.', dateDMYSlash),
+		"en-CA": newLocale('.', dateISO),
+		"fr-CA": newLocale(',', dateISO),
+		"es-MX": newLocale('.', dateDMYSlash),
+		"es-US": newLocale('.', dateMDYSlash),
+		"de-CH": newLocale('.', dateDMYDot),
+	}
+)
+
+// DefaultLocale is used for languages without known conventions: ISO dates
+// and a decimal point
+var DefaultLocale = newLocale('.', dateISO)
+
+// LocaleFor returns the conventions of a BCP 47 tag such as "pt-BR", falling
+// back from the region to the language and then to DefaultLocale.
+func LocaleFor(tag string) Locale {
+	parts := strings.Split(strings.ReplaceAll(tag, "_", "-"), "-")
+	language := strings.ToLower(parts[0])
+	for _, part := range parts[1:] {
+		// The region is the first two-letter subtag; scripts have four
+		if len(part) == 2 {
+			if l, ok := regionLocales[language+"-"+strings.ToUpper(part)]; ok {
+				return l
+			}
+			break
+		}
+	}
+	if l, ok := languageLocales[language]; ok {
+		return l
+	}
+	return DefaultLocale
+}
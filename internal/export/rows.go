@@ -0,0 +1,168 @@
+package export
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	dateType   = reflect.TypeOf(Date{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// column is a leaf field of a response struct
+type column struct {
+	name  string
+	index []int
+	kind  string // The export tag: "date" or "datetime" for timestamps kept as strings
+}
+
+// columns walks t's fields the way encoding/json names them. Embedded structs
+// are inlined, nested structs get their field name as a prefix, and slices
+// other than lists of strings or integers are left out. `export:"-"` skips a
+// field.
+func columns(t reflect.Type, prefix string, index []int) []column {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var cols []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		kind := f.Tag.Get("export")
+		if name == "-" || kind == "-" {
+			continue
+		}
+		idx := append(append([]int{}, index...), i)
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch {
+		case ft.Kind() == reflect.Struct && ft != timeType && ft != dateType && !reflect.PointerTo(ft).Implements(valuerType):
+			if f.Anonymous && name == "" {
+				cols = append(cols, columns(ft, prefix, idx)...)
+			} else {
+				cols = append(cols, columns(ft, prefix+jsonName(f, name)+"_", idx)...)
+			}
+		case ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array:
+			if isListable(ft.Elem()) {
+				cols = append(cols, column{name: prefix + jsonName(f, name), index: idx})
+			}
+		case ft.Kind() == reflect.Map || ft.Kind() == reflect.Func || ft.Kind() == reflect.Chan:
+		default:
+			cols = append(cols, column{name: prefix + jsonName(f, name), index: idx, kind: kind})
+		}
+	}
+	return cols
+}
+
+func jsonName(f reflect.StructField, name string) string {
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func isListable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// Columns returns the header for rows of struct type t: the JSON field names,
+// flattened as described in columns
+func Columns(t reflect.Type) []string {
+	cols := columns(t, "", nil)
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.name
+	}
+	return names
+}
+
+// Values returns the cells of a struct in Columns order. Fields under a nil
+// pointer are empty, lists are joined with ", " and strings tagged
+// `export:"date"` or `export:"datetime"` become dates.
+func Values(v any) []any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	cols := columns(rv.Type(), "", nil)
+	values := make([]any, len(cols))
+	for i, c := range cols {
+		field, err := rv.FieldByIndexErr(c.index)
+		if err != nil {
+			continue // Behind a nil pointer
+		}
+		values[i] = fieldValue(field, c.kind)
+	}
+	return values
+}
+
+func fieldValue(field reflect.Value, kind string) any {
+	for field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, field.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(field.Index(i).Interface())
+		}
+		return strings.Join(items, ", ")
+	case reflect.String:
+		s := field.String()
+		if s == "" {
+			return nil
+		}
+		switch kind {
+		case "date":
+			if t, err := time.Parse("2006-01-02", s); err == nil {
+				return Date{t}
+			}
+		case "datetime":
+			if t, err := parseTimestamp(s); err == nil {
+				return t
+			}
+		}
+		return s
+	}
+	if kind == "date" && field.Type() == timeType {
+		return Date{field.Interface().(time.Time)}
+	}
+	return field.Interface()
+}
+
+// parseTimestamp reads RFC 3339 timestamps and SQLite's zone-less UTC ones
+func parseTimestamp(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A workbook with one worksheet, streamed into the zip as rows are written.
+// Styles: 1 is a date, 2 a date and time, 3 the bold header.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="2"><numFmt numFmtId="164" formatCode="%[1]s"/><numFmt numFmtId="165" formatCode="%[1]s hh:mm:ss"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`
+
+	// The header row stays in view while scrolling
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><sheetData>`
+
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+const (
+	xlsxStyleDate     = 1
+	xlsxStyleDateTime = 2
+	xlsxStyleHeader   = 3
+)
+
+// excelEpoch is day 0 of Excel's date serial numbers
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type xlsxWriter struct {
+	zip      *zip.Writer
+	sheet    *bufio.Writer
+	settings Settings
+	rows     int
+}
+
+func newXLSXWriter(w io.Writer, settings Settings, sheet string) (*xlsxWriter, error) {
+	x := &xlsxWriter{zip: zip.NewWriter(w), settings: settings}
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xmlEscape(sheetName(sheet)))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", fmt.Sprintf(xlsxStyles, xmlEscape(settings.Locale.DateFormat))},
+	}
+	for _, part := range parts {
+		f, err := x.zip.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x.sheet = bufio.NewWriter(f)
+	if _, err := x.sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxWriter) WriteHeader(columns []string) error {
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return x.writeRow(values, xlsxStyleHeader)
+}
+
+func (x *xlsxWriter) Write(values []any) error {
+	return x.writeRow(values, 0)
+}
+
+// writeRow writes one <row>; style applies to cells without a style of their own
+func (x *xlsxWriter) writeRow(values []any, style int) error {
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(x.rows)
+		switch v := cellValue(v).(type) {
+		case string:
+			// Inline strings are only ever text, never formulas
+			fmt.Fprintf(x.sheet, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, styleAttr(style))
+			xml.EscapeText(x.sheet, []byte(v))
+			x.sheet.WriteString(`</t></is></c>`)
+		case int64:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%d</v></c>`, ref, styleAttr(style), v)
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(style), strconv.FormatFloat(v, 'g', -1, 64))
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s"%s t="b"><v>%d</v></c>`, ref, styleAttr(style), b)
+		case Date:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(xlsxStyleDate), excelSerial(v.Time))
+		case time.Time:
+			fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(xlsxStyleDateTime), excelSerial(v.In(x.settings.Location)))
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+func styleAttr(style int) string {
+	if style == 0 {
+		return ""
+	}
+	return ` s="` + strconv.Itoa(style) + `"`
+}
+
+// excelSerial is t's wall clock as days since excelEpoch
+func excelSerial(t time.Time) string {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	days := float64(wall.Unix()-excelEpoch.Unix()) / 86400
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+// columnName converts a 0-based index to A, B, ..., Z, AA, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// sheetName drops the characters Excel forbids in sheet names and its 31 character limit
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+// DeletionReceiptHeader carries the receipt token when reading a deletion report
+const DeletionReceiptHeader = "X-Deletion-Receipt"
+
+type AccountDeletionHandler struct {
+	svc      service.AccountDeletionService
+	validate *validator.Validate
+}
+
+func NewAccountDeletionHandler(svc service.AccountDeletionService) *AccountDeletionHandler {
+	return &AccountDeletionHandler{svc: svc, validate: validator.New()}
+}
+
+type DeleteAccountRequest struct {
+	Password  string `json:"password" validate:"required"`
+	GraceDays *int   `json:"grace_days" validate:"omitnil,min=0,max=30"` // Defaults to the server's grace period; 0 purges at once
+}
+
+// DeleteMe godoc
+// @Summary Delete the authenticated account
+// @Description Re-checks the password and schedules the account for deletion. Until purge_after the account keeps working and POST /me/restore cancels the deletion; then every row of the account is purged. With grace_days 0 the purge happens right away and the response carries the report. Keep the receipt: with it, GET /account-deletions/{id} shows the report after the account is gone.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param input body DeleteAccountRequest true "Password and optional grace period"
+// @Success 200 {object} handler.AccountDeletionResponse "Purged"
+// @Success 202 {object} handler.AccountDeletionResponse "Scheduled"
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me [delete]
+func (h *AccountDeletionHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	var grace *time.Duration
+	if req.GraceDays != nil {
+		d := time.Duration(*req.GraceDays) * 24 * time.Hour
+		grace = &d
+	}
+
+	deletion, err := h.svc.RequestDeletion(r.Context(), userID.(string), req.Password, grace)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			h.respondWithError(w, http.StatusUnauthorized, "Password is incorrect")
+		case errors.Is(err, service.ErrDeletionPending):
+			h.respondWithError(w, http.StatusConflict, "Account deletion already requested")
+		case errors.Is(err, service.ErrInvalidGracePeriod):
+			h.respondWithError(w, http.StatusBadRequest, "grace_days is out of range")
+		case errors.Is(err, service.ErrUserNotFound):
+			h.respondWithError(w, http.StatusNotFound, "User not found")
+		default:
+			slog.Error("Failed to delete account", "error", err)
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	status := http.StatusAccepted
+	if deletion.PurgedAt != nil {
+		status = http.StatusOK
+	}
+	h.respondWithJSON(w, status, toAccountDeletionResponse(deletion))
+}
+
+// GetMyDeletion godoc
+// @Summary Get the pending deletion of the authenticated account
+// @Tags users
+// @Produce json
+// @Success 200 {object} handler.AccountDeletionResponse
+// @Failure 404 {object} map[string]string
+// @Router /me/deletion [get]
+func (h *AccountDeletionHandler) GetMyDeletion(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	deletion, err := h.svc.GetPendingDeletion(r.Context(), userID.(string))
+	if err != nil {
+		if errors.Is(err, service.ErrNoPendingDeletion) {
+			h.respondWithError(w, http.StatusNotFound, "No account deletion pending")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toAccountDeletionResponse(deletion))
+}
+
+// RestoreMe godoc
+// @Summary Restore the authenticated account
+// @Description Cancels a pending deletion during its grace period.
+// @Tags users
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Router /me/restore [post]
+func (h *AccountDeletionHandler) RestoreMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := h.svc.RestoreAccount(r.Context(), userID.(string)); err != nil {
+		if errors.Is(err, service.ErrNoPendingDeletion) {
+			h.respondWithError(w, http.StatusNotFound, "No account deletion pending")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeletionReport godoc
+// @Summary Get an account deletion report
+// @Description Public: the receipt returned by DELETE /me authorizes it, so the report stays readable after the account is gone. deleted_rows lists the rows purged per table; verified is true when they match the rows counted before the purge and none are left.
+// @Tags users
+// @Produce json
+// @Param id path string true "Deletion ID"
+// @Param X-Deletion-Receipt header string true "Receipt token"
+// @Success 200 {object} handler.AccountDeletionResponse
+// @Failure 404 {object} map[string]string
+// @Router /account-deletions/{id} [get]
+func (h *AccountDeletionHandler) GetDeletionReport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	receipt := r.Header.Get(DeletionReceiptHeader)
+	if receipt == "" {
+		h.respondWithError(w, http.StatusNotFound, "Account deletion not found")
+		return
+	}
+
+	deletion, err := h.svc.GetDeletion(r.Context(), id, receipt)
+	if err != nil {
+		if errors.Is(err, service.ErrDeletionNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "Account deletion not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toAccountDeletionResponse(deletion))
+}
+
+func toAccountDeletionResponse(deletion service.AccountDeletion) AccountDeletionResponse {
+	status := "scheduled"
+	if deletion.PurgedAt != nil {
+		status = "purged"
+	}
+	return AccountDeletionResponse{
+		ID:          deletion.ID,
+		Receipt:     deletion.Receipt,
+		Status:      status,
+		RequestedAt: deletion.RequestedAt,
+		PurgeAfter:  deletion.PurgeAfter,
+		PurgedAt:    deletion.PurgedAt,
+		DeletedRows: deletion.DeletedRows,
+		Verified:    deletion.Verified,
+	}
+}
+
+func (h *AccountDeletionHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *AccountDeletionHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -2,35 +2,60 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/joaoapaenas/my-api/internal/service"
 )
 
 type AnalyticsHandler struct {
-	svc service.AnalyticsService
+	svc     service.AnalyticsService
+	exports service.ExportService // nil without CSV and XLSX responses
 }
 
 func NewAnalyticsHandler(svc service.AnalyticsService) *AnalyticsHandler {
 	return &AnalyticsHandler{svc: svc}
 }
 
+// WithExports lets the reports answer as CSV or XLSX, see writeTable
+func (h *AnalyticsHandler) WithExports(exports service.ExportService) *AnalyticsHandler {
+	h.exports = exports
+	return h
+}
+
 // GetTimeReport godoc
 // @Summary Get net study time report by subject
 // @Tags analytics
 // @Produce json
-// @Param start_date_from query string false "Start Date From (YYYY-MM-DD)"
-// @Param start_date_to query string false "Start Date To (YYYY-MM-DD)"
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param start_date_from query string false "Start Date From (YYYY-MM-DD, local day)"
+// @Param start_date_to query string false "Start Date To (YYYY-MM-DD, inclusive)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
 // @Success 200 {array} handler.TimeReportResponse
+// @Failure 400 {object} map[string]string
 // @Router /analytics/time-report [get]
 func (h *AnalyticsHandler) GetTimeReport(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
 	startDateFrom := r.URL.Query().Get("start_date_from")
 	startDateTo := r.URL.Query().Get("start_date_to")
 
-	report, err := h.svc.GetTimeReport(r.Context(), startDateFrom, startDateTo)
+	report, err := h.svc.GetTimeReport(r.Context(), userID, cal, startDateFrom, startDateTo)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
@@ -41,12 +66,16 @@ func (h *AnalyticsHandler) GetTimeReport(w http.ResponseWriter, r *http.Request)
 		response[i] = TimeReportResponse{
 			SubjectID:     row.SubjectID,
 			SubjectName:   row.SubjectName,
-			ColorHex:      row.ColorHex.String, // Extract string from sql.NullString
-			SessionsCount: int(row.SessionsCount),
+			ColorHex:      row.ColorHex,
+			SessionsCount: row.SessionsCount,
 			TotalHoursNet: row.TotalHoursNet,
 		}
 	}
 
+	if writeTable(w, r, h.exports, "time-by-subject", response) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, response)
 }
 
@@ -54,11 +83,30 @@ func (h *AnalyticsHandler) GetTimeReport(w http.ResponseWriter, r *http.Request)
 // @Summary Get global accuracy by subject
 // @Tags analytics
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param start_date_from query string false "Start Date From (YYYY-MM-DD, local day)"
+// @Param start_date_to query string false "Start Date To (YYYY-MM-DD, inclusive)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
 // @Success 200 {array} handler.AccuracyReportResponse
+// @Failure 400 {object} map[string]string
 // @Router /analytics/accuracy [get]
 func (h *AnalyticsHandler) GetGlobalAccuracy(w http.ResponseWriter, r *http.Request) {
-	report, err := h.svc.GetGlobalAccuracy(r.Context())
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	startDateFrom := r.URL.Query().Get("start_date_from")
+	startDateTo := r.URL.Query().Get("start_date_to")
+
+	report, err := h.svc.GetGlobalAccuracy(r.Context(), userID, cal, startDateFrom, startDateTo)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
@@ -76,6 +124,10 @@ func (h *AnalyticsHandler) GetGlobalAccuracy(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	if writeTable(w, r, h.exports, "accuracy-by-subject", response) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, response)
 }
 
@@ -83,17 +135,25 @@ func (h *AnalyticsHandler) GetGlobalAccuracy(w http.ResponseWriter, r *http.Requ
 // @Summary Get weak points (accuracy by topic) for a subject
 // @Tags analytics
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param subject_id path string true "Subject ID"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
 // @Success 200 {array} handler.TopicAccuracyResponse
 // @Router /analytics/weak-points/{subject_id} [get]
 func (h *AnalyticsHandler) GetWeakPoints(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
 	subjectID := chi.URLParam(r, "subject_id")
 	if subjectID == "" {
 		h.respondWithError(w, http.StatusBadRequest, "Subject ID is required")
 		return
 	}
 
-	report, err := h.svc.GetWeakPoints(r.Context(), subjectID)
+	report, err := h.svc.GetWeakPoints(r.Context(), userID, subjectID)
 	if err != nil {
 		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
@@ -111,17 +171,446 @@ func (h *AnalyticsHandler) GetWeakPoints(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if writeTable(w, r, h.exports, "accuracy-by-topic", response) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, response)
 }
 
+// RankWeakPoints godoc
+// @Summary Rank weak topics across all subjects
+// @Description Topics ordered by the Wilson lower bound (95%) of their miss rate, with exercise logs weighted by recency. Unlike the raw accuracy, a topic with a few answers only ranks high with strong evidence. Topics with too few questions are left out.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param subject_id query string false "Subject ID"
+// @Param min_questions query int false "Minimum questions per topic (default 10)"
+// @Param half_life_days query int false "Age in days at which an exercise counts half (default 30)"
+// @Param limit query int false "Maximum number of topics"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {array} handler.WeakPointResponse
+// @Failure 400 {object} map[string]string
+// @Router /analytics/weak-points [get]
+func (h *AnalyticsHandler) RankWeakPoints(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	query := service.WeakPointQuery{SubjectID: r.URL.Query().Get("subject_id")}
+	var err error
+	if query.MinQuestions, err = positiveQueryInt(r, "min_questions"); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	halfLife, err := positiveQueryInt(r, "half_life_days")
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := positiveQueryInt(r, "limit")
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.HalfLifeDays, query.Limit = int(halfLife), int(limit)
+
+	points, err := h.svc.RankWeakPoints(r.Context(), userID, query)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := make([]WeakPointResponse, len(points))
+	for i, point := range points {
+		response[i] = WeakPointResponse{
+			TopicID:                    point.TopicID,
+			TopicName:                  point.TopicName,
+			SubjectID:                  point.SubjectID,
+			SubjectName:                point.SubjectName,
+			TotalQuestions:             int(point.Questions),
+			TotalCorrect:               int(point.Correct),
+			AccuracyPercentage:         point.Accuracy,
+			WeightedQuestions:          point.WeightedQuestions,
+			WeightedAccuracyPercentage: point.WeightedAccuracy,
+			WeaknessScore:              point.Score,
+			LastPracticedAt:            point.LastPracticed.UTC().Format(time.RFC3339),
+		}
+	}
+
+	if writeTable(w, r, h.exports, "weak-points", response) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetFocus godoc
+// @Summary Get focus quality from session pauses
+// @Description Net/gross ratio, pauses per gross hour, average and longest pause and the pause length distribution, overall and by subject, time of day and weekday. Sessions count where they started, in the user's timezone.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param start_date_from query string false "Start Date From (YYYY-MM-DD, local day)"
+// @Param start_date_to query string false "Start Date To (YYYY-MM-DD, inclusive)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {object} handler.FocusReportResponse
+// @Failure 400 {object} map[string]string
+// @Router /analytics/focus [get]
+func (h *AnalyticsHandler) GetFocus(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	startDateFrom := r.URL.Query().Get("start_date_from")
+	startDateTo := r.URL.Query().Get("start_date_to")
+
+	report, err := h.svc.GetFocus(r.Context(), userID, cal, startDateFrom, startDateTo)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := FocusReportResponse{
+		Overall:     toFocusStatsResponse(report.Overall),
+		BySubject:   make([]SubjectFocusResponse, len(report.BySubject)),
+		ByTimeOfDay: make([]TimeOfDayFocusResponse, len(report.ByTimeOfDay)),
+		ByWeekday:   make([]WeekdayFocusResponse, len(report.ByWeekday)),
+	}
+	for i, subject := range report.BySubject {
+		response.BySubject[i] = SubjectFocusResponse{
+			SubjectID:          subject.SubjectID,
+			SubjectName:        subject.SubjectName,
+			ColorHex:           subject.ColorHex,
+			FocusStatsResponse: toFocusStatsResponse(subject.FocusStats),
+		}
+	}
+	for i, period := range report.ByTimeOfDay {
+		response.ByTimeOfDay[i] = TimeOfDayFocusResponse{
+			TimeOfDay:          period.Name,
+			StartHour:          period.StartHour,
+			EndHour:            period.EndHour,
+			FocusStatsResponse: toFocusStatsResponse(period.FocusStats),
+		}
+	}
+	for i, day := range report.ByWeekday {
+		response.ByWeekday[i] = WeekdayFocusResponse{
+			Weekday:            int(day.Weekday),
+			WeekdayName:        day.Weekday.String(),
+			FocusStatsResponse: toFocusStatsResponse(day.FocusStats),
+		}
+	}
+
+	if writeTable(w, r, h.exports, "focus-by-subject", response.BySubject) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func toFocusStatsResponse(stats service.FocusStats) FocusStatsResponse {
+	lengths := make([]PauseLengthResponse, len(stats.PauseLengths))
+	for i, bucket := range stats.PauseLengths {
+		lengths[i] = PauseLengthResponse{
+			Label:      bucket.Label,
+			MinSeconds: bucket.MinSeconds,
+			MaxSeconds: bucket.MaxSeconds,
+			Count:      bucket.Count,
+		}
+	}
+	return FocusStatsResponse{
+		SessionsCount:       stats.Sessions,
+		GrossHours:          secondsToHours(stats.GrossSeconds),
+		NetHours:            secondsToHours(stats.NetSeconds),
+		FocusRatio:          stats.FocusRatio,
+		PausesCount:         stats.Pauses,
+		PausesPerHour:       stats.PausesPerHour,
+		AvgPauseSeconds:     stats.AvgPauseSeconds,
+		LongestPauseSeconds: stats.LongestPauseSeconds,
+		PauseLengths:        lengths,
+	}
+}
+
+// GetProductivity godoc
+// @Summary Get the hour×weekday productivity profile
+// @Description Net minutes and exercise accuracy per local hour and weekday. Net time is spread over the unpaused part of each session and split at hour boundaries; exercises count in the hour they were logged.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param start_date_from query string false "Start Date From (YYYY-MM-DD, local day)"
+// @Param start_date_to query string false "Start Date To (YYYY-MM-DD, inclusive)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {object} handler.ProductivityProfileResponse
+// @Failure 400 {object} map[string]string
+// @Router /analytics/productivity [get]
+func (h *AnalyticsHandler) GetProductivity(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	startDateFrom := r.URL.Query().Get("start_date_from")
+	startDateTo := r.URL.Query().Get("start_date_to")
+
+	profile, err := h.svc.GetProductivity(r.Context(), userID, cal, startDateFrom, startDateTo)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := ProductivityProfileResponse{
+		Weekdays: make([]ProductivityWeekdayResponse, len(profile.Weekdays)),
+		Hours:    toProductivityHourResponses(profile.Hours),
+	}
+	for i, day := range profile.Weekdays {
+		response.Weekdays[i] = ProductivityWeekdayResponse{
+			Weekday:                    int(day.Weekday),
+			WeekdayName:                day.Weekday.String(),
+			ProductivityTotalsResponse: toProductivityTotalsResponse(day.ProductivityTotals),
+			Hours:                      toProductivityHourResponses(day.Hours),
+		}
+	}
+
+	var cells []ProductivityCellResponse
+	for _, day := range response.Weekdays {
+		for _, hour := range day.Hours {
+			cells = append(cells, ProductivityCellResponse{Weekday: day.Weekday, WeekdayName: day.WeekdayName, ProductivityHourResponse: hour})
+		}
+	}
+	if writeTable(w, r, h.exports, "productivity", cells) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func toProductivityTotalsResponse(totals service.ProductivityTotals) ProductivityTotalsResponse {
+	return ProductivityTotalsResponse{
+		NetMinutes:         math.Round(float64(totals.NetSeconds)/60*100) / 100,
+		Questions:          int(totals.Questions),
+		Correct:            int(totals.Correct),
+		AccuracyPercentage: totals.Accuracy,
+	}
+}
+
+func toProductivityHourResponses(hours []service.ProductivityHour) []ProductivityHourResponse {
+	response := make([]ProductivityHourResponse, len(hours))
+	for i, hour := range hours {
+		response[i] = ProductivityHourResponse{
+			Hour:                       hour.Hour,
+			ProductivityTotalsResponse: toProductivityTotalsResponse(hour.ProductivityTotals),
+		}
+	}
+	return response
+}
+
+// GetStudyBalance godoc
+// @Summary Get planned vs actual study time by subject
+// @Description Compares net hours per subject with the active cycle's planned share and with the subjects' exam weights, flagging over- and under-studied subjects with their deficit in hours.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param start_date_from query string false "Start Date From (YYYY-MM-DD, local day)"
+// @Param start_date_to query string false "Start Date To (YYYY-MM-DD, inclusive)"
+// @Param tolerance query number false "Allowed relative deviation before flagging (default 0.2)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {object} handler.StudyBalanceResponse
+// @Failure 400 {object} map[string]string
+// @Router /analytics/balance [get]
+func (h *AnalyticsHandler) GetStudyBalance(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	startDateFrom := r.URL.Query().Get("start_date_from")
+	startDateTo := r.URL.Query().Get("start_date_to")
+
+	var tolerance float64
+	if v := r.URL.Query().Get("tolerance"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, service.ErrInvalidTolerance.Error())
+			return
+		}
+		tolerance = t
+	}
+
+	balance, err := h.svc.GetStudyBalance(r.Context(), userID, cal, startDateFrom, startDateTo, tolerance)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) || errors.Is(err, service.ErrInvalidTolerance) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := StudyBalanceResponse{
+		TotalHoursNet: secondsToHours(balance.TotalNetSeconds),
+		CycleID:       balance.CycleID,
+		CycleName:     balance.CycleName,
+		Subjects:      make([]SubjectBalanceResponse, len(balance.Subjects)),
+	}
+	for i, subject := range balance.Subjects {
+		response.Subjects[i] = SubjectBalanceResponse{
+			SubjectID:        subject.SubjectID,
+			SubjectName:      subject.SubjectName,
+			ColorHex:         subject.ColorHex,
+			TotalHoursNet:    secondsToHours(subject.NetSeconds),
+			ActualPercentage: subject.ActualShare,
+			Plan:             toBalanceTargetResponse(subject.Plan),
+			Exam:             toBalanceTargetResponse(subject.Exam),
+		}
+	}
+
+	if writeTable(w, r, h.exports, "balance", response.Subjects) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func toBalanceTargetResponse(target *service.BalanceTarget) *BalanceTargetResponse {
+	if target == nil {
+		return nil
+	}
+	return &BalanceTargetResponse{
+		SharePercentage: target.Share,
+		ExpectedHours:   secondsToHours(target.ExpectedSeconds),
+		DeficitHours:    secondsToHours(target.DeficitSeconds),
+		Status:          target.Status,
+	}
+}
+
+// Compare godoc
+// @Summary Compare a period with a baseline
+// @Description Net hours, sessions, questions and accuracy in both periods, with their deltas, in total and per subject. The previous baseline is the period of equal length just before, or the previous months for whole-month periods.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param from query string true "From (YYYY-MM-DD, local day)"
+// @Param to query string true "To (YYYY-MM-DD, inclusive)"
+// @Param baseline query string false "previous (default) or custom"
+// @Param baseline_from query string false "Baseline From, for a custom baseline"
+// @Param baseline_to query string false "Baseline To (inclusive), for a custom baseline"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {object} handler.ComparisonResponse
+// @Failure 400 {object} map[string]string
+// @Router /analytics/compare [get]
+func (h *AnalyticsHandler) Compare(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	comparison, err := h.svc.Compare(r.Context(), userID, cal, service.CompareQuery{
+		From:         q.Get("from"),
+		To:           q.Get("to"),
+		Baseline:     q.Get("baseline"),
+		BaselineFrom: q.Get("baseline_from"),
+		BaselineTo:   q.Get("baseline_to"),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) || errors.Is(err, service.ErrInvalidBaseline) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := ComparisonResponse{
+		Period:   toPeriodResponse(comparison.From, comparison.To, cal),
+		Baseline: toPeriodResponse(comparison.BaselineFrom, comparison.BaselineTo, cal),
+		Total:    toPeriodComparisonResponse(comparison.Total),
+		Subjects: make([]SubjectComparisonResponse, len(comparison.Subjects)),
+	}
+	for i, subject := range comparison.Subjects {
+		response.Subjects[i] = SubjectComparisonResponse{
+			SubjectID:                subject.SubjectID,
+			SubjectName:              subject.SubjectName,
+			ColorHex:                 subject.ColorHex,
+			PeriodComparisonResponse: toPeriodComparisonResponse(subject.PeriodComparison),
+		}
+	}
+
+	if writeTable(w, r, h.exports, "compare", response.Subjects) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// toPeriodResponse shows a half-open [from, to) interval as inclusive local dates
+func toPeriodResponse(from, to time.Time, cal service.Calendar) PeriodResponse {
+	return PeriodResponse{
+		From: from.In(cal.Location).Format("2006-01-02"),
+		To:   to.Add(-time.Nanosecond).In(cal.Location).Format("2006-01-02"),
+	}
+}
+
+func toPeriodComparisonResponse(c service.PeriodComparison) PeriodComparisonResponse {
+	totals := func(t service.PeriodTotals) PeriodTotalsResponse {
+		return PeriodTotalsResponse{
+			TotalHoursNet:      secondsToHours(t.NetSeconds),
+			SessionsCount:      t.Sessions,
+			TotalQuestions:     int(t.Questions),
+			TotalCorrect:       int(t.Correct),
+			AccuracyPercentage: t.Accuracy,
+		}
+	}
+	return PeriodComparisonResponse{
+		Current:  totals(c.Current),
+		Baseline: totals(c.Baseline),
+		Delta: PeriodDeltaResponse{
+			TotalHoursNet:  secondsToHours(c.Delta.NetSeconds),
+			SessionsCount:  c.Delta.Sessions,
+			TotalQuestions: int(c.Delta.Questions),
+			AccuracyPoints: c.Delta.Accuracy,
+		},
+	}
+}
+
 // GetHeatmap godoc
 // @Summary Get study activity heatmap
+// @Description Net study time per local day. Sessions crossing midnight are split between both days.
 // @Tags analytics
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param days query int false "Number of days (default 30)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
 // @Success 200 {array} handler.HeatmapDayResponse
+// @Failure 400 {object} map[string]string
 // @Router /analytics/heatmap [get]
 func (h *AnalyticsHandler) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
 	daysStr := r.URL.Query().Get("days")
 	var days int64 = 30
 	if daysStr != "" {
@@ -130,39 +619,217 @@ func (h *AnalyticsHandler) GetHeatmap(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	heatmap, err := h.svc.GetHeatmap(r.Context(), days)
+	heatmap, err := h.svc.GetHeatmap(r.Context(), userID, cal, days)
 	if err != nil {
 		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	// Map DB Result to JSON Response
+	// Map Service Result to JSON Response
 	response := make([]HeatmapDayResponse, len(heatmap))
-	for i, row := range heatmap {
-		// Handle interface{} types returned by SQLite driver for calculated fields
-		dateStr, _ := row.StudyDate.(string)
+	for i, day := range heatmap {
+		response[i] = HeatmapDayResponse{
+			StudyDate:     day.Date,
+			SessionsCount: day.SessionsCount,
+			TotalSeconds:  int(day.TotalSeconds),
+		}
+	}
+
+	if writeTable(w, r, h.exports, "heatmap", response) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetTrends godoc
+// @Summary Get study trends over time
+// @Description Net study time, questions and accuracy per day, week or month, with trailing moving averages. Periods without activity are included. Net time is omitted when filtering by topic, since sessions are not linked to topics.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param granularity query string false "day (default), week or month"
+// @Param from query string false "From (YYYY-MM-DD, local day)"
+// @Param to query string false "To (YYYY-MM-DD, inclusive; defaults to today)"
+// @Param subject_id query string false "Subject ID"
+// @Param topic_id query string false "Topic ID"
+// @Param window query int false "Periods in the moving averages (default 7 days, 4 weeks or 3 months)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {array} handler.TrendPointResponse
+// @Failure 400 {object} map[string]string
+// @Router /analytics/trends [get]
+func (h *AnalyticsHandler) GetTrends(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	query := service.TrendQuery{
+		Granularity: q.Get("granularity"),
+		From:        q.Get("from"),
+		To:          q.Get("to"),
+		SubjectID:   q.Get("subject_id"),
+		TopicID:     q.Get("topic_id"),
+	}
+	window, err := positiveQueryInt(r, "window")
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.Window = int(window)
 
-		var totalSec int
-		// TotalSeconds might come back as int64 or float64 depending on the driver/OS
-		switch v := row.TotalSeconds.(type) {
-		case int64:
-			totalSec = int(v)
-		case float64:
-			totalSec = int(v)
-		default:
-			totalSec = 0
+	points, err := h.svc.GetTrends(r.Context(), userID, cal, query)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDateRange) || errors.Is(err, service.ErrInvalidGranularity) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
 
-		response[i] = HeatmapDayResponse{
-			StudyDate:     dateStr,
-			SessionsCount: int(row.SessionsCount),
-			TotalSeconds:  totalSec,
+	withTime := query.TopicID == ""
+	response := make([]TrendPointResponse, len(points))
+	for i, point := range points {
+		response[i] = TrendPointResponse{
+			PeriodStart:       point.PeriodStart.Format("2006-01-02"),
+			Questions:         int(point.Questions),
+			Correct:           int(point.Correct),
+			Accuracy:          point.Accuracy,
+			AccuracyMovingAvg: point.AccuracyMovingAvg,
+		}
+		if withTime {
+			netHours := secondsToHours(point.NetSeconds)
+			netHoursAvg := secondsToHours(int64(point.NetSecondsMovingAvg))
+			response[i].NetHours = &netHours
+			response[i].NetHoursMovingAvg = &netHoursAvg
+		}
+	}
+
+	if writeTable(w, r, h.exports, "trends", response) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// positiveQueryInt reads an optional positive integer query parameter; 0 if absent
+func positiveQueryInt(r *http.Request, name string) (int64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// secondsToHours rounds to two decimals, like the time report
+func secondsToHours(seconds int64) float64 {
+	return math.Round(float64(seconds)/3600*100) / 100
+}
+
+func (h *AnalyticsHandler) userID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := r.Context().Value("userID").(string)
+	if !ok || userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return "", false
+	}
+	return userID, true
+}
+
+// GetReadiness godoc
+// @Summary Forecast exam-day readiness
+// @Description Projects each subject to the exam date at the pace of the last 14 days: net hours and questions still to come, syllabus coverage (topics with exercises) and accuracy with its trend, with a low, medium or high risk level and the reasons for it. Recomputed on every request.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param exam_date query string false "Exam date (YYYY-MM-DD), defaults to the profile's"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {object} handler.ReadinessForecastResponse
+// @Failure 400 {object} map[string]string
+// @Router /analytics/readiness [get]
+func (h *AnalyticsHandler) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	userID, cal, ok := h.userCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	forecast, err := h.svc.GetReadiness(r.Context(), userID, cal, r.URL.Query().Get("exam_date"))
+	if err != nil {
+		if errors.Is(err, service.ErrNoExamDate) || errors.Is(err, service.ErrInvalidExamDate) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := ReadinessForecastResponse{
+		ExamDate:   forecast.ExamDate.Format("2006-01-02"),
+		DaysLeft:   forecast.DaysLeft,
+		PacePeriod: toPeriodResponse(forecast.PaceFrom, forecast.PaceTo, cal),
+		Risk:       forecast.Risk,
+		Subjects:   make([]SubjectReadinessResponse, len(forecast.Subjects)),
+	}
+	for i, subject := range forecast.Subjects {
+		response.Subjects[i] = SubjectReadinessResponse{
+			SubjectID:                  subject.SubjectID,
+			SubjectName:                subject.SubjectName,
+			ColorHex:                   subject.ColorHex,
+			NetHoursPerDay:             math.Round(subject.NetSecondsPerDay/3600*100) / 100,
+			QuestionsPerDay:            subject.QuestionsPerDay,
+			ProjectedHoursNet:          secondsToHours(subject.ProjectedNetSeconds),
+			ProjectedQuestions:         int(subject.ProjectedQuestions),
+			TopicsCount:                subject.Topics,
+			TopicsTouched:              subject.TopicsTouched,
+			TopicsRevised:              subject.TopicsRevised,
+			ProjectedTopicsTouched:     subject.ProjectedTopicsTouched,
+			CoveragePercentage:         subject.Coverage,
+			ProjectedCoverage:          subject.ProjectedCoverage,
+			AccuracyPercentage:         subject.Accuracy,
+			PreviousAccuracy:           subject.PreviousAccuracy,
+			AccuracyTrendPointsPerWeek: subject.AccuracyTrend,
+			ProjectedAccuracy:          subject.ProjectedAccuracy,
+			Risk:                       subject.Risk,
+			RiskReasons:                subject.RiskReasons,
+		}
+	}
+
+	if writeTable(w, r, h.exports, "readiness", response.Subjects) {
+		return
 	}
 
 	h.respondWithJSON(w, http.StatusOK, response)
 }
 
+// userCalendar returns the authenticated user and the calendar to bucket in:
+// the profile settings, with the ?tz= query parameter overriding the timezone.
+func (h *AnalyticsHandler) userCalendar(w http.ResponseWriter, r *http.Request) (string, service.Calendar, bool) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return "", service.Calendar{}, false
+	}
+
+	cal, err := h.svc.Calendar(r.Context(), userID, r.URL.Query().Get("tz"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid timezone")
+			return "", service.Calendar{}, false
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return "", service.Calendar{}, false
+	}
+	return userID, cal, true
+}
+
 func (h *AnalyticsHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -2,13 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joaoapaenas/my-api/internal/config"
+	"github.com/joaoapaenas/my-api/internal/middleware"
 	"github.com/joaoapaenas/my-api/internal/service"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
@@ -36,21 +38,24 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 1. Find User
-	user, err := h.userService.GetUserByEmail(r.Context(), req.Email)
+	// 1. Verify credentials (applies the account lockout policy)
+	user, err := h.userService.Authenticate(r.Context(), req.Email, req.Password)
 	if err != nil {
+		var locked *service.AccountLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Retry-After", middleware.RetryAfterSeconds(time.Until(locked.Until)))
+			http.Error(w, "Too many failed attempts, try again later", http.StatusTooManyRequests)
+			return
+		}
+		if !errors.Is(err, service.ErrInvalidCredentials) {
+			slog.Error("Failed to authenticate user", "error", err)
+		}
 		// Use generic message for security
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
 	}
 
-	// 2. Check Password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
-		return
-	}
-
-	// 3. Generate JWT
+	// 2. Generate JWT
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub":   user.ID,
 		"email": user.Email, // Ensure this is here
@@ -63,7 +68,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 4. Return Token
+	// 3. Return Token
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(LoginResponse{Token: tokenString})
 }
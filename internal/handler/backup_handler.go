@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/joaoapaenas/my-api/internal/backup"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+// maxImportBytes caps an uploaded archive, and the JSON inside a zipped one
+const maxImportBytes = 64 << 20
+
+type BackupHandler struct {
+	svc service.BackupService
+}
+
+func NewBackupHandler(svc service.BackupService) *BackupHandler {
+	return &BackupHandler{svc: svc}
+}
+
+// ExportAccount godoc
+// @Summary Download a backup of the account
+// @Description Every subject, topic, study cycle, cycle item, session, pause and exercise log of the account, soft-deleted ones included, as a versioned JSON archive. ?format=zip wraps the JSON in a zip. Personal access tokens need account, subjects, cycles and sessions read scopes.
+// @Tags users
+// @Produce json
+// @Produce application/zip
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} backup.Archive
+// @Failure 400 {object} map[string]string
+// @Router /me/export [get]
+func (h *BackupHandler) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	zipped := false
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+	case "zip":
+		zipped = true
+	default:
+		h.respondWithError(w, http.StatusBadRequest, "format must be json or zip")
+		return
+	}
+
+	archive, err := h.svc.ExportAccount(r.Context(), userID.(string))
+	if err != nil {
+		slog.Error("Failed to export account", "error", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	contentType, ext := backup.ContentTypeJSON, "json"
+	if zipped {
+		contentType, ext = backup.ContentTypeZip, "zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%s.%s"`, archive.ExportedAt.Format("2006-01-02"), ext))
+	w.WriteHeader(http.StatusOK)
+	if err := backup.Write(w, archive, zipped); err != nil {
+		slog.Error("Account export interrupted", "error", err)
+	}
+}
+
+// ImportAccount godoc
+// @Summary Import a backup into the account
+// @Description Adds the rows of an archive from GET /me/export (JSON or zip) to this account under new IDs, in one transaction. Nothing already in the account changes. With ?dry_run=true everything is checked and the report shows what would be imported, but nothing is kept. Links to rows missing from the archive are dropped where optional and reported as warnings; active study cycles come in inactive, with a warning, as cycles are shared by all users; other inconsistencies reject the whole archive with 422. Personal access tokens need account, subjects, cycles and sessions write scopes.
+// @Tags users
+// @Accept json
+// @Accept application/zip
+// @Produce json
+// @Param dry_run query bool false "Check and report without importing"
+// @Param archive body backup.Archive true "Account archive"
+// @Success 200 {object} handler.ImportReportResponse "Dry run"
+// @Success 201 {object} handler.ImportReportResponse
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} handler.ImportReportResponse
+// @Router /me/import [post]
+func (h *BackupHandler) ImportAccount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	archive, err := backup.Read(r.Body, maxImportBytes)
+	if err != nil {
+		if errors.Is(err, backup.ErrInvalidArchive) || errors.Is(err, backup.ErrUnsupportedVersion) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	report, err := h.svc.ImportAccount(r.Context(), userID.(string), archive, dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBackup) {
+			h.respondWithJSON(w, http.StatusUnprocessableEntity, toImportReportResponse(report))
+			return
+		}
+		slog.Error("Failed to import account", "error", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	h.respondWithJSON(w, status, toImportReportResponse(report))
+}
+
+func toImportReportResponse(report service.ImportReport) ImportReportResponse {
+	response := ImportReportResponse{
+		DryRun:  report.DryRun,
+		Version: report.Version,
+		Imported: ImportCountsResponse{
+			Subjects:      report.Imported.Subjects,
+			Topics:        report.Imported.Topics,
+			StudyCycles:   report.Imported.StudyCycles,
+			CycleItems:    report.Imported.CycleItems,
+			StudySessions: report.Imported.StudySessions,
+			SessionPauses: report.Imported.SessionPauses,
+			ExerciseLogs:  report.Imported.ExerciseLogs,
+		},
+		Warnings: report.Warnings,
+		Problems: report.Problems,
+	}
+	if response.Warnings == nil {
+		response.Warnings = []string{}
+	}
+	return response
+}
+
+func (h *BackupHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *BackupHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+// batchRoutes are the operations a batch may hold: the creates, updates and
+// deletes of single rows. Restores, merges, imports and sync pushes are units
+// of work of their own and are left out, as is /batch itself.
+var batchRoutes = []struct{ method, pattern string }{
+	{http.MethodPost, "/subjects"},
+	{http.MethodPut, "/subjects/{id}"},
+	{http.MethodPatch, "/subjects/{id}"},
+	{http.MethodDelete, "/subjects/{id}"},
+	{http.MethodPost, "/subjects/{id}/topics"},
+	{http.MethodPut, "/topics/{id}"},
+	{http.MethodPatch, "/topics/{id}"},
+	{http.MethodDelete, "/topics/{id}"},
+	{http.MethodPost, "/study-cycles"},
+	{http.MethodPut, "/study-cycles/{id}"},
+	{http.MethodPatch, "/study-cycles/{id}"},
+	{http.MethodDelete, "/study-cycles/{id}"},
+	{http.MethodPost, "/study-cycles/{id}/items"},
+	{http.MethodPut, "/cycle-items/{id}"},
+	{http.MethodPatch, "/cycle-items/{id}"},
+	{http.MethodDelete, "/cycle-items/{id}"},
+	{http.MethodPost, "/study-sessions"},
+	{http.MethodPut, "/study-sessions/{id}"},
+	{http.MethodDelete, "/study-sessions/{id}"},
+	{http.MethodPost, "/session-pauses"},
+	{http.MethodPut, "/session-pauses/{id}/end"},
+	{http.MethodDelete, "/session-pauses/{id}"},
+	{http.MethodPost, "/exercise-logs"},
+	{http.MethodDelete, "/exercise-logs/{id}"},
+	{http.MethodPost, "/goals"},
+	{http.MethodPut, "/goals/{id}"},
+	{http.MethodDelete, "/goals/{id}"},
+}
+
+// errBatchFailed rolls a batch back once one of its operations fails
+var errBatchFailed = errors.New("batch operation failed")
+
+// Transactor runs fn in one transaction, carried by the context fn gets; a
+// repository.TxManager
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type BatchHandler struct {
+	router   http.Handler
+	tx       Transactor
+	routes   *chi.Mux // batchRoutes, only matched against
+	validate *validator.Validate
+}
+
+// NewBatchHandler serves the operations of a batch with router, the one the
+// API itself is served by, so each goes through the same middleware,
+// validation and ownership checks as when sent on its own
+func NewBatchHandler(router http.Handler, tx Transactor) *BatchHandler {
+	routes := chi.NewRouter()
+	for _, route := range batchRoutes {
+		routes.MethodFunc(route.method, route.pattern, http.NotFound)
+	}
+	return &BatchHandler{router: router, tx: tx, routes: routes, validate: validator.New()}
+}
+
+// Batch godoc
+// @Summary Run several writes in one request
+// @Description Runs up to 100 creates, updates and deletes of subjects, topics, study cycles, cycle items, study sessions, session pauses, exercise logs and goals in order, each answered as its own endpoint would answer it. By default they run in one transaction: the first failed operation rolls back the ones before it and the rest aren't run, and committed comes back false. With continue_on_error each operation is committed on its own and the batch runs to the end. Every operation needs the scope of its endpoint and counts against the rate limit like a request of its own. An operation that finds the database busy fails like any other; only a busy commit makes the server run the whole batch again, and that run checks and counts every operation anew.
+// @Tags batch
+// @Accept json
+// @Produce json
+// @Param input body BatchRequest true "Operations"
+// @Success 200 {object} handler.BatchResponse
+// @Failure 400 {object} map[string]string
+// @Router /batch [post]
+func (h *BatchHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	if r.Context().Value("userID") == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+	for i, op := range req.Operations {
+		u, err := url.Parse(op.Path)
+		if err != nil || !h.routes.Match(chi.NewRouteContext(), op.Method, u.Path) {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("operations[%d]: %s %s can't be batched", i, op.Method, op.Path))
+			return
+		}
+	}
+
+	results := make([]BatchResultResponse, 0, len(req.Operations))
+	if req.ContinueOnError {
+		for _, op := range req.Operations {
+			results = append(results, h.run(r.Context(), r, op))
+		}
+		h.respondWithJSON(w, http.StatusOK, BatchResponse{Committed: true, Results: results})
+		return
+	}
+
+	err := h.tx.WithinTx(r.Context(), func(ctx context.Context) error {
+		// fn runs again when the commit finds the database busy. Each run
+		// serves every operation afresh, so their auth, scope and rate limit
+		// are checked and counted once per run. A busy error inside an
+		// operation is only its 500 and fails the batch, it isn't retried.
+		results = results[:0]
+		for _, op := range req.Operations {
+			result := h.run(ctx, r, op)
+			results = append(results, result)
+			if result.Status >= http.StatusBadRequest {
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFailed) {
+		slog.Error("Failed to run batch", "error", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, BatchResponse{Committed: err == nil, Results: results})
+}
+
+// run serves op on ctx as a request of its own from the batch's caller
+func (h *BatchHandler) run(ctx context.Context, r *http.Request, op BatchOperationRequest) BatchResultResponse {
+	// A fresh route context, or the router would resume routing /batch
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, chi.NewRouteContext())
+	var body io.Reader = http.NoBody
+	if len(op.Body) > 0 {
+		body = bytes.NewReader(op.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, op.Method, op.Path, body)
+	if err != nil {
+		return BatchResultResponse{Status: http.StatusBadRequest}
+	}
+	req.RemoteAddr = r.RemoteAddr
+	req.Header.Set("Content-Type", "application/json")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if op.IfMatch != "" {
+		req.Header.Set("If-Match", op.IfMatch)
+	}
+
+	rec := &batchRecorder{header: http.Header{}}
+	h.router.ServeHTTP(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	result := BatchResultResponse{Status: rec.status, ETag: rec.header.Get("ETag")}
+	switch {
+	case rec.body.Len() == 0:
+	case json.Valid(rec.body.Bytes()):
+		result.Body = json.RawMessage(rec.body.Bytes())
+	default:
+		// Plain text errors, like those of the middleware
+		result.Body, _ = json.Marshal(string(bytes.TrimSpace(rec.body.Bytes())))
+	}
+	return result
+}
+
+// batchRecorder holds the response to one operation of a batch
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *batchRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *batchRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+func (rec *batchRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+func (h *BatchHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *BatchHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
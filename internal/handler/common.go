@@ -1,6 +1,11 @@
 package handler
 
-import "github.com/go-playground/validator/v10"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
 
 // formatValidationErrors formats validator errors into a readable map
 func formatValidationErrors(err error) map[string]string {
@@ -14,22 +19,37 @@ func formatValidationErrors(err error) map[string]string {
 }
 
 // Response DTOs for Swagger documentation
+type UserResponse struct {
+	ID               string    `json:"id"`
+	Email            string    `json:"email"`
+	Name             string    `json:"name"`
+	CreatedAt        time.Time `json:"created_at"`
+	Timezone         string    `json:"timezone"`
+	Locale           string    `json:"locale"`
+	DailyGoalMinutes int       `json:"daily_goal_minutes"`
+	WeekStartDay     int       `json:"week_start_day"`
+	ExamDate         string    `json:"exam_date,omitempty"`
+}
+
 type SubjectResponse struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	ColorHex  string `json:"color_hex,omitempty"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	DeletedAt string `json:"deleted_at,omitempty"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	ColorHex   string   `json:"color_hex,omitempty"`
+	ExamWeight *float64 `json:"exam_weight,omitempty"`
+	CreatedAt  string   `json:"created_at" export:"datetime"`
+	UpdatedAt  string   `json:"updated_at" export:"datetime"`
+	DeletedAt  string   `json:"deleted_at,omitempty" export:"datetime"`
+	Version    int64    `json:"version"` // Also sent as the ETag
 }
 
 type TopicResponse struct {
 	ID        string `json:"id"`
 	SubjectID string `json:"subject_id"`
 	Name      string `json:"name"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	DeletedAt string `json:"deleted_at,omitempty"`
+	CreatedAt string `json:"created_at" export:"datetime"`
+	UpdatedAt string `json:"updated_at" export:"datetime"`
+	DeletedAt string `json:"deleted_at,omitempty" export:"datetime"`
+	Version   int64  `json:"version"`
 }
 
 type StudyCycleResponse struct {
@@ -37,9 +57,10 @@ type StudyCycleResponse struct {
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
 	IsActive    int    `json:"is_active"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
-	DeletedAt   string `json:"deleted_at,omitempty"`
+	CreatedAt   string `json:"created_at" export:"datetime"`
+	UpdatedAt   string `json:"updated_at" export:"datetime"`
+	DeletedAt   string `json:"deleted_at,omitempty" export:"datetime"`
+	Version     int64  `json:"version"`
 }
 
 type CycleItemResponse struct {
@@ -48,8 +69,9 @@ type CycleItemResponse struct {
 	SubjectID              string `json:"subject_id"`
 	OrderIndex             int    `json:"order_index"`
 	PlannedDurationMinutes int    `json:"planned_duration_minutes,omitempty"`
-	CreatedAt              string `json:"created_at"`
-	UpdatedAt              string `json:"updated_at"`
+	CreatedAt              string `json:"created_at" export:"datetime"`
+	UpdatedAt              string `json:"updated_at" export:"datetime"`
+	Version                int64  `json:"version"`
 }
 
 type StudySessionResponse struct {
@@ -77,7 +99,36 @@ type ExerciseLogResponse struct {
 	TopicID        string `json:"topic_id,omitempty"`
 	QuestionsCount int    `json:"questions_count"`
 	CorrectCount   int    `json:"correct_count"`
-	CreatedAt      string `json:"created_at"`
+	CreatedAt      string `json:"created_at" export:"datetime"`
+}
+
+// SessionExportResponse is a row of the session export
+type SessionExportResponse struct {
+	ID                   string     `json:"id"`
+	SubjectID            string     `json:"subject_id"`
+	SubjectName          string     `json:"subject_name"`
+	CycleItemID          string     `json:"cycle_item_id"`
+	StartedAt            time.Time  `json:"started_at"`
+	FinishedAt           *time.Time `json:"finished_at"` // Empty while open
+	GrossDurationSeconds int        `json:"gross_duration_seconds"`
+	NetDurationSeconds   int        `json:"net_duration_seconds"`
+	NetHours             float64    `json:"net_hours"`
+	PausesCount          int        `json:"pauses_count"`
+	Notes                string     `json:"notes"`
+}
+
+// ExerciseLogExportResponse is a row of the exercise log export
+type ExerciseLogExportResponse struct {
+	ID                 string    `json:"id"`
+	SessionID          string    `json:"session_id"`
+	SubjectID          string    `json:"subject_id"`
+	SubjectName        string    `json:"subject_name"`
+	TopicID            string    `json:"topic_id"`
+	TopicName          string    `json:"topic_name"`
+	QuestionsCount     int       `json:"questions_count"`
+	CorrectCount       int       `json:"correct_count"`
+	AccuracyPercentage *float64  `json:"accuracy_percentage"`
+	CreatedAt          time.Time `json:"created_at"`
 }
 
 type MessageResponse struct {
@@ -137,8 +188,453 @@ type TopicAccuracyResponse struct {
 	AccuracyPercentage float64 `json:"accuracy_percentage"`
 }
 
+type WeakPointResponse struct {
+	TopicID                    string  `json:"topic_id"`
+	TopicName                  string  `json:"topic_name"`
+	SubjectID                  string  `json:"subject_id"`
+	SubjectName                string  `json:"subject_name"`
+	TotalQuestions             int     `json:"total_questions"`
+	TotalCorrect               int     `json:"total_correct"`
+	AccuracyPercentage         float64 `json:"accuracy_percentage"`
+	WeightedQuestions          float64 `json:"weighted_questions"`
+	WeightedAccuracyPercentage float64 `json:"weighted_accuracy_percentage"`
+	WeaknessScore              float64 `json:"weakness_score"` // Wilson lower bound of the miss rate, in percent
+	LastPracticedAt            string  `json:"last_practiced_at" export:"datetime"`
+}
+
+type FocusStatsResponse struct {
+	SessionsCount       int                   `json:"sessions_count"`
+	GrossHours          float64               `json:"gross_hours"`
+	NetHours            float64               `json:"net_hours"`
+	FocusRatio          *float64              `json:"focus_ratio"` // Net over gross time
+	PausesCount         int                   `json:"pauses_count"`
+	PausesPerHour       *float64              `json:"pauses_per_hour"`
+	AvgPauseSeconds     float64               `json:"avg_pause_seconds"`
+	LongestPauseSeconds int64                 `json:"longest_pause_seconds"`
+	PauseLengths        []PauseLengthResponse `json:"pause_lengths"`
+}
+
+type PauseLengthResponse struct {
+	Label      string `json:"label"`
+	MinSeconds int64  `json:"min_seconds"`
+	MaxSeconds int64  `json:"max_seconds,omitempty"` // Exclusive; absent for the last bucket
+	Count      int    `json:"count"`
+}
+
+type SubjectFocusResponse struct {
+	SubjectID   string `json:"subject_id"`
+	SubjectName string `json:"subject_name"`
+	ColorHex    string `json:"color_hex,omitempty"`
+	FocusStatsResponse
+}
+
+type TimeOfDayFocusResponse struct {
+	TimeOfDay string `json:"time_of_day"`
+	StartHour int    `json:"start_hour"`
+	EndHour   int    `json:"end_hour"`
+	FocusStatsResponse
+}
+
+type WeekdayFocusResponse struct {
+	Weekday     int    `json:"weekday"` // 0 = Sunday
+	WeekdayName string `json:"weekday_name"`
+	FocusStatsResponse
+}
+
+type FocusReportResponse struct {
+	Overall     FocusStatsResponse       `json:"overall"`
+	BySubject   []SubjectFocusResponse   `json:"by_subject"`
+	ByTimeOfDay []TimeOfDayFocusResponse `json:"by_time_of_day"`
+	ByWeekday   []WeekdayFocusResponse   `json:"by_weekday"`
+}
+
+type ProductivityTotalsResponse struct {
+	NetMinutes         float64  `json:"net_minutes"`
+	Questions          int      `json:"questions"`
+	Correct            int      `json:"correct"`
+	AccuracyPercentage *float64 `json:"accuracy_percentage"`
+}
+
+type ProductivityHourResponse struct {
+	Hour int `json:"hour"`
+	ProductivityTotalsResponse
+}
+
+type ProductivityWeekdayResponse struct {
+	Weekday     int    `json:"weekday"` // 0 = Sunday
+	WeekdayName string `json:"weekday_name"`
+	ProductivityTotalsResponse
+	Hours []ProductivityHourResponse `json:"hours"`
+}
+
+type ProductivityProfileResponse struct {
+	Weekdays []ProductivityWeekdayResponse `json:"weekdays"`
+	Hours    []ProductivityHourResponse    `json:"hours"` // Each hour across all weekdays
+}
+
+// ProductivityCellResponse is one weekday and hour, the rows of the
+// productivity profile as CSV or XLSX
+type ProductivityCellResponse struct {
+	Weekday     int    `json:"weekday"`
+	WeekdayName string `json:"weekday_name"`
+	ProductivityHourResponse
+}
+
+type BalanceTargetResponse struct {
+	SharePercentage float64 `json:"share_percentage"`
+	ExpectedHours   float64 `json:"expected_hours"`
+	DeficitHours    float64 `json:"deficit_hours"` // Negative when over-studied
+	Status          string  `json:"status"`        // over, under, on_track or unexpected
+}
+
+type SubjectBalanceResponse struct {
+	SubjectID        string                 `json:"subject_id"`
+	SubjectName      string                 `json:"subject_name"`
+	ColorHex         string                 `json:"color_hex,omitempty"`
+	TotalHoursNet    float64                `json:"total_hours_net"`
+	ActualPercentage float64                `json:"actual_percentage"`
+	Plan             *BalanceTargetResponse `json:"plan,omitempty"`
+	Exam             *BalanceTargetResponse `json:"exam,omitempty"`
+}
+
+type StudyBalanceResponse struct {
+	TotalHoursNet float64                  `json:"total_hours_net"`
+	CycleID       string                   `json:"cycle_id,omitempty"`
+	CycleName     string                   `json:"cycle_name,omitempty"`
+	Subjects      []SubjectBalanceResponse `json:"subjects"`
+}
+
+type PeriodResponse struct {
+	From string `json:"from"`
+	To   string `json:"to"` // Inclusive
+}
+
+type PeriodTotalsResponse struct {
+	TotalHoursNet      float64  `json:"total_hours_net"`
+	SessionsCount      int      `json:"sessions_count"`
+	TotalQuestions     int      `json:"total_questions"`
+	TotalCorrect       int      `json:"total_correct"`
+	AccuracyPercentage *float64 `json:"accuracy_percentage"`
+}
+
+type PeriodDeltaResponse struct {
+	TotalHoursNet  float64  `json:"total_hours_net"`
+	SessionsCount  int      `json:"sessions_count"`
+	TotalQuestions int      `json:"total_questions"`
+	AccuracyPoints *float64 `json:"accuracy_points"` // Percentage points
+}
+
+type PeriodComparisonResponse struct {
+	Current  PeriodTotalsResponse `json:"current"`
+	Baseline PeriodTotalsResponse `json:"baseline"`
+	Delta    PeriodDeltaResponse  `json:"delta"`
+}
+
+type SubjectComparisonResponse struct {
+	SubjectID   string `json:"subject_id"`
+	SubjectName string `json:"subject_name"`
+	ColorHex    string `json:"color_hex,omitempty"`
+	PeriodComparisonResponse
+}
+
+type ComparisonResponse struct {
+	Period   PeriodResponse              `json:"period"`
+	Baseline PeriodResponse              `json:"baseline"`
+	Total    PeriodComparisonResponse    `json:"total"`
+	Subjects []SubjectComparisonResponse `json:"subjects"`
+}
+
+type SubjectReadinessResponse struct {
+	SubjectID                  string   `json:"subject_id"`
+	SubjectName                string   `json:"subject_name"`
+	ColorHex                   string   `json:"color_hex,omitempty"`
+	NetHoursPerDay             float64  `json:"net_hours_per_day"`
+	QuestionsPerDay            float64  `json:"questions_per_day"`
+	ProjectedHoursNet          float64  `json:"projected_hours_net"` // Still to come by exam day
+	ProjectedQuestions         int      `json:"projected_questions"`
+	TopicsCount                int      `json:"topics_count"`
+	TopicsTouched              int      `json:"topics_touched"`
+	TopicsRevised              int      `json:"topics_revised"` // Practiced in the pace period
+	ProjectedTopicsTouched     int      `json:"projected_topics_touched"`
+	CoveragePercentage         *float64 `json:"coverage_percentage"`
+	ProjectedCoverage          *float64 `json:"projected_coverage_percentage"`
+	AccuracyPercentage         *float64 `json:"accuracy_percentage"`
+	PreviousAccuracy           *float64 `json:"previous_accuracy_percentage"`
+	AccuracyTrendPointsPerWeek *float64 `json:"accuracy_trend_points_per_week"`
+	ProjectedAccuracy          *float64 `json:"projected_accuracy_percentage"`
+	Risk                       string   `json:"risk"` // low, medium or high
+	RiskReasons                []string `json:"risk_reasons"`
+}
+
+type ReadinessForecastResponse struct {
+	ExamDate   string                     `json:"exam_date"`
+	DaysLeft   int                        `json:"days_left"`
+	PacePeriod PeriodResponse             `json:"pace_period"`
+	Risk       string                     `json:"risk"`
+	Subjects   []SubjectReadinessResponse `json:"subjects"`
+}
+
 type HeatmapDayResponse struct {
-	StudyDate     string `json:"study_date"`
+	StudyDate     string `json:"study_date" export:"date"`
 	SessionsCount int    `json:"sessions_count"`
 	TotalSeconds  int    `json:"total_seconds"`
 }
+
+type TrendPointResponse struct {
+	PeriodStart       string   `json:"period_start" export:"date"`
+	NetHours          *float64 `json:"net_hours"` // Null when filtering by topic
+	Questions         int      `json:"questions"`
+	Correct           int      `json:"correct"`
+	Accuracy          *float64 `json:"accuracy_percentage"`
+	NetHoursMovingAvg *float64 `json:"net_hours_moving_avg"`
+	AccuracyMovingAvg *float64 `json:"accuracy_moving_avg"`
+}
+
+type DashboardSessionResponse struct {
+	ID             string `json:"id"`
+	SubjectID      string `json:"subject_id"`
+	SubjectName    string `json:"subject_name"`
+	ColorHex       string `json:"color_hex,omitempty"`
+	CycleItemID    string `json:"cycle_item_id,omitempty"`
+	StartedAt      string `json:"started_at"`
+	ElapsedSeconds int    `json:"elapsed_seconds"`
+	PausedSeconds  int    `json:"paused_seconds"`
+	NetSeconds     int    `json:"net_seconds"`
+	Paused         bool   `json:"paused"`
+}
+
+type DashboardTodayResponse struct {
+	NetMinutes         int      `json:"net_minutes"` // Includes the open session
+	GoalMinutes        int      `json:"goal_minutes"`
+	GoalPercentage     *float64 `json:"goal_percentage"`
+	GoalAttained       bool     `json:"goal_attained"`
+	TotalQuestions     int      `json:"total_questions"`
+	TotalCorrect       int      `json:"total_correct"`
+	AccuracyPercentage *float64 `json:"accuracy_percentage"`
+}
+
+type DashboardCycleItemResponse struct {
+	ID             string `json:"id"`
+	CycleID        string `json:"cycle_id"`
+	CycleName      string `json:"cycle_name"`
+	SubjectID      string `json:"subject_id"`
+	SubjectName    string `json:"subject_name"`
+	ColorHex       string `json:"color_hex,omitempty"`
+	PlannedMinutes int    `json:"planned_minutes,omitempty"`
+	Position       int    `json:"position"`
+	ItemsCount     int    `json:"items_count"`
+	InProgress     bool   `json:"in_progress"`
+}
+
+type DueReviewResponse struct {
+	TopicID       string `json:"topic_id"`
+	TopicName     string `json:"topic_name"`
+	SubjectID     string `json:"subject_id"`
+	SubjectName   string `json:"subject_name"`
+	LastPracticed string `json:"last_practiced"`
+	IntervalDays  int    `json:"interval_days"`
+	OverdueDays   int    `json:"overdue_days"`
+}
+
+type DashboardStreakResponse struct {
+	Current      int    `json:"current"`
+	Longest      int    `json:"longest"`
+	LastAttained string `json:"last_attained,omitempty"`
+}
+
+type DashboardSummaryResponse struct {
+	Date            string                      `json:"date"`
+	OpenSession     *DashboardSessionResponse   `json:"open_session"`
+	Today           DashboardTodayResponse      `json:"today"`
+	CycleItem       *DashboardCycleItemResponse `json:"cycle_item"`
+	DueReviews      []DueReviewResponse         `json:"due_reviews"`
+	DueReviewsCount int                         `json:"due_reviews_count"`
+	Streak          DashboardStreakResponse     `json:"streak"`
+}
+
+type RecommendationReasonResponse struct {
+	Source      string  `json:"source"` // cycle, review or weakness
+	Score       float64 `json:"score"`  // Between 0 and 1, before the weight
+	Weight      float64 `json:"weight"`
+	Explanation string  `json:"explanation"`
+}
+
+type RecommendationResponse struct {
+	SubjectID      string                         `json:"subject_id"`
+	SubjectName    string                         `json:"subject_name"`
+	ColorHex       string                         `json:"color_hex,omitempty"`
+	TopicID        string                         `json:"topic_id,omitempty"`
+	TopicName      string                         `json:"topic_name,omitempty"`
+	CycleItemID    string                         `json:"cycle_item_id,omitempty"`
+	PlannedMinutes int                            `json:"planned_minutes,omitempty"`
+	Score          float64                        `json:"score"`
+	Reasons        []RecommendationReasonResponse `json:"reasons"`
+}
+
+type RecommendationWeightsResponse struct {
+	Cycle    float64 `json:"cycle"`
+	Review   float64 `json:"review"`
+	Weakness float64 `json:"weakness"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	TokenPrefix string   `json:"token_prefix"`
+	Scopes      []string `json:"scopes"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	LastUsedAt  string   `json:"last_used_at,omitempty"`
+	CreatedAt   string   `json:"created_at" export:"datetime"`
+}
+
+type CreatedTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
+
+type GoalResponse struct {
+	ID        string `json:"id"`
+	SubjectID string `json:"subject_id,omitempty"`
+	Metric    string `json:"metric"`
+	Period    string `json:"period"`
+	Target    int    `json:"target"`
+	RestDays  []int  `json:"rest_days"`
+	CreatedAt string `json:"created_at" export:"datetime"`
+	UpdatedAt string `json:"updated_at" export:"datetime"`
+}
+
+type GoalProgressResponse struct {
+	PeriodStart string  `json:"period_start" export:"date"`
+	Value       int     `json:"value"`
+	Attained    bool    `json:"attained"`
+	Percentage  float64 `json:"percentage"`
+}
+
+type GoalWithProgressResponse struct {
+	GoalResponse
+	Progress GoalProgressResponse `json:"progress"`
+}
+
+type GoalStreakResponse struct {
+	GoalID        string               `json:"goal_id"`
+	SubjectID     string               `json:"subject_id,omitempty"`
+	Metric        string               `json:"metric"`
+	Period        string               `json:"period"`
+	Target        int                  `json:"target"`
+	CurrentStreak int                  `json:"current_streak"`
+	LongestStreak int                  `json:"longest_streak"`
+	LastAttained  string               `json:"last_attained,omitempty" export:"date"`
+	Progress      GoalProgressResponse `json:"progress"`
+}
+
+type ImportCountsResponse struct {
+	Subjects      int `json:"subjects"`
+	Topics        int `json:"topics"`
+	StudyCycles   int `json:"study_cycles"`
+	CycleItems    int `json:"cycle_items"`
+	StudySessions int `json:"study_sessions"`
+	SessionPauses int `json:"session_pauses"`
+	ExerciseLogs  int `json:"exercise_logs"`
+}
+
+type ImportReportResponse struct {
+	DryRun   bool                 `json:"dry_run"`
+	Version  int                  `json:"version"`
+	Imported ImportCountsResponse `json:"imported"`
+	Warnings []string             `json:"warnings"`
+	Problems []string             `json:"problems,omitempty"`
+}
+
+type AccountDeletionResponse struct {
+	ID          string           `json:"id"`
+	Receipt     string           `json:"receipt,omitempty"`
+	Status      string           `json:"status"` // "scheduled" or "purged"
+	RequestedAt time.Time        `json:"requested_at"`
+	PurgeAfter  time.Time        `json:"purge_after"`
+	PurgedAt    *time.Time       `json:"purged_at,omitempty"`
+	DeletedRows map[string]int64 `json:"deleted_rows,omitempty"`
+	Verified    bool             `json:"verified"`
+}
+
+type TrashItemResponse struct {
+	Type       string           `json:"type"` // "subject", "topic" or "study_cycle"
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	ParentID   string           `json:"parent_id,omitempty"` // Subject of a topic
+	DeletedAt  time.Time        `json:"deleted_at"`
+	PurgeAfter time.Time        `json:"purge_after"`
+	Dependents map[string]int64 `json:"dependents"` // Rows a purge deletes (subject) or unlinks (topic, study cycle)
+}
+
+type MergeRequest struct {
+	TargetID string `json:"target_id" validate:"required"`
+}
+
+type MergeReportResponse struct {
+	SourceID string           `json:"source_id"`
+	TargetID string           `json:"target_id"`
+	DryRun   bool             `json:"dry_run"`
+	Moved    map[string]int64 `json:"moved"` // Rows moved to the target per table
+}
+
+type SyncChangeResponse struct {
+	Seq       int64           `json:"seq"`
+	Entity    string          `json:"entity"` // "subject", "topic", "study_cycle", "cycle_item", "study_session", "session_pause", "exercise_log" or "goal"
+	ID        string          `json:"id"`
+	Op        string          `json:"op"` // "upsert" or "delete"
+	ChangedAt time.Time       `json:"changed_at"`
+	Data      json.RawMessage `json:"data,omitempty" swaggertype:"object"` // Row as it is now; absent from tombstones
+}
+
+type SyncChangesResponse struct {
+	Changes []SyncChangeResponse `json:"changes"`
+	Cursor  string               `json:"cursor"` // Pass as since to resume after these changes
+	HasMore bool                 `json:"has_more"`
+}
+
+type SyncMutationRequest struct {
+	Entity string          `json:"entity" validate:"required"`
+	Op     string          `json:"op" validate:"required,oneof=upsert delete"`
+	ID     string          `json:"id" validate:"required,uuid"`
+	Data   json.RawMessage `json:"data,omitempty" swaggertype:"object"` // Whole row for an upsert, as the feed serves it
+}
+
+type SyncPushRequest struct {
+	Since     string                `json:"since"` // Cursor of the client's last pull
+	Mutations []SyncMutationRequest `json:"mutations" validate:"required,min=1,max=500,dive"`
+}
+
+type SyncResultResponse struct {
+	Entity  string              `json:"entity"`
+	ID      string              `json:"id"`
+	Status  string              `json:"status"` // "applied", "conflict" or "rejected"
+	Error   string              `json:"error,omitempty"`
+	Current *SyncChangeResponse `json:"current,omitempty"` // Server's change, on a conflict
+}
+
+type SyncPushResponse struct {
+	Results []SyncResultResponse `json:"results"`
+}
+
+type BatchOperationRequest struct {
+	Method  string          `json:"method" validate:"required,oneof=POST PUT PATCH DELETE"`
+	Path    string          `json:"path" validate:"required,startswith=/"` // e.g. "/exercise-logs" or "/subjects/{id}"
+	Body    json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+	IfMatch string          `json:"if_match,omitempty"` // Sent as the operation's If-Match header
+}
+
+type BatchRequest struct {
+	Operations      []BatchOperationRequest `json:"operations" validate:"required,min=1,max=100,dive"`
+	ContinueOnError bool                    `json:"continue_on_error"` // Commit each operation on its own instead of all or none
+}
+
+type BatchResultResponse struct {
+	Status int             `json:"status"`
+	ETag   string          `json:"etag,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty" swaggertype:"object"` // What the operation's own endpoint would have answered
+}
+
+type BatchResponse struct {
+	Committed bool                  `json:"committed"` // False if a failed operation rolled back the batch
+	Results   []BatchResultResponse `json:"results"`
+}
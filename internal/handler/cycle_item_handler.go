@@ -2,20 +2,29 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/service"
 )
 
 type CycleItemHandler struct {
 	svc      service.CycleItemService
+	exports  service.ExportService // nil without CSV and XLSX responses
 	validate *validator.Validate
 }
 
 func NewCycleItemHandler(svc service.CycleItemService) *CycleItemHandler {
-	return &CycleItemHandler{svc: svc, validate: validator.New()}
+	return &CycleItemHandler{svc: svc, validate: newPatchValidator()}
+}
+
+// WithExports lets the list answer as CSV or XLSX, see writeTable
+func (h *CycleItemHandler) WithExports(exports service.ExportService) *CycleItemHandler {
+	h.exports = exports
+	return h
 }
 
 type CreateCycleItemRequest struct {
@@ -30,6 +39,14 @@ type UpdateCycleItemRequest struct {
 	PlannedDurationMinutes int    `json:"planned_duration_minutes" validate:"omitempty,min=1"`
 }
 
+// PatchCycleItemRequest is a JSON merge patch: members left out keep their
+// value; planned_duration_minutes takes 0, and null clears it
+type PatchCycleItemRequest struct {
+	SubjectID              service.Field[string] `json:"subject_id" validate:"omitnil,min=1" swaggertype:"string"`
+	OrderIndex             service.Field[int]    `json:"order_index" validate:"omitnil,min=1" swaggertype:"integer"`
+	PlannedDurationMinutes service.Field[int]    `json:"planned_duration_minutes" validate:"omitnil,min=0" swaggertype:"integer"`
+}
+
 // CreateCycleItem godoc
 // @Summary Create a new cycle item
 // @Tags cycle_items
@@ -73,7 +90,10 @@ func (h *CycleItemHandler) CreateCycleItem(w http.ResponseWriter, r *http.Reques
 // @Summary List all items for a cycle
 // @Tags cycle_items
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param id path string true "Cycle ID"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
 // @Success 200 {array} handler.CycleItemResponse
 // @Router /study-cycles/{id}/items [get]
 func (h *CycleItemHandler) ListCycleItems(w http.ResponseWriter, r *http.Request) {
@@ -89,6 +109,14 @@ func (h *CycleItemHandler) ListCycleItems(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	rows := make([]CycleItemResponse, len(items))
+	for i, item := range items {
+		rows[i] = toCycleItemResponse(item)
+	}
+	if writeTable(w, r, h.exports, "cycle-items", rows) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, items)
 }
 
@@ -97,7 +125,9 @@ func (h *CycleItemHandler) ListCycleItems(w http.ResponseWriter, r *http.Request
 // @Tags cycle_items
 // @Produce json
 // @Param id path string true "Item ID"
+// @Param If-None-Match header string false "ETag of a copy already held"
 // @Success 200 {object} handler.CycleItemResponse
+// @Success 304 "Not Modified"
 // @Router /cycle-items/{id} [get]
 func (h *CycleItemHandler) GetCycleItem(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -112,6 +142,10 @@ func (h *CycleItemHandler) GetCycleItem(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if writeETag(w, r, item.Version) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, item)
 }
 
@@ -122,7 +156,10 @@ func (h *CycleItemHandler) GetCycleItem(w http.ResponseWriter, r *http.Request)
 // @Produce json
 // @Param id path string true "Item ID"
 // @Param input body UpdateCycleItemRequest true "Cycle item info"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
 // @Success 200 {string} string "OK"
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /cycle-items/{id} [put]
 func (h *CycleItemHandler) UpdateCycleItem(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -131,6 +168,12 @@ func (h *CycleItemHandler) UpdateCycleItem(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
 	var req UpdateCycleItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -145,15 +188,87 @@ func (h *CycleItemHandler) UpdateCycleItem(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err := h.svc.UpdateCycleItem(r.Context(), id, req.SubjectID, req.OrderIndex, req.PlannedDurationMinutes)
+	item, err := h.svc.UpdateCycleItem(r.Context(), id, req.SubjectID, req.OrderIndex, req.PlannedDurationMinutes, version)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		switch {
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Cycle item was changed by another request")
+		case errors.Is(err, service.ErrCycleItemNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Cycle item not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
 		return
 	}
 
+	w.Header().Set("ETag", etag(item.Version))
 	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "Cycle item updated successfully"})
 }
 
+// PatchCycleItem godoc
+// @Summary Partially update a cycle item
+// @Description Only the members present change; planned_duration_minutes can be set to 0 or cleared with null.
+// @Tags cycle_items
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param input body PatchCycleItemRequest true "JSON merge patch"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
+// @Success 200 {object} handler.CycleItemResponse
+// @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /cycle-items/{id} [patch]
+func (h *CycleItemHandler) PatchCycleItem(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Item ID is required")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
+	var req PatchCycleItemRequest
+	if status, err := decodeMergePatch(r, &req); err != nil {
+		h.respondWithError(w, status, err.Error())
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	item, err := h.svc.PatchCycleItem(r.Context(), id, service.CycleItemPatch{
+		SubjectID:              req.SubjectID,
+		OrderIndex:             req.OrderIndex,
+		PlannedDurationMinutes: req.PlannedDurationMinutes,
+	}, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPatch):
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Cycle item was changed by another request")
+		case errors.Is(err, service.ErrCycleItemNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Cycle item not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(item.Version))
+	h.respondWithJSON(w, http.StatusOK, item)
+}
+
 // DeleteCycleItem godoc
 // @Summary Delete a cycle item
 // @Tags cycle_items
@@ -176,6 +291,19 @@ func (h *CycleItemHandler) DeleteCycleItem(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func toCycleItemResponse(item database.CycleItem) CycleItemResponse {
+	return CycleItemResponse{
+		ID:                     item.ID,
+		CycleID:                item.CycleID,
+		SubjectID:              item.SubjectID,
+		OrderIndex:             int(item.OrderIndex),
+		PlannedDurationMinutes: int(item.PlannedDurationMinutes.Int64),
+		CreatedAt:              item.CreatedAt,
+		UpdatedAt:              item.UpdatedAt,
+		Version:                item.Version,
+	}
+}
+
 func (h *CycleItemHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
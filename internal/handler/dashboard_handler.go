@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+type DashboardHandler struct {
+	svc service.DashboardService
+}
+
+func NewDashboardHandler(svc service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{svc: svc}
+}
+
+// GetSummary godoc
+// @Summary Get today's dashboard
+// @Description The open session with its live elapsed and paused time, today's net time against the daily goal, today's questions and accuracy, the current cycle item, revisions due and the daily goal streak, all read in one transaction.
+// @Tags dashboard
+// @Produce json
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Success 200 {object} handler.DashboardSummaryResponse
+// @Failure 400 {object} map[string]string
+// @Router /dashboard/summary [get]
+func (h *DashboardHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	summary, err := h.svc.GetSummary(r.Context(), userID.(string), r.URL.Query().Get("tz"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid timezone")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := DashboardSummaryResponse{
+		Date: summary.Date.Format("2006-01-02"),
+		Today: DashboardTodayResponse{
+			NetMinutes:         int(summary.Today.NetSeconds / 60),
+			GoalMinutes:        int(summary.Today.GoalSeconds / 60),
+			GoalPercentage:     summary.Today.GoalPercentage,
+			GoalAttained:       summary.Today.GoalAttained,
+			TotalQuestions:     int(summary.Today.Questions),
+			TotalCorrect:       int(summary.Today.Correct),
+			AccuracyPercentage: summary.Today.Accuracy,
+		},
+		DueReviews:      make([]DueReviewResponse, len(summary.DueReviews)),
+		DueReviewsCount: summary.DueReviewsCount,
+		Streak: DashboardStreakResponse{
+			Current: summary.Streak.Current,
+			Longest: summary.Streak.Longest,
+		},
+	}
+	if session := summary.OpenSession; session != nil {
+		response.OpenSession = &DashboardSessionResponse{
+			ID:             session.ID,
+			SubjectID:      session.SubjectID,
+			SubjectName:    session.SubjectName,
+			ColorHex:       session.ColorHex,
+			CycleItemID:    session.CycleItemID,
+			StartedAt:      session.StartedAt.Format(time.RFC3339),
+			ElapsedSeconds: int(session.ElapsedSeconds),
+			PausedSeconds:  int(session.PausedSeconds),
+			NetSeconds:     int(session.NetSeconds),
+			Paused:         session.Paused,
+		}
+	}
+	if item := summary.CycleItem; item != nil {
+		response.CycleItem = &DashboardCycleItemResponse{
+			ID:             item.ID,
+			CycleID:        item.CycleID,
+			CycleName:      item.CycleName,
+			SubjectID:      item.SubjectID,
+			SubjectName:    item.SubjectName,
+			ColorHex:       item.ColorHex,
+			PlannedMinutes: int(item.PlannedMinutes),
+			Position:       item.Position,
+			ItemsCount:     item.ItemsCount,
+			InProgress:     item.InProgress,
+		}
+	}
+	for i, review := range summary.DueReviews {
+		response.DueReviews[i] = DueReviewResponse{
+			TopicID:       review.TopicID,
+			TopicName:     review.TopicName,
+			SubjectID:     review.SubjectID,
+			SubjectName:   review.SubjectName,
+			LastPracticed: review.LastPracticed.Format("2006-01-02"),
+			IntervalDays:  review.IntervalDays,
+			OverdueDays:   review.OverdueDays,
+		}
+	}
+	if !summary.Streak.LastAttained.IsZero() {
+		response.Streak.LastAttained = summary.Streak.LastAttained.Format("2006-01-02")
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *DashboardHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *DashboardHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidIfMatch = errors.New("If-Match must be a single strong ETag or *")
+	errETagList       = errors.New("If-Match takes one ETag")
+)
+
+// etag is the strong entity tag of a row version
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// writeETag sets the ETag of a single resource and reports whether the
+// request's If-None-Match already names it, in which case it answers 304
+// and the caller is done.
+func writeETag(w http.ResponseWriter, r *http.Request, version int64) bool {
+	tag := etag(version)
+	w.Header().Set("ETag", tag)
+
+	match := r.Header.Get("If-None-Match")
+	if match == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(match, ",") {
+		// If-None-Match compares weakly, so W/"3" matches "3"
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion reads the version an update expects from If-Match. Without
+// the header, or with *, there is nothing to check and it returns nil. Row
+// versions are only issued as strong tags, so a weak or malformed one can
+// never match; a list is rejected as ambiguous.
+func ifMatchVersion(r *http.Request) (*int64, error) {
+	match := strings.TrimSpace(r.Header.Get("If-Match"))
+	if match == "" || match == "*" {
+		return nil, nil
+	}
+	if strings.Contains(match, ",") {
+		return nil, errETagList
+	}
+	if len(match) < 2 || match[0] != '"' || match[len(match)-1] != '"' {
+		return nil, errInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(match[1:len(match)-1], 10, 64)
+	if err != nil {
+		return nil, errInvalidIfMatch
+	}
+	return &version, nil
+}
+
+// ifMatchStatus is the status answering an If-Match that ifMatchVersion refused
+func ifMatchStatus(err error) int {
+	if errors.Is(err, errETagList) {
+		return http.StatusBadRequest
+	}
+	return http.StatusPreconditionFailed
+}
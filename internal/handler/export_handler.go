@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+
+	"github.com/joaoapaenas/my-api/internal/export"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+type ExportHandler struct {
+	svc service.ExportService
+}
+
+func NewExportHandler(svc service.ExportService) *ExportHandler {
+	return &ExportHandler{svc: svc}
+}
+
+// ExportSessions godoc
+// @Summary Export study sessions as CSV or XLSX
+// @Description Streams every session started between two local dates, oldest first. CSV unless ?format=xlsx or an Accept header asks for XLSX. Decimal separators and dates follow the profile locale (CSV fields are separated by ';' where the decimal separator is ','), and timestamps are in the profile timezone.
+// @Tags export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param from query string false "From (YYYY-MM-DD, local day)"
+// @Param to query string false "To (YYYY-MM-DD, inclusive)"
+// @Param format query string false "csv (default) or xlsx"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Success 200 {array} handler.SessionExportResponse
+// @Failure 400 {object} map[string]string
+// @Router /export/study-sessions [get]
+func (h *ExportHandler) ExportSessions(w http.ResponseWriter, r *http.Request) {
+	userID, table, ok := h.table(w, r, "study-sessions", reflect.TypeOf(SessionExportResponse{}))
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	err := h.svc.StreamSessions(r.Context(), userID, table.settings.Location, q.Get("from"), q.Get("to"), func(session service.SessionExport) error {
+		row := SessionExportResponse{
+			ID:                   session.ID,
+			SubjectID:            session.SubjectID,
+			SubjectName:          session.SubjectName,
+			CycleItemID:          session.CycleItemID,
+			StartedAt:            session.StartedAt,
+			FinishedAt:           session.FinishedAt,
+			GrossDurationSeconds: int(session.GrossSeconds),
+			NetDurationSeconds:   int(session.NetSeconds),
+			NetHours:             secondsToHours(session.NetSeconds),
+			PausesCount:          int(session.PausesCount),
+			Notes:                session.Notes,
+		}
+		return table.Write(row)
+	})
+	h.finish(w, table, err)
+}
+
+// ExportExerciseLogs godoc
+// @Summary Export exercise logs as CSV or XLSX
+// @Description Streams every exercise log created between two local dates, oldest first, formatted like the session export.
+// @Tags export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param from query string false "From (YYYY-MM-DD, local day)"
+// @Param to query string false "To (YYYY-MM-DD, inclusive)"
+// @Param format query string false "csv (default) or xlsx"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Success 200 {array} handler.ExerciseLogExportResponse
+// @Failure 400 {object} map[string]string
+// @Router /export/exercise-logs [get]
+func (h *ExportHandler) ExportExerciseLogs(w http.ResponseWriter, r *http.Request) {
+	userID, table, ok := h.table(w, r, "exercise-logs", reflect.TypeOf(ExerciseLogExportResponse{}))
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	err := h.svc.StreamExerciseLogs(r.Context(), userID, table.settings.Location, q.Get("from"), q.Get("to"), func(log service.ExerciseLogExport) error {
+		row := ExerciseLogExportResponse{
+			ID:                 log.ID,
+			SessionID:          log.SessionID,
+			SubjectID:          log.SubjectID,
+			SubjectName:        log.SubjectName,
+			TopicID:            log.TopicID,
+			TopicName:          log.TopicName,
+			QuestionsCount:     int(log.Questions),
+			CorrectCount:       int(log.Correct),
+			AccuracyPercentage: log.Accuracy,
+			CreatedAt:          log.CreatedAt,
+		}
+		return table.Write(row)
+	})
+	h.finish(w, table, err)
+}
+
+// table negotiates the format, CSV unless XLSX is asked for, and loads the
+// user's export settings
+func (h *ExportHandler) table(w http.ResponseWriter, r *http.Request, name string, rowType reflect.Type) (string, *tableResponse, bool) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return "", nil, false
+	}
+
+	format, err := tableFormat(r)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return "", nil, false
+	}
+	if format == export.FormatJSON {
+		format = export.FormatCSV
+	}
+
+	settings, err := h.svc.Settings(r.Context(), userID.(string), r.URL.Query().Get("tz"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid timezone")
+			return "", nil, false
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return "", nil, false
+	}
+	return userID.(string), newTableResponse(w, format, settings, name, rowType), true
+}
+
+// finish closes the table, or reports err while nothing has been sent yet.
+// Once rows are out the status can't change, so a failure cuts the file short.
+func (h *ExportHandler) finish(w http.ResponseWriter, table *tableResponse, err error) {
+	switch {
+	case err == nil:
+		if err := table.Close(); err != nil {
+			slog.Error("Export failed", "table", table.name, "error", err)
+		}
+	case table.Started():
+		slog.Error("Export interrupted", "table", table.name, "error", err)
+	case errors.Is(err, service.ErrInvalidDateRange):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+func (h *ExportHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *ExportHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
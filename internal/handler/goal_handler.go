@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+type GoalHandler struct {
+	svc      service.GoalService
+	exports  service.ExportService // nil without CSV and XLSX responses
+	validate *validator.Validate
+}
+
+func NewGoalHandler(svc service.GoalService) *GoalHandler {
+	return &GoalHandler{svc: svc, validate: validator.New()}
+}
+
+// WithExports lets the list answer as CSV or XLSX, see writeTable
+func (h *GoalHandler) WithExports(exports service.ExportService) *GoalHandler {
+	h.exports = exports
+	return h
+}
+
+type GoalRequest struct {
+	SubjectID string `json:"subject_id"`
+	Metric    string `json:"metric" validate:"required,oneof=net_minutes questions"`
+	Period    string `json:"period" validate:"required,oneof=day week"`
+	Target    int64  `json:"target" validate:"required,min=1"`
+	RestDays  []int  `json:"rest_days" validate:"omitempty,max=6,dive,min=0,max=6"`
+}
+
+// CreateGoal godoc
+// @Summary Create a study goal
+// @Description Time (net_minutes) or question goals per day or week, optionally for one subject. rest_days (0 = Sunday) only apply to daily goals.
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param input body GoalRequest true "Goal info"
+// @Success 201 {object} handler.GoalResponse
+// @Failure 400 {object} map[string]string
+// @Router /goals [post]
+func (h *GoalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	req, ok := h.decodeGoalRequest(w, r)
+	if !ok {
+		return
+	}
+
+	goal, err := h.svc.CreateGoal(r.Context(), userID.(string), req.input())
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, toGoalResponse(goal))
+}
+
+// ListGoals godoc
+// @Summary List goals with their progress in the current day or week
+// @Tags goals
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {array} handler.GoalWithProgressResponse
+// @Router /goals [get]
+func (h *GoalHandler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	statuses, err := h.svc.ListGoals(r.Context(), userID.(string), r.URL.Query().Get("tz"))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	response := make([]GoalWithProgressResponse, len(statuses))
+	for i, status := range statuses {
+		response[i] = GoalWithProgressResponse{
+			GoalResponse: toGoalResponse(status.Goal),
+			Progress:     toGoalProgressResponse(status),
+		}
+	}
+
+	if writeTable(w, r, h.exports, "goals", response) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetGoal godoc
+// @Summary Get a goal by ID
+// @Tags goals
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Success 200 {object} handler.GoalResponse
+// @Failure 404 {object} map[string]string
+// @Router /goals/{id} [get]
+func (h *GoalHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	goal, err := h.svc.GetGoal(r.Context(), chi.URLParam(r, "id"), userID.(string))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toGoalResponse(goal))
+}
+
+// UpdateGoal godoc
+// @Summary Update a goal
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Param input body GoalRequest true "Goal info"
+// @Success 200 {object} handler.GoalResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /goals/{id} [put]
+func (h *GoalHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	req, ok := h.decodeGoalRequest(w, r)
+	if !ok {
+		return
+	}
+
+	goal, err := h.svc.UpdateGoal(r.Context(), chi.URLParam(r, "id"), userID.(string), req.input())
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toGoalResponse(goal))
+}
+
+// DeleteGoal godoc
+// @Summary Delete a goal
+// @Tags goals
+// @Param id path string true "Goal ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /goals/{id} [delete]
+func (h *GoalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := h.svc.DeleteGoal(r.Context(), chi.URLParam(r, "id"), userID.(string)); err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetStreaks godoc
+// @Summary Get current and longest streaks for every goal
+// @Description Evaluated over the last year. Rest days and the unfinished current period don't break a streak.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
+// @Success 200 {array} handler.GoalStreakResponse
+// @Router /analytics/streaks [get]
+func (h *GoalHandler) GetStreaks(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	statuses, err := h.svc.GetStreaks(r.Context(), userID.(string), r.URL.Query().Get("tz"))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	response := make([]GoalStreakResponse, len(statuses))
+	for i, status := range statuses {
+		response[i] = GoalStreakResponse{
+			GoalID:        status.Goal.ID,
+			SubjectID:     status.Goal.SubjectID.String,
+			Metric:        status.Goal.Metric,
+			Period:        status.Goal.Period,
+			Target:        int(status.Goal.Target),
+			CurrentStreak: status.CurrentStreak,
+			LongestStreak: status.LongestStreak,
+			Progress:      toGoalProgressResponse(status),
+		}
+		if !status.LastAttained.IsZero() {
+			response[i].LastAttained = status.LastAttained.Format("2006-01-02")
+		}
+	}
+
+	if writeTable(w, r, h.exports, "streaks", response) {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *GoalHandler) decodeGoalRequest(w http.ResponseWriter, r *http.Request) (GoalRequest, bool) {
+	var req GoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return req, false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return req, false
+	}
+	return req, true
+}
+
+func (req GoalRequest) input() service.GoalInput {
+	return service.GoalInput{
+		SubjectID: req.SubjectID,
+		Metric:    req.Metric,
+		Period:    req.Period,
+		Target:    req.Target,
+		RestDays:  req.RestDays,
+	}
+}
+
+func toGoalResponse(goal database.Goal) GoalResponse {
+	return GoalResponse{
+		ID:        goal.ID,
+		SubjectID: goal.SubjectID.String,
+		Metric:    goal.Metric,
+		Period:    goal.Period,
+		Target:    int(goal.Target),
+		RestDays:  service.RestDaysFromMask(goal.RestDays),
+		CreatedAt: goal.CreatedAt,
+		UpdatedAt: goal.UpdatedAt,
+	}
+}
+
+func toGoalProgressResponse(status service.GoalStatus) GoalProgressResponse {
+	percentage := float64(status.Value) * 100 / float64(status.Goal.Target)
+	return GoalProgressResponse{
+		PeriodStart: status.PeriodStart.Format("2006-01-02"),
+		Value:       int(status.Value),
+		Attained:    status.Attained,
+		Percentage:  math.Round(percentage*100) / 100,
+	}
+}
+
+func (h *GoalHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrGoalNotFound):
+		h.respondWithError(w, http.StatusNotFound, "Goal not found")
+	case errors.Is(err, service.ErrInvalidGoal):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrInvalidTimezone):
+		h.respondWithError(w, http.StatusBadRequest, "Invalid timezone")
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+func (h *GoalHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *GoalHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+type MergeHandler struct {
+	svc      service.MergeService
+	validate *validator.Validate
+}
+
+func NewMergeHandler(svc service.MergeService) *MergeHandler {
+	return &MergeHandler{svc: svc, validate: validator.New()}
+}
+
+// MergeSubjects godoc
+// @Summary Merge a subject into another
+// @Description Moves the topics, cycle items, study sessions, exercise logs and goals of the subject in the path to target_id and moves it to the trash, in one transaction. With ?dry_run=true the report shows what would move, but nothing changes.
+// @Tags subjects
+// @Accept json
+// @Produce json
+// @Param id path string true "Source Subject ID"
+// @Param dry_run query bool false "Report without merging"
+// @Param input body MergeRequest true "Target subject"
+// @Success 200 {object} handler.MergeReportResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subjects/{id}/merge [post]
+func (h *MergeHandler) MergeSubjects(w http.ResponseWriter, r *http.Request) {
+	h.merge(w, r, h.svc.MergeSubjects)
+}
+
+// MergeTopics godoc
+// @Summary Merge a topic into another
+// @Description Moves the exercise logs of the topic in the path to target_id and moves it to the trash, in one transaction. Both topics must be under the same subject. With ?dry_run=true the report shows what would move, but nothing changes.
+// @Tags topics
+// @Accept json
+// @Produce json
+// @Param id path string true "Source Topic ID"
+// @Param dry_run query bool false "Report without merging"
+// @Param input body MergeRequest true "Target topic"
+// @Success 200 {object} handler.MergeReportResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string "Topics of different subjects"
+// @Router /topics/{id}/merge [post]
+func (h *MergeHandler) MergeTopics(w http.ResponseWriter, r *http.Request) {
+	h.merge(w, r, h.svc.MergeTopics)
+}
+
+func (h *MergeHandler) merge(w http.ResponseWriter, r *http.Request, merge func(ctx context.Context, userID, sourceID, targetID string, dryRun bool) (service.MergeReport, error)) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	var req MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	report, err := merge(r.Context(), userID.(string), chi.URLParam(r, "id"), req.TargetID, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMergeIntoItself):
+			h.respondWithError(w, http.StatusBadRequest, "Can't merge into itself")
+		case errors.Is(err, service.ErrMergeNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Source or target not found")
+		case errors.Is(err, service.ErrMergeAcrossSubjects):
+			h.respondWithError(w, http.StatusUnprocessableEntity, "Topics belong to different subjects; merge the subjects first")
+		default:
+			slog.Error("Failed to merge", "error", err)
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, MergeReportResponse{
+		SourceID: report.SourceID,
+		TargetID: report.TargetID,
+		DryRun:   report.DryRun,
+		Moved:    report.Moved,
+	})
+}
+
+func (h *MergeHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *MergeHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
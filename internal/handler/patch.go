@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+// MergePatchContentType is the media type of a JSON merge patch (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+var errNotMergePatch = errors.New("Content-Type must be " + MergePatchContentType)
+
+// newPatchValidator is a validator that also checks merge patch requests:
+// a service.Field validates as its value when it has one and as nil when it
+// is left out or null, so "omitnil,min=2" checks names only when given.
+func newPatchValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterCustomTypeFunc(fieldValue[string], service.Field[string]{})
+	validate.RegisterCustomTypeFunc(fieldValue[int], service.Field[int]{})
+	validate.RegisterCustomTypeFunc(fieldValue[float64], service.Field[float64]{})
+	validate.RegisterCustomTypeFunc(fieldValue[bool], service.Field[bool]{})
+	return validate
+}
+
+func fieldValue[T comparable](v reflect.Value) interface{} {
+	return v.Interface().(service.Field[T]).Ptr()
+}
+
+// decodeMergePatch reads a merge patch body into dst, whose members are
+// service.Field. Plain application/json is accepted as well. Unknown members
+// are refused rather than ignored so a misspelt field isn't silently dropped.
+// On failure it returns the status to answer with.
+func decodeMergePatch(r *http.Request, dst interface{}) (int, error) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+			return http.StatusUnsupportedMediaType, errNotMergePatch
+		}
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return http.StatusBadRequest, errors.New("Invalid request payload")
+	}
+	return 0, nil
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+type RecommendationHandler struct {
+	svc      service.RecommendationService
+	validate *validator.Validate
+}
+
+func NewRecommendationHandler(svc service.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{svc: svc, validate: validator.New()}
+}
+
+type RecommendationWeightsRequest struct {
+	Cycle    *float64 `json:"cycle" validate:"required,min=0,max=10"`
+	Review   *float64 `json:"review" validate:"required,min=0,max=10"`
+	Weakness *float64 `json:"weakness" validate:"required,min=0,max=10"`
+}
+
+// NextRecommendations godoc
+// @Summary Recommend what to study now
+// @Description Ranks the active cycle's next item, topics overdue for review and low-accuracy topics by their weighted scores, each with the reasons behind it.
+// @Tags recommendations
+// @Produce json
+// @Param limit query int false "Maximum recommendations (default 5, at most 50)"
+// @Param tz query string false "IANA timezone (defaults to the profile timezone)"
+// @Success 200 {array} handler.RecommendationResponse
+// @Failure 400 {object} map[string]string
+// @Router /recommendations/next [get]
+func (h *RecommendationHandler) NextRecommendations(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	limit, err := positiveQueryInt(r, "limit")
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recommendations, err := h.svc.NextRecommendations(r.Context(), userID.(string), r.URL.Query().Get("tz"), int(limit))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	response := make([]RecommendationResponse, len(recommendations))
+	for i, rec := range recommendations {
+		response[i] = RecommendationResponse{
+			SubjectID:      rec.SubjectID,
+			SubjectName:    rec.SubjectName,
+			ColorHex:       rec.ColorHex,
+			TopicID:        rec.TopicID,
+			TopicName:      rec.TopicName,
+			CycleItemID:    rec.CycleItemID,
+			PlannedMinutes: int(rec.PlannedMinutes),
+			Score:          rec.Score,
+			Reasons:        make([]RecommendationReasonResponse, len(rec.Reasons)),
+		}
+		for j, reason := range rec.Reasons {
+			response[i].Reasons[j] = RecommendationReasonResponse{
+				Source:      reason.Source,
+				Score:       reason.Score,
+				Weight:      reason.Weight,
+				Explanation: reason.Explanation,
+			}
+		}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetWeights godoc
+// @Summary Get the recommendation scoring weights
+// @Tags recommendations
+// @Produce json
+// @Success 200 {object} handler.RecommendationWeightsResponse
+// @Router /recommendations/weights [get]
+func (h *RecommendationHandler) GetWeights(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	weights, err := h.svc.GetWeights(r.Context(), userID.(string))
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toRecommendationWeightsResponse(weights))
+}
+
+// UpdateWeights godoc
+// @Summary Set the recommendation scoring weights
+// @Description Each source's score (between 0 and 1) is multiplied by its weight, from 0 (off) to 10. At least one weight must be positive.
+// @Tags recommendations
+// @Accept json
+// @Produce json
+// @Param input body RecommendationWeightsRequest true "Weights"
+// @Success 200 {object} handler.RecommendationWeightsResponse
+// @Failure 400 {object} map[string]string
+// @Router /recommendations/weights [put]
+func (h *RecommendationHandler) UpdateWeights(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req RecommendationWeightsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	weights, err := h.svc.UpdateWeights(r.Context(), userID.(string), service.RecommendationWeights{
+		Cycle:    *req.Cycle,
+		Review:   *req.Review,
+		Weakness: *req.Weakness,
+	})
+	if err != nil {
+		h.respondWithServiceError(w, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toRecommendationWeightsResponse(weights))
+}
+
+func toRecommendationWeightsResponse(weights service.RecommendationWeights) RecommendationWeightsResponse {
+	return RecommendationWeightsResponse{
+		Cycle:    weights.Cycle,
+		Review:   weights.Review,
+		Weakness: weights.Weakness,
+	}
+}
+
+func (h *RecommendationHandler) respondWithServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRecommendationWeights):
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrInvalidTimezone):
+		h.respondWithError(w, http.StatusBadRequest, "Invalid timezone")
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+func (h *RecommendationHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *RecommendationHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -15,13 +16,14 @@ type StudyCycleHandler struct {
 }
 
 func NewStudyCycleHandler(svc service.StudyCycleService) *StudyCycleHandler {
-	return &StudyCycleHandler{svc: svc, validate: validator.New()}
+	return &StudyCycleHandler{svc: svc, validate: newPatchValidator()}
 }
 
 type CreateStudyCycleRequest struct {
-	Name        string `json:"name" validate:"required,min=2"`
-	Description string `json:"description"`
-	IsActive    bool   `json:"is_active"`
+	Name        string                   `json:"name" validate:"required,min=2"`
+	Description string                   `json:"description"`
+	IsActive    bool                     `json:"is_active"`
+	Items       []CreateCycleItemRequest `json:"items" validate:"omitempty,dive"` // Created with the cycle, in the same transaction
 }
 
 type UpdateStudyCycleRequest struct {
@@ -30,6 +32,14 @@ type UpdateStudyCycleRequest struct {
 	IsActive    bool   `json:"is_active"`
 }
 
+// PatchStudyCycleRequest is a JSON merge patch: members left out keep their
+// value and null clears the description
+type PatchStudyCycleRequest struct {
+	Name        service.Field[string] `json:"name" validate:"omitnil,min=2" swaggertype:"string"`
+	Description service.Field[string] `json:"description" swaggertype:"string"`
+	IsActive    service.Field[bool]   `json:"is_active" swaggertype:"boolean"`
+}
+
 // CreateStudyCycle godoc
 // @Summary Create a new study cycle
 // @Tags study_cycles
@@ -53,7 +63,15 @@ func (h *StudyCycleHandler) CreateStudyCycle(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	cycle, err := h.svc.CreateStudyCycle(r.Context(), req.Name, req.Description, req.IsActive)
+	items := make([]service.NewCycleItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = service.NewCycleItem{
+			SubjectID:              item.SubjectID,
+			OrderIndex:             item.OrderIndex,
+			PlannedDurationMinutes: item.PlannedDurationMinutes,
+		}
+	}
+	cycle, err := h.svc.CreateStudyCycle(r.Context(), req.Name, req.Description, req.IsActive, items)
 	if err != nil {
 		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
@@ -83,7 +101,9 @@ func (h *StudyCycleHandler) GetActiveStudyCycle(w http.ResponseWriter, r *http.R
 // @Tags study_cycles
 // @Produce json
 // @Param id path string true "Cycle ID"
+// @Param If-None-Match header string false "ETag of a copy already held"
 // @Success 200 {object} handler.StudyCycleResponse
+// @Success 304 "Not Modified"
 // @Router /study-cycles/{id} [get]
 func (h *StudyCycleHandler) GetStudyCycle(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -98,6 +118,10 @@ func (h *StudyCycleHandler) GetStudyCycle(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if writeETag(w, r, cycle.Version) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, cycle)
 }
 
@@ -108,7 +132,10 @@ func (h *StudyCycleHandler) GetStudyCycle(w http.ResponseWriter, r *http.Request
 // @Produce json
 // @Param id path string true "Cycle ID"
 // @Param input body UpdateStudyCycleRequest true "Study cycle info"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
 // @Success 200 {string} string "OK"
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /study-cycles/{id} [put]
 func (h *StudyCycleHandler) UpdateStudyCycle(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -117,6 +144,12 @@ func (h *StudyCycleHandler) UpdateStudyCycle(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
 	var req UpdateStudyCycleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -131,17 +164,90 @@ func (h *StudyCycleHandler) UpdateStudyCycle(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err := h.svc.UpdateStudyCycle(r.Context(), id, req.Name, req.Description, req.IsActive)
+	cycle, err := h.svc.UpdateStudyCycle(r.Context(), id, req.Name, req.Description, req.IsActive, version)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		switch {
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Study cycle was changed by another request")
+		case errors.Is(err, service.ErrStudyCycleNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Study cycle not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
 		return
 	}
 
+	w.Header().Set("ETag", etag(cycle.Version))
 	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "Study cycle updated successfully"})
 }
 
+// PatchStudyCycle godoc
+// @Summary Partially update a study cycle
+// @Description Only the members present change, so the description can be changed or cleared (null) alone.
+// @Tags study_cycles
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Param id path string true "Cycle ID"
+// @Param input body PatchStudyCycleRequest true "JSON merge patch"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
+// @Success 200 {object} handler.StudyCycleResponse
+// @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /study-cycles/{id} [patch]
+func (h *StudyCycleHandler) PatchStudyCycle(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Cycle ID is required")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
+	var req PatchStudyCycleRequest
+	if status, err := decodeMergePatch(r, &req); err != nil {
+		h.respondWithError(w, status, err.Error())
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	cycle, err := h.svc.PatchStudyCycle(r.Context(), id, service.StudyCyclePatch{
+		Name:        req.Name,
+		Description: req.Description,
+		IsActive:    req.IsActive,
+	}, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPatch):
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Study cycle was changed by another request")
+		case errors.Is(err, service.ErrStudyCycleNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Study cycle not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(cycle.Version))
+	h.respondWithJSON(w, http.StatusOK, cycle)
+}
+
 // DeleteStudyCycle godoc
 // @Summary Delete a study cycle
+// @Description Moves the cycle to the trash, see GET /trash.
 // @Tags study_cycles
 // @Param id path string true "Cycle ID"
 // @Success 204
@@ -2,30 +2,49 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/service"
 )
 
 type SubjectHandler struct {
 	svc      service.SubjectService
+	exports  service.ExportService // nil without CSV and XLSX responses
 	validate *validator.Validate
 }
 
 func NewSubjectHandler(svc service.SubjectService) *SubjectHandler {
-	return &SubjectHandler{svc: svc, validate: validator.New()}
+	return &SubjectHandler{svc: svc, validate: newPatchValidator()}
+}
+
+// WithExports lets the list answer as CSV or XLSX, see writeTable
+func (h *SubjectHandler) WithExports(exports service.ExportService) *SubjectHandler {
+	h.exports = exports
+	return h
 }
 
 type CreateSubjectRequest struct {
-	Name     string `json:"name" validate:"required,min=2"`
-	ColorHex string `json:"color_hex" validate:"omitempty,hexcolor"`
+	Name       string   `json:"name" validate:"required,min=2"`
+	ColorHex   string   `json:"color_hex" validate:"omitempty,hexcolor"`
+	ExamWeight *float64 `json:"exam_weight" validate:"omitempty,gt=0"` // Relative weight in the target exam
 }
 
 type UpdateSubjectRequest struct {
-	Name     string `json:"name" validate:"required,min=2"`
-	ColorHex string `json:"color_hex" validate:"omitempty,hexcolor"`
+	Name       string   `json:"name" validate:"required,min=2"`
+	ColorHex   string   `json:"color_hex" validate:"omitempty,hexcolor"`
+	ExamWeight *float64 `json:"exam_weight" validate:"omitempty,gt=0"` // Omit or null to clear
+}
+
+// PatchSubjectRequest is a JSON merge patch: members left out keep their
+// value and null clears color_hex or exam_weight
+type PatchSubjectRequest struct {
+	Name       service.Field[string]  `json:"name" validate:"omitnil,min=2" swaggertype:"string"`
+	ColorHex   service.Field[string]  `json:"color_hex" validate:"omitnil,hexcolor" swaggertype:"string"`
+	ExamWeight service.Field[float64] `json:"exam_weight" validate:"omitnil,gt=0" swaggertype:"number"`
 }
 
 // CreateSubject godoc
@@ -59,7 +78,7 @@ func (h *SubjectHandler) CreateSubject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. Pass userID to Service
-	subject, err := h.svc.CreateSubject(r.Context(), userID.(string), req.Name, req.ColorHex)
+	subject, err := h.svc.CreateSubject(r.Context(), userID.(string), req.Name, req.ColorHex, req.ExamWeight)
 	if err != nil {
 		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
@@ -72,6 +91,9 @@ func (h *SubjectHandler) CreateSubject(w http.ResponseWriter, r *http.Request) {
 // @Summary List all subjects
 // @Tags subjects
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
 // @Success 200 {array} handler.SubjectResponse
 // @Router /subjects [get]
 func (h *SubjectHandler) ListSubjects(w http.ResponseWriter, r *http.Request) {
@@ -89,6 +111,14 @@ func (h *SubjectHandler) ListSubjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rows := make([]SubjectResponse, len(subjects))
+	for i, subject := range subjects {
+		rows[i] = toSubjectResponse(subject)
+	}
+	if writeTable(w, r, h.exports, "subjects", rows) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, subjects)
 }
 
@@ -97,7 +127,9 @@ func (h *SubjectHandler) ListSubjects(w http.ResponseWriter, r *http.Request) {
 // @Tags subjects
 // @Produce json
 // @Param id path string true "Subject ID"
+// @Param If-None-Match header string false "ETag of a copy already held"
 // @Success 200 {object} handler.SubjectResponse
+// @Success 304 "Not Modified"
 // @Router /subjects/{id} [get]
 func (h *SubjectHandler) GetSubject(w http.ResponseWriter, r *http.Request) {
 	// 1. Extract UserID
@@ -121,6 +153,10 @@ func (h *SubjectHandler) GetSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if writeETag(w, r, subject.Version) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, subject)
 }
 
@@ -131,7 +167,10 @@ func (h *SubjectHandler) GetSubject(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Subject ID"
 // @Param input body UpdateSubjectRequest true "Subject info"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
 // @Success 200 {string} string "OK"
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /subjects/{id} [put]
 func (h *SubjectHandler) UpdateSubject(w http.ResponseWriter, r *http.Request) {
 	// 1. Extract UserID
@@ -147,6 +186,12 @@ func (h *SubjectHandler) UpdateSubject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
 	var req UpdateSubjectRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -162,17 +207,96 @@ func (h *SubjectHandler) UpdateSubject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. Pass userID to Service
-	err := h.svc.UpdateSubject(r.Context(), id, userID.(string), req.Name, req.ColorHex)
+	subject, err := h.svc.UpdateSubject(r.Context(), id, userID.(string), req.Name, req.ColorHex, req.ExamWeight, version)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		switch {
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Subject was changed by another request")
+		case errors.Is(err, service.ErrSubjectNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Subject not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
 		return
 	}
 
+	w.Header().Set("ETag", etag(subject.Version))
 	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "Subject updated successfully"})
 }
 
+// PatchSubject godoc
+// @Summary Partially update a subject
+// @Description Only the members present change; null clears color_hex or exam_weight.
+// @Tags subjects
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Param id path string true "Subject ID"
+// @Param input body PatchSubjectRequest true "JSON merge patch"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
+// @Success 200 {object} handler.SubjectResponse
+// @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /subjects/{id} [patch]
+func (h *SubjectHandler) PatchSubject(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Subject ID is required")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
+	var req PatchSubjectRequest
+	if status, err := decodeMergePatch(r, &req); err != nil {
+		h.respondWithError(w, status, err.Error())
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	subject, err := h.svc.PatchSubject(r.Context(), id, userID.(string), service.SubjectPatch{
+		Name:       req.Name,
+		ColorHex:   req.ColorHex,
+		ExamWeight: req.ExamWeight,
+	}, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPatch):
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Subject was changed by another request")
+		case errors.Is(err, service.ErrSubjectNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Subject not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(subject.Version))
+	h.respondWithJSON(w, http.StatusOK, subject)
+}
+
 // DeleteSubject godoc
 // @Summary Delete a subject
+// @Description Moves the subject to the trash with everything under it, see GET /trash.
 // @Tags subjects
 // @Param id path string true "Subject ID"
 // @Success 204
@@ -201,6 +325,22 @@ func (h *SubjectHandler) DeleteSubject(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func toSubjectResponse(subject database.Subject) SubjectResponse {
+	response := SubjectResponse{
+		ID:        subject.ID,
+		Name:      subject.Name,
+		ColorHex:  subject.ColorHex.String,
+		CreatedAt: subject.CreatedAt,
+		UpdatedAt: subject.UpdatedAt,
+		DeletedAt: subject.DeletedAt.String,
+		Version:   subject.Version,
+	}
+	if subject.ExamWeight.Valid {
+		response.ExamWeight = &subject.ExamWeight.Float64
+	}
+	return response
+}
+
 func (h *SubjectHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+// maxSyncPageSize caps the limit of a feed page
+const maxSyncPageSize = 1000
+
+var errInvalidCursor = errors.New("cursor must be one returned by /sync/changes")
+
+type SyncHandler struct {
+	svc      service.SyncService
+	validate *validator.Validate
+}
+
+func NewSyncHandler(svc service.SyncService) *SyncHandler {
+	return &SyncHandler{svc: svc, validate: validator.New()}
+}
+
+// Changes godoc
+// @Summary List changes since a cursor
+// @Description Every subject, topic, study cycle, cycle item, study session, session pause, exercise log and goal of the user created, updated or deleted after the cursor, in commit order. Each row appears once, with its last change: upserts carry the row as it is now, deletes are tombstones without data. Start without since for a full sync, then pass the returned cursor; keep pulling while has_more is true.
+// @Tags sync
+// @Produce json
+// @Param since query string false "Cursor of the last pull"
+// @Param limit query int false "Changes per page (default 500, at most 1000)"
+// @Success 200 {object} handler.SyncChangesResponse
+// @Failure 400 {object} map[string]string
+// @Router /sync/changes [get]
+func (h *SyncHandler) Changes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	since, err := parseSyncCursor(r.URL.Query().Get("since"))
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := positiveQueryInt(r, "limit")
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = service.DefaultSyncPageSize
+	}
+	limit = min(limit, maxSyncPageSize)
+
+	page, err := h.svc.Changes(r.Context(), userID.(string), since, limit)
+	if err != nil {
+		slog.Error("Failed to list sync changes", "error", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	resp := SyncChangesResponse{
+		Changes: make([]SyncChangeResponse, 0, len(page.Changes)),
+		Cursor:  strconv.FormatInt(page.Cursor, 10),
+		HasMore: page.HasMore,
+	}
+	for _, change := range page.Changes {
+		resp.Changes = append(resp.Changes, toSyncChangeResponse(change))
+	}
+	h.respondWithJSON(w, http.StatusOK, resp)
+}
+
+// Push godoc
+// @Summary Apply changes made offline
+// @Description Applies up to 500 mutations in order, in one transaction. Upserts create rows under the client's UUID or update them with the whole row, in the shape the feed serves; deletes move subjects, topics and study cycles to the trash and remove other rows. A mutation of a row changed on the server after since is not applied and comes back as a conflict with the server's change; an invalid one, or one of a row that isn't the user's, as rejected. Goals can't be pushed. Pull after pushing: the feed returns the applied rows as the server stored them.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param input body SyncPushRequest true "Mutations"
+// @Success 200 {object} handler.SyncPushResponse
+// @Failure 400 {object} map[string]string
+// @Router /sync/push [post]
+func (h *SyncHandler) Push(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req SyncPushRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+	since, err := parseSyncCursor(req.Since)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mutations := make([]service.SyncMutation, len(req.Mutations))
+	for i, m := range req.Mutations {
+		mutations[i] = service.SyncMutation{Entity: m.Entity, Op: m.Op, ID: m.ID, Data: m.Data}
+	}
+	results, err := h.svc.Push(r.Context(), userID.(string), since, mutations)
+	if err != nil {
+		slog.Error("Failed to apply sync push", "error", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	resp := SyncPushResponse{Results: make([]SyncResultResponse, 0, len(results))}
+	for _, result := range results {
+		item := SyncResultResponse{Entity: result.Entity, ID: result.ID, Status: result.Status, Error: result.Error}
+		if result.Current != nil {
+			current := toSyncChangeResponse(*result.Current)
+			item.Current = &current
+		}
+		resp.Results = append(resp.Results, item)
+	}
+	h.respondWithJSON(w, http.StatusOK, resp)
+}
+
+// parseSyncCursor reads a cursor; none starts from the beginning
+func parseSyncCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, errInvalidCursor
+	}
+	return seq, nil
+}
+
+func toSyncChangeResponse(change service.SyncChange) SyncChangeResponse {
+	return SyncChangeResponse{
+		Seq:       change.Seq,
+		Entity:    change.Entity,
+		ID:        change.ID,
+		Op:        change.Op,
+		ChangedAt: change.ChangedAt,
+		Data:      change.Data,
+	}
+}
+
+func (h *SyncHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *SyncHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joaoapaenas/my-api/internal/export"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+var errInvalidFormat = errors.New("format must be json, csv or xlsx")
+
+// tableFormat reads how a list or report is asked for: ?format= (json, csv or
+// xlsx) first, then the Accept header, preferring its highest quality. JSON is
+// the default, also when Accept names nothing we produce.
+func tableFormat(r *http.Request) (export.Format, error) {
+	switch format := export.Format(r.URL.Query().Get("format")); format {
+	case export.FormatJSON, export.FormatCSV, export.FormatXLSX:
+		return format, nil
+	case "":
+	default:
+		return "", errInvalidFormat
+	}
+
+	best, bestQuality := export.FormatJSON, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		var format export.Format
+		switch mediaType {
+		case export.ContentTypeCSV:
+			format = export.FormatCSV
+		case export.ContentTypeXLSX:
+			format = export.FormatXLSX
+		case export.ContentTypeJSON, "application/*", "*/*":
+			format = export.FormatJSON
+		default:
+			continue
+		}
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best, nil
+}
+
+// tableResponse streams rows as a CSV or XLSX attachment. Nothing is sent
+// until the first row (or Close), so errors found before that can still be
+// answered with a status code.
+type tableResponse struct {
+	w        http.ResponseWriter
+	format   export.Format
+	settings export.Settings
+	name     string // File and sheet name
+	columns  []string
+	writer   export.Writer
+}
+
+// newTableResponse prepares a table of rowType structs, see export.Columns
+func newTableResponse(w http.ResponseWriter, format export.Format, settings export.Settings, name string, rowType reflect.Type) *tableResponse {
+	return &tableResponse{w: w, format: format, settings: settings, name: name, columns: export.Columns(rowType)}
+}
+
+func (t *tableResponse) start() error {
+	if t.writer != nil {
+		return nil
+	}
+	t.w.Header().Set("Content-Type", t.format.ContentType())
+	t.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, t.name, t.format))
+	t.w.WriteHeader(http.StatusOK)
+
+	writer, err := export.NewWriter(t.format, t.w, t.settings, t.name)
+	if err != nil {
+		return err
+	}
+	t.writer = writer
+	return writer.WriteHeader(t.columns)
+}
+
+// Started reports whether the response is under way
+func (t *tableResponse) Started() bool {
+	return t.writer != nil
+}
+
+// Write sends one row, a struct of the table's row type
+func (t *tableResponse) Write(row any) error {
+	if err := t.start(); err != nil {
+		return err
+	}
+	return t.writer.Write(export.Values(row))
+}
+
+// Close finishes the table, sending just the header if there were no rows
+func (t *tableResponse) Close() error {
+	if err := t.start(); err != nil {
+		return err
+	}
+	return t.writer.Close()
+}
+
+// writeTable answers with rows, a slice of response structs, as CSV or XLSX
+// when the request asks for a table (see tableFormat), localized with the
+// user's export settings. It returns false without writing anything when JSON
+// was asked for, leaving the response to the caller.
+func writeTable(w http.ResponseWriter, r *http.Request, exports service.ExportService, name string, rows any) bool {
+	format, err := tableFormat(r)
+	if err != nil {
+		writeTableError(w, http.StatusBadRequest, err.Error())
+		return true
+	}
+	if format == export.FormatJSON {
+		return false
+	}
+	if exports == nil {
+		writeTableError(w, http.StatusNotAcceptable, "Only JSON is available here")
+		return true
+	}
+
+	userID, _ := r.Context().Value("userID").(string)
+	settings, err := exports.Settings(r.Context(), userID, r.URL.Query().Get("tz"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimezone) {
+			writeTableError(w, http.StatusBadRequest, "Invalid timezone")
+			return true
+		}
+		writeTableError(w, http.StatusInternalServerError, "Internal server error")
+		return true
+	}
+
+	v := reflect.ValueOf(rows)
+	table := newTableResponse(w, format, settings, name, v.Type().Elem())
+	for i := 0; i < v.Len(); i++ {
+		if err := table.Write(v.Index(i).Interface()); err != nil {
+			slog.Error("Writing table failed", "table", name, "error", err)
+			return true
+		}
+	}
+	if err := table.Close(); err != nil {
+		slog.Error("Writing table failed", "table", name, "error", err)
+	}
+	return true
+}
+
+func writeTableError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+type TokenHandler struct {
+	svc      service.PersonalAccessTokenService
+	validate *validator.Validate
+}
+
+func NewTokenHandler(svc service.PersonalAccessTokenService) *TokenHandler {
+	return &TokenHandler{svc: svc, validate: validator.New()}
+}
+
+type CreateTokenRequest struct {
+	Name          string   `json:"name" validate:"required,min=2"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=3650"`
+}
+
+// CreateToken godoc
+// @Summary Create a personal access token
+// @Description The plaintext token is only returned once. Scopes: all, read, or <resource>:read|write for subjects, cycles, sessions, analytics, goals, account, tokens. A request made with a personal access token can only ask for scopes that token already has.
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param input body CreateTokenRequest true "Token info"
+// @Success 201 {object} handler.CreatedTokenResponse
+// @Failure 403 {object} map[string]string
+// @Router /tokens [post]
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	// A token may only mint tokens within its own scopes
+	if scopes, ok := r.Context().Value("tokenScopes").([]string); ok && !service.ScopesWithin(scopes, req.Scopes) {
+		h.respondWithError(w, http.StatusForbidden, "Token scope does not allow the requested scopes")
+		return
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresInDays > 0 {
+		expiresAt = time.Now().AddDate(0, 0, req.ExpiresInDays)
+	}
+
+	token, plaintext, err := h.svc.CreateToken(r.Context(), userID.(string), req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidScope) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, CreatedTokenResponse{
+		PersonalAccessTokenResponse: toTokenResponse(token),
+		Token:                       plaintext,
+	})
+}
+
+// ListTokens godoc
+// @Summary List active personal access tokens
+// @Tags tokens
+// @Produce json
+// @Success 200 {array} handler.PersonalAccessTokenResponse
+// @Router /tokens [get]
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	tokens, err := h.svc.ListTokens(r.Context(), userID.(string))
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := make([]PersonalAccessTokenResponse, len(tokens))
+	for i, token := range tokens {
+		response[i] = toTokenResponse(token)
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// RevokeToken godoc
+// @Summary Revoke a personal access token
+// @Tags tokens
+// @Param id path string true "Token ID"
+// @Success 204
+// @Router /tokens/{id} [delete]
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Token ID is required")
+		return
+	}
+
+	err := h.svc.RevokeToken(r.Context(), id, userID.(string))
+	if err != nil {
+		if errors.Is(err, service.ErrTokenNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "Token not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toTokenResponse(token database.PersonalAccessToken) PersonalAccessTokenResponse {
+	return PersonalAccessTokenResponse{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      strings.Fields(token.Scopes),
+		ExpiresAt:   token.ExpiresAt.String,
+		LastUsedAt:  token.LastUsedAt.String,
+		CreatedAt:   token.CreatedAt,
+	}
+}
+
+func (h *TokenHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *TokenHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
@@ -2,20 +2,29 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/service"
 )
 
 type TopicHandler struct {
 	svc      service.TopicService
+	exports  service.ExportService // nil without CSV and XLSX responses
 	validate *validator.Validate
 }
 
 func NewTopicHandler(svc service.TopicService) *TopicHandler {
-	return &TopicHandler{svc: svc, validate: validator.New()}
+	return &TopicHandler{svc: svc, validate: newPatchValidator()}
+}
+
+// WithExports lets the list answer as CSV or XLSX, see writeTable
+func (h *TopicHandler) WithExports(exports service.ExportService) *TopicHandler {
+	h.exports = exports
+	return h
 }
 
 type CreateTopicRequest struct {
@@ -26,6 +35,11 @@ type UpdateTopicRequest struct {
 	Name string `json:"name" validate:"required,min=2"`
 }
 
+// PatchTopicRequest is a JSON merge patch; members left out keep their value
+type PatchTopicRequest struct {
+	Name service.Field[string] `json:"name" validate:"omitnil,min=2" swaggertype:"string"`
+}
+
 // CreateTopic godoc
 // @Summary Create a new topic for a subject
 // @Tags topics
@@ -69,7 +83,10 @@ func (h *TopicHandler) CreateTopic(w http.ResponseWriter, r *http.Request) {
 // @Summary List all topics for a subject
 // @Tags topics
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param id path string true "Subject ID"
+// @Param format query string false "json (default), csv or xlsx; an Accept header works too"
 // @Success 200 {array} handler.TopicResponse
 // @Router /subjects/{id}/topics [get]
 func (h *TopicHandler) ListTopics(w http.ResponseWriter, r *http.Request) {
@@ -85,6 +102,14 @@ func (h *TopicHandler) ListTopics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rows := make([]TopicResponse, len(topics))
+	for i, topic := range topics {
+		rows[i] = toTopicResponse(topic)
+	}
+	if writeTable(w, r, h.exports, "topics", rows) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, topics)
 }
 
@@ -93,7 +118,9 @@ func (h *TopicHandler) ListTopics(w http.ResponseWriter, r *http.Request) {
 // @Tags topics
 // @Produce json
 // @Param id path string true "Topic ID"
+// @Param If-None-Match header string false "ETag of a copy already held"
 // @Success 200 {object} handler.TopicResponse
+// @Success 304 "Not Modified"
 // @Router /topics/{id} [get]
 func (h *TopicHandler) GetTopic(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -108,6 +135,10 @@ func (h *TopicHandler) GetTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if writeETag(w, r, topic.Version) {
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, topic)
 }
 
@@ -118,7 +149,10 @@ func (h *TopicHandler) GetTopic(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Topic ID"
 // @Param input body UpdateTopicRequest true "Topic info"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
 // @Success 200 {string} string "OK"
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /topics/{id} [put]
 func (h *TopicHandler) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -127,6 +161,12 @@ func (h *TopicHandler) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
 	var req UpdateTopicRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -141,17 +181,88 @@ func (h *TopicHandler) UpdateTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.svc.UpdateTopic(r.Context(), id, req.Name)
+	topic, err := h.svc.UpdateTopic(r.Context(), id, req.Name, version)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		switch {
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Topic was changed by another request")
+		case errors.Is(err, service.ErrTopicNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Topic not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
 		return
 	}
 
+	w.Header().Set("ETag", etag(topic.Version))
 	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "Topic updated successfully"})
 }
 
+// PatchTopic godoc
+// @Summary Partially update a topic
+// @Description Only the members present change.
+// @Tags topics
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Param id path string true "Topic ID"
+// @Param input body PatchTopicRequest true "JSON merge patch"
+// @Param If-Match header string false "ETag the update is based on; * or none skips the check"
+// @Success 200 {object} handler.TopicResponse
+// @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Router /topics/{id} [patch]
+func (h *TopicHandler) PatchTopic(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Topic ID is required")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.respondWithError(w, ifMatchStatus(err), err.Error())
+		return
+	}
+
+	var req PatchTopicRequest
+	if status, err := decodeMergePatch(r, &req); err != nil {
+		h.respondWithError(w, status, err.Error())
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	topic, err := h.svc.PatchTopic(r.Context(), id, service.TopicPatch{
+		Name: req.Name,
+	}, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPatch):
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrVersionMismatch):
+			h.respondWithError(w, http.StatusPreconditionFailed, "Topic was changed by another request")
+		case errors.Is(err, service.ErrTopicNotFound):
+			h.respondWithError(w, http.StatusNotFound, "Topic not found")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", etag(topic.Version))
+	h.respondWithJSON(w, http.StatusOK, topic)
+}
+
 // DeleteTopic godoc
 // @Summary Delete a topic
+// @Description Moves the topic to the trash, see GET /trash.
 // @Tags topics
 // @Param id path string true "Topic ID"
 // @Success 204
@@ -172,6 +283,18 @@ func (h *TopicHandler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func toTopicResponse(topic database.Topic) TopicResponse {
+	return TopicResponse{
+		ID:        topic.ID,
+		SubjectID: topic.SubjectID,
+		Name:      topic.Name,
+		CreatedAt: topic.CreatedAt,
+		UpdatedAt: topic.UpdatedAt,
+		DeletedAt: topic.DeletedAt.String,
+		Version:   topic.Version,
+	}
+}
+
 func (h *TopicHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
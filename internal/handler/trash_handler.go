@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+type TrashHandler struct {
+	svc service.TrashService
+}
+
+func NewTrashHandler(svc service.TrashService) *TrashHandler {
+	return &TrashHandler{svc: svc}
+}
+
+// ListTrash godoc
+// @Summary List deleted subjects, topics and study cycles
+// @Description Deleted items stay restorable until purge_after, when the purge job removes them for good. A subject keeps its topics, cycle items, sessions, exercise logs and goals while in the trash and the purge deletes them with it; purging a topic unlinks its exercise logs and purging a study cycle deletes its items and unlinks their sessions.
+// @Tags trash
+// @Produce json
+// @Param type query string false "Only items of this type (subject, topic or study_cycle)"
+// @Success 200 {array} handler.TrashItemResponse
+// @Failure 400 {object} map[string]string
+// @Router /trash [get]
+func (h *TrashHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	items, err := h.svc.ListTrash(r.Context(), userID.(string), r.URL.Query().Get("type"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTrashType) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	response := make([]TrashItemResponse, len(items))
+	for i, item := range items {
+		response[i] = TrashItemResponse{
+			Type:       item.Type,
+			ID:         item.ID,
+			Name:       item.Name,
+			ParentID:   item.ParentID,
+			DeletedAt:  item.DeletedAt,
+			PurgeAfter: item.PurgeAfter,
+			Dependents: item.Dependents,
+		}
+	}
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+// RestoreSubject godoc
+// @Summary Restore a deleted subject
+// @Description Brings the subject back with everything it had. Topics deleted on their own stay in the trash.
+// @Tags trash
+// @Param id path string true "Subject ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Router /subjects/{id}/restore [post]
+func (h *TrashHandler) RestoreSubject(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, h.svc.RestoreSubject)
+}
+
+// RestoreTopic godoc
+// @Summary Restore a deleted topic
+// @Tags trash
+// @Param id path string true "Topic ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Its subject is in the trash"
+// @Router /topics/{id}/restore [post]
+func (h *TrashHandler) RestoreTopic(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, h.svc.RestoreTopic)
+}
+
+// RestoreStudyCycle godoc
+// @Summary Restore a deleted study cycle
+// @Description Brings the cycle back with its items.
+// @Tags trash
+// @Param id path string true "Cycle ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Router /study-cycles/{id}/restore [post]
+func (h *TrashHandler) RestoreStudyCycle(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, h.svc.RestoreStudyCycle)
+}
+
+func (h *TrashHandler) restore(w http.ResponseWriter, r *http.Request, restore func(ctx context.Context, userID, id string) error) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := restore(r.Context(), userID.(string), chi.URLParam(r, "id")); err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotInTrash):
+			h.respondWithError(w, http.StatusNotFound, "Not found in the trash")
+		case errors.Is(err, service.ErrParentInTrash):
+			h.respondWithError(w, http.StatusConflict, "Restore its subject first")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TrashHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *TrashHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, map[string]string{"error": message})
+}
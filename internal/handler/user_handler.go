@@ -6,9 +6,12 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/middleware"
 	"github.com/joaoapaenas/my-api/internal/service"
 )
 
@@ -34,6 +37,16 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required,min=6"`
 }
 
+// UpdateProfileRequest only changes the fields that are present
+type UpdateProfileRequest struct {
+	Name             *string `json:"name" validate:"omitnil,min=2"`
+	Timezone         *string `json:"timezone" validate:"omitnil,timezone"`
+	Locale           *string `json:"locale" validate:"omitnil,bcp47_language_tag"`
+	DailyGoalMinutes *int    `json:"daily_goal_minutes" validate:"omitnil,min=0,max=1440"`
+	WeekStartDay     *int    `json:"week_start_day" validate:"omitnil,min=0,max=6"`
+	ExamDate         *string `json:"exam_date"` // YYYY-MM-DD, checked by the service; "" clears it
+}
+
 // --- Handlers ---
 
 // CreateUser godoc
@@ -42,7 +55,7 @@ type ChangePasswordRequest struct {
 // @Accept json
 // @Produce json
 // @Param input body CreateUserRequest true "User info"
-// @Success 201 {object} database.User
+// @Success 201 {object} handler.UserResponse
 // @Router /users [post]
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
@@ -70,29 +83,122 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusCreated, user)
+	h.respondWithJSON(w, http.StatusCreated, toUserResponse(user))
 }
 
 // GetUser godoc
 // @Summary Get user by Email
+// @Description Only the authenticated user's own email is found; any other is answered as not found.
 // @Tags users
 // @Param email path string true "User Email"
-// @Success 200 {object} database.User
+// @Success 200 {object} handler.UserResponse
+// @Failure 404 {object} map[string]string
 // @Router /users/{email} [get]
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
 	email := chi.URLParam(r, "email")
 
 	user, err := h.svc.GetUserByEmail(r.Context(), email)
+	// Other users' accounts look the same as missing ones
+	if err == nil && user.ID != userID.(string) {
+		err = service.ErrUserNotFound
+	}
 	if err != nil {
 		if err == sql.ErrNoRows || errors.Is(err, service.ErrUserNotFound) {
-			http.Error(w, "User not found", http.StatusNotFound)
+			h.respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// GetMe godoc
+// @Summary Get the authenticated user's profile
+// @Tags users
+// @Produce json
+// @Success 200 {object} handler.UserResponse
+// @Router /me [get]
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	user, err := h.svc.GetUserByID(r.Context(), userID.(string))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "User not found")
 			return
 		}
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	json.NewEncoder(w).Encode(user)
+	h.respondWithJSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// UpdateMe godoc
+// @Summary Update the authenticated user's profile
+// @Description Only the fields present in the body are changed.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param input body UpdateProfileRequest true "Profile fields"
+// @Success 200 {object} handler.UserResponse
+// @Router /me [patch]
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID")
+	if userID == nil {
+		h.respondWithError(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		h.respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":   "Validation failed",
+			"details": formatValidationErrors(err),
+		})
+		return
+	}
+
+	user, err := h.svc.UpdateProfile(r.Context(), userID.(string), service.ProfileUpdate{
+		Name:             req.Name,
+		Timezone:         req.Timezone,
+		Locale:           req.Locale,
+		DailyGoalMinutes: req.DailyGoalMinutes,
+		WeekStartDay:     req.WeekStartDay,
+		ExamDate:         req.ExamDate,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidProfile) {
+			h.respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		slog.Error("Failed to update profile", "error", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, toUserResponse(user))
 }
 
 // ChangePassword godoc
@@ -102,6 +208,8 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param input body ChangePasswordRequest true "Password info"
 // @Success 200 {object} handler.MessageResponse
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /users/password [put]
 func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	// Extract email from context (set by JWT middleware)
@@ -130,7 +238,17 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 
 	err := h.svc.UpdatePassword(r.Context(), email, req.OldPassword, req.NewPassword)
 	if err != nil {
-		h.respondWithError(w, http.StatusUnauthorized, "Failed to update password. Check old password.")
+		var locked *service.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			w.Header().Set("Retry-After", middleware.RetryAfterSeconds(time.Until(locked.Until)))
+			h.respondWithError(w, http.StatusTooManyRequests, "Too many failed attempts, try again later")
+		case errors.Is(err, service.ErrInvalidCredentials):
+			h.respondWithError(w, http.StatusUnauthorized, "Failed to update password. Check old password.")
+		default:
+			slog.Error("Failed to update password", "error", err)
+			h.respondWithError(w, http.StatusInternalServerError, "Internal server error")
+		}
 		return
 	}
 
@@ -139,6 +257,20 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 
 // --- Helpers ---
 
+func toUserResponse(user database.User) UserResponse {
+	return UserResponse{
+		ID:               user.ID,
+		Email:            user.Email,
+		Name:             user.Name,
+		CreatedAt:        user.CreatedAt,
+		Timezone:         user.Timezone,
+		Locale:           user.Locale,
+		DailyGoalMinutes: int(user.DailyGoalMinutes),
+		WeekStartDay:     int(user.WeekStartDay),
+		ExamDate:         user.ExamDate.String,
+	}
+}
+
 func (h *UserHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
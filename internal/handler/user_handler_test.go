@@ -9,8 +9,10 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/handler"
+	"github.com/joaoapaenas/my-api/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -35,6 +37,21 @@ func (m *MockUserService) UpdatePassword(ctx context.Context, email, oldPassword
 	return args.Error(0)
 }
 
+func (m *MockUserService) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateProfile(ctx context.Context, id string, update service.ProfileUpdate) (database.User, error) {
+	args := m.Called(ctx, id, update)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (m *MockUserService) Authenticate(ctx context.Context, email, password string) (database.User, error) {
+	args := m.Called(ctx, email, password)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
 func TestUserHandler_CreateUser(t *testing.T) {
 	mockSvc := new(MockUserService)
 	h := handler.NewUserHandler(mockSvc)
@@ -53,7 +70,7 @@ func TestUserHandler_CreateUser(t *testing.T) {
 				Name:     "Test User",
 				Password: "password123",
 			},
-			mockReturnUser: database.User{ID: "1", Email: "test@example.com"},
+			mockReturnUser: database.User{ID: "1", Email: "test@example.com", PasswordHash: "secret-hash"},
 			mockReturnErr:  nil,
 			wantStatus:     http.StatusCreated,
 		},
@@ -93,10 +110,91 @@ func TestUserHandler_CreateUser(t *testing.T) {
 
 			assert.Equal(t, tt.wantStatus, rr.Code)
 			if tt.wantStatus == http.StatusCreated {
-				var user database.User
+				assert.NotContains(t, rr.Body.String(), "password_hash")
+				var user handler.UserResponse
 				json.NewDecoder(rr.Body).Decode(&user)
 				assert.Equal(t, tt.mockReturnUser.Email, user.Email)
 			}
 		})
 	}
 }
+
+func TestUserHandler_GetUser(t *testing.T) {
+	mockSvc := new(MockUserService)
+	h := handler.NewUserHandler(mockSvc)
+
+	mockSvc.On("GetUserByEmail", mock.Anything, "me@example.com").Return(database.User{ID: "user-123", Email: "me@example.com"}, nil)
+	mockSvc.On("GetUserByEmail", mock.Anything, "other@example.com").Return(database.User{ID: "user-456", Email: "other@example.com"}, nil)
+	mockSvc.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(database.User{}, service.ErrUserNotFound)
+
+	tests := []struct {
+		name       string
+		email      string
+		wantStatus int
+	}{
+		{name: "Own Profile", email: "me@example.com", wantStatus: http.StatusOK},
+		{name: "Another User", email: "other@example.com", wantStatus: http.StatusNotFound},
+		{name: "Unknown Email", email: "nobody@example.com", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("email", tt.email)
+			req := httptest.NewRequest("GET", "/users/"+tt.email, nil)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(context.WithValue(ctx, "userID", "user-123"))
+			rr := httptest.NewRecorder()
+
+			h.GetUser(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.NotContains(t, rr.Body.String(), "user-456")
+		})
+	}
+}
+
+func TestUserHandler_UpdateMe(t *testing.T) {
+	mockSvc := new(MockUserService)
+	h := handler.NewUserHandler(mockSvc)
+
+	tests := []struct {
+		name       string
+		body       string
+		mockErr    error
+		wantStatus int
+	}{
+		{name: "Success", body: `{"timezone":"America/Sao_Paulo","daily_goal_minutes":240}`, wantStatus: http.StatusOK},
+		{name: "Validation Error", body: `{"timezone":"Mars/Olympus"}`, wantStatus: http.StatusBadRequest},
+		{name: "Service Rejects", body: `{"week_start_day":1}`, mockErr: service.ErrInvalidProfile, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name != "Validation Error" {
+				mockSvc.On("UpdateProfile", mock.Anything, "user-123", mock.Anything).
+					Return(database.User{ID: "user-123", Timezone: "America/Sao_Paulo", DailyGoalMinutes: 240}, tt.mockErr).
+					Once()
+			}
+
+			req := httptest.NewRequest("PATCH", "/me", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "userID", "user-123"))
+			rr := httptest.NewRecorder()
+
+			h.UpdateMe(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus == http.StatusOK {
+				var user handler.UserResponse
+				json.NewDecoder(rr.Body).Decode(&user)
+				assert.Equal(t, "America/Sao_Paulo", user.Timezone)
+				assert.Equal(t, 240, user.DailyGoalMinutes)
+			}
+		})
+	}
+
+	// Only the fields sent are forwarded to the service
+	mockSvc.AssertCalled(t, "UpdateProfile", mock.Anything, "user-123", mock.MatchedBy(func(u service.ProfileUpdate) bool {
+		return u.Timezone != nil && *u.DailyGoalMinutes == 240 && u.Name == nil && u.WeekStartDay == nil
+	}))
+}
@@ -1,11 +1,12 @@
 package middleware
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/joaoapaenas/my-api/internal/service"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type BasicAuthMiddleware struct {
@@ -25,18 +26,16 @@ func (m *BasicAuthMiddleware) BasicAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		user, err := m.userService.GetUserByEmail(r.Context(), email)
-		if err != nil {
-			// User not found or DB error
-			slog.Warn("Basic Auth failed: user not found", "email", email, "error", err)
-			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		// Verify password
-		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-			slog.Warn("Basic Auth failed: invalid password", "email", email)
+		// Verify credentials (applies the account lockout policy)
+		if _, err := m.userService.Authenticate(r.Context(), email, password); err != nil {
+			var locked *service.AccountLockedError
+			if errors.As(err, &locked) {
+				slog.Warn("Basic Auth failed: account locked", "email", email)
+				w.Header().Set("Retry-After", RetryAfterSeconds(time.Until(locked.Until)))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+			slog.Warn("Basic Auth failed", "email", email, "error", err)
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
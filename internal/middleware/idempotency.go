@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+const (
+	// IdempotencyKeyHeader names a POST so its retries replay the first response
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a replayed response
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodyBytes caps the body read to hash a request, before any
+	// handler's own limit; it's the largest a handler takes, /me/import's
+	maxIdempotentBodyBytes = 64 << 20
+)
+
+// IdempotencyMiddleware replays the stored response to POSTs retried with the
+// same Idempotency-Key, per user, instead of running them again. It goes after
+// authentication; requests without a key or a user pass through.
+type IdempotencyMiddleware struct {
+	svc service.IdempotencyService
+}
+
+func NewIdempotencyMiddleware(svc service.IdempotencyService) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{svc: svc}
+}
+
+func (m *IdempotencyMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		userID, _ := r.Context().Value("userID").(string)
+		if r.Method != http.MethodPost || key == "" || userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, err := m.svc.Begin(r.Context(), userID, key, service.IdempotentRequest{
+			Method: r.Method,
+			Path:   r.URL.RequestURI(),
+			Body:   body,
+		})
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, service.ErrIdempotencyKeyInFlight):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "A request with this Idempotency-Key is in progress", http.StatusConflict)
+			return
+		case err != nil:
+			slog.Error("Failed to check idempotency key", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		case stored != nil:
+			for name, values := range stored.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		// The client may be gone by the time the response is stored: a retry
+		// on a flaky connection is what this is for
+		ctx := context.WithoutCancel(r.Context())
+		var captured bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&captured)
+
+		completed := false
+		defer func() {
+			// Server errors and panics free the key so a retry runs again
+			if !completed {
+				if err := m.svc.Release(ctx, userID, key); err != nil {
+					slog.Error("Failed to release idempotency key", "error", err)
+				}
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		err = m.svc.Complete(ctx, userID, key, service.StoredResponse{
+			StatusCode: status,
+			Header:     ww.Header().Clone(),
+			Body:       captured.Bytes(),
+		})
+		if err != nil {
+			slog.Error("Failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	})
+}
@@ -0,0 +1,120 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/middleware"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIdempotency answers Begin with a fixed result and records the rest
+type fakeIdempotency struct {
+	begin     error
+	stored    *service.StoredResponse
+	begun     int
+	completed []int
+	header    http.Header // of the last completed response
+	released  int
+}
+
+func (f *fakeIdempotency) Begin(ctx context.Context, userID, key string, req service.IdempotentRequest) (*service.StoredResponse, error) {
+	f.begun++
+	return f.stored, f.begin
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, userID, key string, resp service.StoredResponse) error {
+	f.completed = append(f.completed, resp.StatusCode)
+	f.header = resp.Header
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, userID, key string) error {
+	f.released++
+	return nil
+}
+
+func (f *fakeIdempotency) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware_Handle(t *testing.T) {
+	serve := func(svc service.IdempotencyService, status int) *httptest.ResponseRecorder {
+		h := middleware.NewIdempotencyMiddleware(svc).Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"1"`)
+			w.Header().Set("Location", "/goals/g1")
+			w.WriteHeader(status)
+		}))
+		req := httptest.NewRequest("POST", "/goals", strings.NewReader(`{}`))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key")
+		req = req.WithContext(context.WithValue(req.Context(), "userID", "user-1"))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("stores client errors", func(t *testing.T) {
+		svc := &fakeIdempotency{}
+		assert.Equal(t, http.StatusBadRequest, serve(svc, http.StatusBadRequest).Code)
+		assert.Equal(t, []int{http.StatusBadRequest}, svc.completed)
+		assert.Zero(t, svc.released)
+	})
+
+	t.Run("stores and replays the handler's headers", func(t *testing.T) {
+		svc := &fakeIdempotency{}
+		serve(svc, http.StatusCreated)
+		assert.Equal(t, `"1"`, svc.header.Get("ETag"))
+		assert.Equal(t, "/goals/g1", svc.header.Get("Location"))
+
+		svc = &fakeIdempotency{stored: &service.StoredResponse{StatusCode: http.StatusCreated, Header: http.Header{
+			"Etag": {`"1"`}, "Location": {"/goals/g1"},
+		}}}
+		rr := serve(svc, http.StatusTeapot)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+		assert.Equal(t, "/goals/g1", rr.Header().Get("Location"))
+		assert.Equal(t, "true", rr.Header().Get(middleware.IdempotentReplayedHeader))
+	})
+
+	t.Run("releases the key on server errors", func(t *testing.T) {
+		svc := &fakeIdempotency{}
+		serve(svc, http.StatusInternalServerError)
+		assert.Empty(t, svc.completed)
+		assert.Equal(t, 1, svc.released)
+	})
+
+	t.Run("asks to retry while the first request runs", func(t *testing.T) {
+		rr := serve(&fakeIdempotency{begin: service.ErrIdempotencyKeyInFlight}, http.StatusCreated)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("refuses bodies too large to hash", func(t *testing.T) {
+		svc := &fakeIdempotency{}
+		h := middleware.NewIdempotencyMiddleware(svc).Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("handler ran")
+		}))
+		req := httptest.NewRequest("POST", "/me/import", io.LimitReader(zeros{}, 65<<20))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key")
+		req = req.WithContext(context.WithValue(req.Context(), "userID", "user-1"))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		assert.Zero(t, svc.begun)
+	})
+}
+
+// zeros is an endless body
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import "net/http"
+
+// RequireIfMatch answers 428 Precondition Required to updates that don't name
+// the version they're based on with If-Match, so a client can't overwrite
+// changes it never saw. When disabled it lets everything through and If-Match
+// stays optional.
+func RequireIfMatch(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method == http.MethodPut || r.Method == http.MethodPatch) && r.Header.Get("If-Match") == "" {
+				http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
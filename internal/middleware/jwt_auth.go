@@ -2,20 +2,34 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joaoapaenas/my-api/internal/config"
+	"github.com/joaoapaenas/my-api/internal/service"
 )
 
+// JWTAuthMiddleware accepts either a JWT from /login or a personal access token
 type JWTAuthMiddleware struct {
-	cfg *config.Config
+	cfg    *config.Config
+	tokens service.PersonalAccessTokenService
+	users  service.UserService
 }
 
-func NewJWTAuthMiddleware(cfg *config.Config) *JWTAuthMiddleware {
-	return &JWTAuthMiddleware{cfg: cfg}
+func NewJWTAuthMiddleware(cfg *config.Config, tokens service.PersonalAccessTokenService) *JWTAuthMiddleware {
+	return &JWTAuthMiddleware{cfg: cfg, tokens: tokens}
+}
+
+// WithUsers makes JWT sessions of accounts that no longer exist invalid. A
+// JWT outlives the purge of its account, which would let it write rows for
+// a user that's gone; personal access tokens are purged with the account.
+func (m *JWTAuthMiddleware) WithUsers(users service.UserService) *JWTAuthMiddleware {
+	m.users = users
+	return m
 }
 
 func (m *JWTAuthMiddleware) Protected(next http.Handler) http.Handler {
@@ -35,6 +49,21 @@ func (m *JWTAuthMiddleware) Protected(next http.Handler) http.Handler {
 
 		tokenString := parts[1]
 
+		// Personal access tokens are opaque and looked up by their hash
+		if m.tokens != nil && strings.HasPrefix(tokenString, service.TokenPrefix) {
+			identity, err := m.tokens.AuthenticateToken(r.Context(), tokenString)
+			if err != nil {
+				http.Error(w, "Invalid or Expired Token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "userID", identity.UserID)
+			ctx = context.WithValue(ctx, "userEmail", identity.Email)
+			ctx = context.WithValue(ctx, "tokenScopes", identity.Scopes)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Parse and Validate
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -49,6 +78,18 @@ func (m *JWTAuthMiddleware) Protected(next http.Handler) http.Handler {
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if m.users != nil {
+				sub, _ := claims["sub"].(string)
+				if _, err := m.users.GetUserByID(r.Context(), sub); err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						http.Error(w, "Invalid or Expired Token", http.StatusUnauthorized)
+						return
+					}
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
+			}
+
 			ctx := context.WithValue(r.Context(), "userID", claims["sub"])
 			ctx = context.WithValue(ctx, "userEmail", claims["email"]) // ADD THIS
 			next.ServeHTTP(w, r.WithContext(ctx))
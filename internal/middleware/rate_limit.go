@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket: Rate tokens per second, holding at most Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests per minute with bursts of up to n.
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// RateLimitStore keeps the token buckets. MemoryStore is the default; other
+// backends (e.g. Redis) can be plugged in to share limits between instances.
+type RateLimitStore interface {
+	// Take consumes one token for key. When no token is available it returns
+	// false and the time until the next one is.
+	Take(key string, limit Limit, now time.Time) (allowed bool, retryAfter time.Duration)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // when the bucket is back to Burst and can be dropped
+}
+
+// MemoryStore is an in-process RateLimitStore.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	// Refill based on the time elapsed since the last request
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate))
+
+	if allowed {
+		return true, 0
+	}
+	return false, secondsToDuration((1 - b.tokens) / limit.Rate)
+}
+
+// sweep drops buckets that have refilled completely, at most once a minute
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// KeyFunc extracts the client key a request is limited by.
+// An empty key skips limiting for that request.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP limits by the client IP in r.RemoteAddr. Behind a reverse proxy,
+// RealIP with the proxy trusted puts the client's own IP there.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// KeyByUser limits authenticated requests by user ID, falling back to the IP.
+func KeyByUser(r *http.Request) string {
+	if userID, ok := r.Context().Value("userID").(string); ok && userID != "" {
+		return userID
+	}
+	return KeyByIP(r)
+}
+
+// KeyByAccount limits login attempts by the targeted account, taken from
+// Basic Auth or the JSON body's "email". The body is restored for the handler.
+func KeyByAccount(r *http.Request) string {
+	if email, _, ok := r.BasicAuth(); ok {
+		return strings.ToLower(strings.TrimSpace(email))
+	}
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+type RateLimiter struct {
+	store RateLimitStore
+	name  string
+	limit Limit
+	key   KeyFunc
+}
+
+// NewRateLimiter builds a limiter; name namespaces its keys inside the store
+// so several limiters can share one store.
+func NewRateLimiter(store RateLimitStore, name string, limit Limit, key KeyFunc) *RateLimiter {
+	return &RateLimiter{store: store, name: name, limit: limit, key: key}
+}
+
+// Throttle rejects requests over the limit with 429 and a Retry-After header.
+// A limiter with a non-positive rate is disabled.
+func (l *RateLimiter) Throttle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.limit.Rate <= 0 || l.limit.Burst <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := l.key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, retryAfter := l.store.Take(l.name+":"+key, l.limit, time.Now())
+		if !allowed {
+			slog.Warn("Rate limit exceeded", "limiter", l.name, "key", key, "path", r.URL.Path)
+			w.Header().Set("Retry-After", RetryAfterSeconds(retryAfter))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RetryAfterSeconds formats a wait as a Retry-After value (whole seconds, at least 1).
+func RetryAfterSeconds(d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := middleware.NewMemoryStore()
+	limit := middleware.Limit{Rate: 1, Burst: 2}
+	now := time.Now()
+
+	allowed, _ := store.Take("k", limit, now)
+	assert.True(t, allowed)
+	allowed, _ = store.Take("k", limit, now)
+	assert.True(t, allowed)
+
+	// Bucket is empty: the next token arrives in one second
+	allowed, retryAfter := store.Take("k", limit, now)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// Other keys have their own bucket
+	allowed, _ = store.Take("other", limit, now)
+	assert.True(t, allowed)
+
+	allowed, _ = store.Take("k", limit, now.Add(time.Second))
+	assert.True(t, allowed)
+}
+
+func TestRateLimiter_Throttle(t *testing.T) {
+	limiter := middleware.NewRateLimiter(middleware.NewMemoryStore(), "login", middleware.PerMinute(1), middleware.KeyByAccount)
+	h := limiter.Throttle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email":"`+email+`"}`))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, send("a@example.com").Code)
+
+	rr := send("A@example.com")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, send("b@example.com").Code)
+}
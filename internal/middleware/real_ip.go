@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP sets r.RemoteAddr to the client IP from X-Forwarded-For or
+// X-Real-IP, but only for requests that come from one of the trusted
+// proxies. Anyone else could send those headers, so without trusted proxies
+// the connection's address is kept and the headers are ignored.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isTrusted(trusted, remoteIP(r.RemoteAddr)) {
+				if ip := forwardedIP(r, trusted); ip.IsValid() {
+					r.RemoteAddr = ip.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP is the nearest address in X-Forwarded-For that isn't one of
+// the trusted proxies: entries before it were set by the client, who can
+// write anything there.
+func forwardedIP(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return netip.Addr{}
+			}
+			if !isTrusted(trusted, ip) {
+				return ip
+			}
+		}
+		return netip.Addr{}
+	}
+
+	ip, _ := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	return ip
+}
+
+func remoteIP(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip, _ := netip.ParseAddr(host)
+	return ip
+}
+
+func isTrusted(trusted []netip.Prefix, ip netip.Addr) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/joaoapaenas/my-api/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		headers    map[string]string
+		wantKey    string
+	}{
+		{name: "No Proxies Trusted", remoteAddr: "203.0.113.7:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, wantKey: "203.0.113.7"},
+		{name: "Untrusted Sender", trusted: trusted, remoteAddr: "203.0.113.7:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, wantKey: "203.0.113.7"},
+		{name: "Trusted Proxy", trusted: trusted, remoteAddr: "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, wantKey: "198.51.100.1"},
+		{name: "Spoofed Hop Before The Client", trusted: trusted, remoteAddr: "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "192.0.2.99, 198.51.100.1, 10.0.0.3"}, wantKey: "198.51.100.1"},
+		{name: "X-Real-IP", trusted: trusted, remoteAddr: "10.0.0.2:4000",
+			headers: map[string]string{"X-Real-IP": "198.51.100.2"}, wantKey: "198.51.100.2"},
+		{name: "Unparsable Header", trusted: trusted, remoteAddr: "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "not-an-ip"}, wantKey: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key string
+			h := middleware.RealIP(tt.trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				key = middleware.KeyByIP(r)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantKey, key)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/joaoapaenas/my-api/internal/service"
+)
+
+// RequireScope restricts personal access tokens to a resource group: safe
+// methods need "<resource>:read", anything else "<resource>:write".
+// JWT sessions carry no scopes and are not restricted.
+func RequireScope(resource string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, ok := r.Context().Value("tokenScopes").([]string)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			required := resource + ":write"
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				required = resource + ":read"
+			}
+
+			if !service.ScopeAllows(scopes, required) {
+				http.Error(w, "Token scope does not allow this request", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// AccountDeletionRepository schedules account deletions and purges accounts
+type AccountDeletionRepository interface {
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+	CreateAccountDeletion(ctx context.Context, arg database.CreateAccountDeletionParams) (database.AccountDeletion, error)
+	GetAccountDeletion(ctx context.Context, id string) (database.AccountDeletion, error)
+	GetPendingAccountDeletion(ctx context.Context, userID string) (database.AccountDeletion, error)
+	CancelAccountDeletion(ctx context.Context, userID string) (int64, error)
+	ListDueAccountDeletions(ctx context.Context, now string) ([]database.AccountDeletion, error)
+	CompleteAccountDeletion(ctx context.Context, arg database.CompleteAccountDeletionParams) error
+
+	CountAccountRows(ctx context.Context, userID string) (database.CountAccountRowsRow, error)
+	ListPurgeableStudyCycles(ctx context.Context, userID string) ([]string, error)
+	PurgeUserExerciseLogs(ctx context.Context, userID string) (int64, error)
+	PurgeUserSessionPauses(ctx context.Context, userID string) (int64, error)
+	PurgeUserStudySessions(ctx context.Context, userID string) (int64, error)
+	PurgeUserCycleItems(ctx context.Context, userID string) (int64, error)
+	PurgeStudyCycle(ctx context.Context, id string) (int64, error)
+	PurgeUserTopics(ctx context.Context, userID string) (int64, error)
+	PurgeUserGoals(ctx context.Context, userID string) (int64, error)
+	PurgeUserSubjects(ctx context.Context, userID string) (int64, error)
+	PurgeUserPersonalAccessTokens(ctx context.Context, userID string) (int64, error)
+	PurgeUserPasswordResetTokens(ctx context.Context, userID string) (int64, error)
+	PurgeUserRecommendationWeights(ctx context.Context, userID string) (int64, error)
+	PurgeUserIdempotencyKeys(ctx context.Context, userID string) (int64, error)
+	PurgeUser(ctx context.Context, id string) (int64, error)
+	PurgeUserSyncChanges(ctx context.Context, userID string) error
+
+	// Tx runs fn in one read-write transaction, committed only if fn returns
+	// nil. Nested calls reuse the transaction.
+	Tx(ctx context.Context, fn func(AccountDeletionRepository) error) error
+}
+
+type SQLAccountDeletionRepository struct {
+	db *sql.DB // nil once bound to a transaction
+	q  *database.Queries
+}
+
+func NewSQLAccountDeletionRepository(db *sql.DB, q *database.Queries) *SQLAccountDeletionRepository {
+	return &SQLAccountDeletionRepository{db: db, q: q}
+}
+
+func (r *SQLAccountDeletionRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	return r.q.GetUserByID(ctx, id)
+}
+
+func (r *SQLAccountDeletionRepository) CreateAccountDeletion(ctx context.Context, arg database.CreateAccountDeletionParams) (database.AccountDeletion, error) {
+	return r.q.CreateAccountDeletion(ctx, arg)
+}
+
+func (r *SQLAccountDeletionRepository) GetAccountDeletion(ctx context.Context, id string) (database.AccountDeletion, error) {
+	return r.q.GetAccountDeletion(ctx, id)
+}
+
+func (r *SQLAccountDeletionRepository) GetPendingAccountDeletion(ctx context.Context, userID string) (database.AccountDeletion, error) {
+	return r.q.GetPendingAccountDeletion(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) CancelAccountDeletion(ctx context.Context, userID string) (int64, error) {
+	return r.q.CancelAccountDeletion(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) ListDueAccountDeletions(ctx context.Context, now string) ([]database.AccountDeletion, error) {
+	return r.q.ListDueAccountDeletions(ctx, now)
+}
+
+func (r *SQLAccountDeletionRepository) CompleteAccountDeletion(ctx context.Context, arg database.CompleteAccountDeletionParams) error {
+	return r.q.CompleteAccountDeletion(ctx, arg)
+}
+
+func (r *SQLAccountDeletionRepository) CountAccountRows(ctx context.Context, userID string) (database.CountAccountRowsRow, error) {
+	return r.q.CountAccountRows(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) ListPurgeableStudyCycles(ctx context.Context, userID string) ([]string, error) {
+	return r.q.ListPurgeableStudyCycles(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserExerciseLogs(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserExerciseLogs(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserSessionPauses(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserSessionPauses(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserStudySessions(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserStudySessions(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserCycleItems(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserCycleItems(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeStudyCycle(ctx context.Context, id string) (int64, error) {
+	return r.q.PurgeStudyCycle(ctx, id)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserTopics(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserTopics(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserGoals(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserGoals(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserSubjects(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserSubjects(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserPersonalAccessTokens(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserPersonalAccessTokens(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserPasswordResetTokens(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserPasswordResetTokens(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserRecommendationWeights(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserRecommendationWeights(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserIdempotencyKeys(ctx context.Context, userID string) (int64, error) {
+	return r.q.PurgeUserIdempotencyKeys(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUser(ctx context.Context, id string) (int64, error) {
+	return r.q.PurgeUser(ctx, id)
+}
+
+func (r *SQLAccountDeletionRepository) PurgeUserSyncChanges(ctx context.Context, userID string) error {
+	return r.q.PurgeUserSyncChanges(ctx, userID)
+}
+
+func (r *SQLAccountDeletionRepository) Tx(ctx context.Context, fn func(AccountDeletionRepository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return withTx(ctx, r.db, nil, r.q, func(q *database.Queries) error {
+		return fn(NewSQLAccountDeletionRepository(nil, q))
+	})
+}
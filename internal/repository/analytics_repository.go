@@ -7,10 +7,16 @@ import (
 )
 
 type AnalyticsRepository interface {
-	GetTimeReportBySubject(ctx context.Context, arg database.GetTimeReportBySubjectParams) ([]database.GetTimeReportBySubjectRow, error)
-	GetAccuracyBySubject(ctx context.Context) ([]database.GetAccuracyBySubjectRow, error)
-	GetAccuracyByTopic(ctx context.Context, subjectID string) ([]database.GetAccuracyByTopicRow, error)
-	GetActivityHeatmap(ctx context.Context, daysCount string) ([]database.GetActivityHeatmapRow, error)
+	ListFinishedSessionsInRange(ctx context.Context, arg database.ListFinishedSessionsInRangeParams) ([]database.ListFinishedSessionsInRangeRow, error)
+	ListSessionPausesInRange(ctx context.Context, arg database.ListSessionPausesInRangeParams) ([]database.ListSessionPausesInRangeRow, error)
+	ListExerciseLogsInRange(ctx context.Context, arg database.ListExerciseLogsInRangeParams) ([]database.ListExerciseLogsInRangeRow, error)
+	GetAccuracyBySubject(ctx context.Context, arg database.GetAccuracyBySubjectParams) ([]database.GetAccuracyBySubjectRow, error)
+	GetAccuracyByTopic(ctx context.Context, subjectID, userID string) ([]database.GetAccuracyByTopicRow, error)
+	ListTopicExerciseLogs(ctx context.Context, userID string) ([]database.ListTopicExerciseLogsRow, error)
+	ListTopicProgress(ctx context.Context, userID string) ([]database.ListTopicProgressRow, error)
+	ListActiveCyclePlan(ctx context.Context, userID string) ([]database.ListActiveCyclePlanRow, error)
+	ListSubjects(ctx context.Context, userID string) ([]database.Subject, error)
+	GetUserByID(ctx context.Context, id string) (database.User, error)
 }
 
 type SQLAnalyticsRepository struct {
@@ -21,18 +27,47 @@ func NewSQLAnalyticsRepository(q database.Querier) *SQLAnalyticsRepository {
 	return &SQLAnalyticsRepository{q: q}
 }
 
-func (r *SQLAnalyticsRepository) GetTimeReportBySubject(ctx context.Context, arg database.GetTimeReportBySubjectParams) ([]database.GetTimeReportBySubjectRow, error) {
-	return r.q.GetTimeReportBySubject(ctx, arg)
+func (r *SQLAnalyticsRepository) ListFinishedSessionsInRange(ctx context.Context, arg database.ListFinishedSessionsInRangeParams) ([]database.ListFinishedSessionsInRangeRow, error) {
+	return r.q.ListFinishedSessionsInRange(ctx, arg)
 }
 
-func (r *SQLAnalyticsRepository) GetAccuracyBySubject(ctx context.Context) ([]database.GetAccuracyBySubjectRow, error) {
-	return r.q.GetAccuracyBySubject(ctx)
+func (r *SQLAnalyticsRepository) ListSessionPausesInRange(ctx context.Context, arg database.ListSessionPausesInRangeParams) ([]database.ListSessionPausesInRangeRow, error) {
+	return r.q.ListSessionPausesInRange(ctx, arg)
 }
 
-func (r *SQLAnalyticsRepository) GetAccuracyByTopic(ctx context.Context, subjectID string) ([]database.GetAccuracyByTopicRow, error) {
-	return r.q.GetAccuracyByTopic(ctx, subjectID)
+func (r *SQLAnalyticsRepository) ListExerciseLogsInRange(ctx context.Context, arg database.ListExerciseLogsInRangeParams) ([]database.ListExerciseLogsInRangeRow, error) {
+	return r.q.ListExerciseLogsInRange(ctx, arg)
 }
 
-func (r *SQLAnalyticsRepository) GetActivityHeatmap(ctx context.Context, daysCount string) ([]database.GetActivityHeatmapRow, error) {
-	return r.q.GetActivityHeatmap(ctx, daysCount)
+func (r *SQLAnalyticsRepository) GetAccuracyBySubject(ctx context.Context, arg database.GetAccuracyBySubjectParams) ([]database.GetAccuracyBySubjectRow, error) {
+	return r.q.GetAccuracyBySubject(ctx, arg)
+}
+
+func (r *SQLAnalyticsRepository) GetAccuracyByTopic(ctx context.Context, subjectID, userID string) ([]database.GetAccuracyByTopicRow, error) {
+	return r.q.GetAccuracyByTopic(ctx, database.GetAccuracyByTopicParams{
+		SubjectID: subjectID,
+		UserID:    userID,
+	})
+}
+
+func (r *SQLAnalyticsRepository) ListTopicExerciseLogs(ctx context.Context, userID string) ([]database.ListTopicExerciseLogsRow, error) {
+	return r.q.ListTopicExerciseLogs(ctx, userID)
+}
+
+func (r *SQLAnalyticsRepository) ListTopicProgress(ctx context.Context, userID string) ([]database.ListTopicProgressRow, error) {
+	return r.q.ListTopicProgress(ctx, userID)
+}
+
+func (r *SQLAnalyticsRepository) ListActiveCyclePlan(ctx context.Context, userID string) ([]database.ListActiveCyclePlanRow, error) {
+	return r.q.ListActiveCyclePlan(ctx, userID)
+}
+
+// ListSubjects exposes the subjects' exam weights
+func (r *SQLAnalyticsRepository) ListSubjects(ctx context.Context, userID string) ([]database.Subject, error) {
+	return r.q.ListSubjects(ctx, userID)
+}
+
+// GetUserByID exposes the profile settings (timezone, week start) analytics depend on
+func (r *SQLAnalyticsRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	return r.q.GetUserByID(ctx, id)
 }
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// BackupRepository reads and restores whole accounts
+type BackupRepository interface {
+	ListBackupSubjects(ctx context.Context, userID string) ([]database.Subject, error)
+	ListBackupTopics(ctx context.Context, userID string) ([]database.Topic, error)
+	ListBackupStudyCycles(ctx context.Context, userID string) ([]database.StudyCycle, error)
+	ListBackupCycleItems(ctx context.Context, userID string) ([]database.CycleItem, error)
+	ListBackupStudySessions(ctx context.Context, userID string) ([]database.StudySession, error)
+	ListBackupSessionPauses(ctx context.Context, userID string) ([]database.ListBackupSessionPausesRow, error)
+	ListBackupExerciseLogs(ctx context.Context, userID string) ([]database.ExerciseLog, error)
+
+	RestoreSubject(ctx context.Context, arg database.RestoreSubjectParams) error
+	RestoreTopic(ctx context.Context, arg database.RestoreTopicParams) error
+	RestoreStudyCycle(ctx context.Context, arg database.RestoreStudyCycleParams) error
+	RestoreCycleItem(ctx context.Context, arg database.RestoreCycleItemParams) error
+	RestoreStudySession(ctx context.Context, arg database.RestoreStudySessionParams) error
+	RestoreSessionPause(ctx context.Context, arg database.RestoreSessionPauseParams) error
+	RestoreExerciseLog(ctx context.Context, arg database.RestoreExerciseLogParams) error
+
+	// ReadTx runs fn with a repository bound to one read-only transaction, so
+	// an export is a single snapshot. Nested calls reuse the transaction.
+	ReadTx(ctx context.Context, fn func(BackupRepository) error) error
+	// Tx runs fn in one read-write transaction, committed only if fn returns
+	// nil. Nested calls reuse the transaction.
+	Tx(ctx context.Context, fn func(BackupRepository) error) error
+}
+
+type SQLBackupRepository struct {
+	db *sql.DB // nil once bound to a transaction
+	q  *database.Queries
+}
+
+func NewSQLBackupRepository(db *sql.DB, q *database.Queries) *SQLBackupRepository {
+	return &SQLBackupRepository{db: db, q: q}
+}
+
+func (r *SQLBackupRepository) ListBackupSubjects(ctx context.Context, userID string) ([]database.Subject, error) {
+	return r.q.ListBackupSubjects(ctx, userID)
+}
+
+func (r *SQLBackupRepository) ListBackupTopics(ctx context.Context, userID string) ([]database.Topic, error) {
+	return r.q.ListBackupTopics(ctx, userID)
+}
+
+func (r *SQLBackupRepository) ListBackupStudyCycles(ctx context.Context, userID string) ([]database.StudyCycle, error) {
+	return r.q.ListBackupStudyCycles(ctx, userID)
+}
+
+func (r *SQLBackupRepository) ListBackupCycleItems(ctx context.Context, userID string) ([]database.CycleItem, error) {
+	return r.q.ListBackupCycleItems(ctx, userID)
+}
+
+func (r *SQLBackupRepository) ListBackupStudySessions(ctx context.Context, userID string) ([]database.StudySession, error) {
+	return r.q.ListBackupStudySessions(ctx, userID)
+}
+
+func (r *SQLBackupRepository) ListBackupSessionPauses(ctx context.Context, userID string) ([]database.ListBackupSessionPausesRow, error) {
+	return r.q.ListBackupSessionPauses(ctx, userID)
+}
+
+func (r *SQLBackupRepository) ListBackupExerciseLogs(ctx context.Context, userID string) ([]database.ExerciseLog, error) {
+	return r.q.ListBackupExerciseLogs(ctx, userID)
+}
+
+func (r *SQLBackupRepository) RestoreSubject(ctx context.Context, arg database.RestoreSubjectParams) error {
+	return r.q.RestoreSubject(ctx, arg)
+}
+
+func (r *SQLBackupRepository) RestoreTopic(ctx context.Context, arg database.RestoreTopicParams) error {
+	return r.q.RestoreTopic(ctx, arg)
+}
+
+func (r *SQLBackupRepository) RestoreStudyCycle(ctx context.Context, arg database.RestoreStudyCycleParams) error {
+	return r.q.RestoreStudyCycle(ctx, arg)
+}
+
+func (r *SQLBackupRepository) RestoreCycleItem(ctx context.Context, arg database.RestoreCycleItemParams) error {
+	return r.q.RestoreCycleItem(ctx, arg)
+}
+
+func (r *SQLBackupRepository) RestoreStudySession(ctx context.Context, arg database.RestoreStudySessionParams) error {
+	return r.q.RestoreStudySession(ctx, arg)
+}
+
+func (r *SQLBackupRepository) RestoreSessionPause(ctx context.Context, arg database.RestoreSessionPauseParams) error {
+	return r.q.RestoreSessionPause(ctx, arg)
+}
+
+func (r *SQLBackupRepository) RestoreExerciseLog(ctx context.Context, arg database.RestoreExerciseLogParams) error {
+	return r.q.RestoreExerciseLog(ctx, arg)
+}
+
+func (r *SQLBackupRepository) ReadTx(ctx context.Context, fn func(BackupRepository) error) error {
+	return r.tx(ctx, &sql.TxOptions{ReadOnly: true}, fn)
+}
+
+func (r *SQLBackupRepository) Tx(ctx context.Context, fn func(BackupRepository) error) error {
+	return r.tx(ctx, nil, fn)
+}
+
+func (r *SQLBackupRepository) tx(ctx context.Context, opts *sql.TxOptions, fn func(BackupRepository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return withTx(ctx, r.db, opts, r.q, func(q *database.Queries) error {
+		return fn(NewSQLBackupRepository(nil, q))
+	})
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// ContextDB is a database.DBTX that runs each statement in the transaction
+// a TxManager put in its context, if any, and on the pool otherwise. Queries
+// built on it let code that only passes a context along, like an HTTP
+// handler, take part in a transaction it knows nothing about.
+type ContextDB struct {
+	db *sql.DB
+}
+
+func NewContextDB(db *sql.DB) *ContextDB {
+	return &ContextDB{db: db}
+}
+
+func (c *ContextDB) conn(ctx context.Context) database.DBTX {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return c.db
+}
+
+func (c *ContextDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.conn(ctx).ExecContext(ctx, query, args...)
+}
+
+func (c *ContextDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.conn(ctx).PrepareContext(ctx, query)
+}
+
+func (c *ContextDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.conn(ctx).QueryContext(ctx, query, args...)
+}
+
+func (c *ContextDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.conn(ctx).QueryRowContext(ctx, query, args...)
+}
@@ -10,7 +10,8 @@ type CycleItemRepository interface {
 	CreateCycleItem(ctx context.Context, arg database.CreateCycleItemParams) (database.CycleItem, error)
 	ListCycleItems(ctx context.Context, cycleID string) ([]database.CycleItem, error)
 	GetCycleItem(ctx context.Context, id string) (database.CycleItem, error)
-	UpdateCycleItem(ctx context.Context, arg database.UpdateCycleItemParams) error
+	UpdateCycleItem(ctx context.Context, arg database.UpdateCycleItemParams) (database.CycleItem, error)
+	PatchCycleItem(ctx context.Context, arg database.PatchCycleItemParams) (database.CycleItem, error)
 	DeleteCycleItem(ctx context.Context, id string) error
 }
 
@@ -34,10 +35,14 @@ func (r *SQLCycleItemRepository) GetCycleItem(ctx context.Context, id string) (d
 	return r.q.GetCycleItem(ctx, id)
 }
 
-func (r *SQLCycleItemRepository) UpdateCycleItem(ctx context.Context, arg database.UpdateCycleItemParams) error {
+func (r *SQLCycleItemRepository) UpdateCycleItem(ctx context.Context, arg database.UpdateCycleItemParams) (database.CycleItem, error) {
 	return r.q.UpdateCycleItem(ctx, arg)
 }
 
+func (r *SQLCycleItemRepository) PatchCycleItem(ctx context.Context, arg database.PatchCycleItemParams) (database.CycleItem, error) {
+	return r.q.PatchCycleItem(ctx, arg)
+}
+
 func (r *SQLCycleItemRepository) DeleteCycleItem(ctx context.Context, id string) error {
 	return r.q.DeleteCycleItem(ctx, id)
 }
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// DashboardRepository reads everything the dashboard summary shows
+type DashboardRepository interface {
+	AnalyticsRepository
+	GetUserOpenSession(ctx context.Context, userID string) (database.GetUserOpenSessionRow, error)
+	ListActiveCycleItems(ctx context.Context, userID string) ([]database.ListActiveCycleItemsRow, error)
+
+	// ReadTx runs fn with a repository bound to one read-only transaction, so
+	// every read sees the same snapshot. Nested calls reuse the transaction.
+	ReadTx(ctx context.Context, fn func(DashboardRepository) error) error
+}
+
+type SQLDashboardRepository struct {
+	*SQLAnalyticsRepository
+	db *sql.DB // nil once bound to a transaction
+	q  *database.Queries
+}
+
+func NewSQLDashboardRepository(db *sql.DB, q *database.Queries) *SQLDashboardRepository {
+	return &SQLDashboardRepository{SQLAnalyticsRepository: NewSQLAnalyticsRepository(q), db: db, q: q}
+}
+
+func (r *SQLDashboardRepository) GetUserOpenSession(ctx context.Context, userID string) (database.GetUserOpenSessionRow, error) {
+	return r.q.GetUserOpenSession(ctx, userID)
+}
+
+func (r *SQLDashboardRepository) ListActiveCycleItems(ctx context.Context, userID string) ([]database.ListActiveCycleItemsRow, error) {
+	return r.q.ListActiveCycleItems(ctx, userID)
+}
+
+func (r *SQLDashboardRepository) ReadTx(ctx context.Context, fn func(DashboardRepository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return withTx(ctx, r.db, &sql.TxOptions{ReadOnly: true}, r.q, func(q *database.Queries) error {
+		return fn(NewSQLDashboardRepository(nil, q))
+	})
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+type ExportRepository interface {
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+	ListSessionsPage(ctx context.Context, arg database.ListSessionsPageParams) ([]database.ListSessionsPageRow, error)
+	ListExerciseLogsPage(ctx context.Context, arg database.ListExerciseLogsPageParams) ([]database.ListExerciseLogsPageRow, error)
+}
+
+type SQLExportRepository struct {
+	q database.Querier
+}
+
+func NewSQLExportRepository(q database.Querier) *SQLExportRepository {
+	return &SQLExportRepository{q: q}
+}
+
+func (r *SQLExportRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	return r.q.GetUserByID(ctx, id)
+}
+
+func (r *SQLExportRepository) ListSessionsPage(ctx context.Context, arg database.ListSessionsPageParams) ([]database.ListSessionsPageRow, error) {
+	return r.q.ListSessionsPage(ctx, arg)
+}
+
+func (r *SQLExportRepository) ListExerciseLogsPage(ctx context.Context, arg database.ListExerciseLogsPageParams) ([]database.ListExerciseLogsPageRow, error) {
+	return r.q.ListExerciseLogsPage(ctx, arg)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+type GoalRepository interface {
+	CreateGoal(ctx context.Context, arg database.CreateGoalParams) (database.Goal, error)
+	ListGoals(ctx context.Context, userID string) ([]database.Goal, error)
+	GetGoal(ctx context.Context, id, userID string) (database.Goal, error)
+
+	// Update and Delete require userID to ensure ownership; they return the rows affected
+	UpdateGoal(ctx context.Context, arg database.UpdateGoalParams) (int64, error)
+	DeleteGoal(ctx context.Context, id, userID string) (int64, error)
+
+	// GetSubject checks that a goal's subject belongs to the user
+	GetSubject(ctx context.Context, id, userID string) (database.Subject, error)
+}
+
+type SQLGoalRepository struct {
+	q database.Querier
+}
+
+func NewSQLGoalRepository(q database.Querier) *SQLGoalRepository {
+	return &SQLGoalRepository{q: q}
+}
+
+func (r *SQLGoalRepository) CreateGoal(ctx context.Context, arg database.CreateGoalParams) (database.Goal, error) {
+	return r.q.CreateGoal(ctx, arg)
+}
+
+func (r *SQLGoalRepository) ListGoals(ctx context.Context, userID string) ([]database.Goal, error) {
+	return r.q.ListGoals(ctx, userID)
+}
+
+func (r *SQLGoalRepository) GetGoal(ctx context.Context, id, userID string) (database.Goal, error) {
+	return r.q.GetGoal(ctx, database.GetGoalParams{ID: id, UserID: userID})
+}
+
+func (r *SQLGoalRepository) UpdateGoal(ctx context.Context, arg database.UpdateGoalParams) (int64, error) {
+	return r.q.UpdateGoal(ctx, arg)
+}
+
+func (r *SQLGoalRepository) DeleteGoal(ctx context.Context, id, userID string) (int64, error) {
+	return r.q.DeleteGoal(ctx, database.DeleteGoalParams{ID: id, UserID: userID})
+}
+
+func (r *SQLGoalRepository) GetSubject(ctx context.Context, id, userID string) (database.Subject, error) {
+	return r.q.GetSubject(ctx, database.GetSubjectParams{ID: id, UserID: userID})
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// IdempotencyRepository stores the responses replayed to Idempotency-Key retries
+type IdempotencyRepository interface {
+	ClaimIdempotencyKey(ctx context.Context, arg database.ClaimIdempotencyKeyParams) (int64, error)
+	GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, arg database.CompleteIdempotencyKeyParams) error
+	ReleaseIdempotencyKey(ctx context.Context, arg database.ReleaseIdempotencyKeyParams) error
+	ReleaseExpiredIdempotencyKey(ctx context.Context, arg database.ReleaseExpiredIdempotencyKeyParams) error
+	PurgeExpiredIdempotencyKeys(ctx context.Context, now string) (int64, error)
+}
+
+type SQLIdempotencyRepository struct {
+	q database.Querier
+}
+
+func NewSQLIdempotencyRepository(q database.Querier) *SQLIdempotencyRepository {
+	return &SQLIdempotencyRepository{q: q}
+}
+
+func (r *SQLIdempotencyRepository) ClaimIdempotencyKey(ctx context.Context, arg database.ClaimIdempotencyKeyParams) (int64, error) {
+	return r.q.ClaimIdempotencyKey(ctx, arg)
+}
+
+func (r *SQLIdempotencyRepository) GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	return r.q.GetIdempotencyKey(ctx, arg)
+}
+
+func (r *SQLIdempotencyRepository) CompleteIdempotencyKey(ctx context.Context, arg database.CompleteIdempotencyKeyParams) error {
+	return r.q.CompleteIdempotencyKey(ctx, arg)
+}
+
+func (r *SQLIdempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, arg database.ReleaseIdempotencyKeyParams) error {
+	return r.q.ReleaseIdempotencyKey(ctx, arg)
+}
+
+func (r *SQLIdempotencyRepository) ReleaseExpiredIdempotencyKey(ctx context.Context, arg database.ReleaseExpiredIdempotencyKeyParams) error {
+	return r.q.ReleaseExpiredIdempotencyKey(ctx, arg)
+}
+
+func (r *SQLIdempotencyRepository) PurgeExpiredIdempotencyKeys(ctx context.Context, now string) (int64, error) {
+	return r.q.PurgeExpiredIdempotencyKeys(ctx, now)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// MergeRepository moves everything that points at one subject or topic onto
+// another
+type MergeRepository interface {
+	GetSubject(ctx context.Context, arg database.GetSubjectParams) (database.Subject, error)
+	GetTopic(ctx context.Context, id string) (database.Topic, error)
+	DeleteSubject(ctx context.Context, arg database.DeleteSubjectParams) error
+	DeleteTopic(ctx context.Context, id string) error
+
+	MoveSubjectTopics(ctx context.Context, arg database.MoveSubjectTopicsParams) (int64, error)
+	MoveSubjectCycleItems(ctx context.Context, arg database.MoveSubjectCycleItemsParams) (int64, error)
+	MoveSubjectStudySessions(ctx context.Context, arg database.MoveSubjectStudySessionsParams) (int64, error)
+	MoveSubjectExerciseLogs(ctx context.Context, arg database.MoveSubjectExerciseLogsParams) (int64, error)
+	MoveSubjectGoals(ctx context.Context, arg database.MoveSubjectGoalsParams) (int64, error)
+	MoveTopicExerciseLogs(ctx context.Context, arg database.MoveTopicExerciseLogsParams) (int64, error)
+
+	// Tx runs fn in one read-write transaction, committed only if fn returns
+	// nil. Nested calls reuse the transaction.
+	Tx(ctx context.Context, fn func(MergeRepository) error) error
+}
+
+type SQLMergeRepository struct {
+	db *sql.DB // nil once bound to a transaction
+	q  *database.Queries
+}
+
+func NewSQLMergeRepository(db *sql.DB, q *database.Queries) *SQLMergeRepository {
+	return &SQLMergeRepository{db: db, q: q}
+}
+
+func (r *SQLMergeRepository) GetSubject(ctx context.Context, arg database.GetSubjectParams) (database.Subject, error) {
+	return r.q.GetSubject(ctx, arg)
+}
+
+func (r *SQLMergeRepository) GetTopic(ctx context.Context, id string) (database.Topic, error) {
+	return r.q.GetTopic(ctx, id)
+}
+
+func (r *SQLMergeRepository) DeleteSubject(ctx context.Context, arg database.DeleteSubjectParams) error {
+	return r.q.DeleteSubject(ctx, arg)
+}
+
+func (r *SQLMergeRepository) DeleteTopic(ctx context.Context, id string) error {
+	return r.q.DeleteTopic(ctx, id)
+}
+
+func (r *SQLMergeRepository) MoveSubjectTopics(ctx context.Context, arg database.MoveSubjectTopicsParams) (int64, error) {
+	return r.q.MoveSubjectTopics(ctx, arg)
+}
+
+func (r *SQLMergeRepository) MoveSubjectCycleItems(ctx context.Context, arg database.MoveSubjectCycleItemsParams) (int64, error) {
+	return r.q.MoveSubjectCycleItems(ctx, arg)
+}
+
+func (r *SQLMergeRepository) MoveSubjectStudySessions(ctx context.Context, arg database.MoveSubjectStudySessionsParams) (int64, error) {
+	return r.q.MoveSubjectStudySessions(ctx, arg)
+}
+
+func (r *SQLMergeRepository) MoveSubjectExerciseLogs(ctx context.Context, arg database.MoveSubjectExerciseLogsParams) (int64, error) {
+	return r.q.MoveSubjectExerciseLogs(ctx, arg)
+}
+
+func (r *SQLMergeRepository) MoveSubjectGoals(ctx context.Context, arg database.MoveSubjectGoalsParams) (int64, error) {
+	return r.q.MoveSubjectGoals(ctx, arg)
+}
+
+func (r *SQLMergeRepository) MoveTopicExerciseLogs(ctx context.Context, arg database.MoveTopicExerciseLogsParams) (int64, error) {
+	return r.q.MoveTopicExerciseLogs(ctx, arg)
+}
+
+func (r *SQLMergeRepository) Tx(ctx context.Context, fn func(MergeRepository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return withTx(ctx, r.db, nil, r.q, func(q *database.Queries) error {
+		return fn(NewSQLMergeRepository(nil, q))
+	})
+}
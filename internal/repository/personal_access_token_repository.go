@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+type PersonalAccessTokenRepository interface {
+	CreateToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error)
+	ListTokens(ctx context.Context, userID string) ([]database.PersonalAccessToken, error)
+
+	// Lookup only returns tokens that are neither revoked nor expired
+	GetTokenByHash(ctx context.Context, tokenHash string) (database.GetPersonalAccessTokenByHashRow, error)
+	TouchToken(ctx context.Context, id string) error
+
+	// Revoke requires userID to ensure ownership; returns the rows affected
+	RevokeToken(ctx context.Context, id, userID string) (int64, error)
+}
+
+type SQLPersonalAccessTokenRepository struct {
+	q database.Querier
+}
+
+func NewSQLPersonalAccessTokenRepository(q database.Querier) *SQLPersonalAccessTokenRepository {
+	return &SQLPersonalAccessTokenRepository{q: q}
+}
+
+func (r *SQLPersonalAccessTokenRepository) CreateToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	return r.q.CreatePersonalAccessToken(ctx, arg)
+}
+
+func (r *SQLPersonalAccessTokenRepository) ListTokens(ctx context.Context, userID string) ([]database.PersonalAccessToken, error) {
+	return r.q.ListPersonalAccessTokens(ctx, userID)
+}
+
+func (r *SQLPersonalAccessTokenRepository) GetTokenByHash(ctx context.Context, tokenHash string) (database.GetPersonalAccessTokenByHashRow, error) {
+	return r.q.GetPersonalAccessTokenByHash(ctx, tokenHash)
+}
+
+func (r *SQLPersonalAccessTokenRepository) TouchToken(ctx context.Context, id string) error {
+	return r.q.TouchPersonalAccessToken(ctx, id)
+}
+
+func (r *SQLPersonalAccessTokenRepository) RevokeToken(ctx context.Context, id, userID string) (int64, error) {
+	return r.q.RevokePersonalAccessToken(ctx, database.RevokePersonalAccessTokenParams{
+		ID:     id,
+		UserID: userID,
+	})
+}
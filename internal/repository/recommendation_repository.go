@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+type RecommendationRepository interface {
+	GetRecommendationWeights(ctx context.Context, userID string) (database.RecommendationWeight, error)
+	UpsertRecommendationWeights(ctx context.Context, arg database.UpsertRecommendationWeightsParams) (database.RecommendationWeight, error)
+
+	// Candidate sources: the cycle position, topic practice history and topic accuracy
+	ListActiveCycleItems(ctx context.Context, userID string) ([]database.ListActiveCycleItemsRow, error)
+	ListTopicExerciseLogs(ctx context.Context, userID string) ([]database.ListTopicExerciseLogsRow, error)
+	ListSubjects(ctx context.Context, userID string) ([]database.Subject, error)
+	GetAccuracyByTopic(ctx context.Context, subjectID, userID string) ([]database.GetAccuracyByTopicRow, error)
+}
+
+type SQLRecommendationRepository struct {
+	q database.Querier
+}
+
+func NewSQLRecommendationRepository(q database.Querier) *SQLRecommendationRepository {
+	return &SQLRecommendationRepository{q: q}
+}
+
+func (r *SQLRecommendationRepository) GetRecommendationWeights(ctx context.Context, userID string) (database.RecommendationWeight, error) {
+	return r.q.GetRecommendationWeights(ctx, userID)
+}
+
+func (r *SQLRecommendationRepository) UpsertRecommendationWeights(ctx context.Context, arg database.UpsertRecommendationWeightsParams) (database.RecommendationWeight, error) {
+	return r.q.UpsertRecommendationWeights(ctx, arg)
+}
+
+func (r *SQLRecommendationRepository) ListActiveCycleItems(ctx context.Context, userID string) ([]database.ListActiveCycleItemsRow, error) {
+	return r.q.ListActiveCycleItems(ctx, userID)
+}
+
+func (r *SQLRecommendationRepository) ListTopicExerciseLogs(ctx context.Context, userID string) ([]database.ListTopicExerciseLogsRow, error) {
+	return r.q.ListTopicExerciseLogs(ctx, userID)
+}
+
+func (r *SQLRecommendationRepository) ListSubjects(ctx context.Context, userID string) ([]database.Subject, error) {
+	return r.q.ListSubjects(ctx, userID)
+}
+
+func (r *SQLRecommendationRepository) GetAccuracyByTopic(ctx context.Context, subjectID, userID string) ([]database.GetAccuracyByTopicRow, error) {
+	return r.q.GetAccuracyByTopic(ctx, database.GetAccuracyByTopicParams{
+		SubjectID: subjectID,
+		UserID:    userID,
+	})
+}
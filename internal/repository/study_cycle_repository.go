@@ -10,9 +10,11 @@ type StudyCycleRepository interface {
 	CreateStudyCycle(ctx context.Context, arg database.CreateStudyCycleParams) (database.StudyCycle, error)
 	GetActiveStudyCycle(ctx context.Context) (database.StudyCycle, error)
 	GetStudyCycle(ctx context.Context, id string) (database.StudyCycle, error)
-	UpdateStudyCycle(ctx context.Context, arg database.UpdateStudyCycleParams) error
+	UpdateStudyCycle(ctx context.Context, arg database.UpdateStudyCycleParams) (database.StudyCycle, error)
+	PatchStudyCycle(ctx context.Context, arg database.PatchStudyCycleParams) (database.StudyCycle, error)
 	DeleteStudyCycle(ctx context.Context, id string) error
 	GetActiveCycleWithItems(ctx context.Context) ([]database.GetActiveCycleWithItemsRow, error)
+	CreateCycleItem(ctx context.Context, arg database.CreateCycleItemParams) (database.CycleItem, error)
 }
 
 type SQLStudyCycleRepository struct {
@@ -35,10 +37,14 @@ func (r *SQLStudyCycleRepository) GetStudyCycle(ctx context.Context, id string)
 	return r.q.GetStudyCycle(ctx, id)
 }
 
-func (r *SQLStudyCycleRepository) UpdateStudyCycle(ctx context.Context, arg database.UpdateStudyCycleParams) error {
+func (r *SQLStudyCycleRepository) UpdateStudyCycle(ctx context.Context, arg database.UpdateStudyCycleParams) (database.StudyCycle, error) {
 	return r.q.UpdateStudyCycle(ctx, arg)
 }
 
+func (r *SQLStudyCycleRepository) PatchStudyCycle(ctx context.Context, arg database.PatchStudyCycleParams) (database.StudyCycle, error) {
+	return r.q.PatchStudyCycle(ctx, arg)
+}
+
 func (r *SQLStudyCycleRepository) DeleteStudyCycle(ctx context.Context, id string) error {
 	return r.q.DeleteStudyCycle(ctx, id)
 }
@@ -46,3 +52,7 @@ func (r *SQLStudyCycleRepository) DeleteStudyCycle(ctx context.Context, id strin
 func (r *SQLStudyCycleRepository) GetActiveCycleWithItems(ctx context.Context) ([]database.GetActiveCycleWithItemsRow, error) {
 	return r.q.GetActiveCycleWithItems(ctx)
 }
+
+func (r *SQLStudyCycleRepository) CreateCycleItem(ctx context.Context, arg database.CreateCycleItemParams) (database.CycleItem, error) {
+	return r.q.CreateCycleItem(ctx, arg)
+}
@@ -12,6 +12,7 @@ type StudySessionRepository interface {
 	GetStudySession(ctx context.Context, id string) (database.StudySession, error)
 	DeleteStudySession(ctx context.Context, id string) error
 	GetOpenSession(ctx context.Context) (database.GetOpenSessionRow, error)
+	EndOpenSessionPauses(ctx context.Context, arg database.EndOpenSessionPausesParams) error
 }
 
 type SQLStudySessionRepository struct {
@@ -41,3 +42,7 @@ func (r *SQLStudySessionRepository) DeleteStudySession(ctx context.Context, id s
 func (r *SQLStudySessionRepository) GetOpenSession(ctx context.Context) (database.GetOpenSessionRow, error) {
 	return r.q.GetOpenSession(ctx)
 }
+
+func (r *SQLStudySessionRepository) EndOpenSessionPauses(ctx context.Context, arg database.EndOpenSessionPausesParams) error {
+	return r.q.EndOpenSessionPauses(ctx, arg)
+}
@@ -17,7 +17,10 @@ type SubjectRepository interface {
 	GetSubject(ctx context.Context, id, userID string) (database.Subject, error)
 
 	// Update expects UserID inside arg to ensure ownership before update
-	UpdateSubject(ctx context.Context, arg database.UpdateSubjectParams) error
+	UpdateSubject(ctx context.Context, arg database.UpdateSubjectParams) (database.Subject, error)
+
+	// Patch only changes the columns flagged in arg, with the same ownership check
+	PatchSubject(ctx context.Context, arg database.PatchSubjectParams) (database.Subject, error)
 
 	// Delete requires userID to ensure ownership
 	DeleteSubject(ctx context.Context, id, userID string) error
@@ -47,10 +50,14 @@ func (r *SQLSubjectRepository) GetSubject(ctx context.Context, id, userID string
 	})
 }
 
-func (r *SQLSubjectRepository) UpdateSubject(ctx context.Context, arg database.UpdateSubjectParams) error {
+func (r *SQLSubjectRepository) UpdateSubject(ctx context.Context, arg database.UpdateSubjectParams) (database.Subject, error) {
 	return r.q.UpdateSubject(ctx, arg)
 }
 
+func (r *SQLSubjectRepository) PatchSubject(ctx context.Context, arg database.PatchSubjectParams) (database.Subject, error) {
+	return r.q.PatchSubject(ctx, arg)
+}
+
 func (r *SQLSubjectRepository) DeleteSubject(ctx context.Context, id, userID string) error {
 	// FIX: Wrap arguments in DeleteSubjectParams
 	return r.q.DeleteSubject(ctx, database.DeleteSubjectParams{
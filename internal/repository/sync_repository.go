@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// SyncRepository reads the change feed and writes rows pushed by offline
+// clients under their own IDs
+type SyncRepository interface {
+	ListSyncChanges(ctx context.Context, arg database.ListSyncChangesParams) ([]database.ListSyncChangesRow, error)
+	GetLatestSyncChange(ctx context.Context, arg database.GetLatestSyncChangeParams) (database.GetLatestSyncChangeRow, error)
+	CompactSyncChanges(ctx context.Context, cutoff string) (int64, error)
+
+	UpsertSyncSubject(ctx context.Context, arg database.UpsertSyncSubjectParams) (int64, error)
+	UpsertSyncTopic(ctx context.Context, arg database.UpsertSyncTopicParams) (int64, error)
+	UpsertSyncStudyCycle(ctx context.Context, arg database.UpsertSyncStudyCycleParams) (int64, error)
+	UpsertSyncCycleItem(ctx context.Context, arg database.UpsertSyncCycleItemParams) (int64, error)
+	UpsertSyncStudySession(ctx context.Context, arg database.UpsertSyncStudySessionParams) (int64, error)
+	UpsertSyncSessionPause(ctx context.Context, arg database.UpsertSyncSessionPauseParams) (int64, error)
+	UpsertSyncExerciseLog(ctx context.Context, arg database.UpsertSyncExerciseLogParams) (int64, error)
+
+	DeleteSyncSubject(ctx context.Context, arg database.DeleteSyncSubjectParams) (int64, error)
+	DeleteSyncTopic(ctx context.Context, arg database.DeleteSyncTopicParams) (int64, error)
+	DeleteSyncStudyCycle(ctx context.Context, arg database.DeleteSyncStudyCycleParams) (int64, error)
+	DeleteSyncCycleItem(ctx context.Context, arg database.DeleteSyncCycleItemParams) (int64, error)
+	DeleteSyncStudySession(ctx context.Context, arg database.DeleteSyncStudySessionParams) (int64, error)
+	DeleteSyncSessionPause(ctx context.Context, arg database.DeleteSyncSessionPauseParams) (int64, error)
+	DeleteSyncExerciseLog(ctx context.Context, arg database.DeleteSyncExerciseLogParams) (int64, error)
+
+	// Tx runs fn in one read-write transaction, committed only if fn returns
+	// nil. Nested calls reuse the transaction.
+	Tx(ctx context.Context, fn func(SyncRepository) error) error
+}
+
+type SQLSyncRepository struct {
+	db *sql.DB // nil once bound to a transaction
+	q  *database.Queries
+}
+
+func NewSQLSyncRepository(db *sql.DB, q *database.Queries) *SQLSyncRepository {
+	return &SQLSyncRepository{db: db, q: q}
+}
+
+func (r *SQLSyncRepository) ListSyncChanges(ctx context.Context, arg database.ListSyncChangesParams) ([]database.ListSyncChangesRow, error) {
+	return r.q.ListSyncChanges(ctx, arg)
+}
+
+func (r *SQLSyncRepository) GetLatestSyncChange(ctx context.Context, arg database.GetLatestSyncChangeParams) (database.GetLatestSyncChangeRow, error) {
+	return r.q.GetLatestSyncChange(ctx, arg)
+}
+
+func (r *SQLSyncRepository) CompactSyncChanges(ctx context.Context, cutoff string) (int64, error) {
+	return r.q.CompactSyncChanges(ctx, cutoff)
+}
+
+func (r *SQLSyncRepository) UpsertSyncSubject(ctx context.Context, arg database.UpsertSyncSubjectParams) (int64, error) {
+	return r.q.UpsertSyncSubject(ctx, arg)
+}
+
+func (r *SQLSyncRepository) UpsertSyncTopic(ctx context.Context, arg database.UpsertSyncTopicParams) (int64, error) {
+	return r.q.UpsertSyncTopic(ctx, arg)
+}
+
+func (r *SQLSyncRepository) UpsertSyncStudyCycle(ctx context.Context, arg database.UpsertSyncStudyCycleParams) (int64, error) {
+	return r.q.UpsertSyncStudyCycle(ctx, arg)
+}
+
+func (r *SQLSyncRepository) UpsertSyncCycleItem(ctx context.Context, arg database.UpsertSyncCycleItemParams) (int64, error) {
+	return r.q.UpsertSyncCycleItem(ctx, arg)
+}
+
+func (r *SQLSyncRepository) UpsertSyncStudySession(ctx context.Context, arg database.UpsertSyncStudySessionParams) (int64, error) {
+	return r.q.UpsertSyncStudySession(ctx, arg)
+}
+
+func (r *SQLSyncRepository) UpsertSyncSessionPause(ctx context.Context, arg database.UpsertSyncSessionPauseParams) (int64, error) {
+	return r.q.UpsertSyncSessionPause(ctx, arg)
+}
+
+func (r *SQLSyncRepository) UpsertSyncExerciseLog(ctx context.Context, arg database.UpsertSyncExerciseLogParams) (int64, error) {
+	return r.q.UpsertSyncExerciseLog(ctx, arg)
+}
+
+func (r *SQLSyncRepository) DeleteSyncSubject(ctx context.Context, arg database.DeleteSyncSubjectParams) (int64, error) {
+	return r.q.DeleteSyncSubject(ctx, arg)
+}
+
+func (r *SQLSyncRepository) DeleteSyncTopic(ctx context.Context, arg database.DeleteSyncTopicParams) (int64, error) {
+	return r.q.DeleteSyncTopic(ctx, arg)
+}
+
+func (r *SQLSyncRepository) DeleteSyncStudyCycle(ctx context.Context, arg database.DeleteSyncStudyCycleParams) (int64, error) {
+	return r.q.DeleteSyncStudyCycle(ctx, arg)
+}
+
+func (r *SQLSyncRepository) DeleteSyncCycleItem(ctx context.Context, arg database.DeleteSyncCycleItemParams) (int64, error) {
+	return r.q.DeleteSyncCycleItem(ctx, arg)
+}
+
+func (r *SQLSyncRepository) DeleteSyncStudySession(ctx context.Context, arg database.DeleteSyncStudySessionParams) (int64, error) {
+	return r.q.DeleteSyncStudySession(ctx, arg)
+}
+
+func (r *SQLSyncRepository) DeleteSyncSessionPause(ctx context.Context, arg database.DeleteSyncSessionPauseParams) (int64, error) {
+	return r.q.DeleteSyncSessionPause(ctx, arg)
+}
+
+func (r *SQLSyncRepository) DeleteSyncExerciseLog(ctx context.Context, arg database.DeleteSyncExerciseLogParams) (int64, error) {
+	return r.q.DeleteSyncExerciseLog(ctx, arg)
+}
+
+func (r *SQLSyncRepository) Tx(ctx context.Context, fn func(SyncRepository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return withTx(ctx, r.db, nil, r.q, func(q *database.Queries) error {
+		return fn(NewSQLSyncRepository(nil, q))
+	})
+}
@@ -10,7 +10,8 @@ type TopicRepository interface {
 	CreateTopic(ctx context.Context, arg database.CreateTopicParams) (database.Topic, error)
 	ListTopicsBySubject(ctx context.Context, subjectID string) ([]database.Topic, error)
 	GetTopic(ctx context.Context, id string) (database.Topic, error)
-	UpdateTopic(ctx context.Context, arg database.UpdateTopicParams) error
+	UpdateTopic(ctx context.Context, arg database.UpdateTopicParams) (database.Topic, error)
+	PatchTopic(ctx context.Context, arg database.PatchTopicParams) (database.Topic, error)
 	DeleteTopic(ctx context.Context, id string) error
 }
 
@@ -34,10 +35,14 @@ func (r *SQLTopicRepository) GetTopic(ctx context.Context, id string) (database.
 	return r.q.GetTopic(ctx, id)
 }
 
-func (r *SQLTopicRepository) UpdateTopic(ctx context.Context, arg database.UpdateTopicParams) error {
+func (r *SQLTopicRepository) UpdateTopic(ctx context.Context, arg database.UpdateTopicParams) (database.Topic, error) {
 	return r.q.UpdateTopic(ctx, arg)
 }
 
+func (r *SQLTopicRepository) PatchTopic(ctx context.Context, arg database.PatchTopicParams) (database.Topic, error) {
+	return r.q.PatchTopic(ctx, arg)
+}
+
 func (r *SQLTopicRepository) DeleteTopic(ctx context.Context, id string) error {
 	return r.q.DeleteTopic(ctx, id)
 }
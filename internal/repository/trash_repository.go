@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// TrashRepository lists, restores and purges soft-deleted subjects, topics and
+// study cycles
+type TrashRepository interface {
+	ListTrashedSubjects(ctx context.Context, userID string) ([]database.ListTrashedSubjectsRow, error)
+	ListTrashedTopics(ctx context.Context, userID string) ([]database.ListTrashedTopicsRow, error)
+	ListTrashedStudyCycles(ctx context.Context, userID string) ([]database.ListTrashedStudyCyclesRow, error)
+	GetSubject(ctx context.Context, arg database.GetSubjectParams) (database.Subject, error)
+	GetTrashedTopic(ctx context.Context, arg database.GetTrashedTopicParams) (database.Topic, error)
+	RestoreTrashedSubject(ctx context.Context, arg database.RestoreTrashedSubjectParams) (int64, error)
+	RestoreTrashedTopic(ctx context.Context, id string) (int64, error)
+	RestoreTrashedStudyCycle(ctx context.Context, arg database.RestoreTrashedStudyCycleParams) (int64, error)
+
+	ListExpiredTrashedSubjects(ctx context.Context, cutoff string) ([]string, error)
+	ListExpiredTrashedTopics(ctx context.Context, cutoff string) ([]string, error)
+	ListExpiredTrashedStudyCycles(ctx context.Context, cutoff string) ([]string, error)
+	PurgeSubjectExerciseLogs(ctx context.Context, subjectID string) error
+	PurgeSubjectSessionPauses(ctx context.Context, subjectID string) error
+	PurgeSubjectStudySessions(ctx context.Context, subjectID string) error
+	DetachSubjectCycleItemSessions(ctx context.Context, subjectID string) error
+	PurgeSubjectCycleItems(ctx context.Context, subjectID string) error
+	DetachSubjectTopicExerciseLogs(ctx context.Context, subjectID string) error
+	PurgeSubjectTopics(ctx context.Context, subjectID string) error
+	PurgeSubjectGoals(ctx context.Context, subjectID string) error
+	PurgeTrashedSubject(ctx context.Context, id string) (int64, error)
+	DetachTopicExerciseLogs(ctx context.Context, topicID string) error
+	PurgeTrashedTopic(ctx context.Context, id string) (int64, error)
+	DetachStudyCycleSessions(ctx context.Context, cycleID string) error
+	PurgeStudyCycleItems(ctx context.Context, cycleID string) error
+	PurgeTrashedStudyCycle(ctx context.Context, id string) (int64, error)
+
+	// Tx runs fn in one read-write transaction, committed only if fn returns
+	// nil. Nested calls reuse the transaction.
+	Tx(ctx context.Context, fn func(TrashRepository) error) error
+}
+
+type SQLTrashRepository struct {
+	db *sql.DB // nil once bound to a transaction
+	q  *database.Queries
+}
+
+func NewSQLTrashRepository(db *sql.DB, q *database.Queries) *SQLTrashRepository {
+	return &SQLTrashRepository{db: db, q: q}
+}
+
+func (r *SQLTrashRepository) ListTrashedSubjects(ctx context.Context, userID string) ([]database.ListTrashedSubjectsRow, error) {
+	return r.q.ListTrashedSubjects(ctx, userID)
+}
+
+func (r *SQLTrashRepository) ListTrashedTopics(ctx context.Context, userID string) ([]database.ListTrashedTopicsRow, error) {
+	return r.q.ListTrashedTopics(ctx, userID)
+}
+
+func (r *SQLTrashRepository) ListTrashedStudyCycles(ctx context.Context, userID string) ([]database.ListTrashedStudyCyclesRow, error) {
+	return r.q.ListTrashedStudyCycles(ctx, userID)
+}
+
+func (r *SQLTrashRepository) GetSubject(ctx context.Context, arg database.GetSubjectParams) (database.Subject, error) {
+	return r.q.GetSubject(ctx, arg)
+}
+
+func (r *SQLTrashRepository) GetTrashedTopic(ctx context.Context, arg database.GetTrashedTopicParams) (database.Topic, error) {
+	return r.q.GetTrashedTopic(ctx, arg)
+}
+
+func (r *SQLTrashRepository) RestoreTrashedSubject(ctx context.Context, arg database.RestoreTrashedSubjectParams) (int64, error) {
+	return r.q.RestoreTrashedSubject(ctx, arg)
+}
+
+func (r *SQLTrashRepository) RestoreTrashedTopic(ctx context.Context, id string) (int64, error) {
+	return r.q.RestoreTrashedTopic(ctx, id)
+}
+
+func (r *SQLTrashRepository) RestoreTrashedStudyCycle(ctx context.Context, arg database.RestoreTrashedStudyCycleParams) (int64, error) {
+	return r.q.RestoreTrashedStudyCycle(ctx, arg)
+}
+
+func (r *SQLTrashRepository) ListExpiredTrashedSubjects(ctx context.Context, cutoff string) ([]string, error) {
+	return r.q.ListExpiredTrashedSubjects(ctx, cutoff)
+}
+
+func (r *SQLTrashRepository) ListExpiredTrashedTopics(ctx context.Context, cutoff string) ([]string, error) {
+	return r.q.ListExpiredTrashedTopics(ctx, cutoff)
+}
+
+func (r *SQLTrashRepository) ListExpiredTrashedStudyCycles(ctx context.Context, cutoff string) ([]string, error) {
+	return r.q.ListExpiredTrashedStudyCycles(ctx, cutoff)
+}
+
+func (r *SQLTrashRepository) PurgeSubjectExerciseLogs(ctx context.Context, subjectID string) error {
+	return r.q.PurgeSubjectExerciseLogs(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) PurgeSubjectSessionPauses(ctx context.Context, subjectID string) error {
+	return r.q.PurgeSubjectSessionPauses(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) PurgeSubjectStudySessions(ctx context.Context, subjectID string) error {
+	return r.q.PurgeSubjectStudySessions(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) DetachSubjectCycleItemSessions(ctx context.Context, subjectID string) error {
+	return r.q.DetachSubjectCycleItemSessions(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) PurgeSubjectCycleItems(ctx context.Context, subjectID string) error {
+	return r.q.PurgeSubjectCycleItems(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) DetachSubjectTopicExerciseLogs(ctx context.Context, subjectID string) error {
+	return r.q.DetachSubjectTopicExerciseLogs(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) PurgeSubjectTopics(ctx context.Context, subjectID string) error {
+	return r.q.PurgeSubjectTopics(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) PurgeSubjectGoals(ctx context.Context, subjectID string) error {
+	return r.q.PurgeSubjectGoals(ctx, subjectID)
+}
+
+func (r *SQLTrashRepository) PurgeTrashedSubject(ctx context.Context, id string) (int64, error) {
+	return r.q.PurgeTrashedSubject(ctx, id)
+}
+
+func (r *SQLTrashRepository) DetachTopicExerciseLogs(ctx context.Context, topicID string) error {
+	return r.q.DetachTopicExerciseLogs(ctx, topicID)
+}
+
+func (r *SQLTrashRepository) PurgeTrashedTopic(ctx context.Context, id string) (int64, error) {
+	return r.q.PurgeTrashedTopic(ctx, id)
+}
+
+func (r *SQLTrashRepository) DetachStudyCycleSessions(ctx context.Context, cycleID string) error {
+	return r.q.DetachStudyCycleSessions(ctx, cycleID)
+}
+
+func (r *SQLTrashRepository) PurgeStudyCycleItems(ctx context.Context, cycleID string) error {
+	return r.q.PurgeStudyCycleItems(ctx, cycleID)
+}
+
+func (r *SQLTrashRepository) PurgeTrashedStudyCycle(ctx context.Context, id string) (int64, error) {
+	return r.q.PurgeTrashedStudyCycle(ctx, id)
+}
+
+func (r *SQLTrashRepository) Tx(ctx context.Context, fn func(TrashRepository) error) error {
+	if r.db == nil {
+		return fn(r)
+	}
+
+	return withTx(ctx, r.db, nil, r.q, func(q *database.Queries) error {
+		return fn(NewSQLTrashRepository(nil, q))
+	})
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// Retries of a transaction SQLite was too busy to run
+const (
+	defaultTxAttempts = 5
+	defaultTxBackoff  = 20 * time.Millisecond
+)
+
+type txKey struct{}
+
+// TxManager runs units of work that span repositories in one transaction.
+// The transaction travels in the context fn gets: repositories built on a
+// ContextDB run every query made with that context in it.
+type TxManager interface {
+	// WithinTx runs fn in one read-write transaction, committed only if fn
+	// returns nil. Nested calls join the transaction of their context, so
+	// only the outermost one commits. fn may run more than once, when SQLite
+	// reports the database busy, and must not keep state between runs.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type SQLTxManager struct {
+	db       *sql.DB
+	attempts int
+	backoff  time.Duration
+}
+
+func NewSQLTxManager(db *sql.DB) *SQLTxManager {
+	return &SQLTxManager{db: db, attempts: defaultTxAttempts, backoff: defaultTxBackoff}
+}
+
+// WithRetries sets how many times a busy transaction is tried in all, and the
+// wait before the second try; each wait doubles the one before
+func (m *SQLTxManager) WithRetries(attempts int, backoff time.Duration) *SQLTxManager {
+	m.attempts = max(attempts, 1)
+	m.backoff = backoff
+	return m
+}
+
+func (m *SQLTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := txFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	return retryBusy(ctx, m.attempts, m.backoff, func() error {
+		return m.run(ctx, fn)
+	})
+}
+
+func (m *SQLTxManager) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// withTx runs fn with q bound to the transaction of ctx or, when ctx carries
+// none, to one of its own on db, committed only if fn returns nil. It lets the
+// repositories with a Tx of their own join a unit of work. A transaction of
+// its own is retried like SQLTxManager's, so fn may run more than once.
+func withTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, q *database.Queries, fn func(*database.Queries) error) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(q.WithTx(tx))
+	}
+
+	return retryBusy(ctx, defaultTxAttempts, defaultTxBackoff, func() error {
+		tx, err := db.BeginTx(ctx, opts)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := fn(q.WithTx(tx)); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// retryBusy runs try until it succeeds, fails with anything but a busy
+// database, or has been tried attempts times, doubling the wait each time
+func retryBusy(ctx context.Context, attempts int, backoff time.Duration, try func() error) error {
+	wait := backoff
+	for attempt := 1; ; attempt++ {
+		err := try()
+		if err == nil || !isBusy(err) || attempt >= attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func txFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// isBusy reports whether err is SQLITE_BUSY: another connection held a lock
+// the transaction needed past the busy timeout, or SQLite gave up waiting to
+// avoid a deadlock. Both drivers in use only say so in the message.
+func isBusy(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") || strings.Contains(msg, "database is locked")
+}
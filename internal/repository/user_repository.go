@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/joaoapaenas/my-api/internal/database"
 )
@@ -9,7 +11,15 @@ import (
 type UserRepository interface {
 	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
 	GetUserByEmail(ctx context.Context, email string) (database.User, error)
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+	UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error
 	UpdateUserPassword(ctx context.Context, id, passwordHash string) error
+
+	// Lockout bookkeeping for failed logins. IncrementLoginFailures returns the
+	// new count; LockUser only applies while the count is still attempts.
+	IncrementLoginFailures(ctx context.Context, id string) (int64, error)
+	LockUser(ctx context.Context, id string, attempts int64, lockedUntil time.Time) error
+	ResetLoginFailures(ctx context.Context, id string) error
 }
 
 type SQLUserRepository struct {
@@ -28,9 +38,33 @@ func (r *SQLUserRepository) GetUserByEmail(ctx context.Context, email string) (d
 	return r.q.GetUserByEmail(ctx, email)
 }
 
+func (r *SQLUserRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	return r.q.GetUserByID(ctx, id)
+}
+
+func (r *SQLUserRepository) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error {
+	return r.q.UpdateUserProfile(ctx, arg)
+}
+
 func (r *SQLUserRepository) UpdateUserPassword(ctx context.Context, id, passwordHash string) error {
 	return r.q.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
 		ID:           id,
 		PasswordHash: passwordHash,
 	})
 }
+
+func (r *SQLUserRepository) IncrementLoginFailures(ctx context.Context, id string) (int64, error) {
+	return r.q.IncrementLoginFailures(ctx, id)
+}
+
+func (r *SQLUserRepository) LockUser(ctx context.Context, id string, attempts int64, lockedUntil time.Time) error {
+	return r.q.LockUser(ctx, database.LockUserParams{
+		LockedUntil:         sql.NullTime{Time: lockedUntil, Valid: !lockedUntil.IsZero()},
+		ID:                  id,
+		FailedLoginAttempts: attempts,
+	})
+}
+
+func (r *SQLUserRepository) ResetLoginFailures(ctx context.Context, id string) error {
+	return r.q.ResetLoginFailures(ctx, id)
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrDeletionPending    = errors.New("account deletion already requested")
+	ErrNoPendingDeletion  = errors.New("no account deletion pending")
+	ErrDeletionNotFound   = errors.New("account deletion not found")
+	ErrInvalidGracePeriod = errors.New("grace period out of range")
+)
+
+const (
+	// DefaultDeletionGrace is how long a deleted account can be restored
+	DefaultDeletionGrace = 7 * 24 * time.Hour
+	// MaxDeletionGrace caps the grace period a user can ask for
+	MaxDeletionGrace = 30 * 24 * time.Hour
+
+	// ReceiptPrefix marks account deletion receipt tokens
+	ReceiptPrefix = "adr_"
+)
+
+// AccountDeletion is a requested account deletion and, once purged, its report
+type AccountDeletion struct {
+	ID          string
+	Receipt     string // Plaintext receipt token; only set on the request
+	RequestedAt time.Time
+	PurgeAfter  time.Time
+	PurgedAt    *time.Time       // nil while the account can be restored
+	DeletedRows map[string]int64 // Rows purged per table; nil until purged
+	Verified    bool             // The purge deleted exactly the rows counted beforehand and left none
+}
+
+type AccountDeletionService interface {
+	// RequestDeletion re-checks the password and schedules the account's
+	// purge once grace (nil for the default) has passed. With no grace the
+	// account is purged at once and the result carries the report.
+	RequestDeletion(ctx context.Context, userID, password string, grace *time.Duration) (AccountDeletion, error)
+	GetPendingDeletion(ctx context.Context, userID string) (AccountDeletion, error)
+	// RestoreAccount cancels a pending deletion
+	RestoreAccount(ctx context.Context, userID string) error
+	// GetDeletion reads a deletion, pending or purged, by its id and receipt
+	// token; it works after the account is gone
+	GetDeletion(ctx context.Context, id, receipt string) (AccountDeletion, error)
+	// PurgeDue purges every account whose grace period ended by now and
+	// returns how many were purged
+	PurgeDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type AccountDeletionManager struct {
+	repo         repository.AccountDeletionRepository
+	defaultGrace time.Duration
+}
+
+func NewAccountDeletionManager(repo repository.AccountDeletionRepository) *AccountDeletionManager {
+	return &AccountDeletionManager{repo: repo, defaultGrace: DefaultDeletionGrace}
+}
+
+// WithDefaultGrace overrides the grace period used when a request names none
+func (s *AccountDeletionManager) WithDefaultGrace(grace time.Duration) *AccountDeletionManager {
+	s.defaultGrace = grace
+	return s
+}
+
+func (s *AccountDeletionManager) RequestDeletion(ctx context.Context, userID, password string, grace *time.Duration) (AccountDeletion, error) {
+	wait := s.defaultGrace
+	if grace != nil {
+		wait = *grace
+	}
+	if wait < 0 || wait > MaxDeletionGrace {
+		return AccountDeletion{}, ErrInvalidGracePeriod
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return AccountDeletion{}, ErrUserNotFound
+		}
+		return AccountDeletion{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return AccountDeletion{}, ErrInvalidCredentials
+	}
+
+	_, err = s.repo.GetPendingAccountDeletion(ctx, userID)
+	if err == nil {
+		return AccountDeletion{}, ErrDeletionPending
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return AccountDeletion{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return AccountDeletion{}, err
+	}
+	receipt := ReceiptPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	row, err := s.repo.CreateAccountDeletion(ctx, database.CreateAccountDeletionParams{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		ReceiptHash: hashToken(receipt),
+		PurgeAfter:  formatSQLiteTime(time.Now().Add(wait)),
+	})
+	if err != nil {
+		return AccountDeletion{}, err
+	}
+
+	if wait == 0 {
+		if err := s.purge(ctx, row); err != nil {
+			return AccountDeletion{}, err
+		}
+		if row, err = s.repo.GetAccountDeletion(ctx, row.ID); err != nil {
+			return AccountDeletion{}, err
+		}
+	}
+
+	deletion := toAccountDeletion(row)
+	deletion.Receipt = receipt
+	return deletion, nil
+}
+
+func (s *AccountDeletionManager) GetPendingDeletion(ctx context.Context, userID string) (AccountDeletion, error) {
+	row, err := s.repo.GetPendingAccountDeletion(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return AccountDeletion{}, ErrNoPendingDeletion
+		}
+		return AccountDeletion{}, err
+	}
+	return toAccountDeletion(row), nil
+}
+
+func (s *AccountDeletionManager) RestoreAccount(ctx context.Context, userID string) error {
+	rows, err := s.repo.CancelAccountDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNoPendingDeletion
+	}
+	return nil
+}
+
+func (s *AccountDeletionManager) GetDeletion(ctx context.Context, id, receipt string) (AccountDeletion, error) {
+	row, err := s.repo.GetAccountDeletion(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return AccountDeletion{}, ErrDeletionNotFound
+		}
+		return AccountDeletion{}, err
+	}
+	// Unknown ids and wrong receipts look the same
+	if subtle.ConstantTimeCompare([]byte(row.ReceiptHash), []byte(hashToken(receipt))) != 1 {
+		return AccountDeletion{}, ErrDeletionNotFound
+	}
+	return toAccountDeletion(row), nil
+}
+
+func (s *AccountDeletionManager) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.ListDueAccountDeletions(ctx, formatSQLiteTime(now))
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, row := range due {
+		if err := s.purge(ctx, row); err != nil {
+			// Left pending, so the next run tries again
+			slog.Error("Failed to purge account", "deletion_id", row.ID, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge deletes every row of the deletion's user in one transaction, children
+// first so it doesn't depend on foreign key enforcement, and records the
+// report. Counts taken before and after make it verifiable.
+func (s *AccountDeletionManager) purge(ctx context.Context, deletion database.AccountDeletion) error {
+	return s.repo.Tx(ctx, func(repo repository.AccountDeletionRepository) error {
+		userID := deletion.UserID
+		counted, err := repo.CountAccountRows(ctx, userID)
+		if err != nil {
+			return err
+		}
+		cycles, err := repo.ListPurgeableStudyCycles(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		steps := []struct {
+			table string
+			purge func(context.Context, string) (int64, error)
+		}{
+			{"exercise_logs", repo.PurgeUserExerciseLogs},
+			{"session_pauses", repo.PurgeUserSessionPauses},
+			{"study_sessions", repo.PurgeUserStudySessions},
+			{"cycle_items", repo.PurgeUserCycleItems},
+			{"study_cycles", func(ctx context.Context, _ string) (int64, error) {
+				var total int64
+				for _, id := range cycles {
+					n, err := repo.PurgeStudyCycle(ctx, id)
+					if err != nil {
+						return 0, err
+					}
+					total += n
+				}
+				return total, nil
+			}},
+			{"topics", repo.PurgeUserTopics},
+			{"goals", repo.PurgeUserGoals},
+			{"subjects", repo.PurgeUserSubjects},
+			{"personal_access_tokens", repo.PurgeUserPersonalAccessTokens},
+			{"password_reset_tokens", repo.PurgeUserPasswordResetTokens},
+			{"recommendation_weights", repo.PurgeUserRecommendationWeights},
+			{"idempotency_keys", repo.PurgeUserIdempotencyKeys},
+			{"users", repo.PurgeUser},
+		}
+		deleted := make(map[string]int64, len(steps))
+		for _, step := range steps {
+			n, err := step.purge(ctx, userID)
+			if err != nil {
+				return err
+			}
+			deleted[step.table] = n
+		}
+		// Last, as the purge itself logged tombstones. The feed isn't study
+		// data, so it stays out of the counts.
+		if err := repo.PurgeUserSyncChanges(ctx, userID); err != nil {
+			return err
+		}
+
+		remaining, err := repo.CountAccountRows(ctx, userID)
+		if err != nil {
+			return err
+		}
+		verified := true
+		expected := accountRowCounts(counted)
+		for table, n := range accountRowCounts(remaining) {
+			if n != 0 || deleted[table] != expected[table] {
+				verified = false
+				slog.Warn("Account purge didn't match its counts", "deletion_id", deletion.ID, "table", table,
+					"counted", expected[table], "deleted", deleted[table], "remaining", n)
+			}
+		}
+
+		report, err := json.Marshal(deleted)
+		if err != nil {
+			return err
+		}
+		var verifiedFlag int64
+		if verified {
+			verifiedFlag = 1
+		}
+		return repo.CompleteAccountDeletion(ctx, database.CompleteAccountDeletionParams{
+			DeletedRows: sql.NullString{String: string(report), Valid: true},
+			Verified:    verifiedFlag,
+			ID:          deletion.ID,
+		})
+	})
+}
+
+func accountRowCounts(row database.CountAccountRowsRow) map[string]int64 {
+	return map[string]int64{
+		"exercise_logs":          row.ExerciseLogs,
+		"session_pauses":         row.SessionPauses,
+		"study_sessions":         row.StudySessions,
+		"cycle_items":            row.CycleItems,
+		"study_cycles":           row.StudyCycles,
+		"topics":                 row.Topics,
+		"goals":                  row.Goals,
+		"subjects":               row.Subjects,
+		"personal_access_tokens": row.PersonalAccessTokens,
+		"password_reset_tokens":  row.PasswordResetTokens,
+		"recommendation_weights": row.RecommendationWeights,
+		"idempotency_keys":       row.IdempotencyKeys,
+		"users":                  row.Users,
+	}
+}
+
+func toAccountDeletion(row database.AccountDeletion) AccountDeletion {
+	deletion := AccountDeletion{ID: row.ID, Verified: row.Verified == 1}
+	deletion.RequestedAt, _ = parseTimestamp(row.RequestedAt)
+	deletion.PurgeAfter, _ = parseTimestamp(row.PurgeAfter)
+	if row.PurgedAt.Valid {
+		if purged, err := parseTimestamp(row.PurgedAt.String); err == nil {
+			deletion.PurgedAt = &purged
+		}
+	}
+	if row.DeletedRows.Valid {
+		if err := json.Unmarshal([]byte(row.DeletedRows.String), &deletion.DeletedRows); err != nil {
+			slog.Warn("Unreadable account deletion report", "deletion_id", row.ID, "error", err)
+		}
+	}
+	return deletion
+}
@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockAccountDeletionRepository is a mock implementation of repository.AccountDeletionRepository
+type MockAccountDeletionRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccountDeletionRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) CreateAccountDeletion(ctx context.Context, arg database.CreateAccountDeletionParams) (database.AccountDeletion, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.AccountDeletion), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) GetAccountDeletion(ctx context.Context, id string) (database.AccountDeletion, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.AccountDeletion), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) GetPendingAccountDeletion(ctx context.Context, userID string) (database.AccountDeletion, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(database.AccountDeletion), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) CancelAccountDeletion(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) ListDueAccountDeletions(ctx context.Context, now string) ([]database.AccountDeletion, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]database.AccountDeletion), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) CompleteAccountDeletion(ctx context.Context, arg database.CompleteAccountDeletionParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockAccountDeletionRepository) CountAccountRows(ctx context.Context, userID string) (database.CountAccountRowsRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(database.CountAccountRowsRow), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) ListPurgeableStudyCycles(ctx context.Context, userID string) ([]string, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserExerciseLogs(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserSessionPauses(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserStudySessions(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserCycleItems(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeStudyCycle(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserTopics(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserGoals(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserSubjects(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserPersonalAccessTokens(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserPasswordResetTokens(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserRecommendationWeights(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserIdempotencyKeys(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUser(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountDeletionRepository) PurgeUserSyncChanges(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockAccountDeletionRepository) Tx(ctx context.Context, fn func(repository.AccountDeletionRepository) error) error {
+	m.Called(ctx)
+	return fn(m)
+}
+
+func TestAccountDeletionManager_RequestDeletion(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	user := database.User{ID: "user-1", PasswordHash: string(hash)}
+
+	t.Run("Schedules the purge after the default grace period", func(t *testing.T) {
+		mockRepo := new(MockAccountDeletionRepository)
+		svc := service.NewAccountDeletionManager(mockRepo).WithDefaultGrace(48 * time.Hour)
+
+		var receiptHash string
+		mockRepo.On("GetUserByID", ctx, "user-1").Return(user, nil)
+		mockRepo.On("GetPendingAccountDeletion", ctx, "user-1").Return(database.AccountDeletion{}, sql.ErrNoRows)
+		mockRepo.On("CreateAccountDeletion", ctx, mock.MatchedBy(func(p database.CreateAccountDeletionParams) bool {
+			receiptHash = p.ReceiptHash
+			return p.UserID == "user-1" && p.PurgeAfter != ""
+		})).Return(database.AccountDeletion{ID: "del-1", PurgeAfter: "2024-03-03 10:00:00"}, nil)
+
+		deletion, err := svc.RequestDeletion(ctx, "user-1", "pass", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "del-1", deletion.ID)
+		assert.Contains(t, deletion.Receipt, service.ReceiptPrefix)
+		assert.NotEqual(t, deletion.Receipt, receiptHash)
+		assert.Nil(t, deletion.PurgedAt)
+		mockRepo.AssertNotCalled(t, "Tx", mock.Anything)
+	})
+
+	t.Run("Rejects a wrong password", func(t *testing.T) {
+		mockRepo := new(MockAccountDeletionRepository)
+		svc := service.NewAccountDeletionManager(mockRepo)
+
+		mockRepo.On("GetUserByID", ctx, "user-1").Return(user, nil)
+
+		_, err := svc.RequestDeletion(ctx, "user-1", "wrong", nil)
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "CreateAccountDeletion", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects a grace period over the maximum", func(t *testing.T) {
+		svc := service.NewAccountDeletionManager(new(MockAccountDeletionRepository))
+
+		grace := service.MaxDeletionGrace + time.Hour
+		_, err := svc.RequestDeletion(ctx, "user-1", "pass", &grace)
+		assert.ErrorIs(t, err, service.ErrInvalidGracePeriod)
+	})
+}
+
+func TestAccountDeletionManager_PurgeDue(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockAccountDeletionRepository)
+	svc := service.NewAccountDeletionManager(mockRepo)
+
+	before := database.CountAccountRowsRow{Subjects: 2, Users: 1}
+	mockRepo.On("ListDueAccountDeletions", ctx, mock.Anything).Return([]database.AccountDeletion{{ID: "del-1", UserID: "user-1"}}, nil)
+	mockRepo.On("Tx", ctx).Return(nil)
+	mockRepo.On("CountAccountRows", ctx, "user-1").Return(before, nil).Once()
+	mockRepo.On("CountAccountRows", ctx, "user-1").Return(database.CountAccountRowsRow{}, nil).Once()
+	mockRepo.On("ListPurgeableStudyCycles", ctx, "user-1").Return([]string{}, nil)
+	for _, purge := range []string{"PurgeUserExerciseLogs", "PurgeUserSessionPauses", "PurgeUserStudySessions", "PurgeUserCycleItems",
+		"PurgeUserTopics", "PurgeUserGoals", "PurgeUserPersonalAccessTokens", "PurgeUserPasswordResetTokens", "PurgeUserRecommendationWeights",
+		"PurgeUserIdempotencyKeys"} {
+		mockRepo.On(purge, ctx, "user-1").Return(int64(0), nil)
+	}
+	// One subject fewer than counted: the purge goes through but isn't verified
+	mockRepo.On("PurgeUserSubjects", ctx, "user-1").Return(int64(1), nil)
+	mockRepo.On("PurgeUser", ctx, "user-1").Return(int64(1), nil)
+	mockRepo.On("PurgeUserSyncChanges", ctx, "user-1").Return(nil)
+	mockRepo.On("CompleteAccountDeletion", ctx, mock.MatchedBy(func(p database.CompleteAccountDeletionParams) bool {
+		return p.ID == "del-1" && p.Verified == 0 && p.DeletedRows.Valid
+	})).Return(nil)
+
+	purged, err := svc.PurgeDue(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	mockRepo.AssertExpectations(t)
+}
@@ -2,17 +2,108 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
 
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/repository"
 )
 
+var (
+	ErrInvalidTimezone    = errors.New("invalid timezone")
+	ErrInvalidDateRange   = errors.New("invalid date range")
+	ErrInvalidGranularity = errors.New("granularity must be day, week or month")
+)
+
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// maxTrendPoints bounds the length of a trend series
+const maxTrendPoints = 1000
+
+// SubjectTimeReport is the net study time of one subject within a date range
+type SubjectTimeReport struct {
+	SubjectID     string
+	SubjectName   string
+	ColorHex      string
+	SessionsCount int
+	NetSeconds    int64
+	TotalHoursNet float64
+}
+
+// HeatmapDay is the study activity of one local calendar day
+type HeatmapDay struct {
+	Date          string // YYYY-MM-DD in the requested timezone
+	SessionsCount int
+	TotalSeconds  int64
+}
+
+// DailyTotal is the study done on one subject during one local day
+type DailyTotal struct {
+	Day        time.Time // Local midnight
+	SubjectID  string
+	NetSeconds int64
+	Questions  int64
+	Correct    int64
+}
+
+// TrendQuery selects a trend series
+type TrendQuery struct {
+	Granularity string // day, week or month
+	From        string // Local dates (YYYY-MM-DD), inclusive; default to a window ending today
+	To          string
+	SubjectID   string // Optional filter
+	TopicID     string // Optional filter; sessions have no topic, so the series only has questions
+	Window      int    // Periods in the moving averages; defaults to 7 days, 4 weeks or 3 months
+}
+
+// TrendPoint is one period of a trend series
+type TrendPoint struct {
+	PeriodStart time.Time // Local midnight
+	NetSeconds  int64
+	Questions   int64
+	Correct     int64
+	Accuracy    *float64 // Percentage; nil without questions
+
+	// Trailing averages over the query window, ending at this period
+	NetSecondsMovingAvg float64
+	AccuracyMovingAvg   *float64 // Pooled over the window's questions
+}
+
 type AnalyticsService interface {
-	GetTimeReport(ctx context.Context, startDateFrom, startDateTo string) ([]database.GetTimeReportBySubjectRow, error)
-	GetGlobalAccuracy(ctx context.Context) ([]database.GetAccuracyBySubjectRow, error)
-	GetWeakPoints(ctx context.Context, subjectID string) ([]database.GetAccuracyByTopicRow, error)
-	GetHeatmap(ctx context.Context, daysCount int64) ([]database.GetActivityHeatmapRow, error)
+	// Calendar resolves the calendar analytics are computed in: the profile's
+	// week start and timezone, with tzOverride (an IANA name) taking precedence.
+	Calendar(ctx context.Context, userID, tzOverride string) (Calendar, error)
+	GetTimeReport(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) ([]SubjectTimeReport, error)
+	GetGlobalAccuracy(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) ([]database.GetAccuracyBySubjectRow, error)
+	GetWeakPoints(ctx context.Context, userID, subjectID string) ([]database.GetAccuracyByTopicRow, error)
+	// RankWeakPoints ranks topics across subjects by the Wilson lower bound
+	// of their recency-weighted miss rate, weakest first
+	RankWeakPoints(ctx context.Context, userID string, query WeakPointQuery) ([]WeakPoint, error)
+	GetHeatmap(ctx context.Context, userID string, cal Calendar, daysCount int64) ([]HeatmapDay, error)
+	// GetDailyTotals returns net time and questions per local day and subject
+	// between two local midnights [from, to), ordered by day. Sessions crossing
+	// midnight are split.
+	GetDailyTotals(ctx context.Context, userID string, cal Calendar, from, to time.Time) ([]DailyTotal, error)
+	// GetFocus reports net/gross ratio and pause statistics by subject, time of day and weekday
+	GetFocus(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) (FocusReport, error)
+	// GetProductivity returns net time and accuracy per local hour and weekday
+	GetProductivity(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) (ProductivityProfile, error)
+	// GetStudyBalance compares actual net time per subject with the active cycle and the exam weights
+	GetStudyBalance(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string, tolerance float64) (StudyBalance, error)
+	// GetReadiness projects syllabus coverage, accuracy and risk per subject to the exam date
+	GetReadiness(ctx context.Context, userID string, cal Calendar, examDate string) (ReadinessForecast, error)
+	// Compare compares a period with a baseline, in total and per subject
+	Compare(ctx context.Context, userID string, cal Calendar, query CompareQuery) (Comparison, error)
+	// GetTrends returns a gap-free series of net time, questions and accuracy per period
+	GetTrends(ctx context.Context, userID string, cal Calendar, query TrendQuery) ([]TrendPoint, error)
 }
 
 type AnalyticsManager struct {
@@ -23,25 +114,398 @@ func NewAnalyticsManager(repo repository.AnalyticsRepository) *AnalyticsManager
 	return &AnalyticsManager{repo: repo}
 }
 
-func (s *AnalyticsManager) GetTimeReport(ctx context.Context, startDateFrom, startDateTo string) ([]database.GetTimeReportBySubjectRow, error) {
-	return s.repo.GetTimeReportBySubject(ctx, database.GetTimeReportBySubjectParams{
-		StartDateFrom: startDateFrom,
-		StartDateTo:   startDateTo,
+func (s *AnalyticsManager) Calendar(ctx context.Context, userID, tzOverride string) (Calendar, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	cal := Calendar{Location: time.UTC, WeekStart: time.Weekday(user.WeekStartDay)}
+	if tzOverride != "" {
+		loc, err := time.LoadLocation(tzOverride)
+		if err != nil {
+			return Calendar{}, ErrInvalidTimezone
+		}
+		cal.Location = loc
+		return cal, nil
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		// Profiles are validated on update, so this only happens with stale tz data
+		slog.Warn("Unknown profile timezone, using UTC", "user_id", userID, "timezone", user.Timezone)
+		return cal, nil
+	}
+	cal.Location = loc
+	return cal, nil
+}
+
+// GetTimeReport sums net time per subject between two local dates (inclusive).
+// Sessions crossing a range boundary only count the part inside the range.
+func (s *AnalyticsManager) GetTimeReport(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) ([]SubjectTimeReport, error) {
+	from, to, err := parseDateRange(startDateFrom, startDateTo, cal.Location)
+	if err != nil {
+		return nil, err
+	}
+	return s.timeReport(ctx, userID, from, to)
+}
+
+// timeReport sums net time per subject in [from, to), clipping sessions that
+// cross the bounds, largest first
+func (s *AnalyticsManager) timeReport(ctx context.Context, userID string, from, to time.Time) ([]SubjectTimeReport, error) {
+	sessions, err := s.listSessions(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	bySubject := make(map[string]*SubjectTimeReport)
+	var order []string
+	for _, session := range sessions {
+		report, ok := bySubject[session.SubjectID]
+		if !ok {
+			report = &SubjectTimeReport{
+				SubjectID:   session.SubjectID,
+				SubjectName: session.SubjectName,
+				ColorHex:    session.ColorHex,
+			}
+			bySubject[session.SubjectID] = report
+			order = append(order, session.SubjectID)
+		}
+		report.SessionsCount++
+		report.NetSeconds += shareBetween(session.Start, session.End, session.NetSeconds, from, to)
+	}
+
+	reports := make([]SubjectTimeReport, 0, len(order))
+	for _, id := range order {
+		report := bySubject[id]
+		report.TotalHoursNet = secondsToHours(report.NetSeconds)
+		reports = append(reports, *report)
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].NetSeconds > reports[j].NetSeconds
 	})
+	return reports, nil
+}
+
+// GetGlobalAccuracy reports accuracy per subject for exercises logged between
+// two local dates (inclusive); empty dates leave the range open.
+func (s *AnalyticsManager) GetGlobalAccuracy(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) ([]database.GetAccuracyBySubjectRow, error) {
+	from, to, err := parseDateRange(startDateFrom, startDateTo, cal.Location)
+	if err != nil {
+		return nil, err
+	}
+	return s.accuracyBySubject(ctx, userID, from, to)
 }
 
-func (s *AnalyticsManager) GetGlobalAccuracy(ctx context.Context) ([]database.GetAccuracyBySubjectRow, error) {
-	return s.repo.GetAccuracyBySubject(ctx)
+func (s *AnalyticsManager) accuracyBySubject(ctx context.Context, userID string, from, to time.Time) ([]database.GetAccuracyBySubjectRow, error) {
+	return s.repo.GetAccuracyBySubject(ctx, database.GetAccuracyBySubjectParams{
+		RangeFrom: formatSQLiteTime(from),
+		RangeTo:   formatSQLiteTime(to),
+		UserID:    userID,
+	})
 }
 
-func (s *AnalyticsManager) GetWeakPoints(ctx context.Context, subjectID string) ([]database.GetAccuracyByTopicRow, error) {
-	return s.repo.GetAccuracyByTopic(ctx, subjectID)
+func (s *AnalyticsManager) GetWeakPoints(ctx context.Context, userID, subjectID string) ([]database.GetAccuracyByTopicRow, error) {
+	return s.repo.GetAccuracyByTopic(ctx, subjectID, userID)
 }
 
-func (s *AnalyticsManager) GetHeatmap(ctx context.Context, daysCount int64) ([]database.GetActivityHeatmapRow, error) {
+// GetHeatmap returns the net study time of each of the last daysCount local
+// days (today included) that had any activity, most recent first.
+func (s *AnalyticsManager) GetHeatmap(ctx context.Context, userID string, cal Calendar, daysCount int64) ([]HeatmapDay, error) {
 	// Default to 30 days if 0 or negative
 	if daysCount <= 0 {
 		daysCount = 30
 	}
-	return s.repo.GetActivityHeatmap(ctx, fmt.Sprintf("%d", daysCount))
+
+	to := cal.StartOfDay(time.Now()).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -int(daysCount))
+
+	sessions, err := s.listSessions(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]*HeatmapDay)
+	for _, session := range sessions {
+		for _, slice := range splitByDay(session.Start, session.End, session.NetSeconds, cal.Location) {
+			if slice.Day.Before(from) || !slice.Day.Before(to) {
+				continue
+			}
+			date := slice.Day.Format("2006-01-02")
+			day, ok := byDay[date]
+			if !ok {
+				day = &HeatmapDay{Date: date}
+				byDay[date] = day
+			}
+			day.SessionsCount++
+			day.TotalSeconds += slice.Seconds
+		}
+	}
+
+	heatmap := make([]HeatmapDay, 0, len(byDay))
+	for _, day := range byDay {
+		heatmap = append(heatmap, *day)
+	}
+	sort.Slice(heatmap, func(i, j int) bool {
+		return heatmap[i].Date > heatmap[j].Date
+	})
+	return heatmap, nil
+}
+
+func (s *AnalyticsManager) GetDailyTotals(ctx context.Context, userID string, cal Calendar, from, to time.Time) ([]DailyTotal, error) {
+	sessions, err := s.listSessions(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.repo.ListExerciseLogsInRange(ctx, database.ListExerciseLogsInRangeParams{
+		UserID:    userID,
+		RangeFrom: formatSQLiteTime(from),
+		RangeTo:   formatSQLiteTime(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		day       int64
+		subjectID string
+	}
+	totals := make(map[key]*DailyTotal)
+	get := func(day time.Time, subjectID string) *DailyTotal {
+		k := key{day.Unix(), subjectID}
+		total, ok := totals[k]
+		if !ok {
+			total = &DailyTotal{Day: day, SubjectID: subjectID}
+			totals[k] = total
+		}
+		return total
+	}
+
+	for _, session := range sessions {
+		for _, slice := range splitByDay(session.Start, session.End, session.NetSeconds, cal.Location) {
+			if slice.Day.Before(from) || !slice.Day.Before(to) {
+				continue
+			}
+			get(slice.Day, session.SubjectID).NetSeconds += slice.Seconds
+		}
+	}
+	for _, log := range logs {
+		created, err := parseTimestamp(log.CreatedAt)
+		if err != nil {
+			slog.Warn("Skipping exercise log with unreadable date", "exercise_log_id", log.ID, "error", err)
+			continue
+		}
+		total := get(cal.StartOfDay(created), log.SubjectID)
+		total.Questions += log.QuestionsCount
+		total.Correct += log.CorrectCount
+	}
+
+	result := make([]DailyTotal, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Day.Equal(result[j].Day) {
+			return result[i].Day.Before(result[j].Day)
+		}
+		return result[i].SubjectID < result[j].SubjectID
+	})
+	return result, nil
+}
+
+func (s *AnalyticsManager) GetTrends(ctx context.Context, userID string, cal Calendar, query TrendQuery) ([]TrendPoint, error) {
+	var (
+		periodStart   func(time.Time) time.Time
+		next          func(time.Time) time.Time
+		defaultWindow int
+		defaultLength int
+	)
+	switch query.Granularity {
+	case GranularityDay, "":
+		periodStart = cal.StartOfDay
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		defaultWindow, defaultLength = 7, 30
+	case GranularityWeek:
+		periodStart = cal.StartOfWeek
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+		defaultWindow, defaultLength = 4, 12
+	case GranularityMonth:
+		periodStart = cal.StartOfMonth
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		defaultWindow, defaultLength = 3, 12
+	default:
+		return nil, ErrInvalidGranularity
+	}
+
+	window := query.Window
+	if window <= 0 {
+		window = defaultWindow
+	}
+
+	from, to, err := parseDateRange(query.From, query.To, cal.Location)
+	if err != nil {
+		return nil, err
+	}
+	if query.To == "" {
+		to = cal.StartOfDay(time.Now()).AddDate(0, 0, 1)
+	}
+	if query.From == "" {
+		from = periodStart(to.AddDate(0, 0, -1))
+		for i := 1; i < defaultLength; i++ {
+			from = periodStart(from.AddDate(0, 0, -1))
+		}
+	}
+	from = periodStart(from)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidDateRange)
+	}
+
+	var points []TrendPoint
+	index := make(map[int64]int)
+	for period := from; period.Before(to); period = next(period) {
+		if len(points) == maxTrendPoints {
+			return nil, fmt.Errorf("%w: more than %d periods", ErrInvalidDateRange, maxTrendPoints)
+		}
+		index[period.Unix()] = len(points)
+		points = append(points, TrendPoint{PeriodStart: period})
+	}
+	pointAt := func(day time.Time) *TrendPoint {
+		if i, ok := index[periodStart(day).Unix()]; ok {
+			return &points[i]
+		}
+		return nil
+	}
+
+	// Sessions aren't linked to topics, so a topic series has no study time
+	if query.TopicID == "" {
+		sessions, err := s.listSessions(ctx, userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range sessions {
+			if query.SubjectID != "" && session.SubjectID != query.SubjectID {
+				continue
+			}
+			for _, slice := range splitByDay(session.Start, session.End, session.NetSeconds, cal.Location) {
+				if slice.Day.Before(from) || !slice.Day.Before(to) {
+					continue
+				}
+				if point := pointAt(slice.Day); point != nil {
+					point.NetSeconds += slice.Seconds
+				}
+			}
+		}
+	}
+
+	logs, err := s.repo.ListExerciseLogsInRange(ctx, database.ListExerciseLogsInRangeParams{
+		UserID:    userID,
+		RangeFrom: formatSQLiteTime(from),
+		RangeTo:   formatSQLiteTime(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		if query.SubjectID != "" && log.SubjectID != query.SubjectID {
+			continue
+		}
+		if query.TopicID != "" && log.TopicID.String != query.TopicID {
+			continue
+		}
+		created, err := parseTimestamp(log.CreatedAt)
+		if err != nil {
+			slog.Warn("Skipping exercise log with unreadable date", "exercise_log_id", log.ID, "error", err)
+			continue
+		}
+		if point := pointAt(cal.StartOfDay(created)); point != nil {
+			point.Questions += log.QuestionsCount
+			point.Correct += log.CorrectCount
+		}
+	}
+
+	// Trailing moving averages; the first periods average over what's available
+	var windowSeconds, windowQuestions, windowCorrect int64
+	for i := range points {
+		point := &points[i]
+		point.Accuracy = accuracyPercentage(point.Correct, point.Questions)
+
+		windowSeconds += point.NetSeconds
+		windowQuestions += point.Questions
+		windowCorrect += point.Correct
+		if i >= window {
+			old := points[i-window]
+			windowSeconds -= old.NetSeconds
+			windowQuestions -= old.Questions
+			windowCorrect -= old.Correct
+		}
+		point.NetSecondsMovingAvg = float64(windowSeconds) / float64(min(i+1, window))
+		point.AccuracyMovingAvg = accuracyPercentage(windowCorrect, windowQuestions)
+	}
+	return points, nil
+}
+
+// accuracyPercentage rounds like the SQL reports do; nil without questions
+func accuracyPercentage(correct, questions int64) *float64 {
+	if questions == 0 {
+		return nil
+	}
+	accuracy := math.Round(float64(correct)*100/float64(questions)*100) / 100
+	return &accuracy
+}
+
+// timedSession is a finished session with parsed timestamps
+type timedSession struct {
+	ID           string
+	SubjectID    string
+	SubjectName  string
+	ColorHex     string
+	Start        time.Time
+	End          time.Time
+	GrossSeconds int64 // As recorded, or the wall-clock span when missing
+	NetSeconds   int64
+}
+
+// listSessions loads the user's finished sessions overlapping [from, to)
+func (s *AnalyticsManager) listSessions(ctx context.Context, userID string, from, to time.Time) ([]timedSession, error) {
+	rows, err := s.repo.ListFinishedSessionsInRange(ctx, database.ListFinishedSessionsInRangeParams{
+		UserID:    userID,
+		RangeFrom: formatSQLiteTime(from),
+		RangeTo:   formatSQLiteTime(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]timedSession, 0, len(rows))
+	for _, row := range rows {
+		start, err := parseTimestamp(row.StartedAt)
+		if err != nil {
+			slog.Warn("Skipping session with unreadable start", "session_id", row.ID, "error", err)
+			continue
+		}
+		end, err := parseTimestamp(row.FinishedAt.String)
+		if err != nil {
+			slog.Warn("Skipping session with unreadable finish", "session_id", row.ID, "error", err)
+			continue
+		}
+		gross := row.GrossDurationSeconds
+		if gross <= 0 {
+			gross = int64(end.Sub(start).Seconds())
+		}
+		sessions = append(sessions, timedSession{
+			ID:           row.ID,
+			SubjectID:    row.SubjectID,
+			SubjectName:  row.SubjectName,
+			ColorHex:     row.ColorHex.String,
+			Start:        start,
+			End:          end,
+			GrossSeconds: gross,
+			NetSeconds:   row.NetDurationSeconds,
+		})
+	}
+	return sessions, nil
+}
+
+func secondsToHours(seconds int64) float64 {
+	return math.Round(float64(seconds)/3600*100) / 100
 }
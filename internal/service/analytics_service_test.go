@@ -0,0 +1,709 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAnalyticsRepository is a mock implementation of repository.AnalyticsRepository
+type MockAnalyticsRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepository) ListFinishedSessionsInRange(ctx context.Context, arg database.ListFinishedSessionsInRangeParams) ([]database.ListFinishedSessionsInRangeRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.ListFinishedSessionsInRangeRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ListSessionPausesInRange(ctx context.Context, arg database.ListSessionPausesInRangeParams) ([]database.ListSessionPausesInRangeRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.ListSessionPausesInRangeRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ListExerciseLogsInRange(ctx context.Context, arg database.ListExerciseLogsInRangeParams) ([]database.ListExerciseLogsInRangeRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.ListExerciseLogsInRangeRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetAccuracyBySubject(ctx context.Context, arg database.GetAccuracyBySubjectParams) ([]database.GetAccuracyBySubjectRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetAccuracyBySubjectRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetAccuracyByTopic(ctx context.Context, subjectID, userID string) ([]database.GetAccuracyByTopicRow, error) {
+	args := m.Called(ctx, subjectID, userID)
+	return args.Get(0).([]database.GetAccuracyByTopicRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ListTopicExerciseLogs(ctx context.Context, userID string) ([]database.ListTopicExerciseLogsRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListTopicExerciseLogsRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ListTopicProgress(ctx context.Context, userID string) ([]database.ListTopicProgressRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListTopicProgressRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ListActiveCyclePlan(ctx context.Context, userID string) ([]database.ListActiveCyclePlanRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListActiveCyclePlanRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ListSubjects(ctx context.Context, userID string) ([]database.Subject, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.Subject), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func sessionRow(id, subjectID, startedAt, finishedAt string, netSeconds int64) database.ListFinishedSessionsInRangeRow {
+	return database.ListFinishedSessionsInRangeRow{
+		ID:                 id,
+		SubjectID:          subjectID,
+		SubjectName:        "Math",
+		StartedAt:          startedAt,
+		FinishedAt:         sql.NullString{String: finishedAt, Valid: true},
+		NetDurationSeconds: netSeconds,
+	}
+}
+
+func TestAnalyticsManager_Calendar(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetUserByID", ctx, "user-1").Return(database.User{
+		ID:           "user-1",
+		Timezone:     "America/Sao_Paulo",
+		WeekStartDay: 0,
+	}, nil)
+
+	t.Run("Profile settings", func(t *testing.T) {
+		cal, err := svc.Calendar(ctx, "user-1", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "America/Sao_Paulo", cal.Location.String())
+		assert.Equal(t, time.Sunday, cal.WeekStart)
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		cal, err := svc.Calendar(ctx, "user-1", "Europe/Lisbon")
+		assert.NoError(t, err)
+		assert.Equal(t, "Europe/Lisbon", cal.Location.String())
+		assert.Equal(t, time.Sunday, cal.WeekStart)
+	})
+
+	t.Run("Invalid override", func(t *testing.T) {
+		_, err := svc.Calendar(ctx, "user-1", "Mars/Olympus")
+		assert.ErrorIs(t, err, service.ErrInvalidTimezone)
+	})
+}
+
+func TestAnalyticsManager_GetTimeReport(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	cal := service.Calendar{Location: loc, WeekStart: time.Monday}
+
+	// 2024-03-10 in São Paulo (UTC-3) is [03:00Z, next day 03:00Z)
+	mockRepo.On("ListFinishedSessionsInRange", ctx, database.ListFinishedSessionsInRangeParams{
+		UserID:    "user-1",
+		RangeFrom: "2024-03-10 03:00:00",
+		RangeTo:   "2024-03-11 03:00:00",
+	}).Return([]database.ListFinishedSessionsInRangeRow{
+		// 22:00-02:00 local: only the two hours before midnight are in range
+		sessionRow("s1", "math", "2024-03-11T01:00:00Z", "2024-03-11 05:00:00", 4*3600),
+		sessionRow("s2", "math", "2024-03-10T12:00:00Z", "2024-03-10T13:00:00Z", 3600),
+	}, nil)
+
+	report, err := svc.GetTimeReport(ctx, "user-1", cal, "2024-03-10", "2024-03-10")
+
+	assert.NoError(t, err)
+	assert.Len(t, report, 1)
+	assert.Equal(t, 2, report[0].SessionsCount)
+	assert.Equal(t, int64(3*3600), report[0].NetSeconds)
+	assert.Equal(t, 3.0, report[0].TotalHoursNet)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalyticsManager_GetTimeReport_InvalidRange(t *testing.T) {
+	svc := service.NewAnalyticsManager(new(MockAnalyticsRepository))
+
+	cal := service.Calendar{Location: time.UTC, WeekStart: time.Monday}
+
+	_, err := svc.GetTimeReport(context.Background(), "user-1", cal, "10/03/2024", "")
+	assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+
+	_, err = svc.GetTimeReport(context.Background(), "user-1", cal, "2024-03-11", "2024-03-10")
+	assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+}
+
+func TestAnalyticsManager_GetHeatmap_SplitsAtLocalMidnight(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+
+	// A 22:00-02:00 session ending in the early hours of today (local time)
+	today := time.Now().In(loc)
+	midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	start := midnight.Add(-2 * time.Hour)
+	end := midnight.Add(2 * time.Hour)
+
+	mockRepo.On("ListFinishedSessionsInRange", ctx, mock.MatchedBy(func(arg database.ListFinishedSessionsInRangeParams) bool {
+		return arg.UserID == "user-1"
+	})).Return([]database.ListFinishedSessionsInRangeRow{
+		sessionRow("s1", "math", start.Format(time.RFC3339), end.UTC().Format("2006-01-02 15:04:05"), 3*3600),
+	}, nil)
+
+	heatmap, err := svc.GetHeatmap(ctx, "user-1", service.Calendar{Location: loc}, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []service.HeatmapDay{
+		{Date: midnight.Format("2006-01-02"), SessionsCount: 1, TotalSeconds: 5400},
+		{Date: start.Format("2006-01-02"), SessionsCount: 1, TotalSeconds: 5400},
+	}, heatmap)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalyticsManager_GetDailyTotals(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	cal := service.Calendar{Location: loc, WeekStart: time.Monday}
+
+	from := time.Date(2024, 3, 10, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 2)
+	params := database.ListFinishedSessionsInRangeParams{
+		UserID:    "user-1",
+		RangeFrom: "2024-03-10 03:00:00",
+		RangeTo:   "2024-03-12 03:00:00",
+	}
+
+	mockRepo.On("ListFinishedSessionsInRange", ctx, params).Return([]database.ListFinishedSessionsInRangeRow{
+		// 23:00-01:00 local
+		sessionRow("s1", "math", "2024-03-11T02:00:00Z", "2024-03-11T04:00:00Z", 7200),
+	}, nil)
+	mockRepo.On("ListExerciseLogsInRange", ctx, database.ListExerciseLogsInRangeParams(params)).Return([]database.ListExerciseLogsInRangeRow{
+		// 23:30 local on the 10th, stored in UTC
+		{ID: "l1", SubjectID: "math", QuestionsCount: 20, CorrectCount: 15, CreatedAt: "2024-03-11 02:30:00"},
+	}, nil)
+
+	totals, err := svc.GetDailyTotals(ctx, "user-1", cal, from, to)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []service.DailyTotal{
+		{Day: from, SubjectID: "math", NetSeconds: 3600, Questions: 20, Correct: 15},
+		{Day: from.AddDate(0, 0, 1), SubjectID: "math", NetSeconds: 3600},
+	}, totals)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalyticsManager_GetTrends(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	cal := service.Calendar{Location: loc, WeekStart: time.Monday}
+
+	params := database.ListFinishedSessionsInRangeParams{
+		UserID:    "user-1",
+		RangeFrom: "2024-03-10 03:00:00",
+		RangeTo:   "2024-03-13 03:00:00",
+	}
+	mockRepo.On("ListFinishedSessionsInRange", ctx, params).Return([]database.ListFinishedSessionsInRangeRow{
+		sessionRow("s1", "math", "2024-03-10T12:00:00Z", "2024-03-10T14:00:00Z", 7200),
+		sessionRow("s2", "bio", "2024-03-12T12:00:00Z", "2024-03-12T13:00:00Z", 3600),
+		sessionRow("s3", "math", "2024-03-12T15:00:00Z", "2024-03-12T16:00:00Z", 3600),
+	}, nil)
+	mockRepo.On("ListExerciseLogsInRange", ctx, database.ListExerciseLogsInRangeParams(params)).Return([]database.ListExerciseLogsInRangeRow{
+		{ID: "l1", SubjectID: "math", TopicID: sql.NullString{String: "algebra", Valid: true}, QuestionsCount: 10, CorrectCount: 5, CreatedAt: "2024-03-10 15:00:00"},
+		{ID: "l2", SubjectID: "math", QuestionsCount: 10, CorrectCount: 10, CreatedAt: "2024-03-12 15:00:00"},
+		{ID: "l3", SubjectID: "bio", QuestionsCount: 10, CorrectCount: 0, CreatedAt: "2024-03-12 15:00:00"},
+	}, nil)
+
+	t.Run("Subject filter fills gaps and averages", func(t *testing.T) {
+		points, err := svc.GetTrends(ctx, "user-1", cal, service.TrendQuery{
+			From: "2024-03-10", To: "2024-03-12", SubjectID: "math", Window: 2,
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, points, 3)
+		assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, loc), points[1].PeriodStart)
+
+		assert.Equal(t, int64(7200), points[0].NetSeconds)
+		assert.Equal(t, 50.0, *points[0].Accuracy)
+		assert.Equal(t, 7200.0, points[0].NetSecondsMovingAvg)
+
+		assert.Equal(t, int64(0), points[1].NetSeconds)
+		assert.Nil(t, points[1].Accuracy)
+		assert.Equal(t, 3600.0, points[1].NetSecondsMovingAvg)
+		assert.Equal(t, 50.0, *points[1].AccuracyMovingAvg)
+
+		assert.Equal(t, int64(3600), points[2].NetSeconds)
+		assert.Equal(t, 100.0, *points[2].Accuracy)
+		assert.Equal(t, 1800.0, points[2].NetSecondsMovingAvg)
+		assert.Equal(t, 100.0, *points[2].AccuracyMovingAvg)
+	})
+
+	t.Run("Weekly buckets", func(t *testing.T) {
+		weekParams := database.ListFinishedSessionsInRangeParams{
+			UserID:    "user-1",
+			RangeFrom: "2024-03-11 03:00:00",
+			RangeTo:   "2024-03-13 03:00:00",
+		}
+		mockRepo.On("ListFinishedSessionsInRange", ctx, weekParams).Return([]database.ListFinishedSessionsInRangeRow{
+			sessionRow("s2", "bio", "2024-03-12T12:00:00Z", "2024-03-12T13:00:00Z", 3600),
+			sessionRow("s3", "math", "2024-03-12T15:00:00Z", "2024-03-12T16:00:00Z", 3600),
+		}, nil)
+		mockRepo.On("ListExerciseLogsInRange", ctx, database.ListExerciseLogsInRangeParams(weekParams)).Return([]database.ListExerciseLogsInRangeRow{
+			{ID: "l2", SubjectID: "math", QuestionsCount: 10, CorrectCount: 10, CreatedAt: "2024-03-12 15:00:00"},
+			{ID: "l3", SubjectID: "bio", QuestionsCount: 10, CorrectCount: 0, CreatedAt: "2024-03-12 15:00:00"},
+		}, nil)
+
+		points, err := svc.GetTrends(ctx, "user-1", cal, service.TrendQuery{
+			Granularity: service.GranularityWeek, From: "2024-03-11", To: "2024-03-12",
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, points, 1)
+		assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, loc), points[0].PeriodStart)
+		assert.Equal(t, int64(7200), points[0].NetSeconds)
+		assert.Equal(t, int64(20), points[0].Questions)
+		assert.Equal(t, 50.0, *points[0].Accuracy)
+	})
+
+	t.Run("Invalid granularity", func(t *testing.T) {
+		_, err := svc.GetTrends(ctx, "user-1", cal, service.TrendQuery{Granularity: "year"})
+		assert.ErrorIs(t, err, service.ErrInvalidGranularity)
+	})
+}
+
+func TestAnalyticsManager_RankWeakPoints(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	ago := func(days int) string { return now.AddDate(0, 0, -days).Format("2006-01-02 15:04:05") }
+	logRow := func(id, topicID, subjectID string, questions, correct int64, createdAt string) database.ListTopicExerciseLogsRow {
+		return database.ListTopicExerciseLogsRow{
+			ID: id, TopicID: topicID, TopicName: topicID, SubjectID: subjectID, SubjectName: subjectID,
+			QuestionsCount: questions, CorrectCount: correct, CreatedAt: createdAt,
+		}
+	}
+
+	mockRepo.On("ListTopicExerciseLogs", ctx, "user-1").Return([]database.ListTopicExerciseLogsRow{
+		logRow("l1", "lucky", "math", 3, 1, ago(0)),
+		logRow("l2", "struggling", "bio", 100, 40, ago(0)),
+		// Poor long ago, solid lately
+		logRow("l3", "recovered", "math", 50, 10, ago(180)),
+		logRow("l4", "recovered", "math", 50, 45, ago(1)),
+		logRow("l5", "untested", "bio", 5, 0, ago(0)),
+	}, nil)
+
+	t.Run("Ranks by confidence, not raw accuracy", func(t *testing.T) {
+		points, err := svc.RankWeakPoints(ctx, "user-1", service.WeakPointQuery{MinQuestions: 3})
+
+		assert.NoError(t, err)
+		var topics []string
+		for _, p := range points {
+			topics = append(topics, p.TopicID)
+		}
+		// 1/3 correct is weaker on paper than 40/100, but far less certain
+		assert.Equal(t, []string{"untested", "struggling", "lucky", "recovered"}, topics)
+		assert.Equal(t, 40.0, points[1].Accuracy)
+		assert.InDelta(t, 50.2, points[1].Score, 0.1)
+		assert.Equal(t, 55.0, points[3].Accuracy)
+		assert.Greater(t, points[3].WeightedAccuracy, 85.0)
+	})
+
+	t.Run("Minimum sample and subject filter", func(t *testing.T) {
+		points, err := svc.RankWeakPoints(ctx, "user-1", service.WeakPointQuery{SubjectID: "bio"})
+
+		assert.NoError(t, err)
+		assert.Len(t, points, 1)
+		assert.Equal(t, "struggling", points[0].TopicID)
+		assert.Equal(t, "bio", points[0].SubjectID)
+	})
+}
+
+func TestAnalyticsManager_GetFocus(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	cal := service.Calendar{Location: loc, WeekStart: time.Monday}
+
+	params := database.ListFinishedSessionsInRangeParams{
+		UserID:    "user-1",
+		RangeFrom: "2024-03-11 03:00:00",
+		RangeTo:   "2024-03-12 03:00:00",
+	}
+	morning := sessionRow("s1", "math", "2024-03-11T11:00:00Z", "2024-03-11T13:00:00Z", 6000) // 08:00 local
+	morning.GrossDurationSeconds = 7200
+	// 22:00 local, no recorded gross time
+	evening := sessionRow("s2", "math", "2024-03-12T01:00:00Z", "2024-03-12T02:00:00Z", 3600)
+	mockRepo.On("ListFinishedSessionsInRange", ctx, params).Return([]database.ListFinishedSessionsInRangeRow{morning, evening}, nil)
+	mockRepo.On("ListSessionPausesInRange", ctx, database.ListSessionPausesInRangeParams(params)).Return([]database.ListSessionPausesInRangeRow{
+		{ID: "p1", SessionID: "s1", StartedAt: "2024-03-11T11:30:00Z", EndedAt: sql.NullString{String: "2024-03-11 11:30:30", Valid: true}},
+		{ID: "p2", SessionID: "s1", StartedAt: "2024-03-11T12:00:00Z", EndedAt: sql.NullString{String: "2024-03-11T12:20:00Z", Valid: true}},
+	}, nil)
+
+	report, err := svc.GetFocus(ctx, "user-1", cal, "2024-03-11", "2024-03-11")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Overall.Sessions)
+	assert.Equal(t, int64(10800), report.Overall.GrossSeconds)
+	assert.Equal(t, 0.8889, *report.Overall.FocusRatio)
+	assert.Equal(t, 2, report.Overall.Pauses)
+	assert.Equal(t, 0.67, *report.Overall.PausesPerHour)
+	assert.Equal(t, 615.0, report.Overall.AvgPauseSeconds)
+	assert.Equal(t, int64(1200), report.Overall.LongestPauseSeconds)
+	assert.Equal(t, []int{1, 0, 0, 1, 0}, []int{
+		report.Overall.PauseLengths[0].Count, report.Overall.PauseLengths[1].Count, report.Overall.PauseLengths[2].Count,
+		report.Overall.PauseLengths[3].Count, report.Overall.PauseLengths[4].Count,
+	})
+
+	assert.Len(t, report.BySubject, 1)
+	assert.Equal(t, "morning", report.ByTimeOfDay[1].Name)
+	assert.Equal(t, 1, report.ByTimeOfDay[1].Sessions)
+	assert.Equal(t, 2, report.ByTimeOfDay[1].Pauses)
+	assert.Equal(t, 1.0, *report.ByTimeOfDay[3].FocusRatio)
+	assert.Nil(t, report.ByTimeOfDay[0].FocusRatio)
+
+	assert.Len(t, report.ByWeekday, 7)
+	assert.Equal(t, time.Monday, report.ByWeekday[0].Weekday)
+	assert.Equal(t, 2, report.ByWeekday[0].Sessions)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalyticsManager_GetProductivity(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	cal := service.Calendar{Location: loc, WeekStart: time.Monday}
+
+	params := database.ListFinishedSessionsInRangeParams{
+		UserID:    "user-1",
+		RangeFrom: "2024-03-11 03:00:00",
+		RangeTo:   "2024-03-12 03:00:00",
+	}
+	// Monday 09:30-11:30 local with a pause from 10:00 to 10:30
+	mockRepo.On("ListFinishedSessionsInRange", ctx, params).Return([]database.ListFinishedSessionsInRangeRow{
+		sessionRow("s1", "math", "2024-03-11T12:30:00Z", "2024-03-11T14:30:00Z", 5400),
+	}, nil)
+	mockRepo.On("ListSessionPausesInRange", ctx, database.ListSessionPausesInRangeParams(params)).Return([]database.ListSessionPausesInRangeRow{
+		{ID: "p1", SessionID: "s1", StartedAt: "2024-03-11T13:00:00Z", EndedAt: sql.NullString{String: "2024-03-11T13:30:00Z", Valid: true}},
+	}, nil)
+	mockRepo.On("ListExerciseLogsInRange", ctx, database.ListExerciseLogsInRangeParams(params)).Return([]database.ListExerciseLogsInRangeRow{
+		{ID: "l1", SubjectID: "math", QuestionsCount: 10, CorrectCount: 7, CreatedAt: "2024-03-11 14:15:00"},
+	}, nil)
+
+	profile, err := svc.GetProductivity(ctx, "user-1", cal, "2024-03-11", "2024-03-11")
+
+	assert.NoError(t, err)
+	assert.Len(t, profile.Weekdays, 7)
+	monday := profile.Weekdays[0]
+	assert.Equal(t, time.Monday, monday.Weekday)
+	assert.Equal(t, int64(5400), monday.NetSeconds)
+	assert.Equal(t, int64(1800), monday.Hours[9].NetSeconds)
+	assert.Equal(t, int64(1800), monday.Hours[10].NetSeconds)
+	assert.Equal(t, int64(1800), monday.Hours[11].NetSeconds)
+	assert.Equal(t, int64(10), monday.Hours[11].Questions)
+	assert.Equal(t, 70.0, *monday.Hours[11].Accuracy)
+	assert.Nil(t, monday.Hours[9].Accuracy)
+	assert.Equal(t, int64(1800), profile.Hours[10].NetSeconds)
+	assert.Equal(t, int64(0), profile.Weekdays[1].NetSeconds)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalyticsManager_GetProductivity_DaylightSaving(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	cal := service.Calendar{Location: loc, WeekStart: time.Sunday}
+
+	tests := []struct {
+		name       string
+		day        string
+		params     database.ListFinishedSessionsInRangeParams
+		session    database.ListFinishedSessionsInRangeRow
+		hours      map[int]int64
+		netSeconds int64
+	}{
+		{
+			// 01:00 EDT to 01:00 EST: the same local hour twice
+			name: "Fall back",
+			day:  "2024-11-03",
+			params: database.ListFinishedSessionsInRangeParams{
+				UserID: "user-1", RangeFrom: "2024-11-03 04:00:00", RangeTo: "2024-11-04 05:00:00",
+			},
+			session:    sessionRow("s1", "math", "2024-11-03T05:00:00Z", "2024-11-03T07:00:00Z", 7200),
+			hours:      map[int]int64{1: 7200},
+			netSeconds: 7200,
+		},
+		{
+			// 01:30 EST to 03:30 EDT: one hour of study, 02:00 never happens
+			name: "Spring forward",
+			day:  "2024-03-10",
+			params: database.ListFinishedSessionsInRangeParams{
+				UserID: "user-1", RangeFrom: "2024-03-10 05:00:00", RangeTo: "2024-03-11 04:00:00",
+			},
+			session:    sessionRow("s1", "math", "2024-03-10T06:30:00Z", "2024-03-10T07:30:00Z", 3600),
+			hours:      map[int]int64{1: 1800, 3: 1800},
+			netSeconds: 3600,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAnalyticsRepository)
+			svc := service.NewAnalyticsManager(mockRepo)
+			ctx := context.Background()
+
+			mockRepo.On("ListFinishedSessionsInRange", ctx, tt.params).Return([]database.ListFinishedSessionsInRangeRow{tt.session}, nil)
+			mockRepo.On("ListSessionPausesInRange", ctx, database.ListSessionPausesInRangeParams(tt.params)).Return([]database.ListSessionPausesInRangeRow{}, nil)
+			mockRepo.On("ListExerciseLogsInRange", ctx, database.ListExerciseLogsInRangeParams(tt.params)).Return([]database.ListExerciseLogsInRangeRow{}, nil)
+
+			profile, err := svc.GetProductivity(ctx, "user-1", cal, tt.day, tt.day)
+
+			assert.NoError(t, err)
+			sunday := profile.Weekdays[0]
+			assert.Equal(t, time.Sunday, sunday.Weekday)
+			assert.Equal(t, tt.netSeconds, sunday.NetSeconds)
+			for hour, seconds := range tt.hours {
+				assert.Equal(t, seconds, sunday.Hours[hour].NetSeconds, "hour %d", hour)
+			}
+		})
+	}
+}
+
+func TestAnalyticsManager_GetStudyBalance(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	cal := service.Calendar{Location: time.UTC, WeekStart: time.Monday}
+
+	mockRepo.On("ListFinishedSessionsInRange", ctx, mock.Anything).Return([]database.ListFinishedSessionsInRangeRow{
+		sessionRow("s1", "math", "2024-03-10T08:00:00Z", "2024-03-10T14:00:00Z", 6*3600),
+		sessionRow("s2", "bio", "2024-03-11T08:00:00Z", "2024-03-11T10:00:00Z", 2*3600),
+		sessionRow("s3", "art", "2024-03-12T08:00:00Z", "2024-03-12T10:00:00Z", 2*3600),
+	}, nil)
+	mockRepo.On("ListActiveCyclePlan", ctx, "user-1").Return([]database.ListActiveCyclePlanRow{
+		{CycleID: "c1", CycleName: "Main", SubjectID: "math", ItemsCount: 2, TimedItemsCount: 2, PlannedMinutes: 60},
+		// No planned duration: counts as the average item (30 minutes)
+		{CycleID: "c1", CycleName: "Main", SubjectID: "bio", ItemsCount: 2, TimedItemsCount: 0},
+		{CycleID: "c0", CycleName: "Old", SubjectID: "art", ItemsCount: 3, TimedItemsCount: 3, PlannedMinutes: 600},
+	}, nil)
+	mockRepo.On("ListSubjects", ctx, "user-1").Return([]database.Subject{
+		{ID: "art", Name: "Art"},
+		{ID: "bio", Name: "Bio", ExamWeight: sql.NullFloat64{Float64: 3, Valid: true}},
+		{ID: "math", Name: "Math", ExamWeight: sql.NullFloat64{Float64: 1, Valid: true}},
+	}, nil)
+
+	balance, err := svc.GetStudyBalance(ctx, "user-1", cal, "2024-03-10", "2024-03-12", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", balance.CycleID)
+	assert.Equal(t, int64(10*3600), balance.TotalNetSeconds)
+	assert.Len(t, balance.Subjects, 3)
+
+	// Ordered by absolute plan deficit: bio (3h short), art (2h off-plan), math (1h over)
+	bio, art, maths := balance.Subjects[0], balance.Subjects[1], balance.Subjects[2]
+	assert.Equal(t, "bio", bio.SubjectID)
+	assert.Equal(t, 50.0, bio.Plan.Share)
+	assert.Equal(t, int64(3*3600), bio.Plan.DeficitSeconds)
+	assert.Equal(t, service.BalanceUnder, bio.Plan.Status)
+	// Exam time only counts weighted subjects: 8h, of which bio should get 75%
+	assert.Equal(t, 75.0, bio.Exam.Share)
+	assert.Equal(t, int64(4*3600), bio.Exam.DeficitSeconds)
+
+	assert.Equal(t, "art", art.SubjectID)
+	assert.Equal(t, service.BalanceUnexpected, art.Plan.Status)
+	assert.Equal(t, int64(-2*3600), art.Plan.DeficitSeconds)
+	assert.Nil(t, art.Exam)
+
+	assert.Equal(t, "math", maths.SubjectID)
+	assert.Equal(t, 60.0, maths.ActualShare)
+	assert.Equal(t, int64(-3600), maths.Plan.DeficitSeconds)
+	assert.Equal(t, service.BalanceOnTrack, maths.Plan.Status)
+	assert.Equal(t, service.BalanceOver, maths.Exam.Status)
+
+	_, err = svc.GetStudyBalance(ctx, "user-1", cal, "", "", 1.5)
+	assert.ErrorIs(t, err, service.ErrInvalidTolerance)
+}
+
+func TestAnalyticsManager_Compare(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	cal := service.Calendar{Location: time.UTC, WeekStart: time.Monday}
+
+	march := database.ListFinishedSessionsInRangeParams{UserID: "user-1", RangeFrom: "2024-03-01 00:00:00", RangeTo: "2024-04-01 00:00:00"}
+	february := database.ListFinishedSessionsInRangeParams{UserID: "user-1", RangeFrom: "2024-02-01 00:00:00", RangeTo: "2024-03-01 00:00:00"}
+
+	mockRepo.On("ListFinishedSessionsInRange", ctx, march).Return([]database.ListFinishedSessionsInRangeRow{
+		sessionRow("s1", "math", "2024-03-05T08:00:00Z", "2024-03-05T11:00:00Z", 3*3600),
+		sessionRow("s2", "math", "2024-03-06T08:00:00Z", "2024-03-06T09:00:00Z", 3600),
+	}, nil)
+	mockRepo.On("ListFinishedSessionsInRange", ctx, february).Return([]database.ListFinishedSessionsInRangeRow{
+		sessionRow("s0", "math", "2024-02-05T08:00:00Z", "2024-02-05T09:00:00Z", 3600),
+	}, nil)
+	mockRepo.On("GetAccuracyBySubject", ctx, database.GetAccuracyBySubjectParams{RangeFrom: march.RangeFrom, RangeTo: march.RangeTo, UserID: "user-1"}).Return([]database.GetAccuracyBySubjectRow{
+		{SubjectID: "math", SubjectName: "Math", TotalQuestions: sql.NullFloat64{Float64: 20, Valid: true}, TotalCorrect: sql.NullFloat64{Float64: 18, Valid: true}},
+		{SubjectID: "bio", SubjectName: "Bio", TotalQuestions: sql.NullFloat64{Float64: 10, Valid: true}, TotalCorrect: sql.NullFloat64{Float64: 5, Valid: true}},
+	}, nil)
+	mockRepo.On("GetAccuracyBySubject", ctx, database.GetAccuracyBySubjectParams{RangeFrom: february.RangeFrom, RangeTo: february.RangeTo, UserID: "user-1"}).Return([]database.GetAccuracyBySubjectRow{
+		{SubjectID: "math", SubjectName: "Math", TotalQuestions: sql.NullFloat64{Float64: 10, Valid: true}, TotalCorrect: sql.NullFloat64{Float64: 6, Valid: true}},
+	}, nil)
+
+	t.Run("Previous month", func(t *testing.T) {
+		comparison, err := svc.Compare(ctx, "user-1", cal, service.CompareQuery{From: "2024-03-01", To: "2024-03-31"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), comparison.BaselineFrom)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), comparison.BaselineTo)
+
+		assert.Equal(t, int64(3*3600), comparison.Total.Delta.NetSeconds)
+		assert.Equal(t, 1, comparison.Total.Delta.Sessions)
+		assert.Equal(t, int64(20), comparison.Total.Delta.Questions)
+		// 23/30 against 6/10
+		assert.Equal(t, 16.67, *comparison.Total.Delta.Accuracy)
+
+		assert.Len(t, comparison.Subjects, 2)
+		maths, bio := comparison.Subjects[0], comparison.Subjects[1]
+		assert.Equal(t, "math", maths.SubjectID)
+		assert.Equal(t, 30.0, *maths.Delta.Accuracy)
+		assert.Equal(t, "bio", bio.SubjectID)
+		assert.Nil(t, bio.Delta.Accuracy)
+		assert.Equal(t, int64(10), bio.Delta.Questions)
+	})
+
+	t.Run("Baseline validation", func(t *testing.T) {
+		_, err := svc.Compare(ctx, "user-1", cal, service.CompareQuery{From: "2024-03-01", To: "2024-03-31", Baseline: service.BaselineCustom})
+		assert.ErrorIs(t, err, service.ErrInvalidBaseline)
+
+		_, err = svc.Compare(ctx, "user-1", cal, service.CompareQuery{From: "2024-03-01"})
+		assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+	})
+}
+
+func TestAnalyticsManager_GetReadiness(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	svc := service.NewAnalyticsManager(mockRepo)
+	ctx := context.Background()
+	cal := service.Calendar{Location: time.UTC, WeekStart: time.Monday}
+
+	today := cal.StartOfDay(time.Now())
+	day := func(offset int) time.Time { return today.AddDate(0, 0, offset) }
+	sqlTime := func(t time.Time) string { return t.Format("2006-01-02 15:04:05") }
+	accuracy := func(subjectID string, questions, correct float64) database.GetAccuracyBySubjectRow {
+		return database.GetAccuracyBySubjectRow{
+			SubjectID:      subjectID,
+			TotalQuestions: sql.NullFloat64{Float64: questions, Valid: true},
+			TotalCorrect:   sql.NullFloat64{Float64: correct, Valid: true},
+		}
+	}
+	topic := func(id, subjectID string, first, last time.Time) database.ListTopicProgressRow {
+		row := database.ListTopicProgressRow{TopicID: id, SubjectID: subjectID}
+		if !first.IsZero() {
+			row.FirstPracticedAt, row.LastPracticedAt = sqlTime(first), sqlTime(last)
+		}
+		return row
+	}
+
+	mockRepo.On("GetUserByID", ctx, "user-1").Return(database.User{ID: "user-1"}, nil)
+	mockRepo.On("ListSubjects", ctx, "user-1").Return([]database.Subject{
+		{ID: "art", Name: "Art"},
+		{ID: "bio", Name: "Bio"},
+		{ID: "math", Name: "Math"},
+	}, nil)
+	mockRepo.On("ListFinishedSessionsInRange", ctx, mock.Anything).Return([]database.ListFinishedSessionsInRangeRow{
+		sessionRow("s1", "math", day(-1).Add(8*time.Hour).Format(time.RFC3339), day(-1).Add(22*time.Hour).Format(time.RFC3339), 14*3600),
+	}, nil)
+	mockRepo.On("GetAccuracyBySubject", ctx, database.GetAccuracyBySubjectParams{RangeFrom: sqlTime(day(-13)), RangeTo: sqlTime(day(1)), UserID: "user-1"}).Return([]database.GetAccuracyBySubjectRow{
+		accuracy("math", 40, 30),
+		accuracy("art", 10, 4),
+	}, nil)
+	mockRepo.On("GetAccuracyBySubject", ctx, database.GetAccuracyBySubjectParams{RangeFrom: sqlTime(day(-27)), RangeTo: sqlTime(day(-13)), UserID: "user-1"}).Return([]database.GetAccuracyBySubjectRow{
+		accuracy("math", 40, 26),
+	}, nil)
+
+	topics := []database.ListTopicProgressRow{
+		topic("m0", "math", day(-60), day(-60)),
+		topic("m1", "math", day(-50), day(-50)),
+		topic("m2", "math", day(-40), day(-20)),
+		topic("m3", "math", day(-30), day(-3)),
+		topic("m4", "math", day(-5), day(-5)),
+		topic("m5", "math", day(-2), day(-1)),
+	}
+	for _, id := range []string{"m6", "m7", "m8", "m9"} {
+		topics = append(topics, topic(id, "math", time.Time{}, time.Time{}))
+	}
+	topics = append(topics,
+		topic("b0", "bio", day(-90), day(-90)),
+		topic("b1", "bio", time.Time{}, time.Time{}),
+		topic("b2", "bio", time.Time{}, time.Time{}),
+		topic("b3", "bio", time.Time{}, time.Time{}),
+	)
+	mockRepo.On("ListTopicProgress", ctx, "user-1").Return(topics, nil)
+
+	t.Run("No exam date", func(t *testing.T) {
+		_, err := svc.GetReadiness(ctx, "user-1", cal, "")
+		assert.ErrorIs(t, err, service.ErrNoExamDate)
+	})
+
+	t.Run("Past exam date", func(t *testing.T) {
+		_, err := svc.GetReadiness(ctx, "user-1", cal, day(-1).Format("2006-01-02"))
+		assert.ErrorIs(t, err, service.ErrInvalidExamDate)
+	})
+
+	t.Run("Forecast", func(t *testing.T) {
+		forecast, err := svc.GetReadiness(ctx, "user-1", cal, day(28).Format("2006-01-02"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 28, forecast.DaysLeft)
+		assert.Equal(t, service.RiskHigh, forecast.Risk)
+		assert.Len(t, forecast.Subjects, 3)
+
+		// High risk first, the lowest projected coverage breaking the tie
+		bio, art, maths := forecast.Subjects[0], forecast.Subjects[1], forecast.Subjects[2]
+		assert.Equal(t, "bio", bio.SubjectID)
+		assert.Equal(t, service.RiskHigh, bio.Risk)
+		assert.Equal(t, []string{service.RiskNoRecentStudy, service.RiskLowCoverage}, bio.RiskReasons)
+		assert.Equal(t, 25.0, *bio.ProjectedCoverage)
+
+		assert.Equal(t, "art", art.SubjectID)
+		assert.Nil(t, art.ProjectedCoverage)
+		assert.Nil(t, art.AccuracyTrend)
+		assert.Equal(t, 40.0, *art.ProjectedAccuracy)
+		assert.Equal(t, []string{service.RiskLowAccuracy}, art.RiskReasons)
+
+		assert.Equal(t, "math", maths.SubjectID)
+		assert.Equal(t, service.RiskLow, maths.Risk)
+		assert.Equal(t, 3600.0, maths.NetSecondsPerDay)
+		assert.Equal(t, int64(28*3600), maths.ProjectedNetSeconds)
+		assert.Equal(t, int64(80), maths.ProjectedQuestions)
+		assert.Equal(t, 6, maths.TopicsTouched)
+		assert.Equal(t, 3, maths.TopicsRevised)
+		// Two new topics in 14 days: four more by exam day
+		assert.Equal(t, 10, maths.ProjectedTopicsTouched)
+		assert.Equal(t, 60.0, *maths.Coverage)
+		assert.Equal(t, 100.0, *maths.ProjectedCoverage)
+		// 65% to 75% over two weeks, fading out over the four weeks left
+		assert.Equal(t, 5.0, *maths.AccuracyTrend)
+		assert.Equal(t, 88.0, *maths.ProjectedAccuracy)
+	})
+}
@@ -0,0 +1,540 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaoapaenas/my-api/internal/backup"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+var ErrInvalidBackup = errors.New("archive can't be imported")
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// maxImportProblems caps the problems listed for a broken archive
+const maxImportProblems = 100
+
+// ImportCounts are rows per table
+type ImportCounts struct {
+	Subjects      int
+	Topics        int
+	StudyCycles   int
+	CycleItems    int
+	StudySessions int
+	SessionPauses int
+	ExerciseLogs  int
+}
+
+// ImportReport is what an import wrote, or would write on a dry run
+type ImportReport struct {
+	DryRun   bool
+	Version  int
+	Imported ImportCounts
+	Warnings []string // Optional links that pointed outside the archive and were dropped
+	Problems []string // Why nothing was imported
+}
+
+type BackupService interface {
+	// ExportAccount reads everything the user owns, soft-deleted rows
+	// included, from one snapshot
+	ExportAccount(ctx context.Context, userID string) (backup.Archive, error)
+	// ImportAccount adds an archive's rows to the user's account under new
+	// IDs, in one transaction. A dry run checks and writes everything, then
+	// rolls back. An archive with problems writes nothing and returns
+	// ErrInvalidBackup along with a report listing them.
+	ImportAccount(ctx context.Context, userID string, archive backup.Archive, dryRun bool) (ImportReport, error)
+}
+
+type BackupManager struct {
+	repo repository.BackupRepository
+}
+
+func NewBackupManager(repo repository.BackupRepository) *BackupManager {
+	return &BackupManager{repo: repo}
+}
+
+func (s *BackupManager) ExportAccount(ctx context.Context, userID string) (backup.Archive, error) {
+	archive := backup.Archive{
+		Kind:          backup.Kind,
+		Version:       backup.Version,
+		ExportedAt:    time.Now().UTC().Truncate(time.Second),
+		Subjects:      []backup.Subject{},
+		Topics:        []backup.Topic{},
+		StudyCycles:   []backup.StudyCycle{},
+		CycleItems:    []backup.CycleItem{},
+		StudySessions: []backup.StudySession{},
+		SessionPauses: []backup.SessionPause{},
+		ExerciseLogs:  []backup.ExerciseLog{},
+	}
+
+	err := s.repo.ReadTx(ctx, func(repo repository.BackupRepository) error {
+		// A busy database runs fn again; start the archive over
+		archive.Subjects, archive.Topics, archive.StudyCycles = archive.Subjects[:0], archive.Topics[:0], archive.StudyCycles[:0]
+		archive.CycleItems, archive.StudySessions = archive.CycleItems[:0], archive.StudySessions[:0]
+		archive.SessionPauses, archive.ExerciseLogs = archive.SessionPauses[:0], archive.ExerciseLogs[:0]
+
+		subjects, err := repo.ListBackupSubjects(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range subjects {
+			archive.Subjects = append(archive.Subjects, backup.Subject{
+				ID:         row.ID,
+				Name:       row.Name,
+				ColorHex:   stringPtr(row.ColorHex),
+				ExamWeight: float64Ptr(row.ExamWeight),
+				CreatedAt:  row.CreatedAt,
+				UpdatedAt:  row.UpdatedAt,
+				DeletedAt:  stringPtr(row.DeletedAt),
+			})
+		}
+
+		topics, err := repo.ListBackupTopics(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range topics {
+			archive.Topics = append(archive.Topics, backup.Topic{
+				ID:        row.ID,
+				SubjectID: row.SubjectID,
+				Name:      row.Name,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+				DeletedAt: stringPtr(row.DeletedAt),
+			})
+		}
+
+		cycles, err := repo.ListBackupStudyCycles(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range cycles {
+			archive.StudyCycles = append(archive.StudyCycles, backup.StudyCycle{
+				ID:          row.ID,
+				Name:        row.Name,
+				Description: stringPtr(row.Description),
+				IsActive:    row.IsActive.Int64 == 1,
+				CreatedAt:   row.CreatedAt,
+				UpdatedAt:   row.UpdatedAt,
+				DeletedAt:   stringPtr(row.DeletedAt),
+			})
+		}
+
+		items, err := repo.ListBackupCycleItems(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range items {
+			archive.CycleItems = append(archive.CycleItems, backup.CycleItem{
+				ID:                     row.ID,
+				CycleID:                row.CycleID,
+				SubjectID:              row.SubjectID,
+				OrderIndex:             row.OrderIndex,
+				PlannedDurationMinutes: int64Ptr(row.PlannedDurationMinutes),
+				CreatedAt:              row.CreatedAt,
+				UpdatedAt:              row.UpdatedAt,
+			})
+		}
+
+		sessions, err := repo.ListBackupStudySessions(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range sessions {
+			archive.StudySessions = append(archive.StudySessions, backup.StudySession{
+				ID:                   row.ID,
+				SubjectID:            row.SubjectID,
+				CycleItemID:          stringPtr(row.CycleItemID),
+				StartedAt:            row.StartedAt,
+				FinishedAt:           stringPtr(row.FinishedAt),
+				GrossDurationSeconds: row.GrossDurationSeconds.Int64,
+				NetDurationSeconds:   row.NetDurationSeconds.Int64,
+				Notes:                stringPtr(row.Notes),
+				CreatedAt:            row.CreatedAt,
+				UpdatedAt:            row.UpdatedAt,
+			})
+		}
+
+		pauses, err := repo.ListBackupSessionPauses(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range pauses {
+			archive.SessionPauses = append(archive.SessionPauses, backup.SessionPause{
+				ID:        row.ID,
+				SessionID: row.SessionID,
+				StartedAt: row.StartedAt,
+				EndedAt:   stringPtr(row.EndedAt),
+			})
+		}
+
+		logs, err := repo.ListBackupExerciseLogs(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range logs {
+			archive.ExerciseLogs = append(archive.ExerciseLogs, backup.ExerciseLog{
+				ID:             row.ID,
+				SessionID:      stringPtr(row.SessionID),
+				SubjectID:      row.SubjectID,
+				TopicID:        stringPtr(row.TopicID),
+				QuestionsCount: row.QuestionsCount,
+				CorrectCount:   row.CorrectCount,
+				CreatedAt:      row.CreatedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return backup.Archive{}, err
+	}
+	return archive, nil
+}
+
+func (s *BackupManager) ImportAccount(ctx context.Context, userID string, archive backup.Archive, dryRun bool) (ImportReport, error) {
+	check := checkArchive(&archive)
+	report := ImportReport{DryRun: dryRun, Version: archive.Version, Warnings: check.warnings, Problems: check.problems}
+	if len(check.problems) > 0 {
+		return report, ErrInvalidBackup
+	}
+
+	now := formatSQLiteTime(time.Now())
+	stamp := func(s string) string {
+		if s == "" {
+			return now
+		}
+		return s
+	}
+	// Archive IDs to new ones, per table
+	subjectIDs, topicIDs, cycleIDs := map[string]string{}, map[string]string{}, map[string]string{}
+	itemIDs, sessionIDs := map[string]string{}, map[string]string{}
+	remap := func(ids map[string]string, id *string) sql.NullString {
+		if id == nil {
+			return sql.NullString{}
+		}
+		return nullString(ids[*id])
+	}
+
+	err := s.repo.Tx(ctx, func(repo repository.BackupRepository) error {
+		// A busy database runs fn again; count from zero
+		report.Imported = ImportCounts{}
+		for _, row := range archive.Subjects {
+			subjectIDs[row.ID] = uuid.New().String()
+			err := repo.RestoreSubject(ctx, database.RestoreSubjectParams{
+				ID:         subjectIDs[row.ID],
+				UserID:     userID,
+				Name:       row.Name,
+				ColorHex:   nullStringPtr(row.ColorHex),
+				ExamWeight: nullFloat64(row.ExamWeight),
+				CreatedAt:  stamp(row.CreatedAt),
+				UpdatedAt:  stamp(row.UpdatedAt),
+				DeletedAt:  nullStringPtr(row.DeletedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("subject %s: %w", row.ID, err)
+			}
+			report.Imported.Subjects++
+		}
+
+		for _, row := range archive.Topics {
+			topicIDs[row.ID] = uuid.New().String()
+			err := repo.RestoreTopic(ctx, database.RestoreTopicParams{
+				ID:        topicIDs[row.ID],
+				SubjectID: subjectIDs[row.SubjectID],
+				Name:      row.Name,
+				CreatedAt: stamp(row.CreatedAt),
+				UpdatedAt: stamp(row.UpdatedAt),
+				DeletedAt: nullStringPtr(row.DeletedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("topic %s: %w", row.ID, err)
+			}
+			report.Imported.Topics++
+		}
+
+		for _, row := range archive.StudyCycles {
+			cycleIDs[row.ID] = uuid.New().String()
+			var isActive int64
+			if row.IsActive {
+				isActive = 1
+			}
+			err := repo.RestoreStudyCycle(ctx, database.RestoreStudyCycleParams{
+				ID:          cycleIDs[row.ID],
+				Name:        row.Name,
+				Description: nullStringPtr(row.Description),
+				IsActive:    sql.NullInt64{Int64: isActive, Valid: true},
+				CreatedAt:   stamp(row.CreatedAt),
+				UpdatedAt:   stamp(row.UpdatedAt),
+				DeletedAt:   nullStringPtr(row.DeletedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("study cycle %s: %w", row.ID, err)
+			}
+			report.Imported.StudyCycles++
+		}
+
+		for _, row := range archive.CycleItems {
+			itemIDs[row.ID] = uuid.New().String()
+			planned := sql.NullInt64{}
+			if row.PlannedDurationMinutes != nil {
+				planned = sql.NullInt64{Int64: *row.PlannedDurationMinutes, Valid: true}
+			}
+			err := repo.RestoreCycleItem(ctx, database.RestoreCycleItemParams{
+				ID:                     itemIDs[row.ID],
+				CycleID:                cycleIDs[row.CycleID],
+				SubjectID:              subjectIDs[row.SubjectID],
+				OrderIndex:             row.OrderIndex,
+				PlannedDurationMinutes: planned,
+				CreatedAt:              stamp(row.CreatedAt),
+				UpdatedAt:              stamp(row.UpdatedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("cycle item %s: %w", row.ID, err)
+			}
+			report.Imported.CycleItems++
+		}
+
+		for _, row := range archive.StudySessions {
+			sessionIDs[row.ID] = uuid.New().String()
+			err := repo.RestoreStudySession(ctx, database.RestoreStudySessionParams{
+				ID:                   sessionIDs[row.ID],
+				SubjectID:            subjectIDs[row.SubjectID],
+				CycleItemID:          remap(itemIDs, row.CycleItemID),
+				StartedAt:            row.StartedAt,
+				FinishedAt:           nullStringPtr(row.FinishedAt),
+				GrossDurationSeconds: sql.NullInt64{Int64: row.GrossDurationSeconds, Valid: true},
+				NetDurationSeconds:   sql.NullInt64{Int64: row.NetDurationSeconds, Valid: true},
+				Notes:                nullStringPtr(row.Notes),
+				CreatedAt:            stamp(row.CreatedAt),
+				UpdatedAt:            stamp(row.UpdatedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("study session %s: %w", row.ID, err)
+			}
+			report.Imported.StudySessions++
+		}
+
+		for _, row := range archive.SessionPauses {
+			err := repo.RestoreSessionPause(ctx, database.RestoreSessionPauseParams{
+				ID:        uuid.New().String(),
+				SessionID: sessionIDs[row.SessionID],
+				StartedAt: row.StartedAt,
+				EndedAt:   nullStringPtr(row.EndedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("session pause %s: %w", row.ID, err)
+			}
+			report.Imported.SessionPauses++
+		}
+
+		for _, row := range archive.ExerciseLogs {
+			err := repo.RestoreExerciseLog(ctx, database.RestoreExerciseLogParams{
+				ID:             uuid.New().String(),
+				SessionID:      remap(sessionIDs, row.SessionID),
+				SubjectID:      subjectIDs[row.SubjectID],
+				TopicID:        remap(topicIDs, row.TopicID),
+				QuestionsCount: row.QuestionsCount,
+				CorrectCount:   row.CorrectCount,
+				CreatedAt:      stamp(row.CreatedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("exercise log %s: %w", row.ID, err)
+			}
+			report.Imported.ExerciseLogs++
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return ImportReport{}, err
+	}
+	return report, nil
+}
+
+// archiveCheck collects what's wrong with an archive before anything is written
+type archiveCheck struct {
+	problems []string
+	warnings []string
+	dropped  int // Problems past maxImportProblems
+}
+
+func (c *archiveCheck) problem(format string, args ...any) {
+	if len(c.problems) >= maxImportProblems {
+		c.dropped++
+		return
+	}
+	c.problems = append(c.problems, fmt.Sprintf(format, args...))
+}
+
+// ids indexes one table's rows, reporting blank and repeated IDs
+func (c *archiveCheck) ids(table string, ids []string) map[string]bool {
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		switch {
+		case id == "":
+			c.problem("%s #%d: missing id", table, i+1)
+		case seen[id]:
+			c.problem("%s %s: duplicate id", table, id)
+		}
+		seen[id] = true
+	}
+	return seen
+}
+
+func (c *archiveCheck) timestamp(table, id, field, value string, required bool) {
+	if value == "" {
+		if required {
+			c.problem("%s %s: missing %s", table, id, field)
+		}
+		return
+	}
+	if _, err := parseTimestamp(value); err != nil {
+		c.problem("%s %s: %s %q isn't a timestamp", table, id, field, value)
+	}
+}
+
+// checkArchive validates every row and link of archive. Links that are
+// optional in the schema and point outside the archive are cleared with a
+// warning, and so is is_active: cycles have no owner, so an active one would
+// be every user's. Everything else that doesn't fit is a problem.
+func checkArchive(archive *backup.Archive) archiveCheck {
+	var c archiveCheck
+
+	subjects := c.ids("subject", collectIDs(archive.Subjects, func(r backup.Subject) string { return r.ID }))
+	for _, row := range archive.Subjects {
+		if row.Name == "" {
+			c.problem("subject %s: missing name", row.ID)
+		}
+		c.timestamp("subject", row.ID, "created_at", row.CreatedAt, false)
+	}
+
+	topics := c.ids("topic", collectIDs(archive.Topics, func(r backup.Topic) string { return r.ID }))
+	for _, row := range archive.Topics {
+		if !subjects[row.SubjectID] {
+			c.problem("topic %s: unknown subject %q", row.ID, row.SubjectID)
+		}
+		if row.Name == "" {
+			c.problem("topic %s: missing name", row.ID)
+		}
+	}
+
+	cycles := c.ids("study cycle", collectIDs(archive.StudyCycles, func(r backup.StudyCycle) string { return r.ID }))
+	for i := range archive.StudyCycles {
+		row := &archive.StudyCycles[i]
+		if row.Name == "" {
+			c.problem("study cycle %s: missing name", row.ID)
+		}
+		if row.IsActive {
+			row.IsActive = false
+			c.warnings = append(c.warnings, fmt.Sprintf("study cycle %s: imported inactive", row.ID))
+		}
+	}
+
+	items := c.ids("cycle item", collectIDs(archive.CycleItems, func(r backup.CycleItem) string { return r.ID }))
+	for _, row := range archive.CycleItems {
+		if !cycles[row.CycleID] {
+			c.problem("cycle item %s: unknown study cycle %q", row.ID, row.CycleID)
+		}
+		if !subjects[row.SubjectID] {
+			c.problem("cycle item %s: unknown subject %q", row.ID, row.SubjectID)
+		}
+	}
+
+	sessions := c.ids("study session", collectIDs(archive.StudySessions, func(r backup.StudySession) string { return r.ID }))
+	for i := range archive.StudySessions {
+		row := &archive.StudySessions[i]
+		if !subjects[row.SubjectID] {
+			c.problem("study session %s: unknown subject %q", row.ID, row.SubjectID)
+		}
+		if row.CycleItemID != nil && !items[*row.CycleItemID] {
+			c.warnings = append(c.warnings, fmt.Sprintf("study session %s: unknown cycle item %q, unlinked", row.ID, *row.CycleItemID))
+			row.CycleItemID = nil
+		}
+		c.timestamp("study session", row.ID, "started_at", row.StartedAt, true)
+		if row.FinishedAt != nil {
+			c.timestamp("study session", row.ID, "finished_at", *row.FinishedAt, false)
+		}
+	}
+
+	c.ids("session pause", collectIDs(archive.SessionPauses, func(r backup.SessionPause) string { return r.ID }))
+	for _, row := range archive.SessionPauses {
+		if !sessions[row.SessionID] {
+			c.problem("session pause %s: unknown study session %q", row.ID, row.SessionID)
+		}
+		c.timestamp("session pause", row.ID, "started_at", row.StartedAt, true)
+		if row.EndedAt != nil {
+			c.timestamp("session pause", row.ID, "ended_at", *row.EndedAt, false)
+		}
+	}
+
+	c.ids("exercise log", collectIDs(archive.ExerciseLogs, func(r backup.ExerciseLog) string { return r.ID }))
+	for i := range archive.ExerciseLogs {
+		row := &archive.ExerciseLogs[i]
+		if !subjects[row.SubjectID] {
+			c.problem("exercise log %s: unknown subject %q", row.ID, row.SubjectID)
+		}
+		if row.QuestionsCount < 0 || row.CorrectCount < 0 || row.CorrectCount > row.QuestionsCount {
+			c.problem("exercise log %s: %d correct out of %d questions", row.ID, row.CorrectCount, row.QuestionsCount)
+		}
+		if row.SessionID != nil && !sessions[*row.SessionID] {
+			c.warnings = append(c.warnings, fmt.Sprintf("exercise log %s: unknown study session %q, unlinked", row.ID, *row.SessionID))
+			row.SessionID = nil
+		}
+		if row.TopicID != nil && !topics[*row.TopicID] {
+			c.warnings = append(c.warnings, fmt.Sprintf("exercise log %s: unknown topic %q, unlinked", row.ID, *row.TopicID))
+			row.TopicID = nil
+		}
+		c.timestamp("exercise log", row.ID, "created_at", row.CreatedAt, false)
+	}
+
+	if c.dropped > 0 {
+		c.problems = append(c.problems, fmt.Sprintf("and %d more", c.dropped))
+	}
+	return c
+}
+
+func collectIDs[T any](rows []T, id func(T) string) []string {
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = id(row)
+	}
+	return ids
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func int64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func float64Ptr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
+func nullStringPtr(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/joaoapaenas/my-api/internal/backup"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBackupRepository is a mock implementation of repository.BackupRepository
+type MockBackupRepository struct {
+	mock.Mock
+}
+
+func (m *MockBackupRepository) ListBackupSubjects(ctx context.Context, userID string) ([]database.Subject, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.Subject), args.Error(1)
+}
+
+func (m *MockBackupRepository) ListBackupTopics(ctx context.Context, userID string) ([]database.Topic, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.Topic), args.Error(1)
+}
+
+func (m *MockBackupRepository) ListBackupStudyCycles(ctx context.Context, userID string) ([]database.StudyCycle, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.StudyCycle), args.Error(1)
+}
+
+func (m *MockBackupRepository) ListBackupCycleItems(ctx context.Context, userID string) ([]database.CycleItem, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.CycleItem), args.Error(1)
+}
+
+func (m *MockBackupRepository) ListBackupStudySessions(ctx context.Context, userID string) ([]database.StudySession, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.StudySession), args.Error(1)
+}
+
+func (m *MockBackupRepository) ListBackupSessionPauses(ctx context.Context, userID string) ([]database.ListBackupSessionPausesRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListBackupSessionPausesRow), args.Error(1)
+}
+
+func (m *MockBackupRepository) ListBackupExerciseLogs(ctx context.Context, userID string) ([]database.ExerciseLog, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ExerciseLog), args.Error(1)
+}
+
+func (m *MockBackupRepository) RestoreSubject(ctx context.Context, arg database.RestoreSubjectParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockBackupRepository) RestoreTopic(ctx context.Context, arg database.RestoreTopicParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockBackupRepository) RestoreStudyCycle(ctx context.Context, arg database.RestoreStudyCycleParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockBackupRepository) RestoreCycleItem(ctx context.Context, arg database.RestoreCycleItemParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockBackupRepository) RestoreStudySession(ctx context.Context, arg database.RestoreStudySessionParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockBackupRepository) RestoreSessionPause(ctx context.Context, arg database.RestoreSessionPauseParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockBackupRepository) RestoreExerciseLog(ctx context.Context, arg database.RestoreExerciseLogParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockBackupRepository) ReadTx(ctx context.Context, fn func(repository.BackupRepository) error) error {
+	m.Called(ctx)
+	return fn(m)
+}
+
+func (m *MockBackupRepository) Tx(ctx context.Context, fn func(repository.BackupRepository) error) error {
+	m.Called(ctx)
+	return fn(m)
+}
+
+func TestBackupManager_ImportAccount(t *testing.T) {
+	ctx := context.Background()
+	ref := func(s string) *string { return &s }
+
+	t.Run("Remaps IDs and unlinks dangling optional references", func(t *testing.T) {
+		mockRepo := new(MockBackupRepository)
+		svc := service.NewBackupManager(mockRepo)
+
+		var subjectID string
+		mockRepo.On("Tx", ctx).Return(nil)
+		mockRepo.On("RestoreSubject", ctx, mock.MatchedBy(func(p database.RestoreSubjectParams) bool {
+			subjectID = p.ID
+			return p.UserID == "user-2" && p.ID != "math" && p.CreatedAt != ""
+		})).Return(nil)
+		mockRepo.On("RestoreExerciseLog", ctx, mock.MatchedBy(func(p database.RestoreExerciseLogParams) bool {
+			return p.SubjectID == subjectID && !p.SessionID.Valid && !p.TopicID.Valid
+		})).Return(nil)
+
+		report, err := svc.ImportAccount(ctx, "user-2", backup.Archive{
+			Kind:         backup.Kind,
+			Version:      backup.Version,
+			Subjects:     []backup.Subject{{ID: "math", Name: "Math"}},
+			ExerciseLogs: []backup.ExerciseLog{{ID: "log", SubjectID: "math", SessionID: ref("gone"), TopicID: ref("gone"), QuestionsCount: 5, CorrectCount: 3}},
+		}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported.Subjects)
+		assert.Equal(t, 1, report.Imported.ExerciseLogs)
+		assert.Len(t, report.Warnings, 2)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects broken archives without writing", func(t *testing.T) {
+		mockRepo := new(MockBackupRepository)
+		svc := service.NewBackupManager(mockRepo)
+
+		report, err := svc.ImportAccount(ctx, "user-2", backup.Archive{
+			Kind:          backup.Kind,
+			Version:       backup.Version,
+			Subjects:      []backup.Subject{{ID: "math", Name: "Math"}, {ID: "math", Name: "Again"}},
+			StudySessions: []backup.StudySession{{ID: "s1", SubjectID: "bio", StartedAt: "soon"}},
+			ExerciseLogs:  []backup.ExerciseLog{{ID: "log", SubjectID: "math", QuestionsCount: 2, CorrectCount: 3}},
+		}, true)
+		assert.ErrorIs(t, err, service.ErrInvalidBackup)
+		assert.Equal(t, []string{
+			"subject math: duplicate id",
+			`study session s1: unknown subject "bio"`,
+			`study session s1: started_at "soon" isn't a timestamp`,
+			"exercise log log: 3 correct out of 2 questions",
+		}, report.Problems)
+		mockRepo.AssertNotCalled(t, "Tx", mock.Anything)
+	})
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+)
+
+const (
+	BalanceOver       = "over"
+	BalanceUnder      = "under"
+	BalanceOnTrack    = "on_track"
+	BalanceUnexpected = "unexpected" // Studied, but absent from the reference
+
+	defaultBalanceTolerance = 0.2
+)
+
+var ErrInvalidTolerance = errors.New("tolerance must be between 0 and 1")
+
+// BalanceTarget compares a subject's actual time with its share in a
+// reference. Against the cycle plan, the expectation splits all the net
+// time of the period, so off-plan study counts as a deficit elsewhere;
+// against the exam weights, only the time spent on weighted subjects.
+type BalanceTarget struct {
+	Share           float64 // Percentage of the reference
+	ExpectedSeconds int64
+	DeficitSeconds  int64  // Expected minus actual; negative when over-studied
+	Status          string // over, under, on_track or unexpected
+}
+
+// SubjectBalance is one subject's actual net time against the plan and the exam
+type SubjectBalance struct {
+	SubjectID   string
+	SubjectName string
+	ColorHex    string
+	NetSeconds  int64
+	ActualShare float64        // Percentage of the period's total net time
+	Plan        *BalanceTarget // nil without an active cycle
+	Exam        *BalanceTarget // nil without an exam weight for this subject
+}
+
+// StudyBalance is the planned-vs-actual report for a period
+type StudyBalance struct {
+	TotalNetSeconds int64
+	CycleID         string // Empty without an active cycle
+	CycleName       string
+	Subjects        []SubjectBalance // Largest absolute plan (else exam) deficit first
+}
+
+// GetStudyBalance compares the net time per subject between two local dates
+// with the active cycle's planned minutes and with the subjects' exam weights. A subject is over- or
+// under-studied when it deviates from its expected time by more than
+// tolerance (a fraction; 0 uses 20%).
+func (s *AnalyticsManager) GetStudyBalance(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string, tolerance float64) (StudyBalance, error) {
+	if tolerance < 0 || tolerance >= 1 {
+		return StudyBalance{}, ErrInvalidTolerance
+	}
+	if tolerance == 0 {
+		tolerance = defaultBalanceTolerance
+	}
+
+	actual, err := s.GetTimeReport(ctx, userID, cal, startDateFrom, startDateTo)
+	if err != nil {
+		return StudyBalance{}, err
+	}
+	plan, err := s.repo.ListActiveCyclePlan(ctx, userID)
+	if err != nil {
+		return StudyBalance{}, err
+	}
+	subjects, err := s.repo.ListSubjects(ctx, userID)
+	if err != nil {
+		return StudyBalance{}, err
+	}
+
+	balance := StudyBalance{Subjects: []SubjectBalance{}}
+	rows := make(map[string]*SubjectBalance)
+	row := func(id string) *SubjectBalance {
+		if b, ok := rows[id]; ok {
+			return b
+		}
+		b := &SubjectBalance{SubjectID: id}
+		rows[id] = b
+		return b
+	}
+	for _, subject := range subjects {
+		b := row(subject.ID)
+		b.SubjectName, b.ColorHex = subject.Name, subject.ColorHex.String
+	}
+	for _, report := range actual {
+		b := row(report.SubjectID)
+		b.SubjectName, b.ColorHex = report.SubjectName, report.ColorHex
+		b.NetSeconds = report.NetSeconds
+		balance.TotalNetSeconds += report.NetSeconds
+	}
+
+	// Only the most recently updated active cycle counts. Items without a
+	// planned duration count as the cycle's average one, or all as one slot
+	// when none has a duration.
+	planWeights := make(map[string]float64)
+	var plannedMinutes, timedItems int64
+	for _, item := range plan {
+		if balance.CycleID == "" {
+			balance.CycleID, balance.CycleName = item.CycleID, item.CycleName
+		}
+		if item.CycleID == balance.CycleID {
+			plannedMinutes += item.PlannedMinutes
+			timedItems += item.TimedItemsCount
+		}
+	}
+	for _, item := range plan {
+		if item.CycleID != balance.CycleID {
+			continue
+		}
+		if plannedMinutes > 0 {
+			average := float64(plannedMinutes) / float64(timedItems)
+			planWeights[item.SubjectID] = float64(item.PlannedMinutes) + float64(item.ItemsCount-item.TimedItemsCount)*average
+		} else {
+			planWeights[item.SubjectID] = float64(item.ItemsCount)
+		}
+	}
+
+	examWeights := make(map[string]float64)
+	var examNetSeconds int64
+	for _, subject := range subjects {
+		if subject.ExamWeight.Valid {
+			examWeights[subject.ID] = subject.ExamWeight.Float64
+			examNetSeconds += row(subject.ID).NetSeconds
+		}
+	}
+
+	for _, b := range rows {
+		if balance.TotalNetSeconds > 0 {
+			b.ActualShare = roundPercentage(float64(b.NetSeconds) / float64(balance.TotalNetSeconds))
+		}
+		if balance.CycleID != "" {
+			b.Plan = balanceTarget(b.NetSeconds, balance.TotalNetSeconds, planWeights, b.SubjectID, tolerance)
+		}
+		if _, ok := examWeights[b.SubjectID]; ok {
+			b.Exam = balanceTarget(b.NetSeconds, examNetSeconds, examWeights, b.SubjectID, tolerance)
+		}
+		if b.NetSeconds > 0 || b.Plan != nil && b.Plan.Share > 0 || b.Exam != nil {
+			balance.Subjects = append(balance.Subjects, *b)
+		}
+	}
+
+	sort.Slice(balance.Subjects, func(i, j int) bool {
+		di, dj := balanceDeficit(balance.Subjects[i]), balanceDeficit(balance.Subjects[j])
+		if di != dj {
+			return di > dj
+		}
+		return balance.Subjects[i].SubjectName < balance.Subjects[j].SubjectName
+	})
+	return balance, nil
+}
+
+// balanceTarget measures actual against the subject's share of weights
+func balanceTarget(actual, total int64, weights map[string]float64, subjectID string, tolerance float64) *BalanceTarget {
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
+	}
+	share := weights[subjectID] / sum
+	expected := int64(math.Round(share * float64(total)))
+
+	target := &BalanceTarget{
+		Share:           roundPercentage(share),
+		ExpectedSeconds: expected,
+		DeficitSeconds:  expected - actual,
+		Status:          BalanceOnTrack,
+	}
+	switch {
+	case share == 0:
+		if actual > 0 {
+			target.Status = BalanceUnexpected
+		}
+	case float64(actual) < float64(expected)*(1-tolerance):
+		target.Status = BalanceUnder
+	case float64(actual) > float64(expected)*(1+tolerance):
+		target.Status = BalanceOver
+	}
+	return target
+}
+
+// balanceDeficit is the absolute deficit the report is ordered by
+func balanceDeficit(b SubjectBalance) int64 {
+	target := b.Plan
+	if target == nil {
+		target = b.Exam
+	}
+	if target == nil {
+		return 0
+	}
+	if target.DeficitSeconds < 0 {
+		return -target.DeficitSeconds
+	}
+	return target.DeficitSeconds
+}
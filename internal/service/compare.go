@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	BaselinePrevious = "previous"
+	BaselineCustom   = "custom"
+)
+
+var ErrInvalidBaseline = errors.New("baseline must be previous or custom, with baseline_from and baseline_to for custom")
+
+// CompareQuery selects the periods to compare. Dates are local and inclusive.
+type CompareQuery struct {
+	From         string
+	To           string
+	Baseline     string // previous (default) or custom
+	BaselineFrom string // Custom baseline only
+	BaselineTo   string
+}
+
+// PeriodTotals is the study done in one period
+type PeriodTotals struct {
+	NetSeconds int64
+	Sessions   int
+	Questions  int64
+	Correct    int64
+	Accuracy   *float64 // Percentage; nil without questions
+}
+
+// PeriodDelta is the current period minus the baseline
+type PeriodDelta struct {
+	NetSeconds int64
+	Sessions   int
+	Questions  int64
+	Accuracy   *float64 // Percentage points; nil unless both periods have questions
+}
+
+type PeriodComparison struct {
+	Current  PeriodTotals
+	Baseline PeriodTotals
+	Delta    PeriodDelta
+}
+
+type SubjectComparison struct {
+	SubjectID   string
+	SubjectName string
+	ColorHex    string
+	PeriodComparison
+}
+
+// Comparison compares two periods in total and per subject
+type Comparison struct {
+	From, To                 time.Time // Local [from, to)
+	BaselineFrom, BaselineTo time.Time
+	Total                    PeriodComparison
+	Subjects                 []SubjectComparison // Largest net time change first
+}
+
+// Compare compares net time, sessions, questions and accuracy between a
+// period and its baseline, using the same filtering as the time report and
+// the accuracy report. The previous baseline is the period of equal length
+// just before, or the same number of whole months before when the period
+// spans whole calendar months.
+func (s *AnalyticsManager) Compare(ctx context.Context, userID string, cal Calendar, query CompareQuery) (Comparison, error) {
+	if query.From == "" || query.To == "" {
+		return Comparison{}, fmt.Errorf("%w: from and to are required", ErrInvalidDateRange)
+	}
+	from, to, err := parseDateRange(query.From, query.To, cal.Location)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	comparison := Comparison{From: from, To: to}
+	switch query.Baseline {
+	case BaselinePrevious, "":
+		if query.BaselineFrom != "" || query.BaselineTo != "" {
+			return Comparison{}, ErrInvalidBaseline
+		}
+		comparison.BaselineFrom, comparison.BaselineTo = previousPeriod(from, to, cal.Location)
+	case BaselineCustom:
+		if query.BaselineFrom == "" || query.BaselineTo == "" {
+			return Comparison{}, ErrInvalidBaseline
+		}
+		comparison.BaselineFrom, comparison.BaselineTo, err = parseDateRange(query.BaselineFrom, query.BaselineTo, cal.Location)
+		if err != nil {
+			return Comparison{}, err
+		}
+	default:
+		return Comparison{}, ErrInvalidBaseline
+	}
+
+	current, err := s.periodTotals(ctx, userID, comparison.From, comparison.To)
+	if err != nil {
+		return Comparison{}, err
+	}
+	baseline, err := s.periodTotals(ctx, userID, comparison.BaselineFrom, comparison.BaselineTo)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	comparison.Subjects = []SubjectComparison{}
+	seen := make(map[string]bool)
+	for _, totals := range []map[string]*subjectTotals{current, baseline} {
+		for id, t := range totals {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			subject := SubjectComparison{SubjectID: id, SubjectName: t.name, ColorHex: t.color}
+			if c, ok := current[id]; ok {
+				subject.Current = c.PeriodTotals
+			}
+			if b, ok := baseline[id]; ok {
+				subject.Baseline = b.PeriodTotals
+			}
+			subject.Delta = periodDelta(subject.Current, subject.Baseline)
+			comparison.Subjects = append(comparison.Subjects, subject)
+
+			comparison.Total.Current.add(subject.Current)
+			comparison.Total.Baseline.add(subject.Baseline)
+		}
+	}
+	comparison.Total.Delta = periodDelta(comparison.Total.Current, comparison.Total.Baseline)
+
+	sort.Slice(comparison.Subjects, func(i, j int) bool {
+		di, dj := comparison.Subjects[i].Delta.NetSeconds, comparison.Subjects[j].Delta.NetSeconds
+		if di < 0 {
+			di = -di
+		}
+		if dj < 0 {
+			dj = -dj
+		}
+		if di != dj {
+			return di > dj
+		}
+		return comparison.Subjects[i].SubjectName < comparison.Subjects[j].SubjectName
+	})
+	return comparison, nil
+}
+
+type subjectTotals struct {
+	name, color string
+	PeriodTotals
+}
+
+// periodTotals combines the time report and the accuracy report for [from, to)
+func (s *AnalyticsManager) periodTotals(ctx context.Context, userID string, from, to time.Time) (map[string]*subjectTotals, error) {
+	times, err := s.timeReport(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	accuracy, err := s.accuracyBySubject(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]*subjectTotals)
+	for _, report := range times {
+		totals[report.SubjectID] = &subjectTotals{
+			name:  report.SubjectName,
+			color: report.ColorHex,
+			PeriodTotals: PeriodTotals{
+				NetSeconds: report.NetSeconds,
+				Sessions:   report.SessionsCount,
+			},
+		}
+	}
+	for _, row := range accuracy {
+		t, ok := totals[row.SubjectID]
+		if !ok {
+			t = &subjectTotals{name: row.SubjectName, color: row.ColorHex.String}
+			totals[row.SubjectID] = t
+		}
+		t.Questions = int64(row.TotalQuestions.Float64)
+		t.Correct = int64(row.TotalCorrect.Float64)
+		t.Accuracy = accuracyPercentage(t.Correct, t.Questions)
+	}
+	return totals, nil
+}
+
+func (t *PeriodTotals) add(other PeriodTotals) {
+	t.NetSeconds += other.NetSeconds
+	t.Sessions += other.Sessions
+	t.Questions += other.Questions
+	t.Correct += other.Correct
+	t.Accuracy = accuracyPercentage(t.Correct, t.Questions)
+}
+
+func periodDelta(current, baseline PeriodTotals) PeriodDelta {
+	delta := PeriodDelta{
+		NetSeconds: current.NetSeconds - baseline.NetSeconds,
+		Sessions:   current.Sessions - baseline.Sessions,
+		Questions:  current.Questions - baseline.Questions,
+	}
+	if current.Accuracy != nil && baseline.Accuracy != nil {
+		points := math.Round((*current.Accuracy-*baseline.Accuracy)*100) / 100
+		delta.Accuracy = &points
+	}
+	return delta
+}
+
+// previousPeriod returns the period just before [from, to): the same number
+// of calendar months for whole-month periods, else the same number of days
+// (or the same duration when the bounds aren't local midnights).
+func previousPeriod(from, to time.Time, loc *time.Location) (time.Time, time.Time) {
+	localFrom, localTo := from.In(loc), to.In(loc)
+	midnights := localFrom.Equal(startOfDay(from, loc)) && localTo.Equal(startOfDay(to, loc))
+
+	if midnights && localFrom.Day() == 1 && localTo.Day() == 1 {
+		months := (localTo.Year()-localFrom.Year())*12 + int(localTo.Month()-localFrom.Month())
+		return localFrom.AddDate(0, -months, 0), localFrom
+	}
+	if midnights {
+		days := int(math.Round(to.Sub(from).Hours() / 24))
+		return localFrom.AddDate(0, 0, -days), localFrom
+	}
+	return from.Add(-to.Sub(from)), from
+}
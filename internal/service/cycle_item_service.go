@@ -3,17 +3,31 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/repository"
 )
 
+var ErrCycleItemNotFound = errors.New("cycle item not found")
+
+// CycleItemPatch changes the fields that are set. A planned duration of 0 is
+// stored as 0; null clears it.
+type CycleItemPatch struct {
+	SubjectID              Field[string]
+	OrderIndex             Field[int]
+	PlannedDurationMinutes Field[int]
+}
+
 type CycleItemService interface {
 	CreateCycleItem(ctx context.Context, cycleID, subjectID string, orderIndex int, plannedDurationMinutes int) (database.CycleItem, error)
 	ListCycleItems(ctx context.Context, cycleID string) ([]database.CycleItem, error)
 	GetCycleItem(ctx context.Context, id string) (database.CycleItem, error)
-	UpdateCycleItem(ctx context.Context, id, subjectID string, orderIndex int, plannedDurationMinutes int) error
+	// UpdateCycleItem fails with ErrVersionMismatch when version is set and stale
+	UpdateCycleItem(ctx context.Context, id, subjectID string, orderIndex int, plannedDurationMinutes int, version *int64) (database.CycleItem, error)
+	PatchCycleItem(ctx context.Context, id string, patch CycleItemPatch, version *int64) (database.CycleItem, error)
 	DeleteCycleItem(ctx context.Context, id string) error
 }
 
@@ -50,18 +64,54 @@ func (s *CycleItemManager) GetCycleItem(ctx context.Context, id string) (databas
 	return s.repo.GetCycleItem(ctx, id)
 }
 
-func (s *CycleItemManager) UpdateCycleItem(ctx context.Context, id, subjectID string, orderIndex int, plannedDurationMinutes int) error {
+func (s *CycleItemManager) UpdateCycleItem(ctx context.Context, id, subjectID string, orderIndex int, plannedDurationMinutes int, version *int64) (database.CycleItem, error) {
 	var duration sql.NullInt64
 	if plannedDurationMinutes > 0 {
 		duration = sql.NullInt64{Int64: int64(plannedDurationMinutes), Valid: true}
 	}
 
-	return s.repo.UpdateCycleItem(ctx, database.UpdateCycleItemParams{
+	item, err := s.repo.UpdateCycleItem(ctx, database.UpdateCycleItemParams{
 		SubjectID:              subjectID,
 		OrderIndex:             int64(orderIndex),
 		PlannedDurationMinutes: duration,
 		ID:                     id,
+		IfVersion:              nullInt64(version),
+	})
+	return versioned(item, err, func() error {
+		_, err := s.repo.GetCycleItem(ctx, id)
+		return err
+	}, ErrCycleItemNotFound)
+}
+
+func (s *CycleItemManager) PatchCycleItem(ctx context.Context, id string, patch CycleItemPatch, version *int64) (database.CycleItem, error) {
+	if patch.SubjectID.Null || patch.OrderIndex.Null {
+		return database.CycleItem{}, fmt.Errorf("%w: subject_id and order_index can't be null", ErrInvalidPatch)
+	}
+	if patch == (CycleItemPatch{}) {
+		item, err := s.repo.GetCycleItem(ctx, id)
+		return unchanged(item, err, func(i database.CycleItem) int64 { return i.Version }, version, ErrCycleItemNotFound)
+	}
+
+	var duration *int64
+	if d := patch.PlannedDurationMinutes.Ptr(); d != nil {
+		minutes := int64(*d)
+		duration = &minutes
+	}
+
+	item, err := s.repo.PatchCycleItem(ctx, database.PatchCycleItemParams{
+		SetSubjectID:              patch.SubjectID.Set,
+		SubjectID:                 patch.SubjectID.Value,
+		SetOrderIndex:             patch.OrderIndex.Set,
+		OrderIndex:                int64(patch.OrderIndex.Value),
+		SetPlannedDurationMinutes: patch.PlannedDurationMinutes.Set,
+		PlannedDurationMinutes:    nullInt64(duration),
+		ID:                        id,
+		IfVersion:                 nullInt64(version),
 	})
+	return versioned(item, err, func() error {
+		_, err := s.repo.GetCycleItem(ctx, id)
+		return err
+	}, ErrCycleItemNotFound)
 }
 
 func (s *CycleItemManager) DeleteCycleItem(ctx context.Context, id string) error {
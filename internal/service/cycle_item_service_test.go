@@ -31,9 +31,14 @@ func (m *MockCycleItemRepository) GetCycleItem(ctx context.Context, id string) (
 	return args.Get(0).(database.CycleItem), args.Error(1)
 }
 
-func (m *MockCycleItemRepository) UpdateCycleItem(ctx context.Context, arg database.UpdateCycleItemParams) error {
+func (m *MockCycleItemRepository) UpdateCycleItem(ctx context.Context, arg database.UpdateCycleItemParams) (database.CycleItem, error) {
 	args := m.Called(ctx, arg)
-	return args.Error(0)
+	return args.Get(0).(database.CycleItem), args.Error(1)
+}
+
+func (m *MockCycleItemRepository) PatchCycleItem(ctx context.Context, arg database.PatchCycleItemParams) (database.CycleItem, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.CycleItem), args.Error(1)
 }
 
 func (m *MockCycleItemRepository) DeleteCycleItem(ctx context.Context, id string) error {
@@ -89,3 +94,51 @@ func TestCycleItemManager_ListCycleItems(t *testing.T) {
 	assert.Equal(t, int64(1), items[0].OrderIndex)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCycleItemManager_PatchCycleItem(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("zero and null are kept apart", func(t *testing.T) {
+		mockRepo := new(MockCycleItemRepository)
+		svc := service.NewCycleItemManager(mockRepo)
+		mockRepo.On("PatchCycleItem", ctx, database.PatchCycleItemParams{
+			SetPlannedDurationMinutes: true,
+			PlannedDurationMinutes:    sql.NullInt64{Int64: 0, Valid: true},
+			ID:                        "item",
+		}).Return(database.CycleItem{ID: "item", Version: 2}, nil).Once()
+		mockRepo.On("PatchCycleItem", ctx, database.PatchCycleItemParams{
+			SetPlannedDurationMinutes: true,
+			ID:                        "item",
+		}).Return(database.CycleItem{ID: "item", Version: 3}, nil).Once()
+
+		_, err := svc.PatchCycleItem(ctx, "item", service.CycleItemPatch{PlannedDurationMinutes: service.Set(0)}, nil)
+		assert.NoError(t, err)
+		_, err = svc.PatchCycleItem(ctx, "item", service.CycleItemPatch{PlannedDurationMinutes: service.Null[int]()}, nil)
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("required fields can't be nulled", func(t *testing.T) {
+		mockRepo := new(MockCycleItemRepository)
+		svc := service.NewCycleItemManager(mockRepo)
+
+		_, err := svc.PatchCycleItem(ctx, "item", service.CycleItemPatch{OrderIndex: service.Null[int]()}, nil)
+
+		assert.ErrorIs(t, err, service.ErrInvalidPatch)
+		mockRepo.AssertNotCalled(t, "PatchCycleItem", mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty patch keeps the version", func(t *testing.T) {
+		mockRepo := new(MockCycleItemRepository)
+		svc := service.NewCycleItemManager(mockRepo)
+		mockRepo.On("GetCycleItem", ctx, "item").Return(database.CycleItem{ID: "item", Version: 4}, nil)
+		stale := int64(3)
+
+		item, err := svc.PatchCycleItem(ctx, "item", service.CycleItemPatch{}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), item.Version)
+		_, err = svc.PatchCycleItem(ctx, "item", service.CycleItemPatch{}, &stale)
+		assert.ErrorIs(t, err, service.ErrVersionMismatch)
+		mockRepo.AssertNotCalled(t, "PatchCycleItem", mock.Anything, mock.Anything)
+	})
+}
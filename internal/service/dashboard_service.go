@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+// dashboardMaxDueReviews bounds the revisions listed on the dashboard
+const dashboardMaxDueReviews = 10
+
+// OpenSessionStatus is the session in progress, measured up to now
+type OpenSessionStatus struct {
+	ID             string
+	SubjectID      string
+	SubjectName    string
+	ColorHex       string
+	CycleItemID    string
+	StartedAt      time.Time
+	ElapsedSeconds int64 // Wall-clock time since the start
+	PausedSeconds  int64 // Ended pauses plus the one in progress
+	NetSeconds     int64
+	Paused         bool
+}
+
+// DashboardToday is the study done so far on the local day
+type DashboardToday struct {
+	NetSeconds     int64    // Finished sessions plus the open one's net time so far
+	GoalSeconds    int64    // The profile's daily goal; 0 without one
+	GoalPercentage *float64 // nil without a goal
+	GoalAttained   bool
+	Questions      int64
+	Correct        int64
+	Accuracy       *float64 // Percentage; nil without questions
+}
+
+// DashboardCycleItem is where the user stands in the active cycle
+type DashboardCycleItem struct {
+	ID             string
+	CycleID        string
+	CycleName      string
+	SubjectID      string
+	SubjectName    string
+	ColorHex       string
+	PlannedMinutes int64
+	Position       int // 1-based
+	ItemsCount     int
+	InProgress     bool // The open session is on this item; otherwise it is the next one
+}
+
+// DashboardStreak counts consecutive days meeting the profile's daily goal,
+// or with at least a minute of study without one
+type DashboardStreak struct {
+	Current      int
+	Longest      int       // Within the last year
+	LastAttained time.Time // Zero if never attained
+}
+
+// DashboardSummary is everything the dashboard shows, read from one snapshot
+type DashboardSummary struct {
+	Date            time.Time // Local midnight of today
+	OpenSession     *OpenSessionStatus
+	Today           DashboardToday
+	CycleItem       *DashboardCycleItem // nil without an active cycle
+	DueReviews      []TopicReview       // Most overdue first, at most dashboardMaxDueReviews
+	DueReviewsCount int
+	Streak          DashboardStreak
+}
+
+type DashboardService interface {
+	// GetSummary computes the dashboard in one read transaction. tzOverride
+	// (an IANA name) takes precedence over the profile timezone.
+	GetSummary(ctx context.Context, userID, tzOverride string) (DashboardSummary, error)
+}
+
+type DashboardManager struct {
+	repo repository.DashboardRepository
+}
+
+func NewDashboardManager(repo repository.DashboardRepository) *DashboardManager {
+	return &DashboardManager{repo: repo}
+}
+
+func (s *DashboardManager) GetSummary(ctx context.Context, userID, tzOverride string) (DashboardSummary, error) {
+	var summary DashboardSummary
+	err := s.repo.ReadTx(ctx, func(repo repository.DashboardRepository) error {
+		var err error
+		summary, err = dashboardSummary(ctx, repo, userID, tzOverride, time.Now())
+		return err
+	})
+	return summary, err
+}
+
+// dashboardSummary reads everything through repo, measuring live figures at now
+func dashboardSummary(ctx context.Context, repo repository.DashboardRepository, userID, tzOverride string, now time.Time) (DashboardSummary, error) {
+	analytics := NewAnalyticsManager(repo)
+	cal, err := analytics.Calendar(ctx, userID, tzOverride)
+	if err != nil {
+		return DashboardSummary{}, err
+	}
+	user, err := repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return DashboardSummary{}, err
+	}
+
+	today := cal.StartOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+	summary := DashboardSummary{Date: today, DueReviews: []TopicReview{}}
+
+	open, err := repo.GetUserOpenSession(ctx, userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return DashboardSummary{}, err
+	default:
+		summary.OpenSession = openSessionStatus(open, now)
+	}
+
+	// A year of daily totals feeds the streak; the open session counts as far as it got
+	from := cal.StartOfWeek(today.AddDate(0, 0, -streakLookbackDays))
+	totals, err := analytics.GetDailyTotals(ctx, userID, cal, from, tomorrow)
+	if err != nil {
+		return DashboardSummary{}, err
+	}
+	if session := summary.OpenSession; session != nil {
+		for _, slice := range splitByDay(session.StartedAt, now, session.NetSeconds, cal.Location) {
+			totals = append(totals, DailyTotal{Day: slice.Day, SubjectID: session.SubjectID, NetSeconds: slice.Seconds})
+		}
+	}
+
+	for _, total := range totals {
+		if total.Day.Equal(today) {
+			summary.Today.NetSeconds += total.NetSeconds
+			summary.Today.Questions += total.Questions
+			summary.Today.Correct += total.Correct
+		}
+	}
+	summary.Today.Accuracy = accuracyPercentage(summary.Today.Correct, summary.Today.Questions)
+	if user.DailyGoalMinutes > 0 {
+		summary.Today.GoalSeconds = user.DailyGoalMinutes * 60
+		percentage := roundPercentage(float64(summary.Today.NetSeconds) / float64(summary.Today.GoalSeconds))
+		summary.Today.GoalPercentage = &percentage
+		summary.Today.GoalAttained = summary.Today.NetSeconds >= summary.Today.GoalSeconds
+	}
+
+	daily := database.Goal{Metric: GoalMetricNetMinutes, Period: GoalPeriodDay, Target: max(user.DailyGoalMinutes, 1)}
+	streak := evaluateGoal(daily, cal, totals, from, today)
+	summary.Streak = DashboardStreak{Current: streak.CurrentStreak, Longest: streak.LongestStreak, LastAttained: streak.LastAttained}
+
+	items, err := repo.ListActiveCycleItems(ctx, userID)
+	if err != nil {
+		return DashboardSummary{}, err
+	}
+	summary.CycleItem = currentCycleItem(activeCycle(items), summary.OpenSession)
+
+	logs, err := repo.ListTopicExerciseLogs(ctx, userID)
+	if err != nil {
+		return DashboardSummary{}, err
+	}
+	for _, review := range topicReviews(logs, cal, today) {
+		if review.OverdueDays >= 0 {
+			summary.DueReviews = append(summary.DueReviews, review)
+		}
+	}
+	sort.SliceStable(summary.DueReviews, func(i, j int) bool {
+		return summary.DueReviews[i].OverdueDays > summary.DueReviews[j].OverdueDays
+	})
+	summary.DueReviewsCount = len(summary.DueReviews)
+	if len(summary.DueReviews) > dashboardMaxDueReviews {
+		summary.DueReviews = summary.DueReviews[:dashboardMaxDueReviews]
+	}
+	return summary, nil
+}
+
+// openSessionStatus measures an open session up to now; nil if its start is unreadable
+func openSessionStatus(row database.GetUserOpenSessionRow, now time.Time) *OpenSessionStatus {
+	start, err := parseTimestamp(row.StartedAt)
+	if err != nil {
+		slog.Warn("Ignoring open session with unreadable start", "session_id", row.ID, "error", err)
+		return nil
+	}
+
+	status := &OpenSessionStatus{
+		ID:             row.ID,
+		SubjectID:      row.SubjectID,
+		SubjectName:    row.SubjectName,
+		ColorHex:       row.ColorHex.String,
+		CycleItemID:    row.CycleItemID.String,
+		StartedAt:      start,
+		ElapsedSeconds: max(int64(now.Sub(start).Seconds()), 0),
+		PausedSeconds:  row.PausedSeconds,
+	}
+	if row.OpenPauseStartedAt != "" {
+		if pauseStart, err := parseTimestamp(row.OpenPauseStartedAt); err == nil {
+			status.Paused = true
+			status.PausedSeconds += max(int64(now.Sub(pauseStart).Seconds()), 0)
+		}
+	}
+	status.NetSeconds = max(status.ElapsedSeconds-status.PausedSeconds, 0)
+	return status
+}
+
+// currentCycleItem is the open session's item, or else the one after the
+// last studied; nil without an active cycle
+func currentCycleItem(items []database.ListActiveCycleItemsRow, open *OpenSessionStatus) *DashboardCycleItem {
+	if len(items) == 0 {
+		return nil
+	}
+
+	current, inProgress := -1, false
+	if open != nil && open.CycleItemID != "" {
+		for i, item := range items {
+			if item.ID == open.CycleItemID {
+				current, inProgress = i, true
+			}
+		}
+	}
+	if current < 0 {
+		current = (lastStudiedItem(items) + 1) % len(items)
+	}
+
+	item := items[current]
+	return &DashboardCycleItem{
+		ID:             item.ID,
+		CycleID:        item.CycleID,
+		CycleName:      item.CycleName,
+		SubjectID:      item.SubjectID,
+		SubjectName:    item.SubjectName,
+		ColorHex:       item.ColorHex.String,
+		PlannedMinutes: item.PlannedDurationMinutes.Int64,
+		Position:       current + 1,
+		ItemsCount:     len(items),
+		InProgress:     inProgress,
+	}
+}
@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDashboardRepository is a mock implementation of repository.DashboardRepository
+type MockDashboardRepository struct {
+	MockAnalyticsRepository
+}
+
+func (m *MockDashboardRepository) GetUserOpenSession(ctx context.Context, userID string) (database.GetUserOpenSessionRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(database.GetUserOpenSessionRow), args.Error(1)
+}
+
+func (m *MockDashboardRepository) ListActiveCycleItems(ctx context.Context, userID string) ([]database.ListActiveCycleItemsRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListActiveCycleItemsRow), args.Error(1)
+}
+
+func (m *MockDashboardRepository) ReadTx(ctx context.Context, fn func(repository.DashboardRepository) error) error {
+	m.Called(ctx)
+	return fn(m)
+}
+
+func TestDashboardManager_GetSummary(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	// A zone where it is around noon, so the sessions below stay on one local day
+	offset := 12 - now.UTC().Hour()
+	tz := "Etc/GMT"
+	if offset != 0 {
+		tz = fmt.Sprintf("Etc/GMT%+d", -offset)
+	}
+	at := func(d time.Duration) string {
+		return now.Add(d).UTC().Format(time.RFC3339)
+	}
+
+	newService := func(open *database.GetUserOpenSessionRow) (*service.DashboardManager, *MockDashboardRepository) {
+		mockRepo := new(MockDashboardRepository)
+		mockRepo.On("ReadTx", ctx).Return(nil)
+		mockRepo.On("GetUserByID", ctx, "user-1").Return(database.User{ID: "user-1", Timezone: tz, DailyGoalMinutes: 30}, nil)
+		if open != nil {
+			mockRepo.On("GetUserOpenSession", ctx, "user-1").Return(*open, nil)
+		} else {
+			mockRepo.On("GetUserOpenSession", ctx, "user-1").Return(database.GetUserOpenSessionRow{}, sql.ErrNoRows)
+		}
+		mockRepo.On("ListFinishedSessionsInRange", ctx, mock.Anything).Return([]database.ListFinishedSessionsInRangeRow{
+			sessionRow("s1", "math", at(-50*time.Hour), at(-49*time.Hour), 600),
+			sessionRow("s2", "math", at(-26*time.Hour), at(-25*time.Hour), 1800),
+			sessionRow("s3", "math", at(-3*time.Hour), at(-150*time.Minute), 1200),
+		}, nil)
+		mockRepo.On("ListExerciseLogsInRange", ctx, mock.Anything).Return([]database.ListExerciseLogsInRangeRow{
+			{ID: "l1", SubjectID: "math", QuestionsCount: 10, CorrectCount: 7, CreatedAt: at(-2 * time.Hour)},
+		}, nil)
+		mockRepo.On("ListActiveCycleItems", ctx, "user-1").Return([]database.ListActiveCycleItemsRow{
+			{ID: "i1", CycleID: "c1", CycleName: "Main", SubjectID: "math", SubjectName: "Math", LastStudiedAt: at(-3 * time.Hour)},
+			{ID: "i2", CycleID: "c1", CycleName: "Main", SubjectID: "bio", SubjectName: "Bio", OrderIndex: 1},
+			{ID: "i3", CycleID: "c1", CycleName: "Main", SubjectID: "math", SubjectName: "Math", OrderIndex: 2},
+		}, nil)
+		mockRepo.On("ListTopicExerciseLogs", ctx, "user-1").Return([]database.ListTopicExerciseLogsRow{
+			// Practiced once five days ago: due after a day, so 4 days overdue
+			{ID: "t1", TopicID: "algebra", TopicName: "Algebra", SubjectID: "math", SubjectName: "Math", CreatedAt: at(-5 * 24 * time.Hour)},
+			{ID: "t2", TopicID: "limits", TopicName: "Limits", SubjectID: "math", SubjectName: "Math", CreatedAt: at(-1 * 24 * time.Hour)},
+			// Not due until tomorrow
+			{ID: "t3", TopicID: "cells", TopicName: "Cells", SubjectID: "bio", SubjectName: "Bio", CreatedAt: at(-2 * time.Hour)},
+		}, nil)
+		return service.NewDashboardManager(mockRepo), mockRepo
+	}
+
+	t.Run("With an open session", func(t *testing.T) {
+		svc, mockRepo := newService(&database.GetUserOpenSessionRow{
+			ID: "s4", SubjectID: "bio", SubjectName: "Bio",
+			CycleItemID:        sql.NullString{String: "i3", Valid: true},
+			StartedAt:          at(-20 * time.Minute),
+			PausedSeconds:      120,
+			OpenPauseStartedAt: at(-time.Minute),
+		})
+
+		summary, err := svc.GetSummary(ctx, "user-1", "")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "ReadTx", 1)
+
+		// 20 minutes elapsed, 2 paused earlier and 1 in the current pause
+		open := summary.OpenSession
+		assert.NotNil(t, open)
+		assert.True(t, open.Paused)
+		assert.InDelta(t, 1200, open.ElapsedSeconds, 2)
+		assert.InDelta(t, 180, open.PausedSeconds, 2)
+		assert.InDelta(t, 1020, open.NetSeconds, 2)
+
+		// The finished session plus the open one, against a 30 minute goal
+		assert.InDelta(t, 2220, summary.Today.NetSeconds, 2)
+		assert.Equal(t, int64(1800), summary.Today.GoalSeconds)
+		assert.True(t, summary.Today.GoalAttained)
+		assert.Equal(t, int64(10), summary.Today.Questions)
+		assert.Equal(t, 70.0, *summary.Today.Accuracy)
+
+		// Yesterday and today met the goal; the day before fell short
+		assert.Equal(t, 2, summary.Streak.Current)
+		assert.Equal(t, 2, summary.Streak.Longest)
+
+		assert.Equal(t, "i3", summary.CycleItem.ID)
+		assert.Equal(t, 3, summary.CycleItem.Position)
+		assert.True(t, summary.CycleItem.InProgress)
+
+		assert.Equal(t, 2, summary.DueReviewsCount)
+		assert.Equal(t, "algebra", summary.DueReviews[0].TopicID)
+		assert.Equal(t, 4, summary.DueReviews[0].OverdueDays)
+		assert.Equal(t, "limits", summary.DueReviews[1].TopicID)
+		assert.Equal(t, 0, summary.DueReviews[1].OverdueDays)
+	})
+
+	t.Run("Without an open session", func(t *testing.T) {
+		svc, _ := newService(nil)
+
+		summary, err := svc.GetSummary(ctx, "user-1", "")
+
+		assert.NoError(t, err)
+		assert.Nil(t, summary.OpenSession)
+		assert.Equal(t, int64(1200), summary.Today.NetSeconds)
+		assert.False(t, summary.Today.GoalAttained)
+		assert.Equal(t, 66.67, *summary.Today.GoalPercentage)
+		// Today isn't over, so yesterday's streak still counts
+		assert.Equal(t, 1, summary.Streak.Current)
+
+		// The item after the last one studied
+		assert.Equal(t, "i2", summary.CycleItem.ID)
+		assert.False(t, summary.CycleItem.InProgress)
+	})
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/export"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+// exportPageSize is how many rows an export reads per query
+const exportPageSize = 500
+
+// SessionExport is a study session as exported
+type SessionExport struct {
+	ID           string
+	SubjectID    string
+	SubjectName  string
+	CycleItemID  string
+	StartedAt    time.Time
+	FinishedAt   *time.Time // nil while the session is open
+	GrossSeconds int64
+	NetSeconds   int64
+	PausesCount  int64
+	Notes        string
+}
+
+// ExerciseLogExport is an exercise log as exported
+type ExerciseLogExport struct {
+	ID          string
+	SessionID   string
+	SubjectID   string
+	SubjectName string
+	TopicID     string
+	TopicName   string
+	Questions   int64
+	Correct     int64
+	Accuracy    *float64 // Percentage; nil without questions
+	CreatedAt   time.Time
+}
+
+type ExportService interface {
+	// Settings are how the user's tables are written: the profile locale's
+	// number and date conventions, in the profile timezone unless tzOverride
+	// (an IANA name) is set
+	Settings(ctx context.Context, userID, tzOverride string) (export.Settings, error)
+	// StreamSessions calls fn with each session started between two local
+	// dates (inclusive), oldest first, reading them a page at a time. An
+	// error from fn stops the stream and is returned.
+	StreamSessions(ctx context.Context, userID string, loc *time.Location, from, to string, fn func(SessionExport) error) error
+	// StreamExerciseLogs does the same for exercise logs, by creation time
+	StreamExerciseLogs(ctx context.Context, userID string, loc *time.Location, from, to string, fn func(ExerciseLogExport) error) error
+}
+
+type ExportManager struct {
+	repo      repository.ExportRepository
+	analytics AnalyticsService
+}
+
+func NewExportManager(repo repository.ExportRepository, analytics AnalyticsService) *ExportManager {
+	return &ExportManager{repo: repo, analytics: analytics}
+}
+
+func (s *ExportManager) Settings(ctx context.Context, userID, tzOverride string) (export.Settings, error) {
+	cal, err := s.analytics.Calendar(ctx, userID, tzOverride)
+	if err != nil {
+		return export.Settings{}, err
+	}
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return export.Settings{}, err
+	}
+	return export.Settings{Locale: export.LocaleFor(user.Locale), Location: cal.Location}, nil
+}
+
+func (s *ExportManager) StreamSessions(ctx context.Context, userID string, loc *time.Location, from, to string, fn func(SessionExport) error) error {
+	start, end, err := parseDateRange(from, to, loc)
+	if err != nil {
+		return err
+	}
+
+	params := database.ListSessionsPageParams{
+		UserID:    userID,
+		RangeFrom: formatSQLiteTime(start),
+		RangeTo:   formatSQLiteTime(end),
+		PageSize:  exportPageSize,
+	}
+	for {
+		rows, err := s.repo.ListSessionsPage(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			session, ok := toSessionExport(row)
+			if !ok {
+				continue
+			}
+			if err := fn(session); err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+		last := rows[len(rows)-1]
+		params.AfterSortKey, params.AfterID = last.SortKey, last.ID
+	}
+}
+
+func toSessionExport(row database.ListSessionsPageRow) (SessionExport, bool) {
+	started, err := parseTimestamp(row.StartedAt)
+	if err != nil {
+		slog.Warn("Skipping session with unreadable start", "session_id", row.ID, "error", err)
+		return SessionExport{}, false
+	}
+	session := SessionExport{
+		ID:           row.ID,
+		SubjectID:    row.SubjectID,
+		SubjectName:  row.SubjectName,
+		CycleItemID:  row.CycleItemID.String,
+		StartedAt:    started,
+		GrossSeconds: row.GrossDurationSeconds,
+		NetSeconds:   row.NetDurationSeconds,
+		PausesCount:  row.PausesCount,
+		Notes:        row.Notes.String,
+	}
+	if row.FinishedAt.Valid {
+		if finished, err := parseTimestamp(row.FinishedAt.String); err == nil {
+			session.FinishedAt = &finished
+		}
+	}
+	return session, true
+}
+
+func (s *ExportManager) StreamExerciseLogs(ctx context.Context, userID string, loc *time.Location, from, to string, fn func(ExerciseLogExport) error) error {
+	start, end, err := parseDateRange(from, to, loc)
+	if err != nil {
+		return err
+	}
+
+	params := database.ListExerciseLogsPageParams{
+		UserID:    userID,
+		RangeFrom: formatSQLiteTime(start),
+		RangeTo:   formatSQLiteTime(end),
+		PageSize:  exportPageSize,
+	}
+	for {
+		rows, err := s.repo.ListExerciseLogsPage(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			created, err := parseTimestamp(row.CreatedAt)
+			if err != nil {
+				slog.Warn("Skipping exercise log with unreadable date", "exercise_log_id", row.ID, "error", err)
+				continue
+			}
+			log := ExerciseLogExport{
+				ID:          row.ID,
+				SessionID:   row.SessionID.String,
+				SubjectID:   row.SubjectID,
+				SubjectName: row.SubjectName,
+				TopicID:     row.TopicID.String,
+				TopicName:   row.TopicName.String,
+				Questions:   row.QuestionsCount,
+				Correct:     row.CorrectCount,
+				Accuracy:    accuracyPercentage(row.CorrectCount, row.QuestionsCount),
+				CreatedAt:   created,
+			}
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+		last := rows[len(rows)-1]
+		params.AfterSortKey, params.AfterID = last.SortKey, last.ID
+	}
+}
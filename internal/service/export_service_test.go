@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockExportRepository is a mock implementation of repository.ExportRepository
+type MockExportRepository struct {
+	mock.Mock
+}
+
+func (m *MockExportRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (m *MockExportRepository) ListSessionsPage(ctx context.Context, arg database.ListSessionsPageParams) ([]database.ListSessionsPageRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.ListSessionsPageRow), args.Error(1)
+}
+
+func (m *MockExportRepository) ListExerciseLogsPage(ctx context.Context, arg database.ListExerciseLogsPageParams) ([]database.ListExerciseLogsPageRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.ListExerciseLogsPageRow), args.Error(1)
+}
+
+func TestExportManager_Settings(t *testing.T) {
+	ctx := context.Background()
+	mockAnalytics := new(MockAnalyticsRepository)
+	mockAnalytics.On("GetUserByID", ctx, "user-1").Return(database.User{ID: "user-1", Timezone: "America/Sao_Paulo"}, nil)
+	mockRepo := new(MockExportRepository)
+	mockRepo.On("GetUserByID", ctx, "user-1").Return(database.User{ID: "user-1", Locale: "pt-BR"}, nil)
+	svc := service.NewExportManager(mockRepo, service.NewAnalyticsManager(mockAnalytics))
+
+	settings, err := svc.Settings(ctx, "user-1", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "America/Sao_Paulo", settings.Location.String())
+	assert.Equal(t, byte(','), settings.Locale.Decimal)
+	assert.Equal(t, ';', settings.Locale.Delimiter)
+	assert.Equal(t, "dd/mm/yyyy", settings.Locale.DateFormat)
+
+	_, err = svc.Settings(ctx, "user-1", "Mars/Olympus")
+	assert.ErrorIs(t, err, service.ErrInvalidTimezone)
+}
+
+func TestExportManager_StreamSessions(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	// A full first page, then a short one
+	var first []database.ListSessionsPageRow
+	for i := 0; i < 500; i++ {
+		started := start.Add(time.Duration(i) * time.Minute).Format("2006-01-02 15:04:05")
+		first = append(first, database.ListSessionsPageRow{
+			ID:        fmt.Sprintf("s%03d", i),
+			SubjectID: "math",
+			StartedAt: started,
+			SortKey:   started,
+		})
+	}
+	second := []database.ListSessionsPageRow{
+		{ID: "open", SubjectID: "math", StartedAt: "2024-03-02T10:00:00Z", SortKey: "2024-03-02 10:00:00"},
+		{ID: "broken", SubjectID: "math", StartedAt: "yesterday", SortKey: "yesterday"},
+	}
+
+	mockRepo := new(MockExportRepository)
+	mockRepo.On("ListSessionsPage", ctx, mock.MatchedBy(func(p database.ListSessionsPageParams) bool {
+		return p.AfterID == ""
+	})).Return(first, nil).Once()
+	mockRepo.On("ListSessionsPage", ctx, mock.MatchedBy(func(p database.ListSessionsPageParams) bool {
+		return p.AfterID == "s499" && p.AfterSortKey == first[499].SortKey
+	})).Return(second, nil).Once()
+	svc := service.NewExportManager(mockRepo, nil)
+
+	var ids []string
+	err := svc.StreamSessions(ctx, "user-1", time.UTC, "2024-03-01", "2024-03-02", func(s service.SessionExport) error {
+		ids = append(ids, s.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, ids, 501) // The unreadable start is skipped
+	assert.Equal(t, "s000", ids[0])
+	assert.Equal(t, "open", ids[500])
+	mockRepo.AssertExpectations(t)
+
+	t.Run("Invalid range", func(t *testing.T) {
+		err := svc.StreamSessions(ctx, "user-1", time.UTC, "2024-03-02", "2024-03-01", func(service.SessionExport) error { return nil })
+		assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+	})
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// pauseLengths are the upper bounds of the pause length distribution; the
+// last bucket is open-ended.
+var pauseLengths = [...]struct {
+	Label      string
+	MaxSeconds int64
+}{
+	{"under_1m", 60},
+	{"1m_to_5m", 5 * 60},
+	{"5m_to_15m", 15 * 60},
+	{"15m_to_30m", 30 * 60},
+	{"over_30m", 0},
+}
+
+// timesOfDay split the day for the focus breakdown, by local start hour
+var timesOfDay = []struct {
+	Name      string
+	StartHour int
+	EndHour   int
+}{
+	{"night", 0, 6},
+	{"morning", 6, 12},
+	{"afternoon", 12, 18},
+	{"evening", 18, 24},
+}
+
+// FocusStats summarises how focused a set of sessions was
+type FocusStats struct {
+	Sessions            int
+	GrossSeconds        int64
+	NetSeconds          int64
+	FocusRatio          *float64 // Net over gross time; nil without gross time
+	Pauses              int
+	PausesPerHour       *float64 // Per gross hour; nil without gross time
+	AvgPauseSeconds     float64
+	LongestPauseSeconds int64
+	PauseLengths        []PauseLengthCount
+}
+
+// PauseLengthCount is one bucket of the pause length distribution
+type PauseLengthCount struct {
+	Label      string
+	MinSeconds int64
+	MaxSeconds int64 // Exclusive; 0 for the open-ended last bucket
+	Count      int
+}
+
+type SubjectFocus struct {
+	SubjectID   string
+	SubjectName string
+	ColorHex    string
+	FocusStats
+}
+
+type TimeOfDayFocus struct {
+	Name      string // night, morning, afternoon or evening
+	StartHour int
+	EndHour   int
+	FocusStats
+}
+
+type WeekdayFocus struct {
+	Weekday time.Weekday
+	FocusStats
+}
+
+// FocusReport breaks focus down by subject, time of day and weekday. Each
+// session counts where it started, in the user's timezone.
+type FocusReport struct {
+	Overall     FocusStats
+	BySubject   []SubjectFocus   // By subject name
+	ByTimeOfDay []TimeOfDayFocus // Always all four, from night to evening
+	ByWeekday   []WeekdayFocus   // Always all seven, from the week start
+}
+
+// interval is a [Start, End) span of time, such as an ended pause
+type interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (i interval) Seconds() int64 {
+	return int64(i.End.Sub(i.Start).Seconds())
+}
+
+// GetFocus reports focus quality for sessions overlapping the local dates
+// [startDateFrom, startDateTo]; empty dates leave the range open.
+func (s *AnalyticsManager) GetFocus(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) (FocusReport, error) {
+	from, to, err := parseDateRange(startDateFrom, startDateTo, cal.Location)
+	if err != nil {
+		return FocusReport{}, err
+	}
+
+	sessions, err := s.listSessions(ctx, userID, from, to)
+	if err != nil {
+		return FocusReport{}, err
+	}
+	pauses, err := s.listPauses(ctx, userID, from, to)
+	if err != nil {
+		return FocusReport{}, err
+	}
+
+	var overall focusTally
+	subjects := make(map[string]*focusTally)
+	var subjectOrder []SubjectFocus
+	periods := make([]focusTally, len(timesOfDay))
+	weekdays := make([]focusTally, 7)
+
+	for _, session := range sessions {
+		sessionPauses := pauses[session.ID]
+		overall.add(session, sessionPauses)
+
+		subject, ok := subjects[session.SubjectID]
+		if !ok {
+			subject = &focusTally{}
+			subjects[session.SubjectID] = subject
+			subjectOrder = append(subjectOrder, SubjectFocus{
+				SubjectID:   session.SubjectID,
+				SubjectName: session.SubjectName,
+				ColorHex:    session.ColorHex,
+			})
+		}
+		subject.add(session, sessionPauses)
+
+		local := session.Start.In(cal.Location)
+		for i, period := range timesOfDay {
+			if local.Hour() >= period.StartHour && local.Hour() < period.EndHour {
+				periods[i].add(session, sessionPauses)
+			}
+		}
+		weekdays[local.Weekday()].add(session, sessionPauses)
+	}
+
+	report := FocusReport{
+		Overall:     overall.stats(),
+		BySubject:   make([]SubjectFocus, 0, len(subjectOrder)),
+		ByTimeOfDay: make([]TimeOfDayFocus, 0, len(timesOfDay)),
+		ByWeekday:   make([]WeekdayFocus, 0, 7),
+	}
+	for _, subject := range subjectOrder {
+		subject.FocusStats = subjects[subject.SubjectID].stats()
+		report.BySubject = append(report.BySubject, subject)
+	}
+	sort.SliceStable(report.BySubject, func(i, j int) bool {
+		return report.BySubject[i].SubjectName < report.BySubject[j].SubjectName
+	})
+	for i, period := range timesOfDay {
+		report.ByTimeOfDay = append(report.ByTimeOfDay, TimeOfDayFocus{
+			Name:       period.Name,
+			StartHour:  period.StartHour,
+			EndHour:    period.EndHour,
+			FocusStats: periods[i].stats(),
+		})
+	}
+	for i := 0; i < 7; i++ {
+		day := (cal.WeekStart + time.Weekday(i)) % 7
+		report.ByWeekday = append(report.ByWeekday, WeekdayFocus{Weekday: day, FocusStats: weekdays[day].stats()})
+	}
+	return report, nil
+}
+
+// focusTally accumulates sessions and their pauses into FocusStats
+type focusTally struct {
+	sessions     int
+	gross, net   int64
+	pauses       int
+	pauseSeconds int64
+	longest      int64
+	lengths      [len(pauseLengths)]int
+}
+
+func (t *focusTally) add(session timedSession, pauses []interval) {
+	t.sessions++
+	t.gross += session.GrossSeconds
+	t.net += session.NetSeconds
+	for _, pause := range pauses {
+		seconds := pause.Seconds()
+		t.pauses++
+		t.pauseSeconds += seconds
+		t.longest = max(t.longest, seconds)
+		for i, bucket := range pauseLengths {
+			if bucket.MaxSeconds == 0 || seconds < bucket.MaxSeconds {
+				t.lengths[i]++
+				break
+			}
+		}
+	}
+}
+
+func (t *focusTally) stats() FocusStats {
+	stats := FocusStats{
+		Sessions:            t.sessions,
+		GrossSeconds:        t.gross,
+		NetSeconds:          t.net,
+		Pauses:              t.pauses,
+		LongestPauseSeconds: t.longest,
+		PauseLengths:        make([]PauseLengthCount, len(pauseLengths)),
+	}
+	if t.gross > 0 {
+		ratio := math.Round(float64(t.net)/float64(t.gross)*10000) / 10000
+		perHour := math.Round(float64(t.pauses)/(float64(t.gross)/3600)*100) / 100
+		stats.FocusRatio, stats.PausesPerHour = &ratio, &perHour
+	}
+	if t.pauses > 0 {
+		stats.AvgPauseSeconds = math.Round(float64(t.pauseSeconds)/float64(t.pauses)*10) / 10
+	}
+
+	var lower int64
+	for i, bucket := range pauseLengths {
+		stats.PauseLengths[i] = PauseLengthCount{
+			Label:      bucket.Label,
+			MinSeconds: lower,
+			MaxSeconds: bucket.MaxSeconds,
+			Count:      t.lengths[i],
+		}
+		lower = bucket.MaxSeconds
+	}
+	return stats
+}
+
+// listPauses loads the ended pauses of the sessions listSessions returns for
+// the same range, by session ID
+func (s *AnalyticsManager) listPauses(ctx context.Context, userID string, from, to time.Time) (map[string][]interval, error) {
+	rows, err := s.repo.ListSessionPausesInRange(ctx, database.ListSessionPausesInRangeParams{
+		UserID:    userID,
+		RangeFrom: formatSQLiteTime(from),
+		RangeTo:   formatSQLiteTime(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pauses := make(map[string][]interval)
+	for _, row := range rows {
+		start, err := parseTimestamp(row.StartedAt)
+		if err != nil {
+			slog.Warn("Skipping pause with unreadable start", "pause_id", row.ID, "error", err)
+			continue
+		}
+		end, err := parseTimestamp(row.EndedAt.String)
+		if err != nil || end.Before(start) {
+			slog.Warn("Skipping pause with unreadable or inverted end", "pause_id", row.ID, "error", err)
+			continue
+		}
+		pauses[row.SessionID] = append(pauses[row.SessionID], interval{Start: start, End: end})
+	}
+	return pauses, nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+const (
+	GoalMetricNetMinutes = "net_minutes"
+	GoalMetricQuestions  = "questions"
+
+	GoalPeriodDay  = "day"
+	GoalPeriodWeek = "week"
+)
+
+// streakLookbackDays bounds how far back streaks are evaluated
+const streakLookbackDays = 366
+
+var (
+	ErrGoalNotFound = errors.New("goal not found")
+	ErrInvalidGoal  = errors.New("invalid goal")
+)
+
+// GoalInput describes a goal to create or update
+type GoalInput struct {
+	SubjectID string // Empty for a goal across all subjects
+	Metric    string
+	Period    string
+	Target    int64
+	RestDays  []int // Weekdays (0 = Sunday) a daily goal may be skipped without breaking the streak
+}
+
+// GoalStatus is a goal evaluated against the study data
+type GoalStatus struct {
+	Goal        database.Goal
+	PeriodStart time.Time // Local start of the current day or week
+	Value       int64     // Progress in the current period, in the goal's metric
+	Attained    bool
+
+	CurrentStreak int       // Consecutive attained periods up to now; the current period only counts once attained
+	LongestStreak int       // Within the lookback window
+	LastAttained  time.Time // Zero if never attained
+}
+
+type GoalService interface {
+	CreateGoal(ctx context.Context, userID string, input GoalInput) (database.Goal, error)
+	// ListGoals returns the goals with their progress in the current period
+	ListGoals(ctx context.Context, userID, tzOverride string) ([]GoalStatus, error)
+	GetGoal(ctx context.Context, id, userID string) (database.Goal, error)
+	UpdateGoal(ctx context.Context, id, userID string, input GoalInput) (database.Goal, error)
+	DeleteGoal(ctx context.Context, id, userID string) error
+	// GetStreaks evaluates every goal over the last year, including streaks
+	GetStreaks(ctx context.Context, userID, tzOverride string) ([]GoalStatus, error)
+}
+
+type GoalManager struct {
+	repo      repository.GoalRepository
+	analytics AnalyticsService
+}
+
+func NewGoalManager(repo repository.GoalRepository, analytics AnalyticsService) *GoalManager {
+	return &GoalManager{repo: repo, analytics: analytics}
+}
+
+func (s *GoalManager) CreateGoal(ctx context.Context, userID string, input GoalInput) (database.Goal, error) {
+	restDays, err := s.validate(ctx, userID, input)
+	if err != nil {
+		return database.Goal{}, err
+	}
+
+	return s.repo.CreateGoal(ctx, database.CreateGoalParams{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		SubjectID: nullString(input.SubjectID),
+		Metric:    input.Metric,
+		Period:    input.Period,
+		Target:    input.Target,
+		RestDays:  restDays,
+	})
+}
+
+func (s *GoalManager) ListGoals(ctx context.Context, userID, tzOverride string) ([]GoalStatus, error) {
+	return s.evaluate(ctx, userID, tzOverride, 0)
+}
+
+func (s *GoalManager) GetGoal(ctx context.Context, id, userID string) (database.Goal, error) {
+	goal, err := s.repo.GetGoal(ctx, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Goal{}, ErrGoalNotFound
+	}
+	return goal, err
+}
+
+func (s *GoalManager) UpdateGoal(ctx context.Context, id, userID string, input GoalInput) (database.Goal, error) {
+	restDays, err := s.validate(ctx, userID, input)
+	if err != nil {
+		return database.Goal{}, err
+	}
+
+	rows, err := s.repo.UpdateGoal(ctx, database.UpdateGoalParams{
+		SubjectID: nullString(input.SubjectID),
+		Metric:    input.Metric,
+		Period:    input.Period,
+		Target:    input.Target,
+		RestDays:  restDays,
+		ID:        id,
+		UserID:    userID,
+	})
+	if err != nil {
+		return database.Goal{}, err
+	}
+	if rows == 0 {
+		return database.Goal{}, ErrGoalNotFound
+	}
+	return s.GetGoal(ctx, id, userID)
+}
+
+func (s *GoalManager) DeleteGoal(ctx context.Context, id, userID string) error {
+	rows, err := s.repo.DeleteGoal(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrGoalNotFound
+	}
+	return nil
+}
+
+func (s *GoalManager) GetStreaks(ctx context.Context, userID, tzOverride string) ([]GoalStatus, error) {
+	return s.evaluate(ctx, userID, tzOverride, streakLookbackDays)
+}
+
+// validate checks the input and returns its rest days as a bitmask
+func (s *GoalManager) validate(ctx context.Context, userID string, input GoalInput) (int64, error) {
+	if input.Metric != GoalMetricNetMinutes && input.Metric != GoalMetricQuestions {
+		return 0, fmt.Errorf("%w: unknown metric %q", ErrInvalidGoal, input.Metric)
+	}
+	if input.Period != GoalPeriodDay && input.Period != GoalPeriodWeek {
+		return 0, fmt.Errorf("%w: unknown period %q", ErrInvalidGoal, input.Period)
+	}
+	if input.Target <= 0 {
+		return 0, fmt.Errorf("%w: target must be positive", ErrInvalidGoal)
+	}
+
+	var restDays int64
+	for _, day := range input.RestDays {
+		if day < 0 || day > 6 {
+			return 0, fmt.Errorf("%w: rest days must be between 0 (Sunday) and 6", ErrInvalidGoal)
+		}
+		restDays |= 1 << day
+	}
+	if restDays != 0 && input.Period != GoalPeriodDay {
+		return 0, fmt.Errorf("%w: rest days only apply to daily goals", ErrInvalidGoal)
+	}
+	if restDays == 1<<7-1 {
+		return 0, fmt.Errorf("%w: a goal needs at least one working day", ErrInvalidGoal)
+	}
+
+	if input.SubjectID != "" {
+		if _, err := s.repo.GetSubject(ctx, input.SubjectID, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, fmt.Errorf("%w: subject not found", ErrInvalidGoal)
+			}
+			return 0, err
+		}
+	}
+	return restDays, nil
+}
+
+// evaluate computes every goal's current progress and its streaks over the
+// last lookbackDays days (0 only evaluates the current period).
+func (s *GoalManager) evaluate(ctx context.Context, userID, tzOverride string, lookbackDays int) ([]GoalStatus, error) {
+	goals, err := s.repo.ListGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]GoalStatus, 0, len(goals))
+	if len(goals) == 0 {
+		return statuses, nil
+	}
+
+	cal, err := s.analytics.Calendar(ctx, userID, tzOverride)
+	if err != nil {
+		return nil, err
+	}
+
+	today := cal.StartOfDay(time.Now())
+	from := cal.StartOfWeek(today.AddDate(0, 0, -lookbackDays))
+	to := today.AddDate(0, 0, 1)
+
+	totals, err := s.analytics.GetDailyTotals(ctx, userID, cal, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, goal := range goals {
+		statuses = append(statuses, evaluateGoal(goal, cal, totals, from, today))
+	}
+	return statuses, nil
+}
+
+// evaluateGoal walks the goal's periods from the one containing from up to
+// the one containing today.
+func evaluateGoal(goal database.Goal, cal Calendar, totals []DailyTotal, from, today time.Time) GoalStatus {
+	periodStart := func(day time.Time) time.Time {
+		if goal.Period == GoalPeriodWeek {
+			return cal.StartOfWeek(day)
+		}
+		return day
+	}
+
+	values := make(map[int64]int64)
+	for _, total := range totals {
+		if goal.SubjectID.Valid && goal.SubjectID.String != total.SubjectID {
+			continue
+		}
+		key := periodStart(total.Day).Unix()
+		if goal.Metric == GoalMetricQuestions {
+			values[key] += total.Questions
+		} else {
+			values[key] += total.NetSeconds
+		}
+	}
+
+	status := GoalStatus{Goal: goal, PeriodStart: periodStart(today)}
+	run := 0
+	for period := periodStart(from); !period.After(status.PeriodStart); period = nextPeriod(goal, period) {
+		value := values[period.Unix()]
+		if goal.Metric == GoalMetricNetMinutes {
+			value /= 60
+		}
+		attained := value >= goal.Target
+		current := period.Equal(status.PeriodStart)
+
+		switch {
+		case attained:
+			run++
+			status.LastAttained = period
+		case current, goal.Period == GoalPeriodDay && goal.RestDays&(1<<period.Weekday()) != 0:
+			// An unfinished period or a rest day doesn't break the streak
+		default:
+			run = 0
+		}
+		if run > status.LongestStreak {
+			status.LongestStreak = run
+		}
+
+		if current {
+			status.Value = value
+			status.Attained = attained
+		}
+	}
+	status.CurrentStreak = run
+	return status
+}
+
+func nextPeriod(goal database.Goal, period time.Time) time.Time {
+	if goal.Period == GoalPeriodWeek {
+		return period.AddDate(0, 0, 7)
+	}
+	return period.AddDate(0, 0, 1)
+}
+
+// RestDaysFromMask lists the weekdays (0 = Sunday) set in a goal's rest_days bitmask
+func RestDaysFromMask(mask int64) []int {
+	days := []int{}
+	for day := 0; day < 7; day++ {
+		if mask&(1<<day) != 0 {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func nullString(s string) sql.NullString {
+	if s == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: s, Valid: true}
+}
@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockGoalRepository is a mock implementation of repository.GoalRepository
+type MockGoalRepository struct {
+	mock.Mock
+}
+
+func (m *MockGoalRepository) CreateGoal(ctx context.Context, arg database.CreateGoalParams) (database.Goal, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Goal), args.Error(1)
+}
+
+func (m *MockGoalRepository) ListGoals(ctx context.Context, userID string) ([]database.Goal, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.Goal), args.Error(1)
+}
+
+func (m *MockGoalRepository) GetGoal(ctx context.Context, id, userID string) (database.Goal, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Get(0).(database.Goal), args.Error(1)
+}
+
+func (m *MockGoalRepository) UpdateGoal(ctx context.Context, arg database.UpdateGoalParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockGoalRepository) DeleteGoal(ctx context.Context, id, userID string) (int64, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockGoalRepository) GetSubject(ctx context.Context, id, userID string) (database.Subject, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Get(0).(database.Subject), args.Error(1)
+}
+
+func TestGoalManager_CreateGoal(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockGoalRepository)
+		svc := service.NewGoalManager(mockRepo, service.NewAnalyticsManager(new(MockAnalyticsRepository)))
+
+		mockRepo.On("GetSubject", ctx, "subject-1", "user-1").Return(database.Subject{ID: "subject-1"}, nil)
+		mockRepo.On("CreateGoal", ctx, mock.MatchedBy(func(arg database.CreateGoalParams) bool {
+			// Saturday and Sunday
+			return arg.UserID == "user-1" && arg.SubjectID.String == "subject-1" && arg.RestDays == 0b1000001
+		})).Return(database.Goal{ID: "goal-1", Metric: service.GoalMetricNetMinutes}, nil)
+
+		goal, err := svc.CreateGoal(ctx, "user-1", service.GoalInput{
+			SubjectID: "subject-1",
+			Metric:    service.GoalMetricNetMinutes,
+			Period:    service.GoalPeriodDay,
+			Target:    240,
+			RestDays:  []int{0, 6},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "goal-1", goal.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rest days on a weekly goal", func(t *testing.T) {
+		svc := service.NewGoalManager(new(MockGoalRepository), service.NewAnalyticsManager(new(MockAnalyticsRepository)))
+
+		_, err := svc.CreateGoal(ctx, "user-1", service.GoalInput{
+			Metric:   service.GoalMetricQuestions,
+			Period:   service.GoalPeriodWeek,
+			Target:   200,
+			RestDays: []int{0},
+		})
+		assert.ErrorIs(t, err, service.ErrInvalidGoal)
+	})
+
+	t.Run("Subject of another user", func(t *testing.T) {
+		mockRepo := new(MockGoalRepository)
+		svc := service.NewGoalManager(mockRepo, service.NewAnalyticsManager(new(MockAnalyticsRepository)))
+
+		mockRepo.On("GetSubject", ctx, "subject-2", "user-1").Return(database.Subject{}, sql.ErrNoRows)
+
+		_, err := svc.CreateGoal(ctx, "user-1", service.GoalInput{
+			SubjectID: "subject-2",
+			Metric:    service.GoalMetricQuestions,
+			Period:    service.GoalPeriodWeek,
+			Target:    200,
+		})
+		assert.ErrorIs(t, err, service.ErrInvalidGoal)
+	})
+}
+
+func TestGoalManager_GetStreaks(t *testing.T) {
+	mockRepo := new(MockGoalRepository)
+	analyticsRepo := new(MockAnalyticsRepository)
+	svc := service.NewGoalManager(mockRepo, service.NewAnalyticsManager(analyticsRepo))
+	ctx := context.Background()
+
+	today := time.Now().UTC()
+	midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	day := func(daysAgo int) time.Time { return midnight.AddDate(0, 0, -daysAgo) }
+	studied := func(daysAgo int, minutes int64) database.ListFinishedSessionsInRangeRow {
+		start := day(daysAgo).Add(9 * time.Hour)
+		end := start.Add(time.Duration(minutes) * time.Minute)
+		return sessionRow("s", "math", start.Format(time.RFC3339), end.Format(time.RFC3339), minutes*60)
+	}
+
+	// Goal of 60 minutes a day, resting on the weekday of 2 days ago
+	mockRepo.On("ListGoals", ctx, "user-1").Return([]database.Goal{{
+		ID:       "goal-1",
+		Metric:   service.GoalMetricNetMinutes,
+		Period:   service.GoalPeriodDay,
+		Target:   60,
+		RestDays: 1 << day(2).Weekday(),
+	}}, nil)
+	analyticsRepo.On("GetUserByID", ctx, "user-1").Return(database.User{ID: "user-1", Timezone: "UTC", WeekStartDay: 1}, nil)
+	analyticsRepo.On("ListFinishedSessionsInRange", ctx, mock.Anything).Return([]database.ListFinishedSessionsInRangeRow{
+		studied(9, 90), studied(8, 90), studied(7, 60), // 3-day streak
+		studied(5, 30),                                  // Too short: breaks it
+		studied(4, 60), studied(3, 60), studied(1, 120), // Rest day 2 days ago is skipped
+		studied(0, 20), // Today isn't over yet
+	}, nil)
+	analyticsRepo.On("ListExerciseLogsInRange", ctx, mock.Anything).Return([]database.ListExerciseLogsInRangeRow{}, nil)
+
+	streaks, err := svc.GetStreaks(ctx, "user-1", "")
+
+	assert.NoError(t, err)
+	assert.Len(t, streaks, 1)
+	assert.Equal(t, 3, streaks[0].CurrentStreak)
+	assert.Equal(t, 3, streaks[0].LongestStreak)
+	assert.Equal(t, int64(20), streaks[0].Value)
+	assert.False(t, streaks[0].Attained)
+	assert.Equal(t, day(1), streaks[0].LastAttained)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was used for a different request")
+	ErrIdempotencyKeyInFlight = errors.New("a request with this idempotency key is still running")
+)
+
+// DefaultIdempotencyTTL is how long a response is replayed to retries
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotentRequest is what a retry has to repeat to get the stored response
+type IdempotentRequest struct {
+	Method string
+	Path   string
+	Body   []byte
+}
+
+// StoredResponse is the first response to a request, replayed to its retries
+type StoredResponse struct {
+	StatusCode int
+	Header     http.Header // As the handler set it, ETag and Location included
+	Body       []byte
+}
+
+// IdempotencyService makes retried POSTs safe. The first request with a key
+// claims it and runs; its response is stored for the user and key until the
+// TTL ends, and retries get that response instead of running again.
+type IdempotencyService interface {
+	// Begin claims key for the request and returns nil when it should run. A
+	// retry gets the stored response, or ErrIdempotencyKeyInFlight while the
+	// first request runs; a different request under the same key gets
+	// ErrIdempotencyKeyReused.
+	Begin(ctx context.Context, userID, key string, req IdempotentRequest) (*StoredResponse, error)
+	// Complete stores the response of the request that claimed key
+	Complete(ctx context.Context, userID, key string, resp StoredResponse) error
+	// Release frees key without a response, so a retry runs again
+	Release(ctx context.Context, userID, key string) error
+	// PurgeExpired deletes the responses whose TTL ended by now and returns
+	// how many there were
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type IdempotencyManager struct {
+	repo repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+func NewIdempotencyManager(repo repository.IdempotencyRepository) *IdempotencyManager {
+	return &IdempotencyManager{repo: repo, ttl: DefaultIdempotencyTTL}
+}
+
+// WithTTL overrides how long responses are replayed
+func (s *IdempotencyManager) WithTTL(ttl time.Duration) *IdempotencyManager {
+	s.ttl = ttl
+	return s
+}
+
+func (s *IdempotencyManager) Begin(ctx context.Context, userID, key string, req IdempotentRequest) (*StoredResponse, error) {
+	now := time.Now()
+	// An expired claim is as good as none
+	err := s.repo.ReleaseExpiredIdempotencyKey(ctx, database.ReleaseExpiredIdempotencyKeyParams{
+		UserID:         userID,
+		IdempotencyKey: key,
+		ExpiresAt:      formatSQLiteTime(now),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hash := requestHash(req)
+	claimed, err := s.repo.ClaimIdempotencyKey(ctx, database.ClaimIdempotencyKeyParams{
+		UserID:         userID,
+		IdempotencyKey: key,
+		RequestHash:    hash,
+		ExpiresAt:      formatSQLiteTime(now.Add(s.ttl)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claimed == 1 {
+		return nil, nil
+	}
+
+	row, err := s.repo.GetIdempotencyKey(ctx, database.GetIdempotencyKeyParams{UserID: userID, IdempotencyKey: key})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Released since the claim failed; the client can retry
+			return nil, ErrIdempotencyKeyInFlight
+		}
+		return nil, err
+	}
+	if row.RequestHash != hash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !row.StatusCode.Valid {
+		return nil, ErrIdempotencyKeyInFlight
+	}
+	header := http.Header{}
+	if row.ResponseHeaders.Valid {
+		if err := json.Unmarshal([]byte(row.ResponseHeaders.String), &header); err != nil {
+			return nil, err
+		}
+	} else if row.ContentType.Valid {
+		// Stored before the headers were
+		header.Set("Content-Type", row.ContentType.String)
+	}
+	return &StoredResponse{
+		StatusCode: int(row.StatusCode.Int64),
+		Header:     header,
+		Body:       row.ResponseBody,
+	}, nil
+}
+
+func (s *IdempotencyManager) Complete(ctx context.Context, userID, key string, resp StoredResponse) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	return s.repo.CompleteIdempotencyKey(ctx, database.CompleteIdempotencyKeyParams{
+		StatusCode:      sql.NullInt64{Int64: int64(resp.StatusCode), Valid: true},
+		ContentType:     nullString(resp.Header.Get("Content-Type")),
+		ResponseHeaders: sql.NullString{String: string(header), Valid: true},
+		ResponseBody:    resp.Body,
+		UserID:          userID,
+		IdempotencyKey:  key,
+	})
+}
+
+func (s *IdempotencyManager) Release(ctx context.Context, userID, key string) error {
+	return s.repo.ReleaseIdempotencyKey(ctx, database.ReleaseIdempotencyKeyParams{UserID: userID, IdempotencyKey: key})
+}
+
+func (s *IdempotencyManager) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	purged, err := s.repo.PurgeExpiredIdempotencyKeys(ctx, formatSQLiteTime(now))
+	return int(purged), err
+}
+
+// requestHash fingerprints a request, so a key reused for another endpoint
+// or another body is told apart from a retry
+func requestHash(req IdempotentRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.Path + "\n"))
+	h.Write(req.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
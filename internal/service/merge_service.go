@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+var (
+	ErrMergeNotFound       = errors.New("source or target not found")
+	ErrMergeIntoItself     = errors.New("source and target are the same")
+	ErrMergeAcrossSubjects = errors.New("topics belong to different subjects")
+)
+
+// MergeReport tells what a merge moved from the source to the target
+type MergeReport struct {
+	SourceID string
+	TargetID string
+	DryRun   bool
+	Moved    map[string]int64 // Rows moved per table
+}
+
+// MergeService folds duplicate subjects and topics into one. A merge moves
+// every row pointing at the source onto the target and moves the source to
+// the trash, all in one transaction; a dry run reports the same counts and
+// rolls back.
+type MergeService interface {
+	// MergeSubjects moves topics, cycle items, sessions, exercise logs and
+	// goals. Both subjects must be the user's and not deleted.
+	MergeSubjects(ctx context.Context, userID, sourceID, targetID string, dryRun bool) (MergeReport, error)
+	// MergeTopics moves exercise logs. Both topics must be live and under the
+	// same subject of the user; merge their subjects first otherwise.
+	MergeTopics(ctx context.Context, userID, sourceID, targetID string, dryRun bool) (MergeReport, error)
+}
+
+type MergeManager struct {
+	repo repository.MergeRepository
+}
+
+func NewMergeManager(repo repository.MergeRepository) *MergeManager {
+	return &MergeManager{repo: repo}
+}
+
+func (s *MergeManager) MergeSubjects(ctx context.Context, userID, sourceID, targetID string, dryRun bool) (MergeReport, error) {
+	if sourceID == targetID {
+		return MergeReport{}, ErrMergeIntoItself
+	}
+
+	report := MergeReport{SourceID: sourceID, TargetID: targetID, DryRun: dryRun, Moved: map[string]int64{}}
+	err := s.repo.Tx(ctx, func(repo repository.MergeRepository) error {
+		for _, id := range []string{sourceID, targetID} {
+			if _, err := repo.GetSubject(ctx, database.GetSubjectParams{ID: id, UserID: userID}); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrMergeNotFound
+				}
+				return err
+			}
+		}
+
+		moves := []struct {
+			table string
+			move  func() (int64, error)
+		}{
+			{"topics", func() (int64, error) {
+				return repo.MoveSubjectTopics(ctx, database.MoveSubjectTopicsParams{TargetID: targetID, SourceID: sourceID})
+			}},
+			{"cycle_items", func() (int64, error) {
+				return repo.MoveSubjectCycleItems(ctx, database.MoveSubjectCycleItemsParams{TargetID: targetID, SourceID: sourceID})
+			}},
+			{"study_sessions", func() (int64, error) {
+				return repo.MoveSubjectStudySessions(ctx, database.MoveSubjectStudySessionsParams{TargetID: targetID, SourceID: sourceID})
+			}},
+			{"exercise_logs", func() (int64, error) {
+				return repo.MoveSubjectExerciseLogs(ctx, database.MoveSubjectExerciseLogsParams{TargetID: targetID, SourceID: sourceID})
+			}},
+			{"goals", func() (int64, error) {
+				return repo.MoveSubjectGoals(ctx, database.MoveSubjectGoalsParams{TargetID: targetID, SourceID: sourceID})
+			}},
+		}
+		for _, m := range moves {
+			n, err := m.move()
+			if err != nil {
+				return err
+			}
+			report.Moved[m.table] = n
+		}
+
+		if err := repo.DeleteSubject(ctx, database.DeleteSubjectParams{ID: sourceID, UserID: userID}); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return MergeReport{}, err
+	}
+	return report, nil
+}
+
+func (s *MergeManager) MergeTopics(ctx context.Context, userID, sourceID, targetID string, dryRun bool) (MergeReport, error) {
+	if sourceID == targetID {
+		return MergeReport{}, ErrMergeIntoItself
+	}
+
+	report := MergeReport{SourceID: sourceID, TargetID: targetID, DryRun: dryRun, Moved: map[string]int64{}}
+	err := s.repo.Tx(ctx, func(repo repository.MergeRepository) error {
+		var subjectIDs []string
+		for _, id := range []string{sourceID, targetID} {
+			topic, err := repo.GetTopic(ctx, id)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrMergeNotFound
+				}
+				return err
+			}
+			// Topics belong to the user through their subject
+			if _, err := repo.GetSubject(ctx, database.GetSubjectParams{ID: topic.SubjectID, UserID: userID}); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrMergeNotFound
+				}
+				return err
+			}
+			subjectIDs = append(subjectIDs, topic.SubjectID)
+		}
+		if subjectIDs[0] != subjectIDs[1] {
+			return ErrMergeAcrossSubjects
+		}
+
+		n, err := repo.MoveTopicExerciseLogs(ctx, database.MoveTopicExerciseLogsParams{TargetID: targetID, SourceID: sourceID})
+		if err != nil {
+			return err
+		}
+		report.Moved["exercise_logs"] = n
+
+		if err := repo.DeleteTopic(ctx, sourceID); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return MergeReport{}, err
+	}
+	return report, nil
+}
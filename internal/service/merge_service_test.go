@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMergeRepository is a mock implementation of repository.MergeRepository
+type MockMergeRepository struct {
+	mock.Mock
+	committed bool
+}
+
+func (m *MockMergeRepository) GetSubject(ctx context.Context, arg database.GetSubjectParams) (database.Subject, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Subject), args.Error(1)
+}
+
+func (m *MockMergeRepository) GetTopic(ctx context.Context, id string) (database.Topic, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Topic), args.Error(1)
+}
+
+func (m *MockMergeRepository) DeleteSubject(ctx context.Context, arg database.DeleteSubjectParams) error {
+	return m.Called(ctx, arg).Error(0)
+}
+
+func (m *MockMergeRepository) DeleteTopic(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockMergeRepository) MoveSubjectTopics(ctx context.Context, arg database.MoveSubjectTopicsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMergeRepository) MoveSubjectCycleItems(ctx context.Context, arg database.MoveSubjectCycleItemsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMergeRepository) MoveSubjectStudySessions(ctx context.Context, arg database.MoveSubjectStudySessionsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMergeRepository) MoveSubjectExerciseLogs(ctx context.Context, arg database.MoveSubjectExerciseLogsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMergeRepository) MoveSubjectGoals(ctx context.Context, arg database.MoveSubjectGoalsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMergeRepository) MoveTopicExerciseLogs(ctx context.Context, arg database.MoveTopicExerciseLogsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Tx records whether fn asked for a commit
+func (m *MockMergeRepository) Tx(ctx context.Context, fn func(repository.MergeRepository) error) error {
+	m.Called(ctx)
+	err := fn(m)
+	m.committed = err == nil
+	return err
+}
+
+func TestMergeManager_MergeSubjects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Dry run reports the moves and rolls back", func(t *testing.T) {
+		mockRepo := new(MockMergeRepository)
+		svc := service.NewMergeManager(mockRepo)
+
+		mockRepo.On("Tx", ctx).Return(nil)
+		mockRepo.On("GetSubject", ctx, mock.Anything).Return(database.Subject{}, nil)
+		mockRepo.On("MoveSubjectTopics", ctx, database.MoveSubjectTopicsParams{TargetID: "pt", SourceID: "lp"}).Return(int64(3), nil)
+		mockRepo.On("MoveSubjectCycleItems", ctx, mock.Anything).Return(int64(1), nil)
+		mockRepo.On("MoveSubjectStudySessions", ctx, mock.Anything).Return(int64(12), nil)
+		mockRepo.On("MoveSubjectExerciseLogs", ctx, mock.Anything).Return(int64(20), nil)
+		mockRepo.On("MoveSubjectGoals", ctx, mock.Anything).Return(int64(0), nil)
+		mockRepo.On("DeleteSubject", ctx, database.DeleteSubjectParams{ID: "lp", UserID: "user-1"}).Return(nil)
+
+		report, err := svc.MergeSubjects(ctx, "user-1", "lp", "pt", true)
+		assert.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, map[string]int64{"topics": 3, "cycle_items": 1, "study_sessions": 12, "exercise_logs": 20, "goals": 0}, report.Moved)
+		assert.False(t, mockRepo.committed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown target moves nothing", func(t *testing.T) {
+		mockRepo := new(MockMergeRepository)
+		svc := service.NewMergeManager(mockRepo)
+
+		mockRepo.On("Tx", ctx).Return(nil)
+		mockRepo.On("GetSubject", ctx, database.GetSubjectParams{ID: "lp", UserID: "user-1"}).Return(database.Subject{}, nil)
+		mockRepo.On("GetSubject", ctx, database.GetSubjectParams{ID: "pt", UserID: "user-1"}).Return(database.Subject{}, sql.ErrNoRows)
+
+		_, err := svc.MergeSubjects(ctx, "user-1", "lp", "pt", false)
+		assert.ErrorIs(t, err, service.ErrMergeNotFound)
+		mockRepo.AssertNotCalled(t, "MoveSubjectTopics", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed moves roll back", func(t *testing.T) {
+		mockRepo := new(MockMergeRepository)
+		svc := service.NewMergeManager(mockRepo)
+
+		mockRepo.On("Tx", ctx).Return(nil)
+		mockRepo.On("GetSubject", ctx, mock.Anything).Return(database.Subject{}, nil)
+		mockRepo.On("MoveSubjectTopics", ctx, mock.Anything).Return(int64(0), errors.New("disk I/O error"))
+
+		_, err := svc.MergeSubjects(ctx, "user-1", "lp", "pt", false)
+		assert.Error(t, err)
+		assert.False(t, mockRepo.committed)
+	})
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidPatch rejects a partial update, e.g. one nulling a required field
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// Field is one member of a partial update. A JSON merge patch tells three
+// cases apart and so does Field: left out (zero Field), null (Set and Null)
+// and a value, zero values included (Set with Value).
+type Field[T comparable] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// Set returns a Field changing the member to v
+func Set[T comparable](v T) Field[T] {
+	return Field[T]{Set: true, Value: v}
+}
+
+// Null returns a Field clearing the member
+func Null[T comparable]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+// Ptr is the new value, nil when the field is nulled or left out
+func (f Field[T]) Ptr() *T {
+	if !f.Set || f.Null {
+		return nil
+	}
+	return &f.Value
+}
+
+// UnmarshalJSON reads a merge patch member. It only runs for members that are
+// present, which is what tells a left out field from a null one.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// versioned explains why a versioned update returned no row: the row still
+// exists, so it moved past the expected version, or get can't find it either.
+// Other results pass through.
+func versioned[T any](row T, err error, get func() error, notFound error) (T, error) {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return row, err
+	}
+	var zero T
+	if err := get(); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zero, notFound
+		}
+		return zero, err
+	}
+	return zero, ErrVersionMismatch
+}
+
+// unchanged answers a patch that changes nothing with the current row, still
+// checking it is at the expected version, so it doesn't bump the version
+func unchanged[T any](row T, err error, rowVersion func(T) int64, version *int64, notFound error) (T, error) {
+	var zero T
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zero, notFound
+		}
+		return zero, err
+	}
+	if version != nil && *version != rowVersion(row) {
+		return zero, ErrVersionMismatch
+	}
+	return row, nil
+}
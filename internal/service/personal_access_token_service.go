@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+// TokenPrefix marks personal access tokens so the auth middleware can tell them from JWTs
+const TokenPrefix = "pat_"
+
+// sqliteTimeFormat matches datetime('now') so stored timestamps compare as text
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+var (
+	ErrInvalidToken  = errors.New("invalid or expired token")
+	ErrTokenNotFound = errors.New("token not found")
+	ErrInvalidScope  = errors.New("invalid token scope")
+)
+
+const (
+	ScopeAll  = "all"  // Full access, same as a JWT
+	ScopeRead = "read" // Read access to every resource
+)
+
+// TokenResources are the resource groups a scope can target, as
+// "<resource>:read" or "<resource>:write" (write implies read).
+var TokenResources = []string{"subjects", "cycles", "sessions", "analytics", "goals", "account", "tokens"}
+
+// TokenIdentity is what a valid personal access token resolves to
+type TokenIdentity struct {
+	TokenID string
+	UserID  string
+	Email   string
+	Scopes  []string
+}
+
+type PersonalAccessTokenService interface {
+	// CreateToken returns the stored token and its plaintext, which is never retrievable again.
+	// A zero expiresAt creates a token that does not expire.
+	CreateToken(ctx context.Context, userID, name string, scopes []string, expiresAt time.Time) (database.PersonalAccessToken, string, error)
+	ListTokens(ctx context.Context, userID string) ([]database.PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, id, userID string) error
+	AuthenticateToken(ctx context.Context, token string) (TokenIdentity, error)
+}
+
+type PersonalAccessTokenManager struct {
+	repo repository.PersonalAccessTokenRepository
+}
+
+func NewPersonalAccessTokenManager(repo repository.PersonalAccessTokenRepository) *PersonalAccessTokenManager {
+	return &PersonalAccessTokenManager{repo: repo}
+}
+
+func (s *PersonalAccessTokenManager) CreateToken(ctx context.Context, userID, name string, scopes []string, expiresAt time.Time) (database.PersonalAccessToken, string, error) {
+	scopes, err := NormalizeScopes(scopes)
+	if err != nil {
+		return database.PersonalAccessToken{}, "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return database.PersonalAccessToken{}, "", err
+	}
+	plaintext := TokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	var expires sql.NullString
+	if !expiresAt.IsZero() {
+		expires = sql.NullString{String: expiresAt.UTC().Format(sqliteTimeFormat), Valid: true}
+	}
+
+	token, err := s.repo.CreateToken(ctx, database.CreatePersonalAccessTokenParams{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   hashToken(plaintext),
+		TokenPrefix: plaintext[:len(TokenPrefix)+6],
+		Scopes:      strings.Join(scopes, " "),
+		ExpiresAt:   expires,
+	})
+	if err != nil {
+		return database.PersonalAccessToken{}, "", err
+	}
+	return token, plaintext, nil
+}
+
+func (s *PersonalAccessTokenManager) ListTokens(ctx context.Context, userID string) ([]database.PersonalAccessToken, error) {
+	tokens, err := s.repo.ListTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		return []database.PersonalAccessToken{}, nil
+	}
+	return tokens, nil
+}
+
+func (s *PersonalAccessTokenManager) RevokeToken(ctx context.Context, id, userID string) error {
+	rows, err := s.repo.RevokeToken(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func (s *PersonalAccessTokenManager) AuthenticateToken(ctx context.Context, token string) (TokenIdentity, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return TokenIdentity{}, ErrInvalidToken
+	}
+
+	row, err := s.repo.GetTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TokenIdentity{}, ErrInvalidToken
+		}
+		return TokenIdentity{}, err
+	}
+
+	// Only record usage once a minute to avoid a write on every request
+	if shouldTouch(row.LastUsedAt, time.Now()) {
+		if err := s.repo.TouchToken(ctx, row.ID); err != nil {
+			slog.Warn("Failed to update token last use", "token_id", row.ID, "error", err)
+		}
+	}
+
+	return TokenIdentity{
+		TokenID: row.ID,
+		UserID:  row.UserID,
+		Email:   row.Email,
+		Scopes:  strings.Fields(row.Scopes),
+	}, nil
+}
+
+// NormalizeScopes validates the requested scopes and removes duplicates
+func NormalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !validScope(scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// ScopeAllows reports whether the granted scopes cover the required
+// "<resource>:<read|write>" scope.
+func ScopeAllows(granted []string, required string) bool {
+	resource, access, _ := strings.Cut(required, ":")
+	for _, scope := range granted {
+		switch {
+		case scope == ScopeAll, scope == required:
+			return true
+		case access == "read" && (scope == ScopeRead || scope == resource+":write"):
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesWithin reports whether every requested scope grants no more than
+// the granted scopes do, so a token can't mint one wider than itself.
+func ScopesWithin(granted, requested []string) bool {
+	for _, scope := range requested {
+		switch scope {
+		case ScopeAll:
+			if !slices.Contains(granted, ScopeAll) {
+				return false
+			}
+		case ScopeRead:
+			for _, resource := range TokenResources {
+				if !ScopeAllows(granted, resource+":read") {
+					return false
+				}
+			}
+		default:
+			if !ScopeAllows(granted, scope) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validScope(scope string) bool {
+	if scope == ScopeAll || scope == ScopeRead {
+		return true
+	}
+	resource, access, ok := strings.Cut(scope, ":")
+	if !ok || (access != "read" && access != "write") {
+		return false
+	}
+	for _, r := range TokenResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func shouldTouch(lastUsedAt sql.NullString, now time.Time) bool {
+	if !lastUsedAt.Valid {
+		return true
+	}
+	last, err := time.Parse(sqliteTimeFormat, lastUsedAt.String)
+	if err != nil {
+		return true
+	}
+	return now.Sub(last) >= time.Minute
+}
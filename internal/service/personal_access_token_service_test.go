@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPersonalAccessTokenRepository is a mock implementation of repository.PersonalAccessTokenRepository
+type MockPersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenRepository) CreateToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) ListTokens(ctx context.Context, userID string) ([]database.PersonalAccessToken, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) GetTokenByHash(ctx context.Context, tokenHash string) (database.GetPersonalAccessTokenByHashRow, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(database.GetPersonalAccessTokenByHashRow), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) TouchToken(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) RevokeToken(ctx context.Context, id, userID string) (int64, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestPersonalAccessTokenManager_CreateToken(t *testing.T) {
+	mockRepo := new(MockPersonalAccessTokenRepository)
+	svc := service.NewPersonalAccessTokenManager(mockRepo)
+
+	ctx := context.Background()
+	var stored database.CreatePersonalAccessTokenParams
+	mockRepo.On("CreateToken", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(database.CreatePersonalAccessTokenParams)
+	}).Return(database.PersonalAccessToken{ID: "token-uuid"}, nil)
+
+	_, plaintext, err := svc.CreateToken(ctx, "user-123", "cli", []string{"sessions:write", "Analytics:read", "sessions:write"}, time.Time{})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, service.TokenPrefix))
+	sum := sha256.Sum256([]byte(plaintext))
+	assert.Equal(t, hex.EncodeToString(sum[:]), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, plaintext)
+	assert.Equal(t, "sessions:write analytics:read", stored.Scopes)
+	assert.False(t, stored.ExpiresAt.Valid)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPersonalAccessTokenManager_CreateToken_InvalidScope(t *testing.T) {
+	mockRepo := new(MockPersonalAccessTokenRepository)
+	svc := service.NewPersonalAccessTokenManager(mockRepo)
+
+	_, _, err := svc.CreateToken(context.Background(), "user-123", "cli", []string{"admin"}, time.Time{})
+
+	assert.ErrorIs(t, err, service.ErrInvalidScope)
+	mockRepo.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything)
+}
+
+func TestPersonalAccessTokenManager_AuthenticateToken(t *testing.T) {
+	mockRepo := new(MockPersonalAccessTokenRepository)
+	svc := service.NewPersonalAccessTokenManager(mockRepo)
+
+	ctx := context.Background()
+	token := service.TokenPrefix + "secret"
+	sum := sha256.Sum256([]byte(token))
+
+	mockRepo.On("GetTokenByHash", ctx, hex.EncodeToString(sum[:])).Return(database.GetPersonalAccessTokenByHashRow{
+		ID:         "token-uuid",
+		UserID:     "user-123",
+		Scopes:     "analytics:read",
+		LastUsedAt: sql.NullString{},
+		Email:      "test@example.com",
+	}, nil)
+	mockRepo.On("TouchToken", ctx, "token-uuid").Return(nil)
+
+	identity, err := svc.AuthenticateToken(ctx, token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-123", identity.UserID)
+	assert.Equal(t, []string{"analytics:read"}, identity.Scopes)
+	mockRepo.AssertExpectations(t)
+
+	_, err = svc.AuthenticateToken(ctx, "not-a-pat")
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+}
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{"all"}, "subjects:write", true},
+		{[]string{"read"}, "analytics:read", true},
+		{[]string{"read"}, "sessions:write", false},
+		{[]string{"sessions:write"}, "sessions:read", true},
+		{[]string{"analytics:read"}, "analytics:write", false},
+		{[]string{"analytics:read"}, "sessions:read", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, service.ScopeAllows(tt.granted, tt.required), "%v -> %s", tt.granted, tt.required)
+	}
+}
+
+func TestScopesWithin(t *testing.T) {
+	tests := []struct {
+		granted   []string
+		requested []string
+		want      bool
+	}{
+		{[]string{"all"}, []string{"all"}, true},
+		{[]string{"tokens:write"}, []string{"all"}, false},
+		{[]string{"tokens:write"}, []string{"read"}, false},
+		{[]string{"read"}, []string{"read", "goals:read"}, true},
+		{[]string{"tokens:write"}, []string{"tokens:read"}, true},
+		{[]string{"tokens:write"}, []string{"tokens:write", "subjects:write"}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, service.ScopesWithin(tt.granted, tt.requested), "%v -> %v", tt.granted, tt.requested)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+)
+
+// ProductivityTotals is the study done in one bucket of the profile
+type ProductivityTotals struct {
+	NetSeconds int64
+	Questions  int64
+	Correct    int64
+	Accuracy   *float64 // Percentage; nil without questions
+}
+
+func (t *ProductivityTotals) add(other ProductivityTotals) {
+	t.NetSeconds += other.NetSeconds
+	t.Questions += other.Questions
+	t.Correct += other.Correct
+	t.Accuracy = accuracyPercentage(t.Correct, t.Questions)
+}
+
+// ProductivityHour is one local hour (0-23), summed over the range
+type ProductivityHour struct {
+	Hour int
+	ProductivityTotals
+}
+
+type ProductivityWeekday struct {
+	Weekday time.Weekday
+	ProductivityTotals
+	Hours []ProductivityHour // All 24
+}
+
+// ProductivityProfile is an hour×weekday matrix of net time and accuracy in
+// the user's timezone
+type ProductivityProfile struct {
+	Weekdays []ProductivityWeekday // All seven, from the week start
+	Hours    []ProductivityHour    // Each hour across all weekdays
+}
+
+// GetProductivity builds the hour×weekday profile for the local dates
+// [startDateFrom, startDateTo]. Net time is spread over the time a session
+// wasn't paused and cut at every local hour; exercises count in the hour
+// they were logged.
+func (s *AnalyticsManager) GetProductivity(ctx context.Context, userID string, cal Calendar, startDateFrom, startDateTo string) (ProductivityProfile, error) {
+	from, to, err := parseDateRange(startDateFrom, startDateTo, cal.Location)
+	if err != nil {
+		return ProductivityProfile{}, err
+	}
+
+	sessions, err := s.listSessions(ctx, userID, from, to)
+	if err != nil {
+		return ProductivityProfile{}, err
+	}
+	pauses, err := s.listPauses(ctx, userID, from, to)
+	if err != nil {
+		return ProductivityProfile{}, err
+	}
+	logs, err := s.repo.ListExerciseLogsInRange(ctx, database.ListExerciseLogsInRangeParams{
+		UserID:    userID,
+		RangeFrom: formatSQLiteTime(from),
+		RangeTo:   formatSQLiteTime(to),
+	})
+	if err != nil {
+		return ProductivityProfile{}, err
+	}
+
+	var matrix [7][24]ProductivityTotals
+	for _, session := range sessions {
+		for _, slice := range splitByHour(session, pauses[session.ID], cal.Location) {
+			if slice.Start.Before(from) || !slice.Start.Before(to) {
+				continue
+			}
+			local := slice.Start.In(cal.Location)
+			matrix[local.Weekday()][local.Hour()].add(ProductivityTotals{NetSeconds: slice.Seconds})
+		}
+	}
+	for _, log := range logs {
+		created, err := parseTimestamp(log.CreatedAt)
+		if err != nil {
+			slog.Warn("Skipping exercise log with unreadable date", "exercise_log_id", log.ID, "error", err)
+			continue
+		}
+		local := created.In(cal.Location)
+		matrix[local.Weekday()][local.Hour()].add(ProductivityTotals{Questions: log.QuestionsCount, Correct: log.CorrectCount})
+	}
+
+	profile := ProductivityProfile{
+		Weekdays: make([]ProductivityWeekday, 0, 7),
+		Hours:    make([]ProductivityHour, 24),
+	}
+	for hour := range profile.Hours {
+		profile.Hours[hour].Hour = hour
+	}
+	for i := 0; i < 7; i++ {
+		day := ProductivityWeekday{
+			Weekday: (cal.WeekStart + time.Weekday(i)) % 7,
+			Hours:   make([]ProductivityHour, 24),
+		}
+		for hour, totals := range matrix[day.Weekday] {
+			day.Hours[hour] = ProductivityHour{Hour: hour, ProductivityTotals: totals}
+			day.add(totals)
+			profile.Hours[hour].add(totals)
+		}
+		profile.Weekdays = append(profile.Weekdays, day)
+	}
+	return profile, nil
+}
+
+// hourSlice is the part of a session's net time that falls in one local hour
+type hourSlice struct {
+	Start   time.Time
+	Seconds int64
+}
+
+// splitByHour spreads a session's net seconds evenly over the time it wasn't
+// paused and cuts that at every local hour in loc. Like splitByDay, the
+// slices always add up to the net seconds.
+func splitByHour(session timedSession, pauses []interval, loc *time.Location) []hourSlice {
+	active := activeIntervals(session.Start, session.End, pauses)
+	var total time.Duration
+	for _, interval := range active {
+		total += interval.End.Sub(interval.Start)
+	}
+	if total <= 0 {
+		return []hourSlice{{Start: session.Start, Seconds: session.NetSeconds}}
+	}
+
+	share := func(elapsed time.Duration) int64 {
+		return int64(math.Round(float64(session.NetSeconds) * float64(elapsed) / float64(total)))
+	}
+
+	var slices []hourSlice
+	var elapsed time.Duration
+	for _, interval := range active {
+		for start := interval.Start; start.Before(interval.End); {
+			end := nextLocalHour(start, loc)
+			if !end.After(start) {
+				// Can't happen, but a slice that doesn't advance would loop forever
+				end = start.Add(time.Hour)
+			}
+			if end.After(interval.End) {
+				end = interval.End
+			}
+			before := share(elapsed)
+			elapsed += end.Sub(start)
+			slices = append(slices, hourSlice{Start: start, Seconds: share(elapsed) - before})
+			start = end
+		}
+	}
+	return slices
+}
+
+// activeIntervals is [start, end) minus the pauses, clipped to the session
+func activeIntervals(start, end time.Time, pauses []interval) []interval {
+	sorted := append([]interval(nil), pauses...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	var active []interval
+	cursor := start
+	for _, pause := range sorted {
+		if pause.Start.After(cursor) {
+			active = append(active, interval{Start: cursor, End: minTime(pause.Start, end)})
+		}
+		if pause.End.After(cursor) {
+			cursor = pause.End
+		}
+		if !cursor.Before(end) {
+			return active
+		}
+	}
+	return append(active, interval{Start: cursor, End: end})
+}
+
+// nextLocalHour returns the start of the local hour after t's. It works in
+// absolute time under t's UTC offset: rebuilding the hour with time.Date
+// would land on the first of the two 01:00s when clocks fall back, before t.
+// Zones offset by minutes, like +05:30, cut at their own half hours.
+func nextLocalHour(t time.Time, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(time.Hour).Add(time.Hour - shift)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
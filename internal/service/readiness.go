@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+
+	// Why a subject is at risk
+	RiskNoRecentStudy     = "no_recent_study"     // No time or questions in the pace window
+	RiskNoRecentQuestions = "no_recent_questions" // Studied, but accuracy can't be projected
+	RiskLowCoverage       = "low_coverage"        // Under 70% of topics touched by exam day
+	RiskPartialCoverage   = "partial_coverage"    // Under 90%
+	RiskLowAccuracy       = "low_accuracy"        // Projected under 50%
+	RiskModerateAccuracy  = "moderate_accuracy"   // Projected under 70%
+
+	// readinessPaceDays is the window the current pace is measured over
+	readinessPaceDays = 14
+	// readinessMinQuestions is how many questions each window needs before an accuracy trend is drawn
+	readinessMinQuestions = 20
+	// readinessTrendDays is the time constant the accuracy trend fades over;
+	// a projection can gain at most this many days' worth of the current trend
+	readinessTrendDays = 30.0
+)
+
+var (
+	ErrNoExamDate      = errors.New("no exam date set")
+	ErrInvalidExamDate = errors.New("exam date must be today or a later YYYY-MM-DD date")
+)
+
+// SubjectReadiness projects one subject to exam day at its current pace
+type SubjectReadiness struct {
+	SubjectID   string
+	SubjectName string
+	ColorHex    string
+
+	// Pace over the last readinessPaceDays days and what it adds up to by exam day
+	NetSecondsPerDay    float64
+	QuestionsPerDay     float64
+	ProjectedNetSeconds int64 // Further net time by exam day
+	ProjectedQuestions  int64
+
+	// Syllabus coverage: a topic is touched once it has an exercise log
+	Topics                 int
+	TopicsTouched          int
+	TopicsRevised          int      // Practiced within the pace window
+	ProjectedTopicsTouched int      // At the pace of newly touched topics
+	Coverage               *float64 // Percentage of topics touched; nil without topics
+	ProjectedCoverage      *float64
+
+	// Accuracy trajectory
+	Accuracy          *float64 // Pace window; nil without questions
+	PreviousAccuracy  *float64 // The window before it
+	AccuracyTrend     *float64 // Percentage points per week; nil with too few questions in either window
+	ProjectedAccuracy *float64 // On exam day, with the trend fading out
+
+	Risk        string   // low, medium or high
+	RiskReasons []string // Empty when low
+}
+
+// ReadinessForecast projects every subject to exam day
+type ReadinessForecast struct {
+	ExamDate time.Time // Local midnight
+	DaysLeft int       // Study days left, today included
+	PaceFrom time.Time // Local [from, to) the pace is measured over
+	PaceTo   time.Time
+	Risk     string             // The highest subject risk
+	Subjects []SubjectReadiness // Highest risk first
+}
+
+// GetReadiness forecasts syllabus coverage and accuracy on exam day for each
+// subject from the last 14 days of study. examDate (YYYY-MM-DD) overrides the
+// profile's.
+func (s *AnalyticsManager) GetReadiness(ctx context.Context, userID string, cal Calendar, examDate string) (ReadinessForecast, error) {
+	if examDate == "" {
+		user, err := s.repo.GetUserByID(ctx, userID)
+		if err != nil {
+			return ReadinessForecast{}, err
+		}
+		if !user.ExamDate.Valid {
+			return ReadinessForecast{}, ErrNoExamDate
+		}
+		examDate = user.ExamDate.String
+	}
+	exam, err := time.ParseInLocation("2006-01-02", examDate, cal.Location)
+	if err != nil {
+		return ReadinessForecast{}, fmt.Errorf("%w: %q", ErrInvalidExamDate, examDate)
+	}
+	today := cal.StartOfDay(time.Now())
+	if exam.Before(today) {
+		return ReadinessForecast{}, fmt.Errorf("%w: %s has passed", ErrInvalidExamDate, examDate)
+	}
+
+	forecast := ReadinessForecast{
+		ExamDate: exam,
+		DaysLeft: calendarDays(today, exam),
+		PaceFrom: today.AddDate(0, 0, 1-readinessPaceDays),
+		PaceTo:   today.AddDate(0, 0, 1),
+		Risk:     RiskLow,
+		Subjects: []SubjectReadiness{},
+	}
+	previousFrom := forecast.PaceFrom.AddDate(0, 0, -readinessPaceDays)
+
+	subjects, err := s.repo.ListSubjects(ctx, userID)
+	if err != nil {
+		return ReadinessForecast{}, err
+	}
+	times, err := s.timeReport(ctx, userID, forecast.PaceFrom, forecast.PaceTo)
+	if err != nil {
+		return ReadinessForecast{}, err
+	}
+	recent, err := s.accuracyBySubject(ctx, userID, forecast.PaceFrom, forecast.PaceTo)
+	if err != nil {
+		return ReadinessForecast{}, err
+	}
+	previous, err := s.accuracyBySubject(ctx, userID, previousFrom, forecast.PaceFrom)
+	if err != nil {
+		return ReadinessForecast{}, err
+	}
+	topics, err := s.repo.ListTopicProgress(ctx, userID)
+	if err != nil {
+		return ReadinessForecast{}, err
+	}
+
+	rows := make(map[string]*readinessTally, len(subjects))
+	for _, subject := range subjects {
+		rows[subject.ID] = &readinessTally{SubjectReadiness: SubjectReadiness{
+			SubjectID:   subject.ID,
+			SubjectName: subject.Name,
+			ColorHex:    subject.ColorHex.String,
+		}}
+	}
+	for _, report := range times {
+		if t, ok := rows[report.SubjectID]; ok {
+			t.netSeconds = report.NetSeconds
+		}
+	}
+	for _, row := range recent {
+		if t, ok := rows[row.SubjectID]; ok {
+			t.questions, t.correct = int64(row.TotalQuestions.Float64), int64(row.TotalCorrect.Float64)
+		}
+	}
+	for _, row := range previous {
+		if t, ok := rows[row.SubjectID]; ok {
+			t.previousQuestions, t.previousCorrect = int64(row.TotalQuestions.Float64), int64(row.TotalCorrect.Float64)
+		}
+	}
+	paceFrom, paceTo := formatSQLiteTime(forecast.PaceFrom), formatSQLiteTime(forecast.PaceTo)
+	for _, topic := range topics {
+		t, ok := rows[topic.SubjectID]
+		if !ok {
+			continue
+		}
+		t.Topics++
+		if topic.FirstPracticedAt == "" {
+			continue
+		}
+		// Both sides are in datetime()'s format, so they compare as text
+		t.TopicsTouched++
+		if topic.LastPracticedAt >= paceFrom && topic.LastPracticedAt < paceTo {
+			t.TopicsRevised++
+		}
+		if topic.FirstPracticedAt >= paceFrom && topic.FirstPracticedAt < paceTo {
+			t.newTopics++
+		}
+	}
+
+	for _, t := range rows {
+		subject := t.project(forecast.DaysLeft)
+		forecast.Subjects = append(forecast.Subjects, subject)
+		if riskRank(subject.Risk) > riskRank(forecast.Risk) {
+			forecast.Risk = subject.Risk
+		}
+	}
+
+	sort.Slice(forecast.Subjects, func(i, j int) bool {
+		a, b := forecast.Subjects[i], forecast.Subjects[j]
+		if riskRank(a.Risk) != riskRank(b.Risk) {
+			return riskRank(a.Risk) > riskRank(b.Risk)
+		}
+		if ca, cb := coverageOrFull(a.ProjectedCoverage), coverageOrFull(b.ProjectedCoverage); ca != cb {
+			return ca < cb
+		}
+		return a.SubjectName < b.SubjectName
+	})
+	return forecast, nil
+}
+
+// readinessTally collects a subject's raw counts before projecting them
+type readinessTally struct {
+	SubjectReadiness
+	netSeconds        int64
+	questions         int64
+	correct           int64
+	previousQuestions int64
+	previousCorrect   int64
+	newTopics         int // First practiced within the pace window
+}
+
+func (t *readinessTally) project(daysLeft int) SubjectReadiness {
+	r := t.SubjectReadiness
+	days := float64(daysLeft)
+
+	r.NetSecondsPerDay = math.Round(float64(t.netSeconds)/readinessPaceDays*100) / 100
+	r.QuestionsPerDay = math.Round(float64(t.questions)/readinessPaceDays*100) / 100
+	r.ProjectedNetSeconds = int64(math.Round(float64(t.netSeconds) / readinessPaceDays * days))
+	r.ProjectedQuestions = int64(math.Round(float64(t.questions) / readinessPaceDays * days))
+
+	r.ProjectedTopicsTouched = r.TopicsTouched + int(math.Round(float64(t.newTopics)/readinessPaceDays*days))
+	if r.ProjectedTopicsTouched > r.Topics {
+		r.ProjectedTopicsTouched = r.Topics
+	}
+	if r.Topics > 0 {
+		coverage := roundPercentage(float64(r.TopicsTouched) / float64(r.Topics))
+		projected := roundPercentage(float64(r.ProjectedTopicsTouched) / float64(r.Topics))
+		r.Coverage, r.ProjectedCoverage = &coverage, &projected
+	}
+
+	r.Accuracy = accuracyPercentage(t.correct, t.questions)
+	r.PreviousAccuracy = accuracyPercentage(t.previousCorrect, t.previousQuestions)
+	if r.Accuracy != nil {
+		projected := *r.Accuracy
+		if t.questions >= readinessMinQuestions && t.previousQuestions >= readinessMinQuestions {
+			// The windows' midpoints are readinessPaceDays apart
+			perDay := (*r.Accuracy - *r.PreviousAccuracy) / readinessPaceDays
+			trend := math.Round(perDay*7*100) / 100
+			r.AccuracyTrend = &trend
+			projected += perDay * readinessTrendDays * (1 - math.Exp(-days/readinessTrendDays))
+		}
+		projected = math.Round(math.Max(0, math.Min(100, projected))*100) / 100
+		r.ProjectedAccuracy = &projected
+	}
+
+	r.Risk, r.RiskReasons = RiskLow, []string{}
+	raise := func(risk, reason string) {
+		if riskRank(risk) > riskRank(r.Risk) {
+			r.Risk = risk
+		}
+		r.RiskReasons = append(r.RiskReasons, reason)
+	}
+	if t.netSeconds == 0 && t.questions == 0 {
+		raise(RiskHigh, RiskNoRecentStudy)
+	} else if r.ProjectedAccuracy == nil {
+		raise(RiskMedium, RiskNoRecentQuestions)
+	}
+	if r.ProjectedCoverage != nil {
+		switch {
+		case *r.ProjectedCoverage < 70:
+			raise(RiskHigh, RiskLowCoverage)
+		case *r.ProjectedCoverage < 90:
+			raise(RiskMedium, RiskPartialCoverage)
+		}
+	}
+	if r.ProjectedAccuracy != nil {
+		switch {
+		case *r.ProjectedAccuracy < 50:
+			raise(RiskHigh, RiskLowAccuracy)
+		case *r.ProjectedAccuracy < 70:
+			raise(RiskMedium, RiskModerateAccuracy)
+		}
+	}
+	return r
+}
+
+func riskRank(risk string) int {
+	switch risk {
+	case RiskHigh:
+		return 2
+	case RiskMedium:
+		return 1
+	}
+	return 0
+}
+
+// coverageOrFull sorts subjects without topics after the ones with gaps
+func coverageOrFull(coverage *float64) float64 {
+	if coverage == nil {
+		return 100
+	}
+	return *coverage
+}
+
+// calendarDays counts the local days in [from, to), ignoring DST shifts
+func calendarDays(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+// Recommendation sources
+const (
+	RecommendationCycle    = "cycle"    // The active cycle's next item
+	RecommendationReview   = "review"   // A topic whose revision is overdue
+	RecommendationWeakness = "weakness" // A topic with low accuracy
+)
+
+const (
+	defaultRecommendationLimit = 5
+	maxRecommendationLimit     = 50
+	maxRecommendationWeight    = 10
+
+	// A topic is due for review reviewFirstIntervalDays after its first day
+	// of practice; every further day of practice doubles the interval, up to
+	// reviewMaxIntervalDays.
+	reviewFirstIntervalDays = 1
+	reviewMaxIntervalDays   = 60
+
+	// Topics answered at least weaknessMinQuestions times below
+	// weaknessAccuracyThreshold percent are weaknesses
+	weaknessMinQuestions      = 10
+	weaknessAccuracyThreshold = 70.0
+)
+
+var ErrInvalidRecommendationWeights = errors.New("invalid recommendation weights")
+
+// RecommendationWeights scale each source's score; 0 turns a source off
+type RecommendationWeights struct {
+	Cycle    float64
+	Review   float64
+	Weakness float64
+}
+
+// DefaultRecommendationWeights apply until the user sets their own
+var DefaultRecommendationWeights = RecommendationWeights{Cycle: 1, Review: 1, Weakness: 1}
+
+// RecommendationReason is one source's case for a recommendation
+type RecommendationReason struct {
+	Source      string  // cycle, review or weakness
+	Score       float64 // Between 0 and 1, before the weight
+	Weight      float64
+	Explanation string
+}
+
+// Recommendation is something to study now: a subject from the cycle, or a topic
+type Recommendation struct {
+	SubjectID   string
+	SubjectName string
+	ColorHex    string
+	TopicID     string // Empty for a cycle item
+	TopicName   string
+
+	CycleItemID    string // Set for the cycle's next item
+	PlannedMinutes int64  // The cycle item's planned duration; 0 if unset
+
+	Score   float64 // Sum of the weighted reason scores
+	Reasons []RecommendationReason
+}
+
+type RecommendationService interface {
+	// NextRecommendations ranks what to study now, best first. tzOverride (an
+	// IANA name) sets the calendar review intervals are counted in.
+	NextRecommendations(ctx context.Context, userID, tzOverride string, limit int) ([]Recommendation, error)
+	GetWeights(ctx context.Context, userID string) (RecommendationWeights, error)
+	UpdateWeights(ctx context.Context, userID string, weights RecommendationWeights) (RecommendationWeights, error)
+}
+
+type RecommendationManager struct {
+	repo      repository.RecommendationRepository
+	analytics AnalyticsService
+}
+
+func NewRecommendationManager(repo repository.RecommendationRepository, analytics AnalyticsService) *RecommendationManager {
+	return &RecommendationManager{repo: repo, analytics: analytics}
+}
+
+func (s *RecommendationManager) GetWeights(ctx context.Context, userID string) (RecommendationWeights, error) {
+	row, err := s.repo.GetRecommendationWeights(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultRecommendationWeights, nil
+	}
+	if err != nil {
+		return RecommendationWeights{}, err
+	}
+	return RecommendationWeights{Cycle: row.CycleWeight, Review: row.ReviewWeight, Weakness: row.WeaknessWeight}, nil
+}
+
+func (s *RecommendationManager) UpdateWeights(ctx context.Context, userID string, weights RecommendationWeights) (RecommendationWeights, error) {
+	for _, weight := range []float64{weights.Cycle, weights.Review, weights.Weakness} {
+		if weight < 0 || weight > maxRecommendationWeight || math.IsNaN(weight) {
+			return RecommendationWeights{}, fmt.Errorf("%w: weights must be between 0 and %d", ErrInvalidRecommendationWeights, maxRecommendationWeight)
+		}
+	}
+	if weights.Cycle == 0 && weights.Review == 0 && weights.Weakness == 0 {
+		return RecommendationWeights{}, fmt.Errorf("%w: at least one weight must be positive", ErrInvalidRecommendationWeights)
+	}
+
+	row, err := s.repo.UpsertRecommendationWeights(ctx, database.UpsertRecommendationWeightsParams{
+		UserID:         userID,
+		CycleWeight:    weights.Cycle,
+		ReviewWeight:   weights.Review,
+		WeaknessWeight: weights.Weakness,
+	})
+	if err != nil {
+		return RecommendationWeights{}, err
+	}
+	return RecommendationWeights{Cycle: row.CycleWeight, Review: row.ReviewWeight, Weakness: row.WeaknessWeight}, nil
+}
+
+// NextRecommendations scores the candidates of every source with a positive
+// weight. A topic that is both overdue and weak becomes one recommendation
+// with both reasons, its scores added up.
+func (s *RecommendationManager) NextRecommendations(ctx context.Context, userID, tzOverride string, limit int) ([]Recommendation, error) {
+	if limit <= 0 {
+		limit = defaultRecommendationLimit
+	}
+	if limit > maxRecommendationLimit {
+		limit = maxRecommendationLimit
+	}
+
+	weights, err := s.GetWeights(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := s.analytics.Calendar(ctx, userID, tzOverride)
+	if err != nil {
+		return nil, err
+	}
+	subjects, err := s.repo.ListSubjects(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]*Recommendation)
+	var order []string
+	add := func(key string, rec Recommendation, reason RecommendationReason) {
+		c, ok := candidates[key]
+		if !ok {
+			c = &rec
+			candidates[key] = c
+			order = append(order, key)
+		}
+		c.Reasons = append(c.Reasons, reason)
+		c.Score += reason.Score * reason.Weight
+	}
+
+	if weights.Cycle > 0 {
+		if err := s.cycleCandidate(ctx, userID, weights.Cycle, add); err != nil {
+			return nil, err
+		}
+	}
+	if weights.Review > 0 {
+		if err := s.reviewCandidates(ctx, userID, cal, subjects, weights.Review, add); err != nil {
+			return nil, err
+		}
+	}
+	if weights.Weakness > 0 {
+		if err := s.weaknessCandidates(ctx, userID, subjects, weights.Weakness, add); err != nil {
+			return nil, err
+		}
+	}
+
+	recommendations := make([]Recommendation, 0, len(order))
+	for _, key := range order {
+		rec := candidates[key]
+		rec.Score = math.Round(rec.Score*1000) / 1000
+		recommendations = append(recommendations, *rec)
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.SubjectName != b.SubjectName {
+			return a.SubjectName < b.SubjectName
+		}
+		return a.TopicName < b.TopicName
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+type addCandidate func(key string, rec Recommendation, reason RecommendationReason)
+
+// cycleCandidate recommends the item after the last one studied in the most
+// recently updated active cycle, or its first item if none was studied yet
+func (s *RecommendationManager) cycleCandidate(ctx context.Context, userID string, weight float64, add addCandidate) error {
+	rows, err := s.repo.ListActiveCycleItems(ctx, userID)
+	if err != nil {
+		return err
+	}
+	items := activeCycle(rows)
+	if len(items) == 0 {
+		return nil
+	}
+
+	next, last := 0, lastStudiedItem(items)
+	explanation := fmt.Sprintf("First item of cycle %q", items[0].CycleName)
+	if last >= 0 {
+		next = (last + 1) % len(items)
+		explanation = fmt.Sprintf("Next in cycle %q (item %d of %d), after %s", items[0].CycleName, next+1, len(items), items[last].SubjectName)
+	}
+
+	item := items[next]
+	add("cycle:"+item.ID, Recommendation{
+		SubjectID:      item.SubjectID,
+		SubjectName:    item.SubjectName,
+		ColorHex:       item.ColorHex.String,
+		CycleItemID:    item.ID,
+		PlannedMinutes: item.PlannedDurationMinutes.Int64,
+	}, RecommendationReason{Source: RecommendationCycle, Score: 1, Weight: weight, Explanation: explanation})
+	return nil
+}
+
+// reviewCandidates recommends the topics past their review date. The score
+// grows with the delay relative to the interval, reaching 0.5 when a topic is
+// overdue by a whole interval.
+func (s *RecommendationManager) reviewCandidates(ctx context.Context, userID string, cal Calendar, subjects []database.Subject, weight float64, add addCandidate) error {
+	logs, err := s.repo.ListTopicExerciseLogs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	colors := make(map[string]string, len(subjects))
+	for _, subject := range subjects {
+		colors[subject.ID] = subject.ColorHex.String
+	}
+
+	for _, review := range topicReviews(logs, cal, cal.StartOfDay(time.Now())) {
+		if review.OverdueDays <= 0 {
+			continue
+		}
+		add("topic:"+review.TopicID, Recommendation{
+			SubjectID:   review.SubjectID,
+			SubjectName: review.SubjectName,
+			ColorHex:    colors[review.SubjectID],
+			TopicID:     review.TopicID,
+			TopicName:   review.TopicName,
+		}, RecommendationReason{
+			Source: RecommendationReview,
+			Score:  math.Round(float64(review.OverdueDays)/float64(review.OverdueDays+review.IntervalDays)*1000) / 1000,
+			Weight: weight,
+			Explanation: fmt.Sprintf("Review overdue by %s: last practiced %s, due every %s",
+				pluralDays(review.OverdueDays), review.LastPracticed.Format("2006-01-02"), pluralDays(review.IntervalDays)),
+		})
+	}
+	return nil
+}
+
+// weaknessCandidates recommends the topics under the accuracy threshold,
+// scored by how far below it they are
+func (s *RecommendationManager) weaknessCandidates(ctx context.Context, userID string, subjects []database.Subject, weight float64, add addCandidate) error {
+	for _, subject := range subjects {
+		topics, err := s.repo.GetAccuracyByTopic(ctx, subject.ID, userID)
+		if err != nil {
+			return err
+		}
+		for _, topic := range topics {
+			questions := int64(topic.TotalQuestions.Float64)
+			if questions < weaknessMinQuestions || topic.AccuracyPercentage >= weaknessAccuracyThreshold {
+				continue
+			}
+
+			add("topic:"+topic.TopicID, Recommendation{
+				SubjectID:   subject.ID,
+				SubjectName: subject.Name,
+				ColorHex:    subject.ColorHex.String,
+				TopicID:     topic.TopicID,
+				TopicName:   topic.TopicName,
+			}, RecommendationReason{
+				Source: RecommendationWeakness,
+				Score:  math.Round((weaknessAccuracyThreshold-topic.AccuracyPercentage)/weaknessAccuracyThreshold*1000) / 1000,
+				Weight: weight,
+				Explanation: fmt.Sprintf("Accuracy %.4g%% over %d questions, below %.4g%%",
+					topic.AccuracyPercentage, questions, weaknessAccuracyThreshold),
+			})
+		}
+	}
+	return nil
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+// activeCycle keeps the items of the first cycle ListActiveCycleItems
+// returns, the most recently updated one
+func activeCycle(rows []database.ListActiveCycleItemsRow) []database.ListActiveCycleItemsRow {
+	var items []database.ListActiveCycleItemsRow
+	for _, row := range rows {
+		if row.CycleID == rows[0].CycleID {
+			items = append(items, row)
+		}
+	}
+	return items
+}
+
+// lastStudiedItem returns the index of the item with the latest session, or -1
+func lastStudiedItem(items []database.ListActiveCycleItemsRow) int {
+	last := -1
+	for i, item := range items {
+		// Both are in datetime()'s format, so they compare as text
+		if item.LastStudiedAt != "" && (last < 0 || item.LastStudiedAt >= items[last].LastStudiedAt) {
+			last = i
+		}
+	}
+	return last
+}
+
+// TopicReview is a practiced topic's revision schedule
+type TopicReview struct {
+	TopicID       string
+	TopicName     string
+	SubjectID     string
+	SubjectName   string
+	LastPracticed time.Time // Local midnight
+	IntervalDays  int
+	OverdueDays   int // 0 when due today, negative until then
+}
+
+// topicReviews schedules every topic in logs, in order of first practice
+func topicReviews(logs []database.ListTopicExerciseLogsRow, cal Calendar, today time.Time) []TopicReview {
+	type practice struct {
+		review TopicReview
+		days   map[int64]bool // Local days practiced
+	}
+	var order []string
+	topics := make(map[string]*practice)
+	for _, log := range logs {
+		created, err := parseTimestamp(log.CreatedAt)
+		if err != nil {
+			slog.Warn("Skipping exercise log with unreadable date", "exercise_log_id", log.ID, "error", err)
+			continue
+		}
+		p, ok := topics[log.TopicID]
+		if !ok {
+			p = &practice{
+				review: TopicReview{TopicID: log.TopicID, TopicName: log.TopicName, SubjectID: log.SubjectID, SubjectName: log.SubjectName},
+				days:   make(map[int64]bool),
+			}
+			topics[log.TopicID] = p
+			order = append(order, log.TopicID)
+		}
+		day := cal.StartOfDay(created)
+		p.days[day.Unix()] = true
+		if day.After(p.review.LastPracticed) {
+			p.review.LastPracticed = day
+		}
+	}
+
+	reviews := make([]TopicReview, 0, len(order))
+	for _, id := range order {
+		p := topics[id]
+		interval := reviewFirstIntervalDays
+		for i := 1; i < len(p.days) && interval < reviewMaxIntervalDays; i++ {
+			interval *= 2
+		}
+		p.review.IntervalDays = min(interval, reviewMaxIntervalDays)
+		p.review.OverdueDays = calendarDays(p.review.LastPracticed.AddDate(0, 0, p.review.IntervalDays), today)
+		reviews = append(reviews, p.review)
+	}
+	return reviews
+}
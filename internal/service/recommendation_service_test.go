@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRecommendationRepository is a mock implementation of repository.RecommendationRepository
+type MockRecommendationRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecommendationRepository) GetRecommendationWeights(ctx context.Context, userID string) (database.RecommendationWeight, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(database.RecommendationWeight), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) UpsertRecommendationWeights(ctx context.Context, arg database.UpsertRecommendationWeightsParams) (database.RecommendationWeight, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.RecommendationWeight), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) ListActiveCycleItems(ctx context.Context, userID string) ([]database.ListActiveCycleItemsRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListActiveCycleItemsRow), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) ListTopicExerciseLogs(ctx context.Context, userID string) ([]database.ListTopicExerciseLogsRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListTopicExerciseLogsRow), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) ListSubjects(ctx context.Context, userID string) ([]database.Subject, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.Subject), args.Error(1)
+}
+
+func (m *MockRecommendationRepository) GetAccuracyByTopic(ctx context.Context, subjectID, userID string) ([]database.GetAccuracyByTopicRow, error) {
+	args := m.Called(ctx, subjectID, userID)
+	return args.Get(0).([]database.GetAccuracyByTopicRow), args.Error(1)
+}
+
+func TestRecommendationManager_NextRecommendations(t *testing.T) {
+	ctx := context.Background()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	at := func(days int) string {
+		return today.AddDate(0, 0, days).Add(10 * time.Hour).Format("2006-01-02 15:04:05")
+	}
+
+	newService := func(weights *database.RecommendationWeight) (*service.RecommendationManager, *MockRecommendationRepository) {
+		analyticsRepo := new(MockAnalyticsRepository)
+		analyticsRepo.On("GetUserByID", ctx, "user-1").Return(database.User{ID: "user-1", Timezone: "UTC"}, nil)
+		mockRepo := new(MockRecommendationRepository)
+		if weights != nil {
+			mockRepo.On("GetRecommendationWeights", ctx, "user-1").Return(*weights, nil)
+		} else {
+			mockRepo.On("GetRecommendationWeights", ctx, "user-1").Return(database.RecommendationWeight{}, sql.ErrNoRows)
+		}
+
+		mockRepo.On("ListSubjects", ctx, "user-1").Return([]database.Subject{
+			{ID: "bio", Name: "Bio"},
+			{ID: "math", Name: "Math", ColorHex: sql.NullString{String: "#00f", Valid: true}},
+		}, nil)
+		mockRepo.On("ListActiveCycleItems", ctx, "user-1").Return([]database.ListActiveCycleItemsRow{
+			{ID: "i1", CycleID: "c1", CycleName: "Main", SubjectID: "math", SubjectName: "Math", OrderIndex: 0, LastStudiedAt: at(-1)},
+			{ID: "i2", CycleID: "c1", CycleName: "Main", SubjectID: "bio", SubjectName: "Bio", OrderIndex: 1, PlannedDurationMinutes: sql.NullInt64{Int64: 50, Valid: true}},
+			{ID: "i3", CycleID: "c1", CycleName: "Main", SubjectID: "math", SubjectName: "Math", OrderIndex: 2, LastStudiedAt: at(-2)},
+			// An older active cycle is ignored
+			{ID: "i9", CycleID: "c0", CycleName: "Old", SubjectID: "bio", SubjectName: "Bio", LastStudiedAt: at(0)},
+		}, nil)
+		mockRepo.On("ListTopicExerciseLogs", ctx, "user-1").Return([]database.ListTopicExerciseLogsRow{
+			// Two days of practice: due two days after the last, so 7 days overdue
+			{ID: "l1", TopicID: "algebra", TopicName: "Algebra", SubjectID: "math", SubjectName: "Math", QuestionsCount: 10, CorrectCount: 3, CreatedAt: at(-10)},
+			{ID: "l2", TopicID: "algebra", TopicName: "Algebra", SubjectID: "math", SubjectName: "Math", QuestionsCount: 10, CorrectCount: 4, CreatedAt: at(-9)},
+			{ID: "l3", TopicID: "cells", TopicName: "Cells", SubjectID: "bio", SubjectName: "Bio", QuestionsCount: 10, CorrectCount: 8, CreatedAt: at(0)},
+		}, nil)
+		mockRepo.On("GetAccuracyByTopic", ctx, "math", "user-1").Return([]database.GetAccuracyByTopicRow{
+			{TopicID: "algebra", TopicName: "Algebra", TotalQuestions: sql.NullFloat64{Float64: 20, Valid: true}, AccuracyPercentage: 35},
+			// Too few questions to tell
+			{TopicID: "limits", TopicName: "Limits", TotalQuestions: sql.NullFloat64{Float64: 5, Valid: true}, AccuracyPercentage: 10},
+		}, nil)
+		mockRepo.On("GetAccuracyByTopic", ctx, "bio", "user-1").Return([]database.GetAccuracyByTopicRow{
+			{TopicID: "cells", TopicName: "Cells", TotalQuestions: sql.NullFloat64{Float64: 10, Valid: true}, AccuracyPercentage: 80},
+		}, nil)
+
+		return service.NewRecommendationManager(mockRepo, service.NewAnalyticsManager(analyticsRepo)), mockRepo
+	}
+
+	t.Run("Default weights", func(t *testing.T) {
+		svc, _ := newService(nil)
+
+		recommendations, err := svc.NextRecommendations(ctx, "user-1", "", 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, recommendations, 2)
+
+		// Overdue and weak: 7/9 + 0.5
+		algebra := recommendations[0]
+		assert.Equal(t, "algebra", algebra.TopicID)
+		assert.Equal(t, "#00f", algebra.ColorHex)
+		assert.Equal(t, 1.278, algebra.Score)
+		assert.Len(t, algebra.Reasons, 2)
+		assert.Equal(t, service.RecommendationReview, algebra.Reasons[0].Source)
+		assert.Equal(t, 0.778, algebra.Reasons[0].Score)
+		assert.Equal(t, "Review overdue by 7 days: last practiced "+today.AddDate(0, 0, -9).Format("2006-01-02")+", due every 2 days", algebra.Reasons[0].Explanation)
+		assert.Equal(t, service.RecommendationWeakness, algebra.Reasons[1].Source)
+		assert.Equal(t, 0.5, algebra.Reasons[1].Score)
+
+		// The item after the last one studied
+		cycle := recommendations[1]
+		assert.Equal(t, "i2", cycle.CycleItemID)
+		assert.Equal(t, "bio", cycle.SubjectID)
+		assert.Equal(t, int64(50), cycle.PlannedMinutes)
+		assert.Equal(t, 1.0, cycle.Score)
+		assert.Equal(t, `Next in cycle "Main" (item 2 of 3), after Math`, cycle.Reasons[0].Explanation)
+	})
+
+	t.Run("User weights", func(t *testing.T) {
+		svc, mockRepo := newService(&database.RecommendationWeight{UserID: "user-1", CycleWeight: 3, ReviewWeight: 1})
+
+		recommendations, err := svc.NextRecommendations(ctx, "user-1", "", 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, recommendations, 1)
+		assert.Equal(t, "i2", recommendations[0].CycleItemID)
+		assert.Equal(t, 3.0, recommendations[0].Score)
+		// Weaknesses are off
+		mockRepo.AssertNotCalled(t, "GetAccuracyByTopic", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRecommendationManager_UpdateWeights(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockRecommendationRepository)
+		svc := service.NewRecommendationManager(mockRepo, nil)
+
+		mockRepo.On("UpsertRecommendationWeights", ctx, database.UpsertRecommendationWeightsParams{
+			UserID: "user-1", CycleWeight: 2, ReviewWeight: 0, WeaknessWeight: 1.5,
+		}).Return(database.RecommendationWeight{UserID: "user-1", CycleWeight: 2, WeaknessWeight: 1.5}, nil)
+
+		weights, err := svc.UpdateWeights(ctx, "user-1", service.RecommendationWeights{Cycle: 2, Weakness: 1.5})
+
+		assert.NoError(t, err)
+		assert.Equal(t, service.RecommendationWeights{Cycle: 2, Weakness: 1.5}, weights)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		svc := service.NewRecommendationManager(new(MockRecommendationRepository), nil)
+
+		_, err := svc.UpdateWeights(ctx, "user-1", service.RecommendationWeights{})
+		assert.ErrorIs(t, err, service.ErrInvalidRecommendationWeights)
+
+		_, err = svc.UpdateWeights(ctx, "user-1", service.RecommendationWeights{Cycle: -1, Review: 1})
+		assert.ErrorIs(t, err, service.ErrInvalidRecommendationWeights)
+	})
+}
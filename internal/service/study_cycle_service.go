@@ -3,30 +3,53 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/repository"
 )
 
+var ErrStudyCycleNotFound = errors.New("study cycle not found")
+
+// StudyCyclePatch changes the fields that are set. Only the description can
+// be nulled; an empty one is kept as such.
+type StudyCyclePatch struct {
+	Name        Field[string]
+	Description Field[string]
+	IsActive    Field[bool]
+}
+
+// NewCycleItem is an item created along with its cycle
+type NewCycleItem struct {
+	SubjectID              string
+	OrderIndex             int
+	PlannedDurationMinutes int
+}
+
 type StudyCycleService interface {
-	CreateStudyCycle(ctx context.Context, name, description string, isActive bool) (database.StudyCycle, error)
+	// CreateStudyCycle creates the cycle and its items together, or nothing
+	CreateStudyCycle(ctx context.Context, name, description string, isActive bool, items []NewCycleItem) (database.StudyCycle, error)
 	GetActiveStudyCycle(ctx context.Context) (database.StudyCycle, error)
 	GetStudyCycle(ctx context.Context, id string) (database.StudyCycle, error)
-	UpdateStudyCycle(ctx context.Context, id, name, description string, isActive bool) error
+	// UpdateStudyCycle fails with ErrVersionMismatch when version is set and stale
+	UpdateStudyCycle(ctx context.Context, id, name, description string, isActive bool, version *int64) (database.StudyCycle, error)
+	PatchStudyCycle(ctx context.Context, id string, patch StudyCyclePatch, version *int64) (database.StudyCycle, error)
 	DeleteStudyCycle(ctx context.Context, id string) error
 	GetActiveCycleWithItems(ctx context.Context) ([]database.GetActiveCycleWithItemsRow, error)
 }
 
 type StudyCycleManager struct {
 	repo repository.StudyCycleRepository
+	tx   repository.TxManager
 }
 
-func NewStudyCycleManager(repo repository.StudyCycleRepository) *StudyCycleManager {
-	return &StudyCycleManager{repo: repo}
+func NewStudyCycleManager(repo repository.StudyCycleRepository, tx repository.TxManager) *StudyCycleManager {
+	return &StudyCycleManager{repo: repo, tx: tx}
 }
 
-func (s *StudyCycleManager) CreateStudyCycle(ctx context.Context, name, description string, isActive bool) (database.StudyCycle, error) {
+func (s *StudyCycleManager) CreateStudyCycle(ctx context.Context, name, description string, isActive bool, items []NewCycleItem) (database.StudyCycle, error) {
 	id := uuid.New().String()
 
 	var desc sql.NullString
@@ -41,12 +64,37 @@ func (s *StudyCycleManager) CreateStudyCycle(ctx context.Context, name, descript
 		active = sql.NullInt64{Int64: 0, Valid: true}
 	}
 
-	return s.repo.CreateStudyCycle(ctx, database.CreateStudyCycleParams{
-		ID:          id,
-		Name:        name,
-		Description: desc,
-		IsActive:    active,
+	var cycle database.StudyCycle
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		cycle, err = s.repo.CreateStudyCycle(ctx, database.CreateStudyCycleParams{
+			ID:          id,
+			Name:        name,
+			Description: desc,
+			IsActive:    active,
+		})
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			var duration sql.NullInt64
+			if item.PlannedDurationMinutes > 0 {
+				duration = sql.NullInt64{Int64: int64(item.PlannedDurationMinutes), Valid: true}
+			}
+			_, err := s.repo.CreateCycleItem(ctx, database.CreateCycleItemParams{
+				ID:                     uuid.New().String(),
+				CycleID:                id,
+				SubjectID:              item.SubjectID,
+				OrderIndex:             int64(item.OrderIndex),
+				PlannedDurationMinutes: duration,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
+	return cycle, err
 }
 
 func (s *StudyCycleManager) GetActiveStudyCycle(ctx context.Context) (database.StudyCycle, error) {
@@ -57,7 +105,7 @@ func (s *StudyCycleManager) GetStudyCycle(ctx context.Context, id string) (datab
 	return s.repo.GetStudyCycle(ctx, id)
 }
 
-func (s *StudyCycleManager) UpdateStudyCycle(ctx context.Context, id, name, description string, isActive bool) error {
+func (s *StudyCycleManager) UpdateStudyCycle(ctx context.Context, id, name, description string, isActive bool, version *int64) (database.StudyCycle, error) {
 	var desc sql.NullString
 	if description != "" {
 		desc = sql.NullString{String: description, Valid: true}
@@ -70,12 +118,53 @@ func (s *StudyCycleManager) UpdateStudyCycle(ctx context.Context, id, name, desc
 		active = sql.NullInt64{Int64: 0, Valid: true}
 	}
 
-	return s.repo.UpdateStudyCycle(ctx, database.UpdateStudyCycleParams{
+	cycle, err := s.repo.UpdateStudyCycle(ctx, database.UpdateStudyCycleParams{
 		Name:        name,
 		Description: desc,
 		IsActive:    active,
 		ID:          id,
+		IfVersion:   nullInt64(version),
+	})
+	return versioned(cycle, err, func() error {
+		_, err := s.repo.GetStudyCycle(ctx, id)
+		return err
+	}, ErrStudyCycleNotFound)
+}
+
+func (s *StudyCycleManager) PatchStudyCycle(ctx context.Context, id string, patch StudyCyclePatch, version *int64) (database.StudyCycle, error) {
+	if patch.Name.Null {
+		return database.StudyCycle{}, fmt.Errorf("%w: name can't be null", ErrInvalidPatch)
+	}
+	if patch.IsActive.Null {
+		return database.StudyCycle{}, fmt.Errorf("%w: is_active can't be null", ErrInvalidPatch)
+	}
+	if patch == (StudyCyclePatch{}) {
+		cycle, err := s.repo.GetStudyCycle(ctx, id)
+		return unchanged(cycle, err, func(c database.StudyCycle) int64 { return c.Version }, version, ErrStudyCycleNotFound)
+	}
+
+	var active sql.NullInt64
+	if patch.IsActive.Set {
+		active = sql.NullInt64{Valid: true}
+		if patch.IsActive.Value {
+			active.Int64 = 1
+		}
+	}
+
+	cycle, err := s.repo.PatchStudyCycle(ctx, database.PatchStudyCycleParams{
+		SetName:        patch.Name.Set,
+		Name:           patch.Name.Value,
+		SetDescription: patch.Description.Set,
+		Description:    nullStringPtr(patch.Description.Ptr()),
+		SetIsActive:    patch.IsActive.Set,
+		IsActive:       active,
+		ID:             id,
+		IfVersion:      nullInt64(version),
 	})
+	return versioned(cycle, err, func() error {
+		_, err := s.repo.GetStudyCycle(ctx, id)
+		return err
+	}, ErrStudyCycleNotFound)
 }
 
 func (s *StudyCycleManager) DeleteStudyCycle(ctx context.Context, id string) error {
@@ -31,9 +31,14 @@ func (m *MockStudyCycleRepository) GetStudyCycle(ctx context.Context, id string)
 	return args.Get(0).(database.StudyCycle), args.Error(1)
 }
 
-func (m *MockStudyCycleRepository) UpdateStudyCycle(ctx context.Context, arg database.UpdateStudyCycleParams) error {
+func (m *MockStudyCycleRepository) UpdateStudyCycle(ctx context.Context, arg database.UpdateStudyCycleParams) (database.StudyCycle, error) {
 	args := m.Called(ctx, arg)
-	return args.Error(0)
+	return args.Get(0).(database.StudyCycle), args.Error(1)
+}
+
+func (m *MockStudyCycleRepository) PatchStudyCycle(ctx context.Context, arg database.PatchStudyCycleParams) (database.StudyCycle, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.StudyCycle), args.Error(1)
 }
 
 func (m *MockStudyCycleRepository) DeleteStudyCycle(ctx context.Context, id string) error {
@@ -46,9 +51,14 @@ func (m *MockStudyCycleRepository) GetActiveCycleWithItems(ctx context.Context)
 	return args.Get(0).([]database.GetActiveCycleWithItemsRow), args.Error(1)
 }
 
+func (m *MockStudyCycleRepository) CreateCycleItem(ctx context.Context, arg database.CreateCycleItemParams) (database.CycleItem, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.CycleItem), args.Error(1)
+}
+
 func TestStudyCycleManager_CreateStudyCycle(t *testing.T) {
 	mockRepo := new(MockStudyCycleRepository)
-	svc := service.NewStudyCycleManager(mockRepo)
+	svc := service.NewStudyCycleManager(mockRepo, inlineTx{})
 
 	ctx := context.Background()
 	name := "Cycle 1"
@@ -64,7 +74,7 @@ func TestStudyCycleManager_CreateStudyCycle(t *testing.T) {
 		IsActive:    sql.NullInt64{Int64: 1, Valid: true},
 	}, nil)
 
-	cycle, err := svc.CreateStudyCycle(ctx, name, description, isActive)
+	cycle, err := svc.CreateStudyCycle(ctx, name, description, isActive, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, name, cycle.Name)
@@ -72,9 +82,51 @@ func TestStudyCycleManager_CreateStudyCycle(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestStudyCycleManager_CreateStudyCycle_WithItems(t *testing.T) {
+	ctx := context.Background()
+	items := []service.NewCycleItem{
+		{SubjectID: "math-uuid", OrderIndex: 1, PlannedDurationMinutes: 50},
+		{SubjectID: "history-uuid", OrderIndex: 2},
+	}
+
+	t.Run("Items join the new cycle", func(t *testing.T) {
+		mockRepo := new(MockStudyCycleRepository)
+		svc := service.NewStudyCycleManager(mockRepo, inlineTx{})
+
+		var cycleID string
+		mockRepo.On("CreateStudyCycle", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			cycleID = args.Get(1).(database.CreateStudyCycleParams).ID
+		}).Return(database.StudyCycle{Name: "Cycle 1"}, nil)
+		mockRepo.On("CreateCycleItem", ctx, mock.MatchedBy(func(arg database.CreateCycleItemParams) bool {
+			return arg.CycleID == cycleID && arg.SubjectID == "math-uuid" && arg.OrderIndex == 1 && arg.PlannedDurationMinutes.Int64 == 50
+		})).Return(database.CycleItem{}, nil).Once()
+		mockRepo.On("CreateCycleItem", ctx, mock.MatchedBy(func(arg database.CreateCycleItemParams) bool {
+			return arg.CycleID == cycleID && arg.SubjectID == "history-uuid" && !arg.PlannedDurationMinutes.Valid
+		})).Return(database.CycleItem{}, nil).Once()
+
+		_, err := svc.CreateStudyCycle(ctx, "Cycle 1", "", true, items)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("A failed item fails the unit of work", func(t *testing.T) {
+		mockRepo := new(MockStudyCycleRepository)
+		svc := service.NewStudyCycleManager(mockRepo, inlineTx{})
+
+		mockRepo.On("CreateStudyCycle", ctx, mock.Anything).Return(database.StudyCycle{}, nil)
+		mockRepo.On("CreateCycleItem", ctx, mock.Anything).Return(database.CycleItem{}, sql.ErrConnDone).Once()
+
+		_, err := svc.CreateStudyCycle(ctx, "Cycle 1", "", true, items)
+
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		mockRepo.AssertNumberOfCalls(t, "CreateCycleItem", 1)
+	})
+}
+
 func TestStudyCycleManager_GetActiveStudyCycle(t *testing.T) {
 	mockRepo := new(MockStudyCycleRepository)
-	svc := service.NewStudyCycleManager(mockRepo)
+	svc := service.NewStudyCycleManager(mockRepo, inlineTx{})
 
 	ctx := context.Background()
 	expectedCycle := database.StudyCycle{ID: "active-uuid", Name: "Active Cycle", IsActive: sql.NullInt64{Int64: 1, Valid: true}}
@@ -11,6 +11,8 @@ import (
 
 type StudySessionService interface {
 	CreateStudySession(ctx context.Context, subjectID, cycleItemID, startedAt string) (database.StudySession, error)
+	// UpdateSessionDuration stops the session when finishedAt is set, ending
+	// the pauses still open at that time
 	UpdateSessionDuration(ctx context.Context, id, finishedAt string, grossSeconds, netSeconds int, notes string) error
 	GetStudySession(ctx context.Context, id string) (database.StudySession, error)
 	DeleteStudySession(ctx context.Context, id string) error
@@ -19,10 +21,11 @@ type StudySessionService interface {
 
 type StudySessionManager struct {
 	repo repository.StudySessionRepository
+	tx   repository.TxManager
 }
 
-func NewStudySessionManager(repo repository.StudySessionRepository) *StudySessionManager {
-	return &StudySessionManager{repo: repo}
+func NewStudySessionManager(repo repository.StudySessionRepository, tx repository.TxManager) *StudySessionManager {
+	return &StudySessionManager{repo: repo, tx: tx}
 }
 
 func (s *StudySessionManager) CreateStudySession(ctx context.Context, subjectID, cycleItemID, startedAt string) (database.StudySession, error) {
@@ -62,12 +65,21 @@ func (s *StudySessionManager) UpdateSessionDuration(ctx context.Context, id, fin
 		sessionNotes = sql.NullString{String: notes, Valid: true}
 	}
 
-	return s.repo.UpdateSessionDuration(ctx, database.UpdateSessionDurationParams{
-		FinishedAt:           finished,
-		GrossDurationSeconds: gross,
-		NetDurationSeconds:   net,
-		Notes:                sessionNotes,
-		ID:                   id,
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.repo.UpdateSessionDuration(ctx, database.UpdateSessionDurationParams{
+			FinishedAt:           finished,
+			GrossDurationSeconds: gross,
+			NetDurationSeconds:   net,
+			Notes:                sessionNotes,
+			ID:                   id,
+		})
+		if err != nil || !finished.Valid {
+			return err
+		}
+		return s.repo.EndOpenSessionPauses(ctx, database.EndOpenSessionPausesParams{
+			EndedAt:   finished,
+			SessionID: id,
+		})
 	})
 }
 
@@ -41,9 +41,22 @@ func (m *MockStudySessionRepository) GetOpenSession(ctx context.Context) (databa
 	return args.Get(0).(database.GetOpenSessionRow), args.Error(1)
 }
 
+func (m *MockStudySessionRepository) EndOpenSessionPauses(ctx context.Context, arg database.EndOpenSessionPausesParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// inlineTx is a repository.TxManager that runs units of work on their
+// context as is, for services tested against mocks
+type inlineTx struct{}
+
+func (inlineTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func TestStudySessionManager_CreateStudySession(t *testing.T) {
 	mockRepo := new(MockStudySessionRepository)
-	svc := service.NewStudySessionManager(mockRepo)
+	svc := service.NewStudySessionManager(mockRepo, inlineTx{})
 
 	ctx := context.Background()
 	subjectID := "subject-uuid"
@@ -69,7 +82,7 @@ func TestStudySessionManager_CreateStudySession(t *testing.T) {
 
 func TestStudySessionManager_UpdateSessionDuration(t *testing.T) {
 	mockRepo := new(MockStudySessionRepository)
-	svc := service.NewStudySessionManager(mockRepo)
+	svc := service.NewStudySessionManager(mockRepo, inlineTx{})
 
 	ctx := context.Background()
 	sessionID := "session-uuid"
@@ -81,9 +94,26 @@ func TestStudySessionManager_UpdateSessionDuration(t *testing.T) {
 	mockRepo.On("UpdateSessionDuration", ctx, mock.MatchedBy(func(arg database.UpdateSessionDurationParams) bool {
 		return arg.ID == sessionID && arg.FinishedAt.String == finishedAt && arg.GrossDurationSeconds.Int64 == int64(gross) && arg.NetDurationSeconds.Int64 == int64(net)
 	})).Return(nil)
+	mockRepo.On("EndOpenSessionPauses", ctx, database.EndOpenSessionPausesParams{
+		EndedAt:   sql.NullString{String: finishedAt, Valid: true},
+		SessionID: sessionID,
+	}).Return(nil)
 
 	err := svc.UpdateSessionDuration(ctx, sessionID, finishedAt, gross, net, notes)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestStudySessionManager_UpdateSessionDuration_NotStopped(t *testing.T) {
+	mockRepo := new(MockStudySessionRepository)
+	svc := service.NewStudySessionManager(mockRepo, inlineTx{})
+
+	ctx := context.Background()
+	mockRepo.On("UpdateSessionDuration", ctx, mock.Anything).Return(nil)
+
+	err := svc.UpdateSessionDuration(ctx, "session-uuid", "", 0, 0, "Notes only")
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "EndOpenSessionPauses", mock.Anything, mock.Anything)
+}
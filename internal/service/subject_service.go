@@ -3,17 +3,38 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/repository"
 )
 
+var (
+	ErrSubjectNotFound = errors.New("subject not found")
+	// ErrVersionMismatch means the row changed since the version the client
+	// last read; the update is not applied
+	ErrVersionMismatch = errors.New("version mismatch")
+)
+
+// SubjectPatch changes the fields that are set; the color and exam weight can
+// be nulled, the name can't
+type SubjectPatch struct {
+	Name       Field[string]
+	ColorHex   Field[string]
+	ExamWeight Field[float64]
+}
+
 type SubjectService interface {
-	CreateSubject(ctx context.Context, userID, name, colorHex string) (database.Subject, error)
+	// CreateSubject creates a subject; examWeight may be nil when unknown
+	CreateSubject(ctx context.Context, userID, name, colorHex string, examWeight *float64) (database.Subject, error)
 	ListSubjects(ctx context.Context, userID string) ([]database.Subject, error)
 	GetSubject(ctx context.Context, id, userID string) (database.Subject, error)
-	UpdateSubject(ctx context.Context, id, userID, name, colorHex string) error
+	// UpdateSubject applies only while the subject is at version, when given
+	UpdateSubject(ctx context.Context, id, userID, name, colorHex string, examWeight *float64, version *int64) (database.Subject, error)
+	// PatchSubject applies a partial update, with the same version check
+	PatchSubject(ctx context.Context, id, userID string, patch SubjectPatch, version *int64) (database.Subject, error)
 	DeleteSubject(ctx context.Context, id, userID string) error
 }
 
@@ -25,7 +46,7 @@ func NewSubjectManager(repo repository.SubjectRepository) *SubjectManager {
 	return &SubjectManager{repo: repo}
 }
 
-func (s *SubjectManager) CreateSubject(ctx context.Context, userID, name, colorHex string) (database.Subject, error) {
+func (s *SubjectManager) CreateSubject(ctx context.Context, userID, name, colorHex string, examWeight *float64) (database.Subject, error) {
 	id := uuid.New().String()
 
 	var color sql.NullString
@@ -35,10 +56,11 @@ func (s *SubjectManager) CreateSubject(ctx context.Context, userID, name, colorH
 
 	// We now pass userID into the Params
 	return s.repo.CreateSubject(ctx, database.CreateSubjectParams{
-		ID:       id,
-		UserID:   userID,
-		Name:     name,
-		ColorHex: color,
+		ID:         id,
+		UserID:     userID,
+		Name:       name,
+		ColorHex:   color,
+		ExamWeight: nullFloat64(examWeight),
 	})
 }
 
@@ -58,21 +80,67 @@ func (s *SubjectManager) GetSubject(ctx context.Context, id, userID string) (dat
 	return s.repo.GetSubject(ctx, id, userID)
 }
 
-func (s *SubjectManager) UpdateSubject(ctx context.Context, id, userID, name, colorHex string) error {
+func (s *SubjectManager) UpdateSubject(ctx context.Context, id, userID, name, colorHex string, examWeight *float64, version *int64) (database.Subject, error) {
 	var color sql.NullString
 	if colorHex != "" {
 		color = sql.NullString{String: colorHex, Valid: true}
 	}
 
 	// We now pass userID into the Params to ensure the WHERE clause checks ownership
-	return s.repo.UpdateSubject(ctx, database.UpdateSubjectParams{
-		Name:     name,
-		ColorHex: color,
-		ID:       id,
-		UserID:   userID,
+	subject, err := s.repo.UpdateSubject(ctx, database.UpdateSubjectParams{
+		Name:       name,
+		ColorHex:   color,
+		ExamWeight: nullFloat64(examWeight),
+		ID:         id,
+		UserID:     userID,
+		IfVersion:  nullInt64(version),
+	})
+	return versioned(subject, err, func() error {
+		_, err := s.repo.GetSubject(ctx, id, userID)
+		return err
+	}, ErrSubjectNotFound)
+}
+
+func (s *SubjectManager) PatchSubject(ctx context.Context, id, userID string, patch SubjectPatch, version *int64) (database.Subject, error) {
+	if patch.Name.Null {
+		return database.Subject{}, fmt.Errorf("%w: name can't be null", ErrInvalidPatch)
+	}
+	if patch == (SubjectPatch{}) {
+		subject, err := s.repo.GetSubject(ctx, id, userID)
+		return unchanged(subject, err, func(s database.Subject) int64 { return s.Version }, version, ErrSubjectNotFound)
+	}
+
+	subject, err := s.repo.PatchSubject(ctx, database.PatchSubjectParams{
+		SetName:       patch.Name.Set,
+		Name:          patch.Name.Value,
+		SetColorHex:   patch.ColorHex.Set,
+		ColorHex:      nullStringPtr(patch.ColorHex.Ptr()),
+		SetExamWeight: patch.ExamWeight.Set,
+		ExamWeight:    nullFloat64(patch.ExamWeight.Ptr()),
+		ID:            id,
+		UserID:        userID,
+		IfVersion:     nullInt64(version),
 	})
+	return versioned(subject, err, func() error {
+		_, err := s.repo.GetSubject(ctx, id, userID)
+		return err
+	}, ErrSubjectNotFound)
 }
 
 func (s *SubjectManager) DeleteSubject(ctx context.Context, id, userID string) error {
 	return s.repo.DeleteSubject(ctx, id, userID)
 }
+
+func nullFloat64(f *float64) sql.NullFloat64 {
+	if f == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *f, Valid: true}
+}
+
+func nullInt64(i *int64) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *i, Valid: true}
+}
@@ -31,9 +31,14 @@ func (m *MockSubjectRepository) GetSubject(ctx context.Context, id, userID strin
 	return args.Get(0).(database.Subject), args.Error(1)
 }
 
-func (m *MockSubjectRepository) UpdateSubject(ctx context.Context, arg database.UpdateSubjectParams) error {
+func (m *MockSubjectRepository) UpdateSubject(ctx context.Context, arg database.UpdateSubjectParams) (database.Subject, error) {
 	args := m.Called(ctx, arg)
-	return args.Error(0)
+	return args.Get(0).(database.Subject), args.Error(1)
+}
+
+func (m *MockSubjectRepository) PatchSubject(ctx context.Context, arg database.PatchSubjectParams) (database.Subject, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Subject), args.Error(1)
 }
 
 func (m *MockSubjectRepository) DeleteSubject(ctx context.Context, id, userID string) error {
@@ -53,9 +58,9 @@ func TestSubjectManager_CreateSubject(t *testing.T) {
 	// Expect CreateSubject to be called with UserID
 	mockRepo.On("CreateSubject", ctx, mock.MatchedBy(func(arg database.CreateSubjectParams) bool {
 		return arg.Name == name &&
-			   arg.ColorHex.String == colorHex &&
-			   arg.ColorHex.Valid &&
-			   arg.UserID == userID
+			arg.ColorHex.String == colorHex &&
+			arg.ColorHex.Valid &&
+			arg.UserID == userID
 	})).Return(database.Subject{
 		ID:       "uuid",
 		UserID:   userID,
@@ -63,7 +68,7 @@ func TestSubjectManager_CreateSubject(t *testing.T) {
 		ColorHex: sql.NullString{String: colorHex, Valid: true},
 	}, nil)
 
-	subject, err := svc.CreateSubject(ctx, userID, name, colorHex)
+	subject, err := svc.CreateSubject(ctx, userID, name, colorHex, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, name, subject.Name)
@@ -78,99 +83,59 @@ func TestSubjectManager_ListSubjects(t *testing.T) {
 
 	ctx := context.Background()
 	userID := "user-123"
-	expected
-	package service_test
+	expectedSubjects := []database.Subject{
+		{ID: "1", UserID: userID, Name: "Math"},
+		{ID: "2", UserID: userID, Name: "Physics"},
+	}
 
-import (
-		"context"
-		"database/sql"
-		"testing"
-
-		"github.com/joaoapaenas/my-api/internal/database"
-		"github.com/joaoapaenas/my-api/internal/service"
-		"github.com/stretchr/testify/assert"
-		"github.com/stretchr/testify/mock"
-)
+	// Expect ListSubjects to be called with UserID
+	mockRepo.On("ListSubjects", ctx, userID).Return(expectedSubjects, nil)
 
-// MockSubjectRepository is a mock implementation of repository.SubjectRepository
-type MockSubjectRepository struct {
-		mock.Mock
-}
-
-func (m *MockSubjectRepository) CreateSubject(ctx context.Context, arg database.CreateSubjectParams) (database.Subject, error) {
-		args := m.Called(ctx, arg)
-		return args.Get(0).(database.Subject), args.Error(1)
-}
-
-func (m *MockSubjectRepository) ListSubjects(ctx context.Context, userID string) ([]database.Subject, error) {
-		args := m.Called(ctx, userID)
-		return args.Get(0).([]database.Subject), args.Error(1)
-}
+	subjects, err := svc.ListSubjects(ctx, userID)
 
-func (m *MockSubjectRepository) GetSubject(ctx context.Context, id, userID string) (database.Subject, error) {
-		args := m.Called(ctx, id, userID)
-		return args.Get(0).(database.Subject), args.Error(1)
-}
-
-func (m *MockSubjectRepository) UpdateSubject(ctx context.Context, arg database.UpdateSubjectParams) error {
-		args := m.Called(ctx, arg)
-		return args.Error(0)
+	assert.NoError(t, err)
+	assert.Len(t, subjects, 2)
+	assert.Equal(t, "Math", subjects[0].Name)
+	mockRepo.AssertExpectations(t)
 }
 
-func (m *MockSubjectRepository) DeleteSubject(ctx context.Context, id, userID string) error {
-		args := m.Called(ctx, id, userID)
-		return args.Error(0)
-}
+func TestSubjectManager_UpdateSubject_Version(t *testing.T) {
+	ctx := context.Background()
+	version := int64(3)
 
-func TestSubjectManager_CreateSubject(t *testing.T) {
+	t.Run("matching version", func(t *testing.T) {
 		mockRepo := new(MockSubjectRepository)
 		svc := service.NewSubjectManager(mockRepo)
+		mockRepo.On("UpdateSubject", ctx, mock.MatchedBy(func(arg database.UpdateSubjectParams) bool {
+			return arg.ID == "s1" && arg.IfVersion == sql.NullInt64{Int64: 3, Valid: true}
+		})).Return(database.Subject{ID: "s1", Name: "Math", Version: 4}, nil)
 
-		ctx := context.Background()
-		userID := "user-123"
-		name := "Mathematics"
-		colorHex := "#FF0000"
-
-		// Expect CreateSubject to be called with UserID
-		mockRepo.On("CreateSubject", ctx, mock.MatchedBy(func(arg database.CreateSubjectParams) bool {
-			return arg.Name == name &&
-				   arg.ColorHex.String == colorHex &&
-				   arg.ColorHex.Valid &&
-				   arg.UserID == userID
-		})).Return(database.Subject{
-			ID:       "uuid",
-			UserID:   userID,
-			Name:     name,
-			ColorHex: sql.NullString{String: colorHex, Valid: true},
-		}, nil)
-
-		subject, err := svc.CreateSubject(ctx, userID, name, colorHex)
+		subject, err := svc.UpdateSubject(ctx, "s1", "user-123", "Math", "", nil, &version)
 
 		assert.NoError(t, err)
-		assert.Equal(t, name, subject.Name)
-		assert.Equal(t, userID, subject.UserID)
-		assert.Equal(t, colorHex, subject.ColorHex.String)
+		assert.Equal(t, int64(4), subject.Version)
 		mockRepo.AssertExpectations(t)
-}
+	})
 
-func TestSubjectManager_ListSubjects(t *testing.T) {
+	t.Run("stale version", func(t *testing.T) {
 		mockRepo := new(MockSubjectRepository)
 		svc := service.NewSubjectManager(mockRepo)
+		mockRepo.On("UpdateSubject", ctx, mock.Anything).Return(database.Subject{}, sql.ErrNoRows)
+		mockRepo.On("GetSubject", ctx, "s1", "user-123").Return(database.Subject{ID: "s1", Version: 5}, nil)
+
+		_, err := svc.UpdateSubject(ctx, "s1", "user-123", "Math", "", nil, &version)
 
-		ctx := context.Background()
-		userID := "user-123"
-		expectedSubjects := []database.Subject{
-			{ID: "1", UserID: userID, Name: "Math"},
-			{ID: "2", UserID: userID, Name: "Physics"},
-		}
+		assert.ErrorIs(t, err, service.ErrVersionMismatch)
+	})
 
-		// Expect ListSubjects to be called with UserID
-		mockRepo.On("ListSubjects", ctx, userID).Return(expectedSubjects, nil)
+	t.Run("missing subject", func(t *testing.T) {
+		mockRepo := new(MockSubjectRepository)
+		svc := service.NewSubjectManager(mockRepo)
+		mockRepo.On("UpdateSubject", ctx, mock.Anything).Return(database.Subject{}, sql.ErrNoRows)
+		mockRepo.On("GetSubject", ctx, "s1", "user-123").Return(database.Subject{}, sql.ErrNoRows)
 
-		subjects, err := svc.ListSubjects(ctx, userID)
+		_, err := svc.UpdateSubject(ctx, "s1", "user-123", "Math", "", nil, nil)
 
-		assert.NoError(t, err)
-		assert.Len(t, subjects, 2)
-		assert.Equal(t, "Math", subjects[0].Name)
-		mockRepo.AssertExpectations(t)
+		assert.ErrorIs(t, err, service.ErrSubjectNotFound)
+	})
 }
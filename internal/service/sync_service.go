@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joaoapaenas/my-api/internal/backup"
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+var (
+	ErrInvalidMutation = errors.New("invalid mutation")
+	// ErrSyncNotFound rejects a mutation of a row, or pointing at a row, that
+	// isn't the user's or no longer exists
+	ErrSyncNotFound = errors.New("not found")
+)
+
+// DefaultSyncRetention is how long superseded changes stay in the feed
+const DefaultSyncRetention = 30 * 24 * time.Hour
+
+// Entity types of the change feed
+const (
+	SyncSubject      = "subject"
+	SyncTopic        = "topic"
+	SyncStudyCycle   = "study_cycle"
+	SyncCycleItem    = "cycle_item"
+	SyncStudySession = "study_session"
+	SyncSessionPause = "session_pause"
+	SyncExerciseLog  = "exercise_log"
+	SyncGoal         = "goal"
+)
+
+// Change and mutation operations
+const (
+	SyncUpsert = "upsert"
+	SyncDelete = "delete"
+)
+
+// Outcomes of a pushed mutation
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+// DefaultSyncPageSize is how many changes a feed page holds unless asked
+const DefaultSyncPageSize = 500
+
+// SyncChange is the last change of a row. Data is the row as it is now, in
+// the same shape as its archive record plus its version where it has one;
+// tombstones have none.
+type SyncChange struct {
+	Seq       int64
+	Entity    string
+	ID        string
+	Op        string
+	ChangedAt time.Time
+	Data      json.RawMessage
+}
+
+type SyncPage struct {
+	Changes []SyncChange
+	Cursor  int64 // Seq to resume from: the last change's, or since when empty
+	HasMore bool
+}
+
+// SyncMutation is one change made offline. Data holds the whole row for an
+// upsert, in the shape the feed serves; fields the server owns (timestamps,
+// version) are ignored.
+type SyncMutation struct {
+	Entity string
+	Op     string
+	ID     string
+	Data   json.RawMessage
+}
+
+type SyncResult struct {
+	Entity  string
+	ID      string
+	Status  string
+	Error   string      // Why the mutation was rejected
+	Current *SyncChange // Server change the mutation conflicts with
+}
+
+// SyncService backs offline-first clients. The feed lists every created,
+// updated and deleted row of the user in commit order, one entry per row with
+// its last change, and a client resumes from the cursor of the last page.
+// Pushed mutations create rows under client-generated IDs, update or delete
+// them; a mutation of a row that changed on the server after the client's
+// cursor is reported as a conflict instead, with the server's version. Goals
+// are in the feed but are written through their own endpoints.
+type SyncService interface {
+	// Changes lists up to limit changes after since
+	Changes(ctx context.Context, userID string, since, limit int64) (SyncPage, error)
+	// Push applies mutations in order, in one transaction. Conflicting and
+	// rejected mutations are skipped and reported; the rest are applied.
+	Push(ctx context.Context, userID string, since int64, mutations []SyncMutation) ([]SyncResult, error)
+	// CompactChanges deletes the changes logged longer than the retention
+	// period before now that a later change of the same row supersedes, and
+	// returns how many there were
+	CompactChanges(ctx context.Context, now time.Time) (int, error)
+}
+
+type SyncManager struct {
+	repo      repository.SyncRepository
+	retention time.Duration
+}
+
+func NewSyncManager(repo repository.SyncRepository) *SyncManager {
+	return &SyncManager{repo: repo, retention: DefaultSyncRetention}
+}
+
+// WithRetention overrides how long superseded changes are kept
+func (s *SyncManager) WithRetention(retention time.Duration) *SyncManager {
+	s.retention = retention
+	return s
+}
+
+func (s *SyncManager) Changes(ctx context.Context, userID string, since, limit int64) (SyncPage, error) {
+	rows, err := s.repo.ListSyncChanges(ctx, database.ListSyncChangesParams{UserID: userID, Since: since, Limit: limit + 1})
+	if err != nil {
+		return SyncPage{}, err
+	}
+
+	page := SyncPage{Changes: []SyncChange{}, Cursor: since}
+	if int64(len(rows)) > limit {
+		rows, page.HasMore = rows[:limit], true
+	}
+	for _, row := range rows {
+		page.Changes = append(page.Changes, toSyncChange(row))
+	}
+	if n := len(page.Changes); n > 0 {
+		page.Cursor = page.Changes[n-1].Seq
+	}
+	return page, nil
+}
+
+func (s *SyncManager) Push(ctx context.Context, userID string, since int64, mutations []SyncMutation) ([]SyncResult, error) {
+	results := make([]SyncResult, len(mutations))
+	err := s.repo.Tx(ctx, func(repo repository.SyncRepository) error {
+		// Rows this push already wrote: changes of its own aren't conflicts
+		pushed := map[string]bool{}
+		for i, m := range mutations {
+			result, err := push(ctx, repo, userID, since, pushed, m)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", m.Entity, m.ID, err)
+			}
+			results[i] = result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *SyncManager) CompactChanges(ctx context.Context, now time.Time) (int, error) {
+	compacted, err := s.repo.CompactSyncChanges(ctx, formatSQLiteTime(now.Add(-s.retention)))
+	return int(compacted), err
+}
+
+// syncWrite writes one mutation and returns how many rows it touched
+type syncWrite func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error)
+
+func push(ctx context.Context, repo repository.SyncRepository, userID string, since int64, pushed map[string]bool, m SyncMutation) (SyncResult, error) {
+	result := SyncResult{Entity: m.Entity, ID: m.ID}
+	reject := func(err error) (SyncResult, error) {
+		result.Status, result.Error = SyncRejected, err.Error()
+		return result, nil
+	}
+
+	write, err := mutationWrite(m)
+	if err != nil {
+		return reject(err)
+	}
+
+	key := m.Entity + " " + m.ID
+	latest, err := repo.GetLatestSyncChange(ctx, database.GetLatestSyncChangeParams{UserID: userID, Entity: m.Entity, EntityID: m.ID})
+	seen := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return result, err
+	}
+	if seen && latest.Seq > since && !pushed[key] {
+		current := toSyncChange(database.ListSyncChangesRow(latest))
+		result.Status, result.Current = SyncConflict, &current
+		return result, nil
+	}
+	if seen && m.Op == SyncDelete && latest.Op == SyncDelete {
+		// Already gone
+		result.Status = SyncApplied
+		return result, nil
+	}
+
+	n, err := write(ctx, repo, userID)
+	if err != nil {
+		return result, err
+	}
+	if n == 0 {
+		return reject(ErrSyncNotFound)
+	}
+	pushed[key] = true
+	result.Status = SyncApplied
+	return result, nil
+}
+
+// mutationWrite checks a mutation and returns the write applying it
+func mutationWrite(m SyncMutation) (syncWrite, error) {
+	if _, err := uuid.Parse(m.ID); err != nil {
+		return nil, fmt.Errorf("%w: id must be a UUID", ErrInvalidMutation)
+	}
+	switch m.Op {
+	case SyncDelete:
+		return deleteWrite(m)
+	case SyncUpsert:
+		if len(m.Data) == 0 || string(m.Data) == "null" {
+			return nil, fmt.Errorf("%w: an upsert needs data", ErrInvalidMutation)
+		}
+		return upsertWrite(m)
+	default:
+		return nil, fmt.Errorf("%w: op must be upsert or delete", ErrInvalidMutation)
+	}
+}
+
+// deleteWrite deletes the way the API does: subjects, topics and study cycles
+// go to the trash, other rows for good
+func deleteWrite(m SyncMutation) (syncWrite, error) {
+	switch m.Entity {
+	case SyncSubject:
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.DeleteSyncSubject(ctx, database.DeleteSyncSubjectParams{ID: m.ID, UserID: userID})
+		}, nil
+	case SyncTopic:
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.DeleteSyncTopic(ctx, database.DeleteSyncTopicParams{ID: m.ID, UserID: userID})
+		}, nil
+	case SyncStudyCycle:
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.DeleteSyncStudyCycle(ctx, database.DeleteSyncStudyCycleParams{ID: m.ID, UserID: userID})
+		}, nil
+	case SyncCycleItem:
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.DeleteSyncCycleItem(ctx, database.DeleteSyncCycleItemParams{ID: m.ID, UserID: userID})
+		}, nil
+	case SyncStudySession:
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.DeleteSyncStudySession(ctx, database.DeleteSyncStudySessionParams{ID: m.ID, UserID: userID})
+		}, nil
+	case SyncSessionPause:
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.DeleteSyncSessionPause(ctx, database.DeleteSyncSessionPauseParams{ID: m.ID, UserID: userID})
+		}, nil
+	case SyncExerciseLog:
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.DeleteSyncExerciseLog(ctx, database.DeleteSyncExerciseLogParams{ID: m.ID, UserID: userID})
+		}, nil
+	default:
+		return nil, unpushable(m.Entity)
+	}
+}
+
+// upsertWrite reads the row of an upsert. The row's own id, when it sends
+// one, must be the mutation's.
+func upsertWrite(m SyncMutation) (syncWrite, error) {
+	switch m.Entity {
+	case SyncSubject:
+		var row backup.Subject
+		if err := decodeMutation(m, &row, &row.ID); err != nil {
+			return nil, err
+		}
+		if row.Name == "" {
+			return nil, missing("name")
+		}
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.UpsertSyncSubject(ctx, database.UpsertSyncSubjectParams{
+				ID:         m.ID,
+				UserID:     userID,
+				Name:       row.Name,
+				ColorHex:   nullStringPtr(row.ColorHex),
+				ExamWeight: nullFloat64(row.ExamWeight),
+			})
+		}, nil
+
+	case SyncTopic:
+		var row backup.Topic
+		if err := decodeMutation(m, &row, &row.ID); err != nil {
+			return nil, err
+		}
+		if row.Name == "" {
+			return nil, missing("name")
+		}
+		if row.SubjectID == "" {
+			return nil, missing("subject_id")
+		}
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.UpsertSyncTopic(ctx, database.UpsertSyncTopicParams{
+				ID:        m.ID,
+				Name:      row.Name,
+				SubjectID: row.SubjectID,
+				UserID:    userID,
+			})
+		}, nil
+
+	case SyncStudyCycle:
+		var row backup.StudyCycle
+		if err := decodeMutation(m, &row, &row.ID); err != nil {
+			return nil, err
+		}
+		if row.Name == "" {
+			return nil, missing("name")
+		}
+		var isActive int64
+		if row.IsActive {
+			isActive = 1
+		}
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.UpsertSyncStudyCycle(ctx, database.UpsertSyncStudyCycleParams{
+				ID:          m.ID,
+				Name:        row.Name,
+				Description: nullStringPtr(row.Description),
+				IsActive:    sql.NullInt64{Int64: isActive, Valid: true},
+				UserID:      userID,
+			})
+		}, nil
+
+	case SyncCycleItem:
+		var row backup.CycleItem
+		if err := decodeMutation(m, &row, &row.ID); err != nil {
+			return nil, err
+		}
+		if row.CycleID == "" {
+			return nil, missing("cycle_id")
+		}
+		if row.SubjectID == "" {
+			return nil, missing("subject_id")
+		}
+		if row.PlannedDurationMinutes != nil && *row.PlannedDurationMinutes < 1 {
+			return nil, fmt.Errorf("%w: planned_duration_minutes must be positive", ErrInvalidMutation)
+		}
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.UpsertSyncCycleItem(ctx, database.UpsertSyncCycleItemParams{
+				ID:                     m.ID,
+				OrderIndex:             row.OrderIndex,
+				PlannedDurationMinutes: nullInt64(row.PlannedDurationMinutes),
+				CycleID:                row.CycleID,
+				SubjectID:              row.SubjectID,
+				UserID:                 userID,
+			})
+		}, nil
+
+	case SyncStudySession:
+		var row backup.StudySession
+		if err := decodeMutation(m, &row, &row.ID); err != nil {
+			return nil, err
+		}
+		if row.SubjectID == "" {
+			return nil, missing("subject_id")
+		}
+		if err := checkPeriod("started_at", row.StartedAt, "finished_at", row.FinishedAt); err != nil {
+			return nil, err
+		}
+		if row.GrossDurationSeconds < 0 || row.NetDurationSeconds < 0 || row.NetDurationSeconds > row.GrossDurationSeconds {
+			return nil, fmt.Errorf("%w: durations must satisfy 0 <= net_duration_seconds <= gross_duration_seconds", ErrInvalidMutation)
+		}
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.UpsertSyncStudySession(ctx, database.UpsertSyncStudySessionParams{
+				ID:                   m.ID,
+				CycleItemID:          nullStringPtr(row.CycleItemID),
+				StartedAt:            row.StartedAt,
+				FinishedAt:           nullStringPtr(row.FinishedAt),
+				GrossDurationSeconds: sql.NullInt64{Int64: row.GrossDurationSeconds, Valid: true},
+				NetDurationSeconds:   sql.NullInt64{Int64: row.NetDurationSeconds, Valid: true},
+				Notes:                nullStringPtr(row.Notes),
+				SubjectID:            row.SubjectID,
+				UserID:               userID,
+			})
+		}, nil
+
+	case SyncSessionPause:
+		var row backup.SessionPause
+		if err := decodeMutation(m, &row, &row.ID); err != nil {
+			return nil, err
+		}
+		if row.SessionID == "" {
+			return nil, missing("session_id")
+		}
+		if err := checkPeriod("started_at", row.StartedAt, "ended_at", row.EndedAt); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.UpsertSyncSessionPause(ctx, database.UpsertSyncSessionPauseParams{
+				ID:        m.ID,
+				StartedAt: row.StartedAt,
+				EndedAt:   nullStringPtr(row.EndedAt),
+				SessionID: row.SessionID,
+				UserID:    userID,
+			})
+		}, nil
+
+	case SyncExerciseLog:
+		var row backup.ExerciseLog
+		if err := decodeMutation(m, &row, &row.ID); err != nil {
+			return nil, err
+		}
+		if row.SubjectID == "" {
+			return nil, missing("subject_id")
+		}
+		if row.CorrectCount < 0 || row.CorrectCount > row.QuestionsCount {
+			return nil, fmt.Errorf("%w: counts must satisfy 0 <= correct_count <= questions_count", ErrInvalidMutation)
+		}
+		return func(ctx context.Context, repo repository.SyncRepository, userID string) (int64, error) {
+			return repo.UpsertSyncExerciseLog(ctx, database.UpsertSyncExerciseLogParams{
+				ID:             m.ID,
+				SessionID:      nullStringPtr(row.SessionID),
+				TopicID:        nullStringPtr(row.TopicID),
+				QuestionsCount: row.QuestionsCount,
+				CorrectCount:   row.CorrectCount,
+				SubjectID:      row.SubjectID,
+				UserID:         userID,
+			})
+		}, nil
+
+	default:
+		return nil, unpushable(m.Entity)
+	}
+}
+
+func decodeMutation(m SyncMutation, row any, id *string) error {
+	if err := json.Unmarshal(m.Data, row); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMutation, err)
+	}
+	if *id != "" && *id != m.ID {
+		return fmt.Errorf("%w: data.id doesn't match id", ErrInvalidMutation)
+	}
+	return nil
+}
+
+func missing(field string) error {
+	return fmt.Errorf("%w: %s is required", ErrInvalidMutation, field)
+}
+
+// checkPeriod checks the timestamps of a session or pause: a start, and an
+// end no earlier than it when there is one
+func checkPeriod(startField, start, endField string, end *string) error {
+	started, err := parseTimestamp(start)
+	if err != nil {
+		return fmt.Errorf("%w: %s must be a timestamp", ErrInvalidMutation, startField)
+	}
+	if end == nil {
+		return nil
+	}
+	ended, err := parseTimestamp(*end)
+	if err != nil {
+		return fmt.Errorf("%w: %s must be a timestamp", ErrInvalidMutation, endField)
+	}
+	if ended.Before(started) {
+		return fmt.Errorf("%w: %s is before %s", ErrInvalidMutation, endField, startField)
+	}
+	return nil
+}
+
+func unpushable(entity string) error {
+	if entity == SyncGoal {
+		return fmt.Errorf("%w: goals are written through /goals", ErrInvalidMutation)
+	}
+	return fmt.Errorf("%w: unknown entity %q", ErrInvalidMutation, entity)
+}
+
+func toSyncChange(row database.ListSyncChangesRow) SyncChange {
+	change := SyncChange{Seq: row.Seq, Entity: row.Entity, ID: row.EntityID, Op: row.Op}
+	change.ChangedAt, _ = parseTimestamp(row.ChangedAt)
+	if row.Data.Valid {
+		change.Data = json.RawMessage(row.Data.String)
+	}
+	return change
+}
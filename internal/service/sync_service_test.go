@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSyncRepository is a mock implementation of repository.SyncRepository
+type MockSyncRepository struct {
+	mock.Mock
+}
+
+func (m *MockSyncRepository) ListSyncChanges(ctx context.Context, arg database.ListSyncChangesParams) ([]database.ListSyncChangesRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.ListSyncChangesRow), args.Error(1)
+}
+
+func (m *MockSyncRepository) GetLatestSyncChange(ctx context.Context, arg database.GetLatestSyncChangeParams) (database.GetLatestSyncChangeRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.GetLatestSyncChangeRow), args.Error(1)
+}
+
+func (m *MockSyncRepository) CompactSyncChanges(ctx context.Context, cutoff string) (int64, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpsertSyncSubject(ctx context.Context, arg database.UpsertSyncSubjectParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpsertSyncTopic(ctx context.Context, arg database.UpsertSyncTopicParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpsertSyncStudyCycle(ctx context.Context, arg database.UpsertSyncStudyCycleParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpsertSyncCycleItem(ctx context.Context, arg database.UpsertSyncCycleItemParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpsertSyncStudySession(ctx context.Context, arg database.UpsertSyncStudySessionParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpsertSyncSessionPause(ctx context.Context, arg database.UpsertSyncSessionPauseParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpsertSyncExerciseLog(ctx context.Context, arg database.UpsertSyncExerciseLogParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) DeleteSyncSubject(ctx context.Context, arg database.DeleteSyncSubjectParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) DeleteSyncTopic(ctx context.Context, arg database.DeleteSyncTopicParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) DeleteSyncStudyCycle(ctx context.Context, arg database.DeleteSyncStudyCycleParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) DeleteSyncCycleItem(ctx context.Context, arg database.DeleteSyncCycleItemParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) DeleteSyncStudySession(ctx context.Context, arg database.DeleteSyncStudySessionParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) DeleteSyncSessionPause(ctx context.Context, arg database.DeleteSyncSessionPauseParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) DeleteSyncExerciseLog(ctx context.Context, arg database.DeleteSyncExerciseLogParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSyncRepository) Tx(ctx context.Context, fn func(repository.SyncRepository) error) error {
+	return fn(m)
+}
+
+func TestSyncManager_Changes(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockSyncRepository)
+	svc := service.NewSyncManager(mockRepo)
+
+	// One row more than the limit tells there is another page
+	mockRepo.On("ListSyncChanges", ctx, database.ListSyncChangesParams{UserID: "user-1", Since: 10, Limit: 3}).Return([]database.ListSyncChangesRow{
+		{Seq: 11, Entity: "subject", EntityID: "s1", Op: "upsert", ChangedAt: "2024-03-01 10:00:00", Data: sql.NullString{String: `{"id":"s1"}`, Valid: true}},
+		{Seq: 14, Entity: "topic", EntityID: "t1", Op: "delete", ChangedAt: "2024-03-01 10:00:00"},
+		{Seq: 15, Entity: "topic", EntityID: "t2", Op: "upsert", ChangedAt: "2024-03-01 10:00:00"},
+	}, nil)
+
+	page, err := svc.Changes(ctx, "user-1", 10, 2)
+	assert.NoError(t, err)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, int64(14), page.Cursor)
+	if assert.Len(t, page.Changes, 2) {
+		assert.JSONEq(t, `{"id":"s1"}`, string(page.Changes[0].Data))
+		assert.Nil(t, page.Changes[1].Data)
+	}
+
+	// An empty page keeps the cursor
+	mockRepo.On("ListSyncChanges", ctx, database.ListSyncChangesParams{UserID: "user-1", Since: 20, Limit: 3}).Return([]database.ListSyncChangesRow{}, nil)
+	page, err = svc.Changes(ctx, "user-1", 20, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), page.Cursor)
+	assert.Empty(t, page.Changes)
+}
+
+func TestSyncManager_Push(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockSyncRepository)
+	svc := service.NewSyncManager(mockRepo)
+
+	const subjectID, topicID, goneID = "6a0c7d52-3c1e-4c57-9a1e-2f4b8d1e0a01", "6a0c7d52-3c1e-4c57-9a1e-2f4b8d1e0a02", "6a0c7d52-3c1e-4c57-9a1e-2f4b8d1e0a03"
+	latest := func(entity, id string) database.GetLatestSyncChangeParams {
+		return database.GetLatestSyncChangeParams{UserID: "user-1", Entity: entity, EntityID: id}
+	}
+
+	// The subject changed on the server after the client's cursor
+	mockRepo.On("GetLatestSyncChange", ctx, latest("subject", subjectID)).
+		Return(database.GetLatestSyncChangeRow{Seq: 12, Entity: "subject", EntityID: subjectID, Op: "upsert"}, nil)
+	// The topic is new the first time; its second mutation sees the change the first made
+	mockRepo.On("GetLatestSyncChange", ctx, latest("topic", topicID)).
+		Return(database.GetLatestSyncChangeRow{}, sql.ErrNoRows).Once()
+	mockRepo.On("GetLatestSyncChange", ctx, latest("topic", topicID)).
+		Return(database.GetLatestSyncChangeRow{Seq: 13, Entity: "topic", EntityID: topicID, Op: "upsert"}, nil).Once()
+	mockRepo.On("UpsertSyncTopic", ctx, database.UpsertSyncTopicParams{ID: topicID, Name: "Algebra", SubjectID: subjectID, UserID: "user-1"}).Return(int64(1), nil)
+	mockRepo.On("UpsertSyncTopic", ctx, database.UpsertSyncTopicParams{ID: topicID, Name: "Linear algebra", SubjectID: subjectID, UserID: "user-1"}).Return(int64(1), nil)
+	// Deleting what the server already deleted is a no-op
+	mockRepo.On("GetLatestSyncChange", ctx, latest("cycle_item", goneID)).
+		Return(database.GetLatestSyncChangeRow{Seq: 5, Entity: "cycle_item", EntityID: goneID, Op: "delete"}, nil)
+
+	results, err := svc.Push(ctx, "user-1", 10, []service.SyncMutation{
+		{Entity: "subject", Op: "upsert", ID: subjectID, Data: []byte(`{"name": "Maths"}`)},
+		{Entity: "topic", Op: "upsert", ID: topicID, Data: []byte(`{"subject_id": "` + subjectID + `", "name": "Algebra"}`)},
+		{Entity: "topic", Op: "upsert", ID: topicID, Data: []byte(`{"subject_id": "` + subjectID + `", "name": "Linear algebra"}`)},
+		{Entity: "cycle_item", Op: "delete", ID: goneID},
+		{Entity: "topic", Op: "upsert", ID: "not-a-uuid", Data: []byte(`{"name": "Algebra"}`)},
+		{Entity: "topic", Op: "upsert", ID: topicID, Data: []byte(`{"id": "` + subjectID + `", "name": "Algebra"}`)},
+	})
+	assert.NoError(t, err)
+	var statuses []string
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []string{
+		service.SyncConflict, service.SyncApplied, service.SyncApplied, service.SyncApplied, service.SyncRejected, service.SyncRejected,
+	}, statuses)
+	if assert.NotNil(t, results[0].Current) {
+		assert.Equal(t, int64(12), results[0].Current.Seq)
+	}
+	assert.Contains(t, results[4].Error, "UUID")
+	mockRepo.AssertNotCalled(t, "UpsertSyncSubject", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "DeleteSyncCycleItem", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSyncManager_CompactChanges(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockSyncRepository)
+	svc := service.NewSyncManager(mockRepo).WithRetention(24 * time.Hour)
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	mockRepo.On("CompactSyncChanges", ctx, "2024-03-09 12:00:00").Return(int64(3), nil)
+
+	compacted, err := svc.CompactChanges(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, compacted)
+	mockRepo.AssertExpectations(t)
+}
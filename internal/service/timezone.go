@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Bounds used when a date range is open on one side
+var (
+	rangeMin = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	rangeMax = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+)
+
+// parseTimestamp reads both the RFC 3339 timestamps clients send and the
+// zone-less ones SQLite writes, which are UTC.
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{sqliteTimeFormat, "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised timestamp %q", s)
+}
+
+// formatSQLiteTime converts t to the UTC format produced by datetime()
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// Calendar is how a user's days and weeks are laid out
+type Calendar struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+}
+
+// StartOfDay returns local midnight of t's day
+func (c Calendar) StartOfDay(t time.Time) time.Time {
+	return startOfDay(t, c.Location)
+}
+
+// StartOfWeek returns local midnight of the first day of t's week
+func (c Calendar) StartOfWeek(t time.Time) time.Time {
+	day := c.StartOfDay(t)
+	offset := (int(day.Weekday()) - int(c.WeekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// StartOfMonth returns local midnight of the first day of t's month
+func (c Calendar) StartOfMonth(t time.Time) time.Time {
+	t = t.In(c.Location)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, c.Location)
+}
+
+// startOfDay returns midnight of t's calendar day in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// parseDateRange turns the "from"/"to" query values into a half-open
+// [from, to) interval. Plain dates (YYYY-MM-DD) are local days in loc and
+// "to" includes its whole day; RFC 3339 timestamps are used as they are.
+// Empty values leave that side open.
+func parseDateRange(from, to string, loc *time.Location) (time.Time, time.Time, error) {
+	start, end := rangeMin, rangeMax
+
+	if from != "" {
+		t, _, err := parseDateBound(from, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDateRange, err)
+		}
+		start = t
+	}
+	if to != "" {
+		t, isDate, err := parseDateBound(to, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDateRange, err)
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		end = t
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be before to", ErrInvalidDateRange)
+	}
+	return start, end, nil
+}
+
+func parseDateBound(s string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is neither YYYY-MM-DD nor RFC 3339", s)
+	}
+	return t, false, nil
+}
+
+// daySlice is the part of a session that falls on one local calendar day
+type daySlice struct {
+	Day     time.Time // Local midnight
+	Seconds int64
+}
+
+// splitByDay spreads seconds evenly over [start, end) and cuts the interval
+// at every local midnight in loc, so a session from 22:00 to 02:00 credits
+// both days. The slices always add up to seconds.
+func splitByDay(start, end time.Time, seconds int64, loc *time.Location) []daySlice {
+	day := startOfDay(start, loc)
+	if !end.After(start) {
+		return []daySlice{{Day: day, Seconds: seconds}}
+	}
+
+	var slices []daySlice
+	for day.Before(end) {
+		next := day.AddDate(0, 0, 1)
+		slices = append(slices, daySlice{Day: day, Seconds: shareBetween(start, end, seconds, day, next)})
+		day = next
+	}
+	return slices
+}
+
+// shareBetween returns the part of seconds, spread evenly over [start, end),
+// that falls inside [from, to)
+func shareBetween(start, end time.Time, seconds int64, from, to time.Time) int64 {
+	return cumulativeShare(start, end, seconds, to) - cumulativeShare(start, end, seconds, from)
+}
+
+// cumulativeShare returns the part of seconds, spread evenly over [start, end),
+// that falls before t. Rounding the running total keeps adjacent shares exact.
+func cumulativeShare(start, end time.Time, seconds int64, t time.Time) int64 {
+	switch {
+	case !t.After(start):
+		return 0
+	case !t.Before(end):
+		return seconds
+	}
+	fraction := float64(t.Sub(start)) / float64(end.Sub(start))
+	return int64(math.Round(float64(seconds) * fraction))
+}
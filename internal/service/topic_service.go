@@ -2,17 +2,28 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/repository"
 )
 
+var ErrTopicNotFound = errors.New("topic not found")
+
+// TopicPatch changes the fields that are set
+type TopicPatch struct {
+	Name Field[string]
+}
+
 type TopicService interface {
 	CreateTopic(ctx context.Context, subjectID, name string) (database.Topic, error)
 	ListTopicsBySubject(ctx context.Context, subjectID string) ([]database.Topic, error)
 	GetTopic(ctx context.Context, id string) (database.Topic, error)
-	UpdateTopic(ctx context.Context, id, name string) error
+	// UpdateTopic fails with ErrVersionMismatch when version is set and stale
+	UpdateTopic(ctx context.Context, id, name string, version *int64) (database.Topic, error)
+	PatchTopic(ctx context.Context, id string, patch TopicPatch, version *int64) (database.Topic, error)
 	DeleteTopic(ctx context.Context, id string) error
 }
 
@@ -41,11 +52,37 @@ func (s *TopicManager) GetTopic(ctx context.Context, id string) (database.Topic,
 	return s.repo.GetTopic(ctx, id)
 }
 
-func (s *TopicManager) UpdateTopic(ctx context.Context, id, name string) error {
-	return s.repo.UpdateTopic(ctx, database.UpdateTopicParams{
-		Name: name,
-		ID:   id,
+func (s *TopicManager) UpdateTopic(ctx context.Context, id, name string, version *int64) (database.Topic, error) {
+	topic, err := s.repo.UpdateTopic(ctx, database.UpdateTopicParams{
+		Name:      name,
+		ID:        id,
+		IfVersion: nullInt64(version),
+	})
+	return versioned(topic, err, func() error {
+		_, err := s.repo.GetTopic(ctx, id)
+		return err
+	}, ErrTopicNotFound)
+}
+
+func (s *TopicManager) PatchTopic(ctx context.Context, id string, patch TopicPatch, version *int64) (database.Topic, error) {
+	if patch.Name.Null {
+		return database.Topic{}, fmt.Errorf("%w: name can't be null", ErrInvalidPatch)
+	}
+	if patch == (TopicPatch{}) {
+		topic, err := s.repo.GetTopic(ctx, id)
+		return unchanged(topic, err, func(t database.Topic) int64 { return t.Version }, version, ErrTopicNotFound)
+	}
+
+	topic, err := s.repo.PatchTopic(ctx, database.PatchTopicParams{
+		SetName:   patch.Name.Set,
+		Name:      patch.Name.Value,
+		ID:        id,
+		IfVersion: nullInt64(version),
 	})
+	return versioned(topic, err, func() error {
+		_, err := s.repo.GetTopic(ctx, id)
+		return err
+	}, ErrTopicNotFound)
 }
 
 func (s *TopicManager) DeleteTopic(ctx context.Context, id string) error {
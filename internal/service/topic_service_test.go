@@ -30,9 +30,14 @@ func (m *MockTopicRepository) GetTopic(ctx context.Context, id string) (database
 	return args.Get(0).(database.Topic), args.Error(1)
 }
 
-func (m *MockTopicRepository) UpdateTopic(ctx context.Context, arg database.UpdateTopicParams) error {
+func (m *MockTopicRepository) UpdateTopic(ctx context.Context, arg database.UpdateTopicParams) (database.Topic, error) {
 	args := m.Called(ctx, arg)
-	return args.Error(0)
+	return args.Get(0).(database.Topic), args.Error(1)
+}
+
+func (m *MockTopicRepository) PatchTopic(ctx context.Context, arg database.PatchTopicParams) (database.Topic, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Topic), args.Error(1)
 }
 
 func (m *MockTopicRepository) DeleteTopic(ctx context.Context, id string) error {
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+)
+
+var (
+	ErrNotInTrash       = errors.New("not in the trash")
+	ErrParentInTrash    = errors.New("parent is in the trash")
+	ErrInvalidTrashType = errors.New("type must be subject, topic or study_cycle")
+)
+
+// errRestored rolls back a purge whose item left the trash in the meantime
+var errRestored = errors.New("restored before the purge")
+
+// DefaultTrashRetention is how long deleted items stay restorable
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// Trash item types
+const (
+	TrashSubject    = "subject"
+	TrashTopic      = "topic"
+	TrashStudyCycle = "study_cycle"
+)
+
+// TrashItem is a soft-deleted subject, topic or study cycle. Dependents counts
+// the rows that go with it: purging a subject deletes them, purging a topic or
+// cycle unlinks the exercise logs or sessions that point at it.
+type TrashItem struct {
+	Type       string
+	ID         string
+	Name       string
+	ParentID   string // Subject of a topic
+	DeletedAt  time.Time
+	PurgeAfter time.Time
+	Dependents map[string]int64
+}
+
+// TrashService manages what deleting a subject, topic or study cycle leaves
+// behind. Deleting only marks the row: its dependents stay untouched, hidden
+// with it, so restoring brings everything back as it was. Once the retention
+// period ends the purge removes the row for good:
+//   - a subject with its topics, cycle items, sessions, exercise logs and goals
+//   - a topic, unlinking the exercise logs that name it
+//   - a study cycle with its items, unlinking the sessions planned from them
+type TrashService interface {
+	// ListTrash lists the user's trash, newest first; typ filters by item type
+	// when set
+	ListTrash(ctx context.Context, userID, typ string) ([]TrashItem, error)
+	RestoreSubject(ctx context.Context, userID, id string) error
+	// RestoreTopic fails with ErrParentInTrash while its subject is deleted
+	RestoreTopic(ctx context.Context, userID, id string) error
+	RestoreStudyCycle(ctx context.Context, userID, id string) error
+	// PurgeExpired purges everything deleted longer than the retention period
+	// before now and returns how many items were purged
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type TrashManager struct {
+	repo      repository.TrashRepository
+	retention time.Duration
+}
+
+func NewTrashManager(repo repository.TrashRepository) *TrashManager {
+	return &TrashManager{repo: repo, retention: DefaultTrashRetention}
+}
+
+// WithRetention overrides how long deleted items stay restorable
+func (s *TrashManager) WithRetention(retention time.Duration) *TrashManager {
+	s.retention = retention
+	return s
+}
+
+func (s *TrashManager) ListTrash(ctx context.Context, userID, typ string) ([]TrashItem, error) {
+	if typ != "" && typ != TrashSubject && typ != TrashTopic && typ != TrashStudyCycle {
+		return nil, ErrInvalidTrashType
+	}
+
+	items := []TrashItem{}
+	if typ == "" || typ == TrashSubject {
+		subjects, err := s.repo.ListTrashedSubjects(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range subjects {
+			items = append(items, s.trashItem(TrashSubject, row.ID, row.Name, row.DeletedAt, map[string]int64{
+				"topics":         row.Topics,
+				"cycle_items":    row.CycleItems,
+				"study_sessions": row.StudySessions,
+				"exercise_logs":  row.ExerciseLogs,
+				"goals":          row.Goals,
+			}))
+		}
+	}
+	if typ == "" || typ == TrashTopic {
+		topics, err := s.repo.ListTrashedTopics(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range topics {
+			item := s.trashItem(TrashTopic, row.ID, row.Name, row.DeletedAt, map[string]int64{"exercise_logs": row.ExerciseLogs})
+			item.ParentID = row.SubjectID
+			items = append(items, item)
+		}
+	}
+	if typ == "" || typ == TrashStudyCycle {
+		cycles, err := s.repo.ListTrashedStudyCycles(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range cycles {
+			items = append(items, s.trashItem(TrashStudyCycle, row.ID, row.Name, row.DeletedAt, map[string]int64{"cycle_items": row.CycleItems}))
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+func (s *TrashManager) RestoreSubject(ctx context.Context, userID, id string) error {
+	rows, err := s.repo.RestoreTrashedSubject(ctx, database.RestoreTrashedSubjectParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotInTrash
+	}
+	return nil
+}
+
+func (s *TrashManager) RestoreTopic(ctx context.Context, userID, id string) error {
+	return s.repo.Tx(ctx, func(repo repository.TrashRepository) error {
+		topic, err := repo.GetTrashedTopic(ctx, database.GetTrashedTopicParams{ID: id, UserID: userID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotInTrash
+			}
+			return err
+		}
+		if _, err := repo.GetSubject(ctx, database.GetSubjectParams{ID: topic.SubjectID, UserID: userID}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrParentInTrash
+			}
+			return err
+		}
+		_, err = repo.RestoreTrashedTopic(ctx, id)
+		return err
+	})
+}
+
+func (s *TrashManager) RestoreStudyCycle(ctx context.Context, userID, id string) error {
+	rows, err := s.repo.RestoreTrashedStudyCycle(ctx, database.RestoreTrashedStudyCycleParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotInTrash
+	}
+	return nil
+}
+
+func (s *TrashManager) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	cutoff := formatSQLiteTime(now.Add(-s.retention))
+
+	// Subjects first: they take their own trashed topics with them
+	kinds := []struct {
+		typ   string
+		list  func(context.Context, string) ([]string, error)
+		purge func(context.Context, repository.TrashRepository, string) error
+	}{
+		{TrashSubject, s.repo.ListExpiredTrashedSubjects, purgeSubject},
+		{TrashTopic, s.repo.ListExpiredTrashedTopics, purgeTopic},
+		{TrashStudyCycle, s.repo.ListExpiredTrashedStudyCycles, purgeStudyCycle},
+	}
+
+	count := 0
+	for _, kind := range kinds {
+		ids, err := kind.list(ctx, cutoff)
+		if err != nil {
+			return count, err
+		}
+		for _, id := range ids {
+			err := s.repo.Tx(ctx, func(repo repository.TrashRepository) error {
+				return kind.purge(ctx, repo, id)
+			})
+			if errors.Is(err, errRestored) {
+				continue
+			}
+			if err != nil {
+				// Left in the trash, so the next run tries again
+				slog.Error("Failed to purge trash", "type", kind.typ, "id", id, "error", err)
+				continue
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// purgeSubject deletes a trashed subject and everything under it, children
+// first so it doesn't depend on foreign key enforcement
+func purgeSubject(ctx context.Context, repo repository.TrashRepository, id string) error {
+	steps := []func(context.Context, string) error{
+		repo.PurgeSubjectExerciseLogs,
+		repo.PurgeSubjectSessionPauses,
+		repo.PurgeSubjectStudySessions,
+		repo.DetachSubjectCycleItemSessions,
+		repo.PurgeSubjectCycleItems,
+		repo.DetachSubjectTopicExerciseLogs,
+		repo.PurgeSubjectTopics,
+		repo.PurgeSubjectGoals,
+	}
+	for _, step := range steps {
+		if err := step(ctx, id); err != nil {
+			return err
+		}
+	}
+	return purged(repo.PurgeTrashedSubject(ctx, id))
+}
+
+func purgeTopic(ctx context.Context, repo repository.TrashRepository, id string) error {
+	if err := repo.DetachTopicExerciseLogs(ctx, id); err != nil {
+		return err
+	}
+	return purged(repo.PurgeTrashedTopic(ctx, id))
+}
+
+func purgeStudyCycle(ctx context.Context, repo repository.TrashRepository, id string) error {
+	if err := repo.DetachStudyCycleSessions(ctx, id); err != nil {
+		return err
+	}
+	if err := repo.PurgeStudyCycleItems(ctx, id); err != nil {
+		return err
+	}
+	return purged(repo.PurgeTrashedStudyCycle(ctx, id))
+}
+
+// purged checks that the last step of a purge deleted the trashed row itself
+func purged(rows int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errRestored
+	}
+	return nil
+}
+
+func (s *TrashManager) trashItem(typ, id, name string, deletedAt sql.NullString, dependents map[string]int64) TrashItem {
+	item := TrashItem{Type: typ, ID: id, Name: name, Dependents: dependents}
+	if deleted, err := parseTimestamp(deletedAt.String); err == nil {
+		item.DeletedAt = deleted
+		item.PurgeAfter = deleted.Add(s.retention)
+	}
+	return item
+}
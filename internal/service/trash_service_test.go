@@ -0,0 +1,190 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joaoapaenas/my-api/internal/database"
+	"github.com/joaoapaenas/my-api/internal/repository"
+	"github.com/joaoapaenas/my-api/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTrashRepository is a mock implementation of repository.TrashRepository
+type MockTrashRepository struct {
+	mock.Mock
+}
+
+func (m *MockTrashRepository) ListTrashedSubjects(ctx context.Context, userID string) ([]database.ListTrashedSubjectsRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListTrashedSubjectsRow), args.Error(1)
+}
+
+func (m *MockTrashRepository) ListTrashedTopics(ctx context.Context, userID string) ([]database.ListTrashedTopicsRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListTrashedTopicsRow), args.Error(1)
+}
+
+func (m *MockTrashRepository) ListTrashedStudyCycles(ctx context.Context, userID string) ([]database.ListTrashedStudyCyclesRow, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]database.ListTrashedStudyCyclesRow), args.Error(1)
+}
+
+func (m *MockTrashRepository) GetSubject(ctx context.Context, arg database.GetSubjectParams) (database.Subject, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Subject), args.Error(1)
+}
+
+func (m *MockTrashRepository) GetTrashedTopic(ctx context.Context, arg database.GetTrashedTopicParams) (database.Topic, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(database.Topic), args.Error(1)
+}
+
+func (m *MockTrashRepository) RestoreTrashedSubject(ctx context.Context, arg database.RestoreTrashedSubjectParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTrashRepository) RestoreTrashedTopic(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTrashRepository) RestoreTrashedStudyCycle(ctx context.Context, arg database.RestoreTrashedStudyCycleParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTrashRepository) ListExpiredTrashedSubjects(ctx context.Context, cutoff string) ([]string, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTrashRepository) ListExpiredTrashedTopics(ctx context.Context, cutoff string) ([]string, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTrashRepository) ListExpiredTrashedStudyCycles(ctx context.Context, cutoff string) ([]string, error) {
+	args := m.Called(ctx, cutoff)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTrashRepository) PurgeSubjectExerciseLogs(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeSubjectSessionPauses(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeSubjectStudySessions(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) DetachSubjectCycleItemSessions(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeSubjectCycleItems(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) DetachSubjectTopicExerciseLogs(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeSubjectTopics(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeSubjectGoals(ctx context.Context, subjectID string) error {
+	return m.Called(ctx, subjectID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeTrashedSubject(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTrashRepository) DetachTopicExerciseLogs(ctx context.Context, topicID string) error {
+	return m.Called(ctx, topicID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeTrashedTopic(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTrashRepository) DetachStudyCycleSessions(ctx context.Context, cycleID string) error {
+	return m.Called(ctx, cycleID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeStudyCycleItems(ctx context.Context, cycleID string) error {
+	return m.Called(ctx, cycleID).Error(0)
+}
+
+func (m *MockTrashRepository) PurgeTrashedStudyCycle(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTrashRepository) Tx(ctx context.Context, fn func(repository.TrashRepository) error) error {
+	m.Called(ctx)
+	return fn(m)
+}
+
+func TestTrashManager_RestoreTopic(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Waits for a deleted subject", func(t *testing.T) {
+		mockRepo := new(MockTrashRepository)
+		svc := service.NewTrashManager(mockRepo)
+
+		mockRepo.On("Tx", ctx).Return(nil)
+		mockRepo.On("GetTrashedTopic", ctx, database.GetTrashedTopicParams{ID: "topic-1", UserID: "user-1"}).
+			Return(database.Topic{ID: "topic-1", SubjectID: "subject-1"}, nil)
+		mockRepo.On("GetSubject", ctx, database.GetSubjectParams{ID: "subject-1", UserID: "user-1"}).
+			Return(database.Subject{}, sql.ErrNoRows)
+
+		err := svc.RestoreTopic(ctx, "user-1", "topic-1")
+		assert.ErrorIs(t, err, service.ErrParentInTrash)
+		mockRepo.AssertNotCalled(t, "RestoreTrashedTopic", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reports topics that aren't in the trash", func(t *testing.T) {
+		mockRepo := new(MockTrashRepository)
+		svc := service.NewTrashManager(mockRepo)
+
+		mockRepo.On("Tx", ctx).Return(nil)
+		mockRepo.On("GetTrashedTopic", ctx, mock.Anything).Return(database.Topic{}, sql.ErrNoRows)
+
+		err := svc.RestoreTopic(ctx, "user-1", "topic-1")
+		assert.ErrorIs(t, err, service.ErrNotInTrash)
+	})
+}
+
+func TestTrashManager_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockTrashRepository)
+	svc := service.NewTrashManager(mockRepo).WithRetention(24 * time.Hour)
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	cutoff := "2024-03-09 12:00:00"
+	mockRepo.On("ListExpiredTrashedSubjects", ctx, cutoff).Return([]string{}, nil)
+	mockRepo.On("ListExpiredTrashedTopics", ctx, cutoff).Return([]string{"gone", "restored"}, nil)
+	mockRepo.On("ListExpiredTrashedStudyCycles", ctx, cutoff).Return([]string{}, nil)
+	mockRepo.On("Tx", ctx).Return(nil)
+	mockRepo.On("DetachTopicExerciseLogs", ctx, mock.Anything).Return(nil)
+	mockRepo.On("PurgeTrashedTopic", ctx, "gone").Return(int64(1), nil)
+	// Restored between the listing and its purge: nothing is deleted
+	mockRepo.On("PurgeTrashedTopic", ctx, "restored").Return(int64(0), nil)
+
+	purged, err := svc.PurgeExpired(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	mockRepo.AssertExpectations(t)
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/joaoapaenas/my-api/internal/database"
@@ -11,23 +13,75 @@ import (
 )
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrEmailTaken   = errors.New("email already taken")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailTaken         = errors.New("email already taken")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountLocked      = errors.New("account temporarily locked")
+	ErrInvalidProfile     = errors.New("invalid profile settings")
 )
 
+// AccountLockedError is returned by Authenticate while a lockout is active.
+// It matches ErrAccountLocked with errors.Is.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// LockoutPolicy controls progressive lockout after failed logins.
+// Once Threshold consecutive failures are reached the account is locked for
+// BaseDuration, doubling with every further failure up to MaxDuration.
+type LockoutPolicy struct {
+	Threshold    int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	Threshold:    5,
+	BaseDuration: time.Minute,
+	MaxDuration:  time.Hour,
+}
+
+// ProfileUpdate is a partial profile update; nil fields are left unchanged
+type ProfileUpdate struct {
+	Name             *string
+	Timezone         *string
+	Locale           *string
+	DailyGoalMinutes *int
+	WeekStartDay     *int
+	ExamDate         *string // YYYY-MM-DD; empty clears it
+}
+
 type UserService interface {
 	CreateUser(ctx context.Context, email, name, password string) (database.User, error)
 	GetUserByEmail(ctx context.Context, email string) (database.User, error)
+	GetUserByID(ctx context.Context, id string) (database.User, error)
+	UpdateProfile(ctx context.Context, id string, update ProfileUpdate) (database.User, error)
 	UpdatePassword(ctx context.Context, email, oldPassword, newPassword string) error
+	Authenticate(ctx context.Context, email, password string) (database.User, error)
 }
 
 // UserManager implements UserService
 type UserManager struct {
-	repo repository.UserRepository
+	repo    repository.UserRepository
+	lockout LockoutPolicy
 }
 
 func NewUserManager(repo repository.UserRepository) *UserManager {
-	return &UserManager{repo: repo}
+	return &UserManager{repo: repo, lockout: DefaultLockoutPolicy}
+}
+
+// WithLockoutPolicy overrides the default lockout policy
+func (s *UserManager) WithLockoutPolicy(policy LockoutPolicy) *UserManager {
+	s.lockout = policy
+	return s
 }
 
 func (s *UserManager) CreateUser(ctx context.Context, email, name, password string) (database.User, error) {
@@ -60,24 +114,133 @@ func (s *UserManager) GetUserByEmail(ctx context.Context, email string) (databas
 	return user, nil
 }
 
-func (s *UserManager) UpdatePassword(ctx context.Context, email, oldPassword, newPassword string) error {
-	// 1. Get User
-	user, err := s.repo.GetUserByEmail(ctx, email)
+func (s *UserManager) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	return s.repo.GetUserByID(ctx, id)
+}
+
+func (s *UserManager) UpdateProfile(ctx context.Context, id string, update ProfileUpdate) (database.User, error) {
+	user, err := s.repo.GetUserByID(ctx, id)
 	if err != nil {
-		return err
+		return database.User{}, err
+	}
+
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+	if update.Timezone != nil {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil || *update.Timezone == "" {
+			return database.User{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidProfile, *update.Timezone)
+		}
+		user.Timezone = *update.Timezone
+	}
+	if update.Locale != nil {
+		user.Locale = *update.Locale
+	}
+	if update.DailyGoalMinutes != nil {
+		if *update.DailyGoalMinutes < 0 || *update.DailyGoalMinutes > 24*60 {
+			return database.User{}, fmt.Errorf("%w: daily goal must be between 0 and 1440 minutes", ErrInvalidProfile)
+		}
+		user.DailyGoalMinutes = int64(*update.DailyGoalMinutes)
+	}
+	if update.WeekStartDay != nil {
+		if *update.WeekStartDay < 0 || *update.WeekStartDay > 6 {
+			return database.User{}, fmt.Errorf("%w: week start day must be between 0 (Sunday) and 6", ErrInvalidProfile)
+		}
+		user.WeekStartDay = int64(*update.WeekStartDay)
+	}
+	if update.ExamDate != nil {
+		if *update.ExamDate != "" {
+			if _, err := time.Parse("2006-01-02", *update.ExamDate); err != nil {
+				return database.User{}, fmt.Errorf("%w: exam date must be YYYY-MM-DD", ErrInvalidProfile)
+			}
+		}
+		user.ExamDate = nullString(*update.ExamDate)
 	}
 
-	// 2. Verify Old Password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(oldPassword)); err != nil {
-		return errors.New("invalid old password")
+	err = s.repo.UpdateUserProfile(ctx, database.UpdateUserProfileParams{
+		Name:             user.Name,
+		Timezone:         user.Timezone,
+		Locale:           user.Locale,
+		DailyGoalMinutes: user.DailyGoalMinutes,
+		WeekStartDay:     user.WeekStartDay,
+		ExamDate:         user.ExamDate,
+		ID:               user.ID,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return user, nil
+}
+
+// UpdatePassword checks the old password like a login, lockout included, so
+// it can't be used to guess the password instead
+func (s *UserManager) UpdatePassword(ctx context.Context, email, oldPassword, newPassword string) error {
+	// 1. Verify Old Password
+	user, err := s.Authenticate(ctx, email, oldPassword)
+	if err != nil {
+		return err
 	}
 
-	// 3. Hash New Password
+	// 2. Hash New Password
 	newHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	// 4. Update in DB
+	// 3. Update in DB
 	return s.repo.UpdateUserPassword(ctx, user.ID, string(newHash))
 }
+
+// Authenticate verifies the credentials and applies the lockout policy.
+// Locked accounts are rejected before running bcrypt.
+func (s *UserManager) Authenticate(ctx context.Context, email, password string) (database.User, error) {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return database.User{}, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if user.LockedUntil.Valid && user.LockedUntil.Time.After(now) {
+		return database.User{}, &AccountLockedError{Until: user.LockedUntil.Time}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		attempts, err := s.repo.IncrementLoginFailures(ctx, user.ID)
+		if err != nil {
+			return database.User{}, err
+		}
+		lockedUntil := s.lockout.lockUntil(now, attempts)
+		if lockedUntil.IsZero() {
+			return database.User{}, ErrInvalidCredentials
+		}
+		if err := s.repo.LockUser(ctx, user.ID, attempts, lockedUntil); err != nil {
+			return database.User{}, err
+		}
+		return database.User{}, &AccountLockedError{Until: lockedUntil}
+	}
+
+	if user.FailedLoginAttempts > 0 || user.LockedUntil.Valid {
+		if err := s.repo.ResetLoginFailures(ctx, user.ID); err != nil {
+			return database.User{}, err
+		}
+	}
+	return user, nil
+}
+
+// lockUntil returns the end of the lockout for the given number of
+// consecutive failures, or the zero time if the account stays unlocked.
+func (p LockoutPolicy) lockUntil(now time.Time, attempts int64) time.Time {
+	if p.Threshold <= 0 || attempts < int64(p.Threshold) {
+		return time.Time{}
+	}
+
+	duration := p.BaseDuration
+	for i := int64(p.Threshold); i < attempts; i++ {
+		duration *= 2
+		if p.MaxDuration > 0 && duration >= p.MaxDuration {
+			duration = p.MaxDuration
+			break
+		}
+	}
+	return now.Add(duration)
+}
@@ -2,7 +2,10 @@ package service_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/service"
@@ -26,11 +29,36 @@ func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (
 	return args.Get(0).(database.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetUserByID(ctx context.Context, id string) (database.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) UpdateUserPassword(ctx context.Context, id, passwordHash string) error {
 	args := m.Called(ctx, id, passwordHash)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) IncrementLoginFailures(ctx context.Context, id string) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) LockUser(ctx context.Context, id string, attempts int64, lockedUntil time.Time) error {
+	args := m.Called(ctx, id, attempts, lockedUntil)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ResetLoginFailures(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func TestUserManager_CreateUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	svc := service.NewUserManager(mockRepo)
@@ -61,3 +89,168 @@ func TestUserManager_CreateUser(t *testing.T) {
 	assert.Equal(t, name, user.Name)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestUserManager_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	email := "test@example.com"
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	policy := service.LockoutPolicy{Threshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour}
+
+	t.Run("Success resets failures", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo).WithLockoutPolicy(policy)
+
+		mockRepo.On("GetUserByEmail", ctx, email).Return(database.User{
+			ID: "uuid", Email: email, PasswordHash: string(hash), FailedLoginAttempts: 2,
+		}, nil)
+		mockRepo.On("ResetLoginFailures", ctx, "uuid").Return(nil)
+
+		user, err := svc.Authenticate(ctx, email, "password123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "uuid", user.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong password below threshold", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo).WithLockoutPolicy(policy)
+
+		mockRepo.On("GetUserByEmail", ctx, email).Return(database.User{
+			ID: "uuid", Email: email, PasswordHash: string(hash), FailedLoginAttempts: 1,
+		}, nil)
+		mockRepo.On("IncrementLoginFailures", ctx, "uuid").Return(int64(2), nil)
+
+		_, err := svc.Authenticate(ctx, email, "wrong")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "LockUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Lockout grows with each failure", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo).WithLockoutPolicy(policy)
+
+		mockRepo.On("GetUserByEmail", ctx, email).Return(database.User{
+			ID: "uuid", Email: email, PasswordHash: string(hash), FailedLoginAttempts: 3,
+		}, nil)
+		mockRepo.On("IncrementLoginFailures", ctx, "uuid").Return(int64(4), nil)
+		mockRepo.On("LockUser", ctx, "uuid", int64(4), mock.MatchedBy(func(until time.Time) bool {
+			// Threshold reached one failure ago, so the base duration doubled
+			wait := time.Until(until)
+			return wait > time.Minute && wait <= 2*time.Minute
+		})).Return(nil)
+
+		_, err := svc.Authenticate(ctx, email, "wrong")
+
+		var locked *service.AccountLockedError
+		assert.True(t, errors.As(err, &locked))
+		assert.ErrorIs(t, err, service.ErrAccountLocked)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Locked account skips password check", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo).WithLockoutPolicy(policy)
+
+		until := time.Now().Add(10 * time.Minute)
+		mockRepo.On("GetUserByEmail", ctx, email).Return(database.User{
+			ID: "uuid", Email: email, PasswordHash: string(hash), FailedLoginAttempts: 5,
+			LockedUntil: sql.NullTime{Time: until, Valid: true},
+		}, nil)
+
+		_, err := svc.Authenticate(ctx, email, "password123")
+
+		assert.ErrorIs(t, err, service.ErrAccountLocked)
+		mockRepo.AssertNotCalled(t, "ResetLoginFailures", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserManager_UpdatePassword(t *testing.T) {
+	ctx := context.Background()
+	email := "test@example.com"
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	policy := service.LockoutPolicy{Threshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour}
+
+	t.Run("Wrong old password counts as a failed login", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo).WithLockoutPolicy(policy)
+
+		mockRepo.On("GetUserByEmail", ctx, email).Return(database.User{ID: "uuid", Email: email, PasswordHash: string(hash)}, nil)
+		mockRepo.On("IncrementLoginFailures", ctx, "uuid").Return(int64(1), nil)
+
+		err := svc.UpdatePassword(ctx, email, "wrong", "newpassword")
+
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Locked account is refused", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo).WithLockoutPolicy(policy)
+
+		mockRepo.On("GetUserByEmail", ctx, email).Return(database.User{
+			ID: "uuid", Email: email, PasswordHash: string(hash), FailedLoginAttempts: 3,
+			LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		}, nil)
+
+		err := svc.UpdatePassword(ctx, email, "password123", "newpassword")
+
+		assert.ErrorIs(t, err, service.ErrAccountLocked)
+		mockRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Right old password updates it", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo).WithLockoutPolicy(policy)
+
+		mockRepo.On("GetUserByEmail", ctx, email).Return(database.User{ID: "uuid", Email: email, PasswordHash: string(hash)}, nil)
+		mockRepo.On("UpdateUserPassword", ctx, "uuid", mock.AnythingOfType("string")).Return(nil)
+
+		assert.NoError(t, svc.UpdatePassword(ctx, email, "password123", "newpassword"))
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserManager_UpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	current := database.User{ID: "uuid", Name: "Tester", Timezone: "UTC", Locale: "en-US", WeekStartDay: 1}
+
+	t.Run("Partial update keeps other fields", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo)
+
+		tz := "America/Sao_Paulo"
+		goal := 0
+		mockRepo.On("GetUserByID", ctx, "uuid").Return(current, nil)
+		mockRepo.On("UpdateUserProfile", ctx, database.UpdateUserProfileParams{
+			Name:             "Tester",
+			Timezone:         tz,
+			Locale:           "en-US",
+			DailyGoalMinutes: 0,
+			WeekStartDay:     1,
+			ID:               "uuid",
+		}).Return(nil)
+
+		user, err := svc.UpdateProfile(ctx, "uuid", service.ProfileUpdate{Timezone: &tz, DailyGoalMinutes: &goal})
+
+		assert.NoError(t, err)
+		assert.Equal(t, tz, user.Timezone)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown timezone", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		svc := service.NewUserManager(mockRepo)
+
+		tz := "Mars/Olympus"
+		mockRepo.On("GetUserByID", ctx, "uuid").Return(current, nil)
+
+		_, err := svc.UpdateProfile(ctx, "uuid", service.ProfileUpdate{Timezone: &tz})
+
+		assert.ErrorIs(t, err, service.ErrInvalidProfile)
+		mockRepo.AssertNotCalled(t, "UpdateUserProfile", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	defaultWeakPointMinQuestions = 10
+	defaultWeakPointHalfLifeDays = 30
+
+	// wilsonZ is the normal quantile for a 95% confidence interval
+	wilsonZ = 1.96
+)
+
+// WeakPointQuery tunes the weak point ranking
+type WeakPointQuery struct {
+	SubjectID    string // Optional filter
+	MinQuestions int64  // Topics with fewer questions are left out; defaults to 10
+	HalfLifeDays int    // Age at which a log counts half; defaults to 30
+	Limit        int    // 0 returns every ranked topic
+}
+
+// WeakPoint is a topic ranked by how confidently it is a weakness
+type WeakPoint struct {
+	TopicID     string
+	TopicName   string
+	SubjectID   string
+	SubjectName string
+	Questions   int64
+	Correct     int64
+	Accuracy    float64 // Raw percentage
+
+	// Recency-weighted figures the score is computed from
+	WeightedQuestions float64
+	WeightedAccuracy  float64 // Percentage
+
+	// Score is the Wilson lower bound of the weighted miss rate, in percent:
+	// the miss rate the topic has at least, with 95% confidence. A topic with
+	// a handful of answers needs a much worse record to outrank one with many.
+	Score         float64
+	LastPracticed time.Time
+}
+
+// RankWeakPoints ranks the user's topics across subjects, weakest first.
+// Each exercise log is weighted by 0.5^(age / half-life), so recent practice
+// dominates, and topics below the minimum sample are left out.
+func (s *AnalyticsManager) RankWeakPoints(ctx context.Context, userID string, query WeakPointQuery) ([]WeakPoint, error) {
+	minQuestions := query.MinQuestions
+	if minQuestions <= 0 {
+		minQuestions = defaultWeakPointMinQuestions
+	}
+	halfLife := query.HalfLifeDays
+	if halfLife <= 0 {
+		halfLife = defaultWeakPointHalfLifeDays
+	}
+
+	logs, err := s.repo.ListTopicExerciseLogs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type tally struct {
+		point           WeakPoint
+		weightedCorrect float64
+	}
+	now := time.Now()
+	var order []string
+	tallies := make(map[string]*tally)
+	for _, log := range logs {
+		if query.SubjectID != "" && log.SubjectID != query.SubjectID {
+			continue
+		}
+		created, err := parseTimestamp(log.CreatedAt)
+		if err != nil {
+			slog.Warn("Skipping exercise log with unreadable date", "exercise_log_id", log.ID, "error", err)
+			continue
+		}
+
+		t, ok := tallies[log.TopicID]
+		if !ok {
+			t = &tally{point: WeakPoint{
+				TopicID:     log.TopicID,
+				TopicName:   log.TopicName,
+				SubjectID:   log.SubjectID,
+				SubjectName: log.SubjectName,
+			}}
+			tallies[log.TopicID] = t
+			order = append(order, log.TopicID)
+		}
+
+		weight := 1.0
+		if age := now.Sub(created).Hours() / 24; age > 0 {
+			weight = math.Pow(0.5, age/float64(halfLife))
+		}
+		t.point.Questions += log.QuestionsCount
+		t.point.Correct += log.CorrectCount
+		t.point.WeightedQuestions += weight * float64(log.QuestionsCount)
+		t.weightedCorrect += weight * float64(log.CorrectCount)
+		if created.After(t.point.LastPracticed) {
+			t.point.LastPracticed = created
+		}
+	}
+
+	points := []WeakPoint{}
+	for _, topicID := range order {
+		t := tallies[topicID]
+		point := t.point
+		if point.Questions < minQuestions || point.WeightedQuestions == 0 {
+			continue
+		}
+
+		missRate := 1 - t.weightedCorrect/point.WeightedQuestions
+		point.Accuracy = roundPercentage(float64(point.Correct) / float64(point.Questions))
+		point.WeightedAccuracy = roundPercentage(1 - missRate)
+		point.Score = roundPercentage(wilsonLowerBound(missRate, point.WeightedQuestions, wilsonZ))
+		point.WeightedQuestions = math.Round(point.WeightedQuestions*100) / 100
+		points = append(points, point)
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Score > points[j].Score
+	})
+	if query.Limit > 0 && len(points) > query.Limit {
+		points = points[:query.Limit]
+	}
+	return points, nil
+}
+
+// wilsonLowerBound is the lower end of the Wilson score interval for a
+// proportion p observed over n trials; n need not be whole.
+func wilsonLowerBound(p, n, z float64) float64 {
+	z2 := z * z
+	centre := p + z2/(2*n)
+	margin := z * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return math.Max(0, (centre-margin)/(1+z2/n))
+}
+
+// roundPercentage turns a ratio into a percentage with two decimals
+func roundPercentage(ratio float64) float64 {
+	return math.Round(ratio*100*100) / 100
+}
@@ -0,0 +1,105 @@
+-- name: CreateAccountDeletion :one
+INSERT INTO account_deletions (id, user_id, receipt_hash, purge_after)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetAccountDeletion :one
+SELECT * FROM account_deletions
+WHERE id = ?;
+
+-- name: GetPendingAccountDeletion :one
+SELECT * FROM account_deletions
+WHERE user_id = ? AND purged_at IS NULL;
+
+-- name: CancelAccountDeletion :execrows
+DELETE FROM account_deletions
+WHERE user_id = ? AND purged_at IS NULL;
+
+-- name: ListDueAccountDeletions :many
+SELECT * FROM account_deletions
+WHERE purged_at IS NULL AND datetime(purge_after) <= datetime(CAST(sqlc.arg(now) AS TEXT))
+ORDER BY purge_after;
+
+-- name: CompleteAccountDeletion :exec
+UPDATE account_deletions
+SET purged_at = datetime('now'), deleted_rows = ?, verified = ?
+WHERE id = ?;
+
+-- name: CountAccountRows :one
+-- Rows the purge of a user deletes, per table. Study cycles count when all their items are the user's.
+SELECT
+    (SELECT COUNT(*) FROM exercise_logs el JOIN subjects s ON s.id = el.subject_id WHERE s.user_id = sqlc.arg(user_id)) AS exercise_logs,
+    (SELECT COUNT(*) FROM session_pauses sp JOIN study_sessions ss ON ss.id = sp.session_id JOIN subjects s ON s.id = ss.subject_id WHERE s.user_id = sqlc.arg(user_id)) AS session_pauses,
+    (SELECT COUNT(*) FROM study_sessions ss JOIN subjects s ON s.id = ss.subject_id WHERE s.user_id = sqlc.arg(user_id)) AS study_sessions,
+    (SELECT COUNT(*) FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id WHERE s.user_id = sqlc.arg(user_id)) AS cycle_items,
+    (SELECT COUNT(*) FROM study_cycles sc
+     WHERE EXISTS (SELECT 1 FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id WHERE ci.cycle_id = sc.id AND s.user_id = sqlc.arg(user_id))
+       AND NOT EXISTS (SELECT 1 FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id WHERE ci.cycle_id = sc.id AND s.user_id <> sqlc.arg(user_id))) AS study_cycles,
+    (SELECT COUNT(*) FROM topics t JOIN subjects s ON s.id = t.subject_id WHERE s.user_id = sqlc.arg(user_id)) AS topics,
+    (SELECT COUNT(*) FROM goals WHERE user_id = sqlc.arg(user_id)) AS goals,
+    (SELECT COUNT(*) FROM subjects WHERE user_id = sqlc.arg(user_id)) AS subjects,
+    (SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = sqlc.arg(user_id)) AS personal_access_tokens,
+    (SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = sqlc.arg(user_id)) AS password_reset_tokens,
+    (SELECT COUNT(*) FROM recommendation_weights WHERE user_id = sqlc.arg(user_id)) AS recommendation_weights,
+    (SELECT COUNT(*) FROM idempotency_keys WHERE user_id = sqlc.arg(user_id)) AS idempotency_keys,
+    (SELECT COUNT(*) FROM users WHERE id = sqlc.arg(user_id)) AS users;
+
+-- name: ListPurgeableStudyCycles :many
+-- Study cycles whose items are all on the user's subjects, so they go with the account
+SELECT sc.id FROM study_cycles sc
+WHERE EXISTS (SELECT 1 FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id WHERE ci.cycle_id = sc.id AND s.user_id = sqlc.arg(user_id))
+  AND NOT EXISTS (SELECT 1 FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id WHERE ci.cycle_id = sc.id AND s.user_id <> sqlc.arg(user_id));
+
+-- name: PurgeUserExerciseLogs :execrows
+DELETE FROM exercise_logs
+WHERE subject_id IN (SELECT id FROM subjects WHERE user_id = ?);
+
+-- name: PurgeUserSessionPauses :execrows
+DELETE FROM session_pauses
+WHERE session_id IN (
+    SELECT ss.id FROM study_sessions ss JOIN subjects s ON s.id = ss.subject_id WHERE s.user_id = ?
+);
+
+-- name: PurgeUserStudySessions :execrows
+DELETE FROM study_sessions
+WHERE subject_id IN (SELECT id FROM subjects WHERE user_id = ?);
+
+-- name: PurgeUserCycleItems :execrows
+DELETE FROM cycle_items
+WHERE subject_id IN (SELECT id FROM subjects WHERE user_id = ?);
+
+-- name: PurgeStudyCycle :execrows
+DELETE FROM study_cycles
+WHERE id = ?;
+
+-- name: PurgeUserTopics :execrows
+DELETE FROM topics
+WHERE subject_id IN (SELECT id FROM subjects WHERE user_id = ?);
+
+-- name: PurgeUserGoals :execrows
+DELETE FROM goals
+WHERE user_id = ?;
+
+-- name: PurgeUserSubjects :execrows
+DELETE FROM subjects
+WHERE user_id = ?;
+
+-- name: PurgeUserPersonalAccessTokens :execrows
+DELETE FROM personal_access_tokens
+WHERE user_id = ?;
+
+-- name: PurgeUserPasswordResetTokens :execrows
+DELETE FROM password_reset_tokens
+WHERE user_id = ?;
+
+-- name: PurgeUserRecommendationWeights :execrows
+DELETE FROM recommendation_weights
+WHERE user_id = ?;
+
+-- name: PurgeUserIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE user_id = ?;
+
+-- name: PurgeUser :execrows
+DELETE FROM users
+WHERE id = ?;
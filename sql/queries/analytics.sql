@@ -1,23 +1,46 @@
 -- Analytics Queries for Study App
 
--- name: GetTimeReportBySubject :many
-SELECT 
-    s.id AS subject_id,
+-- name: ListFinishedSessionsInRange :many
+-- Finished sessions overlapping [range_from, range_to). Bounds are UTC 'YYYY-MM-DD HH:MM:SS';
+-- day bucketing and splitting happen in the service, in the user's timezone.
+SELECT
+    ss.id,
+    ss.subject_id,
     s.name AS subject_name,
     s.color_hex,
-    COUNT(ss.id) AS sessions_count,
-    ROUND(COALESCE(SUM(ss.net_duration_seconds), 0) / 3600.0, 2) AS total_hours_net
-FROM subjects s
-LEFT JOIN study_sessions ss ON s.id = ss.subject_id 
-    AND ss.finished_at IS NOT NULL
-    AND (sqlc.arg(start_date_from) = '' OR ss.started_at >= sqlc.arg(start_date_from))
-    AND (sqlc.arg(start_date_to) = '' OR ss.started_at <= sqlc.arg(start_date_to))
-WHERE s.deleted_at IS NULL
-GROUP BY s.id, s.name, s.color_hex
-HAVING sessions_count > 0
-ORDER BY total_hours_net DESC;
+    ss.started_at,
+    ss.finished_at,
+    COALESCE(ss.gross_duration_seconds, 0) AS gross_duration_seconds,
+    COALESCE(ss.net_duration_seconds, 0) AS net_duration_seconds
+FROM study_sessions ss
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = sqlc.arg(user_id)
+  AND s.deleted_at IS NULL
+  AND ss.finished_at IS NOT NULL
+  AND datetime(ss.finished_at) > datetime(CAST(sqlc.arg(range_from) AS TEXT))
+  AND datetime(ss.started_at) < datetime(CAST(sqlc.arg(range_to) AS TEXT))
+ORDER BY datetime(ss.started_at);
+
+-- name: ListSessionPausesInRange :many
+-- Ended pauses of the sessions ListFinishedSessionsInRange returns for the same bounds
+SELECT
+    sp.id,
+    sp.session_id,
+    sp.started_at,
+    sp.ended_at
+FROM session_pauses sp
+JOIN study_sessions ss ON ss.id = sp.session_id
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = sqlc.arg(user_id)
+  AND s.deleted_at IS NULL
+  AND ss.finished_at IS NOT NULL
+  AND sp.ended_at IS NOT NULL
+  AND datetime(ss.finished_at) > datetime(CAST(sqlc.arg(range_from) AS TEXT))
+  AND datetime(ss.started_at) < datetime(CAST(sqlc.arg(range_to) AS TEXT))
+ORDER BY sp.session_id, datetime(sp.started_at);
 
 -- name: GetAccuracyBySubject :many
+-- Exercise logs created in [range_from, range_to), bounds as in ListFinishedSessionsInRange
 SELECT 
     s.id AS subject_id,
     s.name AS subject_name,
@@ -30,7 +53,10 @@ SELECT
     ) AS accuracy_percentage
 FROM subjects s
 LEFT JOIN exercise_logs el ON s.id = el.subject_id
-WHERE s.deleted_at IS NULL
+    AND datetime(el.created_at) >= datetime(CAST(sqlc.arg(range_from) AS TEXT))
+    AND datetime(el.created_at) < datetime(CAST(sqlc.arg(range_to) AS TEXT))
+WHERE s.user_id = sqlc.arg(user_id)
+  AND s.deleted_at IS NULL
 GROUP BY s.id, s.name, s.color_hex
 HAVING total_questions > 0
 ORDER BY accuracy_percentage ASC;
@@ -46,20 +72,83 @@ SELECT
         2
     ) AS accuracy_percentage
 FROM topics t
+JOIN subjects s ON s.id = t.subject_id
 LEFT JOIN exercise_logs el ON t.id = el.topic_id
 WHERE t.subject_id = ?
+  AND s.user_id = ?
   AND t.deleted_at IS NULL
 GROUP BY t.id, t.name
 HAVING total_questions > 0
 ORDER BY accuracy_percentage ASC;
 
--- name: GetActivityHeatmap :many
-SELECT 
-    strftime('%Y-%m-%d', started_at) AS study_date,
-    COUNT(DISTINCT id) AS sessions_count,
-    COALESCE(SUM(net_duration_seconds), 0) AS total_seconds
-FROM study_sessions
-WHERE finished_at IS NOT NULL
-  AND datetime(started_at) >= datetime('now', '-' || CAST(sqlc.arg(days_count) AS TEXT) || ' days')
-GROUP BY study_date
-ORDER BY study_date DESC;
+-- name: ListExerciseLogsInRange :many
+-- Exercise logs created in [range_from, range_to), bounds as in ListFinishedSessionsInRange
+SELECT
+    el.id,
+    el.subject_id,
+    el.topic_id,
+    el.questions_count,
+    el.correct_count,
+    el.created_at
+FROM exercise_logs el
+JOIN subjects s ON s.id = el.subject_id
+WHERE s.user_id = sqlc.arg(user_id)
+  AND s.deleted_at IS NULL
+  AND datetime(el.created_at) >= datetime(CAST(sqlc.arg(range_from) AS TEXT))
+  AND datetime(el.created_at) < datetime(CAST(sqlc.arg(range_to) AS TEXT))
+ORDER BY datetime(el.created_at);
+
+-- name: ListTopicExerciseLogs :many
+-- Every exercise log tagged with a live topic, for ranking weak points across subjects
+SELECT
+    el.id,
+    t.id AS topic_id,
+    t.name AS topic_name,
+    s.id AS subject_id,
+    s.name AS subject_name,
+    el.questions_count,
+    el.correct_count,
+    el.created_at
+FROM exercise_logs el
+JOIN topics t ON t.id = el.topic_id
+JOIN subjects s ON s.id = t.subject_id
+WHERE s.user_id = ?
+  AND s.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+ORDER BY datetime(el.created_at);
+
+-- name: ListTopicProgress :many
+-- Every live topic with its first and last practice (empty if never practiced), for syllabus coverage
+SELECT
+    t.id AS topic_id,
+    t.subject_id,
+    CAST(COALESCE(MIN(datetime(el.created_at)), '') AS TEXT) AS first_practiced_at,
+    CAST(COALESCE(MAX(datetime(el.created_at)), '') AS TEXT) AS last_practiced_at
+FROM topics t
+JOIN subjects s ON s.id = t.subject_id
+LEFT JOIN exercise_logs el ON el.topic_id = t.id
+WHERE s.user_id = ?
+  AND s.deleted_at IS NULL
+  AND t.deleted_at IS NULL
+GROUP BY t.id, t.subject_id
+ORDER BY t.subject_id, t.id;
+
+-- name: ListActiveCyclePlan :many
+-- Planned minutes and slots per subject in the active cycle(s), most recently updated cycle first.
+-- Cycles aren't owned by users, so only the user's subjects are considered.
+SELECT
+    sc.id AS cycle_id,
+    sc.name AS cycle_name,
+    ci.subject_id,
+    COUNT(*) AS items_count,
+    COUNT(ci.planned_duration_minutes) AS timed_items_count,
+    CAST(COALESCE(SUM(ci.planned_duration_minutes), 0) AS INTEGER) AS planned_minutes
+FROM cycle_items ci
+JOIN study_cycles sc ON sc.id = ci.cycle_id
+JOIN subjects s ON s.id = ci.subject_id
+WHERE sc.is_active = 1
+  AND sc.deleted_at IS NULL
+  AND s.user_id = ?
+  AND s.deleted_at IS NULL
+GROUP BY sc.id, sc.name, sc.updated_at, ci.subject_id
+ORDER BY sc.updated_at DESC, sc.id;
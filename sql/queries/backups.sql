@@ -0,0 +1,87 @@
+-- Account backups: everything a user owns, soft-deleted rows included, and inserts that keep
+-- the archived timestamps. Study cycles have no owner column, so a user's cycles are the ones
+-- holding items on their subjects.
+
+-- name: ListBackupSubjects :many
+SELECT * FROM subjects
+WHERE user_id = ?
+ORDER BY created_at, id;
+
+-- name: ListBackupTopics :many
+SELECT t.id, t.subject_id, t.name, t.created_at, t.updated_at, t.deleted_at, t.version
+FROM topics t
+JOIN subjects s ON s.id = t.subject_id
+WHERE s.user_id = ?
+ORDER BY t.created_at, t.id;
+
+-- name: ListBackupStudyCycles :many
+SELECT sc.id, sc.name, sc.description, sc.is_active, sc.created_at, sc.updated_at, sc.deleted_at, sc.version
+FROM study_cycles sc
+WHERE sc.id IN (
+    SELECT ci.cycle_id
+    FROM cycle_items ci
+    JOIN subjects s ON s.id = ci.subject_id
+    WHERE s.user_id = ?
+)
+ORDER BY sc.created_at, sc.id;
+
+-- name: ListBackupCycleItems :many
+SELECT ci.id, ci.cycle_id, ci.subject_id, ci.order_index, ci.planned_duration_minutes, ci.created_at, ci.updated_at, ci.version
+FROM cycle_items ci
+JOIN subjects s ON s.id = ci.subject_id
+WHERE s.user_id = ?
+ORDER BY ci.cycle_id, ci.order_index, ci.id;
+
+-- name: ListBackupStudySessions :many
+SELECT ss.id, ss.subject_id, ss.cycle_item_id, ss.started_at, ss.finished_at, ss.gross_duration_seconds,
+       ss.net_duration_seconds, ss.notes, ss.created_at, ss.updated_at
+FROM study_sessions ss
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = ?
+ORDER BY datetime(ss.started_at), ss.id;
+
+-- name: ListBackupSessionPauses :many
+SELECT sp.id, sp.session_id, sp.started_at, sp.ended_at
+FROM session_pauses sp
+JOIN study_sessions ss ON ss.id = sp.session_id
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = ?
+ORDER BY datetime(sp.started_at), sp.id;
+
+-- name: ListBackupExerciseLogs :many
+SELECT el.id, el.session_id, el.subject_id, el.topic_id, el.questions_count, el.correct_count, el.created_at
+FROM exercise_logs el
+JOIN subjects s ON s.id = el.subject_id
+WHERE s.user_id = ?
+ORDER BY datetime(el.created_at), el.id;
+
+-- name: RestoreSubject :exec
+INSERT INTO subjects (id, user_id, name, color_hex, exam_weight, created_at, updated_at, deleted_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: RestoreTopic :exec
+INSERT INTO topics (id, subject_id, name, created_at, updated_at, deleted_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: RestoreStudyCycle :exec
+INSERT INTO study_cycles (id, name, description, is_active, created_at, updated_at, deleted_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: RestoreCycleItem :exec
+INSERT INTO cycle_items (id, cycle_id, subject_id, order_index, planned_duration_minutes, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: RestoreStudySession :exec
+INSERT INTO study_sessions (
+    id, subject_id, cycle_item_id, started_at, finished_at, gross_duration_seconds,
+    net_duration_seconds, notes, created_at, updated_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: RestoreSessionPause :exec
+INSERT INTO session_pauses (id, session_id, started_at, ended_at)
+VALUES (?, ?, ?, ?);
+
+-- name: RestoreExerciseLog :exec
+INSERT INTO exercise_logs (id, session_id, subject_id, topic_id, questions_count, correct_count, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
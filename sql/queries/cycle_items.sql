@@ -12,10 +12,25 @@ ORDER BY order_index;
 SELECT * FROM cycle_items
 WHERE id = ?;
 
--- name: UpdateCycleItem :exec
+-- name: UpdateCycleItem :one
+-- Returns no row when if_version is set and no longer matches
 UPDATE cycle_items
-SET subject_id = ?, order_index = ?, planned_duration_minutes = ?, updated_at = datetime('now')
-WHERE id = ?;
+SET subject_id = sqlc.arg(subject_id), order_index = sqlc.arg(order_index), planned_duration_minutes = sqlc.arg(planned_duration_minutes),
+    updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
+
+-- name: PatchCycleItem :one
+-- planned_duration_minutes can be set to 0 or to NULL, see set_planned_duration_minutes
+UPDATE cycle_items
+SET subject_id = CASE WHEN sqlc.arg(set_subject_id) THEN sqlc.arg(subject_id) ELSE subject_id END,
+    order_index = CASE WHEN sqlc.arg(set_order_index) THEN sqlc.arg(order_index) ELSE order_index END,
+    planned_duration_minutes = CASE WHEN sqlc.arg(set_planned_duration_minutes) THEN sqlc.narg(planned_duration_minutes) ELSE planned_duration_minutes END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id)
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
 
 -- name: DeleteCycleItem :exec
 DELETE FROM cycle_items
@@ -0,0 +1,52 @@
+-- Paged reads for CSV and XLSX exports. Pages are keyed on (datetime(started_at/created_at), id):
+-- pass the sort_key and id of the previous page's last row, or empty strings for the first page.
+
+-- name: ListSessionsPage :many
+-- Sessions started in [range_from, range_to), oldest first, bounds as in ListFinishedSessionsInRange
+SELECT
+    ss.id,
+    ss.subject_id,
+    s.name AS subject_name,
+    ss.cycle_item_id,
+    ss.started_at,
+    ss.finished_at,
+    COALESCE(ss.gross_duration_seconds, 0) AS gross_duration_seconds,
+    COALESCE(ss.net_duration_seconds, 0) AS net_duration_seconds,
+    CAST((
+        SELECT COUNT(*) FROM session_pauses sp WHERE sp.session_id = ss.id
+    ) AS INTEGER) AS pauses_count,
+    ss.notes,
+    CAST(datetime(ss.started_at) AS TEXT) AS sort_key
+FROM study_sessions ss
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = sqlc.arg(user_id)
+  AND s.deleted_at IS NULL
+  AND datetime(ss.started_at) >= datetime(CAST(sqlc.arg(range_from) AS TEXT))
+  AND datetime(ss.started_at) < datetime(CAST(sqlc.arg(range_to) AS TEXT))
+  AND (datetime(ss.started_at), ss.id) > (CAST(sqlc.arg(after_sort_key) AS TEXT), CAST(sqlc.arg(after_id) AS TEXT))
+ORDER BY datetime(ss.started_at), ss.id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListExerciseLogsPage :many
+-- Exercise logs created in [range_from, range_to), oldest first, bounds as in ListFinishedSessionsInRange
+SELECT
+    el.id,
+    el.session_id,
+    el.subject_id,
+    s.name AS subject_name,
+    el.topic_id,
+    t.name AS topic_name,
+    el.questions_count,
+    el.correct_count,
+    el.created_at,
+    CAST(datetime(el.created_at) AS TEXT) AS sort_key
+FROM exercise_logs el
+JOIN subjects s ON s.id = el.subject_id
+LEFT JOIN topics t ON t.id = el.topic_id
+WHERE s.user_id = sqlc.arg(user_id)
+  AND s.deleted_at IS NULL
+  AND datetime(el.created_at) >= datetime(CAST(sqlc.arg(range_from) AS TEXT))
+  AND datetime(el.created_at) < datetime(CAST(sqlc.arg(range_to) AS TEXT))
+  AND (datetime(el.created_at), el.id) > (CAST(sqlc.arg(after_sort_key) AS TEXT), CAST(sqlc.arg(after_id) AS TEXT))
+ORDER BY datetime(el.created_at), el.id
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,23 @@
+-- name: CreateGoal :one
+INSERT INTO goals (id, user_id, subject_id, metric, period, target, rest_days)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListGoals :many
+SELECT * FROM goals
+WHERE user_id = ? AND deleted_at IS NULL
+ORDER BY created_at;
+
+-- name: GetGoal :one
+SELECT * FROM goals
+WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
+
+-- name: UpdateGoal :execrows
+UPDATE goals
+SET subject_id = ?, metric = ?, period = ?, target = ?, rest_days = ?, updated_at = datetime('now')
+WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
+
+-- name: DeleteGoal :execrows
+UPDATE goals
+SET deleted_at = datetime('now')
+WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
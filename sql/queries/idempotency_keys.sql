@@ -0,0 +1,27 @@
+-- name: ClaimIdempotencyKey :execrows
+-- Claims the key for a request; no row is inserted when it's already taken
+INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, expires_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, idempotency_key) DO NOTHING;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = ? AND idempotency_key = ?;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = ?, content_type = ?, response_headers = ?, response_body = ?
+WHERE user_id = ? AND idempotency_key = ?;
+
+-- name: ReleaseIdempotencyKey :exec
+-- Frees the key of a request that failed so a retry runs it again
+DELETE FROM idempotency_keys
+WHERE user_id = ? AND idempotency_key = ?;
+
+-- name: ReleaseExpiredIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = ? AND idempotency_key = ? AND expires_at <= ?;
+
+-- name: PurgeExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at <= ?;
@@ -0,0 +1,30 @@
+-- name: MoveSubjectTopics :execrows
+-- Merging a subject moves every row that points at it, trashed topics included
+UPDATE topics
+SET subject_id = sqlc.arg(target_id), updated_at = datetime('now'), version = version + 1
+WHERE subject_id = sqlc.arg(source_id);
+
+-- name: MoveSubjectCycleItems :execrows
+UPDATE cycle_items
+SET subject_id = sqlc.arg(target_id), updated_at = datetime('now'), version = version + 1
+WHERE subject_id = sqlc.arg(source_id);
+
+-- name: MoveSubjectStudySessions :execrows
+UPDATE study_sessions
+SET subject_id = sqlc.arg(target_id), updated_at = datetime('now')
+WHERE subject_id = sqlc.arg(source_id);
+
+-- name: MoveSubjectExerciseLogs :execrows
+UPDATE exercise_logs
+SET subject_id = sqlc.arg(target_id)
+WHERE subject_id = sqlc.arg(source_id);
+
+-- name: MoveSubjectGoals :execrows
+UPDATE goals
+SET subject_id = sqlc.arg(target_id), updated_at = datetime('now')
+WHERE subject_id = sqlc.arg(source_id);
+
+-- name: MoveTopicExerciseLogs :execrows
+UPDATE exercise_logs
+SET topic_id = sqlc.arg(target_id)
+WHERE topic_id = sqlc.arg(source_id);
@@ -0,0 +1,32 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (id, user_id, name, token_hash, token_prefix, scopes, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListPersonalAccessTokens :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = ? AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT
+    pat.id,
+    pat.user_id,
+    pat.scopes,
+    pat.last_used_at,
+    u.email
+FROM personal_access_tokens pat
+JOIN users u ON pat.user_id = u.id
+WHERE pat.token_hash = ?
+  AND pat.revoked_at IS NULL
+  AND (pat.expires_at IS NULL OR pat.expires_at > datetime('now'));
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens
+SET last_used_at = datetime('now')
+WHERE id = ?;
+
+-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET revoked_at = datetime('now')
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
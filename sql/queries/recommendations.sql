@@ -0,0 +1,39 @@
+-- name: GetRecommendationWeights :one
+SELECT * FROM recommendation_weights
+WHERE user_id = ?;
+
+-- name: UpsertRecommendationWeights :one
+INSERT INTO recommendation_weights (user_id, cycle_weight, review_weight, weakness_weight)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET cycle_weight = excluded.cycle_weight,
+    review_weight = excluded.review_weight,
+    weakness_weight = excluded.weakness_weight,
+    updated_at = datetime('now')
+RETURNING *;
+
+-- name: ListActiveCycleItems :many
+-- Items of the active cycle(s) on the user's subjects, most recently updated cycle first,
+-- in cycle order, with the start of the latest session recorded against each item (empty if none)
+SELECT
+    ci.id,
+    ci.cycle_id,
+    sc.name AS cycle_name,
+    ci.subject_id,
+    s.name AS subject_name,
+    s.color_hex,
+    ci.order_index,
+    ci.planned_duration_minutes,
+    CAST(COALESCE((
+        SELECT MAX(datetime(ss.started_at))
+        FROM study_sessions ss
+        WHERE ss.cycle_item_id = ci.id
+    ), '') AS TEXT) AS last_studied_at
+FROM cycle_items ci
+JOIN study_cycles sc ON sc.id = ci.cycle_id
+JOIN subjects s ON s.id = ci.subject_id
+WHERE sc.is_active = 1
+  AND sc.deleted_at IS NULL
+  AND s.user_id = ?
+  AND s.deleted_at IS NULL
+ORDER BY sc.updated_at DESC, sc.id, ci.order_index;
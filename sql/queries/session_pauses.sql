@@ -8,6 +8,12 @@ UPDATE session_pauses
 SET ended_at = ?
 WHERE id = ?;
 
+-- name: EndOpenSessionPauses :exec
+-- Ends the pauses of a session still running when it's stopped
+UPDATE session_pauses
+SET ended_at = ?
+WHERE session_id = ? AND ended_at IS NULL;
+
 -- name: GetSessionPause :one
 SELECT * FROM session_pauses
 WHERE id = ?;
@@ -12,14 +12,29 @@ LIMIT 1;
 SELECT * FROM study_cycles
 WHERE id = ? AND deleted_at IS NULL;
 
--- name: UpdateStudyCycle :exec
+-- name: UpdateStudyCycle :one
+-- if_version, when set, must match the current version for the update to apply
 UPDATE study_cycles
-SET name = ?, description = ?, is_active = ?, updated_at = datetime('now')
-WHERE id = ? AND deleted_at IS NULL;
+SET name = sqlc.arg(name), description = sqlc.arg(description), is_active = sqlc.arg(is_active),
+    updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
+
+-- name: PatchStudyCycle :one
+-- Columns without their set_ flag keep their value
+UPDATE study_cycles
+SET name = CASE WHEN sqlc.arg(set_name) THEN sqlc.arg(name) ELSE name END,
+    description = CASE WHEN sqlc.arg(set_description) THEN sqlc.narg(description) ELSE description END,
+    is_active = CASE WHEN sqlc.arg(set_is_active) THEN sqlc.arg(is_active) ELSE is_active END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
 
 -- name: DeleteStudyCycle :exec
 UPDATE study_cycles
-SET deleted_at = datetime('now')
+SET deleted_at = datetime('now'), version = version + 1
 WHERE id = ?;
 
 -- name: GetActiveCycleWithItems :many
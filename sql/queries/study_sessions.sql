@@ -29,3 +29,31 @@ JOIN subjects s ON ss.subject_id = s.id
 WHERE ss.finished_at IS NULL
 ORDER BY ss.started_at DESC
 LIMIT 1;
+
+-- name: GetUserOpenSession :one
+-- The user's latest unfinished session, with the seconds of its ended pauses
+-- and the start of the pause in progress (empty if none)
+SELECT
+    ss.id,
+    ss.subject_id,
+    ss.cycle_item_id,
+    ss.started_at,
+    s.name AS subject_name,
+    s.color_hex,
+    CAST(COALESCE((
+        SELECT SUM(ROUND((julianday(sp.ended_at) - julianday(sp.started_at)) * 86400))
+        FROM session_pauses sp
+        WHERE sp.session_id = ss.id AND sp.ended_at IS NOT NULL
+    ), 0) AS INTEGER) AS paused_seconds,
+    CAST(COALESCE((
+        SELECT MAX(sp.started_at)
+        FROM session_pauses sp
+        WHERE sp.session_id = ss.id AND sp.ended_at IS NULL
+    ), '') AS TEXT) AS open_pause_started_at
+FROM study_sessions ss
+JOIN subjects s ON s.id = ss.subject_id
+WHERE s.user_id = ?
+  AND s.deleted_at IS NULL
+  AND ss.finished_at IS NULL
+ORDER BY datetime(ss.started_at) DESC
+LIMIT 1;
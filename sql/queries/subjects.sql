@@ -1,6 +1,6 @@
 -- name: CreateSubject :one
-INSERT INTO subjects (id, user_id, name, color_hex)
-VALUES (?, ?, ?, ?)
+INSERT INTO subjects (id, user_id, name, color_hex, exam_weight)
+VALUES (?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: ListSubjects :many
@@ -12,12 +12,28 @@ ORDER BY name;
 SELECT * FROM subjects
 WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
 
--- name: UpdateSubject :exec
+-- name: UpdateSubject :one
+-- With if_version set the update only applies to that version; no row comes back otherwise
 UPDATE subjects
-SET name = ?, color_hex = ?, updated_at = datetime('now')
-WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
+SET name = sqlc.arg(name), color_hex = sqlc.arg(color_hex), exam_weight = sqlc.arg(exam_weight),
+    updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
+
+-- name: PatchSubject :one
+-- Only columns whose set_ flag is true change, so a merge patch can leave a
+-- field alone, null it or set it to a zero value
+UPDATE subjects
+SET name = CASE WHEN sqlc.arg(set_name) THEN sqlc.arg(name) ELSE name END,
+    color_hex = CASE WHEN sqlc.arg(set_color_hex) THEN sqlc.narg(color_hex) ELSE color_hex END,
+    exam_weight = CASE WHEN sqlc.arg(set_exam_weight) THEN sqlc.narg(exam_weight) ELSE exam_weight END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
 
 -- name: DeleteSubject :exec
 UPDATE subjects
-SET deleted_at = datetime('now')
+SET deleted_at = datetime('now'), version = version + 1
 WHERE id = ? AND user_id = ?;
@@ -0,0 +1,181 @@
+-- name: ListSyncChanges :many
+-- The last change of every row the user's feed logged after the cursor, in commit order. Only
+-- upserts carry the row's current state; tombstones have none.
+SELECT
+    c.seq,
+    c.entity,
+    c.entity_id,
+    c.op,
+    c.changed_at,
+    CAST(CASE WHEN c.op = 'upsert' THEN (
+        SELECT e.data FROM sync_entities e WHERE e.entity = c.entity AND e.entity_id = c.entity_id
+    ) END AS TEXT) AS data
+FROM sync_changes c
+WHERE c.seq IN (
+    SELECT MAX(seq) FROM sync_changes
+    WHERE user_id = sqlc.arg(user_id) AND seq > sqlc.arg(since)
+    GROUP BY entity, entity_id
+)
+ORDER BY c.seq
+LIMIT sqlc.arg(limit);
+
+-- name: GetLatestSyncChange :one
+SELECT
+    c.seq,
+    c.entity,
+    c.entity_id,
+    c.op,
+    c.changed_at,
+    CAST(CASE WHEN c.op = 'upsert' THEN (
+        SELECT e.data FROM sync_entities e WHERE e.entity = c.entity AND e.entity_id = c.entity_id
+    ) END AS TEXT) AS data
+FROM sync_changes c
+WHERE c.user_id = sqlc.arg(user_id) AND c.entity = sqlc.arg(entity) AND c.entity_id = sqlc.arg(entity_id)
+ORDER BY c.seq DESC
+LIMIT 1;
+
+-- name: PurgeUserSyncChanges :exec
+DELETE FROM sync_changes
+WHERE user_id = ?;
+
+-- name: CompactSyncChanges :execrows
+-- Deletes the changes logged by the cutoff that a later change of the same row supersedes. The feed
+-- only serves a row's last change, so no page after any cursor loses anything.
+DELETE FROM sync_changes
+WHERE datetime(changed_at) <= datetime(CAST(sqlc.arg(cutoff) AS TEXT))
+  AND EXISTS (
+    SELECT 1 FROM sync_changes later
+    WHERE later.entity = sync_changes.entity AND later.entity_id = sync_changes.entity_id
+      AND later.user_id = sync_changes.user_id AND later.seq > sync_changes.seq
+  );
+
+-- name: UpsertSyncSubject :execrows
+-- Inserts under the client's ID, or updates the user's live subject; writes nothing otherwise
+INSERT INTO subjects (id, user_id, name, color_hex, exam_weight)
+VALUES (sqlc.arg(id), sqlc.arg(user_id), sqlc.arg(name), sqlc.narg(color_hex), sqlc.narg(exam_weight))
+ON CONFLICT (id) DO UPDATE
+SET name = excluded.name, color_hex = excluded.color_hex, exam_weight = excluded.exam_weight,
+    updated_at = datetime('now'), version = subjects.version + 1
+WHERE subjects.user_id = excluded.user_id AND subjects.deleted_at IS NULL;
+
+-- name: UpsertSyncTopic :execrows
+INSERT INTO topics (id, subject_id, name)
+SELECT sqlc.arg(id), s.id, sqlc.arg(name)
+FROM subjects s
+WHERE s.id = sqlc.arg(subject_id) AND s.user_id = sqlc.arg(user_id) AND s.deleted_at IS NULL
+ON CONFLICT (id) DO UPDATE
+SET subject_id = excluded.subject_id, name = excluded.name, updated_at = datetime('now'), version = topics.version + 1
+WHERE topics.deleted_at IS NULL
+  AND topics.subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
+
+-- name: UpsertSyncStudyCycle :execrows
+-- Cycles have no owner; one holding another user's items is theirs too and isn't updated
+INSERT INTO study_cycles (id, name, description, is_active)
+VALUES (sqlc.arg(id), sqlc.arg(name), sqlc.narg(description), sqlc.arg(is_active))
+ON CONFLICT (id) DO UPDATE
+SET name = excluded.name, description = excluded.description, is_active = excluded.is_active,
+    updated_at = datetime('now'), version = study_cycles.version + 1
+WHERE study_cycles.deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id
+    WHERE ci.cycle_id = study_cycles.id AND s.user_id <> sqlc.arg(user_id)
+  );
+
+-- name: UpsertSyncCycleItem :execrows
+INSERT INTO cycle_items (id, cycle_id, subject_id, order_index, planned_duration_minutes)
+SELECT sqlc.arg(id), sc.id, s.id, sqlc.arg(order_index), sqlc.narg(planned_duration_minutes)
+FROM study_cycles sc, subjects s
+WHERE sc.id = sqlc.arg(cycle_id) AND sc.deleted_at IS NULL
+  AND s.id = sqlc.arg(subject_id) AND s.user_id = sqlc.arg(user_id) AND s.deleted_at IS NULL
+ON CONFLICT (id) DO UPDATE
+SET cycle_id = excluded.cycle_id, subject_id = excluded.subject_id, order_index = excluded.order_index,
+    planned_duration_minutes = excluded.planned_duration_minutes, updated_at = datetime('now'),
+    version = cycle_items.version + 1
+WHERE cycle_items.subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
+
+-- name: UpsertSyncStudySession :execrows
+INSERT INTO study_sessions (
+    id, subject_id, cycle_item_id, started_at, finished_at, gross_duration_seconds, net_duration_seconds, notes
+)
+SELECT sqlc.arg(id), s.id, sqlc.narg(cycle_item_id), sqlc.arg(started_at), sqlc.narg(finished_at),
+       sqlc.arg(gross_duration_seconds), sqlc.arg(net_duration_seconds), sqlc.narg(notes)
+FROM subjects s
+WHERE s.id = sqlc.arg(subject_id) AND s.user_id = sqlc.arg(user_id) AND s.deleted_at IS NULL
+  AND (sqlc.narg(cycle_item_id) IS NULL OR sqlc.narg(cycle_item_id) IN (
+    SELECT ci.id FROM cycle_items ci JOIN subjects cs ON cs.id = ci.subject_id WHERE cs.user_id = sqlc.arg(user_id)
+  ))
+ON CONFLICT (id) DO UPDATE
+SET subject_id = excluded.subject_id, cycle_item_id = excluded.cycle_item_id, started_at = excluded.started_at,
+    finished_at = excluded.finished_at, gross_duration_seconds = excluded.gross_duration_seconds,
+    net_duration_seconds = excluded.net_duration_seconds, notes = excluded.notes, updated_at = datetime('now')
+WHERE study_sessions.subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
+
+-- name: UpsertSyncSessionPause :execrows
+INSERT INTO session_pauses (id, session_id, started_at, ended_at)
+SELECT sqlc.arg(id), ss.id, sqlc.arg(started_at), sqlc.narg(ended_at)
+FROM study_sessions ss
+JOIN subjects s ON s.id = ss.subject_id
+WHERE ss.id = sqlc.arg(session_id) AND s.user_id = sqlc.arg(user_id)
+ON CONFLICT (id) DO UPDATE
+SET session_id = excluded.session_id, started_at = excluded.started_at, ended_at = excluded.ended_at
+WHERE session_pauses.session_id IN (
+    SELECT ss.id FROM study_sessions ss JOIN subjects s ON s.id = ss.subject_id WHERE s.user_id = sqlc.arg(user_id)
+);
+
+-- name: UpsertSyncExerciseLog :execrows
+INSERT INTO exercise_logs (id, session_id, subject_id, topic_id, questions_count, correct_count)
+SELECT sqlc.arg(id), sqlc.narg(session_id), s.id, sqlc.narg(topic_id), sqlc.arg(questions_count), sqlc.arg(correct_count)
+FROM subjects s
+WHERE s.id = sqlc.arg(subject_id) AND s.user_id = sqlc.arg(user_id) AND s.deleted_at IS NULL
+  AND (sqlc.narg(session_id) IS NULL OR sqlc.narg(session_id) IN (
+    SELECT ss.id FROM study_sessions ss JOIN subjects ls ON ls.id = ss.subject_id WHERE ls.user_id = sqlc.arg(user_id)
+  ))
+  AND (sqlc.narg(topic_id) IS NULL OR sqlc.narg(topic_id) IN (
+    SELECT t.id FROM topics t JOIN subjects ts ON ts.id = t.subject_id WHERE ts.user_id = sqlc.arg(user_id) AND t.deleted_at IS NULL
+  ))
+ON CONFLICT (id) DO UPDATE
+SET session_id = excluded.session_id, subject_id = excluded.subject_id, topic_id = excluded.topic_id,
+    questions_count = excluded.questions_count, correct_count = excluded.correct_count
+WHERE exercise_logs.subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
+
+-- name: DeleteSyncSubject :execrows
+UPDATE subjects
+SET deleted_at = datetime('now'), version = version + 1
+WHERE id = ? AND user_id = ? AND deleted_at IS NULL;
+
+-- name: DeleteSyncTopic :execrows
+UPDATE topics
+SET deleted_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
+
+-- name: DeleteSyncStudyCycle :execrows
+UPDATE study_cycles
+SET deleted_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id
+    WHERE ci.cycle_id = study_cycles.id AND s.user_id <> sqlc.arg(user_id)
+  );
+
+-- name: DeleteSyncCycleItem :execrows
+DELETE FROM cycle_items
+WHERE id = sqlc.arg(id)
+  AND subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
+
+-- name: DeleteSyncStudySession :execrows
+DELETE FROM study_sessions
+WHERE id = sqlc.arg(id)
+  AND subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
+
+-- name: DeleteSyncSessionPause :execrows
+DELETE FROM session_pauses
+WHERE id = sqlc.arg(id)
+  AND session_id IN (
+    SELECT ss.id FROM study_sessions ss JOIN subjects s ON s.id = ss.subject_id WHERE s.user_id = sqlc.arg(user_id)
+  );
+
+-- name: DeleteSyncExerciseLog :execrows
+DELETE FROM exercise_logs
+WHERE id = sqlc.arg(id)
+  AND subject_id IN (SELECT id FROM subjects WHERE user_id = sqlc.arg(user_id));
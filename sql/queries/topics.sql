@@ -12,12 +12,23 @@ ORDER BY name;
 SELECT * FROM topics
 WHERE id = ? AND deleted_at IS NULL;
 
--- name: UpdateTopic :exec
+-- name: UpdateTopic :one
+-- Only updates the version the client read when if_version is set
 UPDATE topics
-SET name = ?, updated_at = datetime('now')
-WHERE id = ? AND deleted_at IS NULL;
+SET name = sqlc.arg(name), updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
+
+-- name: PatchTopic :one
+UPDATE topics
+SET name = CASE WHEN sqlc.arg(set_name) THEN sqlc.arg(name) ELSE name END,
+    updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+  AND (sqlc.narg(if_version) IS NULL OR version = sqlc.narg(if_version))
+RETURNING *;
 
 -- name: DeleteTopic :exec
 UPDATE topics
-SET deleted_at = datetime('now')
+SET deleted_at = datetime('now'), version = version + 1
 WHERE id = ?;
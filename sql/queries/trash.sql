@@ -0,0 +1,145 @@
+-- name: ListTrashedSubjects :many
+-- A trashed subject takes its topics, cycle items, sessions, exercise logs and goals with it: they
+-- stay untouched, hidden with the subject, and are purged along with it.
+SELECT
+    s.id,
+    s.name,
+    s.deleted_at,
+    (SELECT COUNT(*) FROM topics t WHERE t.subject_id = s.id) AS topics,
+    (SELECT COUNT(*) FROM cycle_items ci WHERE ci.subject_id = s.id) AS cycle_items,
+    (SELECT COUNT(*) FROM study_sessions ss WHERE ss.subject_id = s.id) AS study_sessions,
+    (SELECT COUNT(*) FROM exercise_logs el WHERE el.subject_id = s.id) AS exercise_logs,
+    (SELECT COUNT(*) FROM goals g WHERE g.subject_id = s.id) AS goals
+FROM subjects s
+WHERE s.user_id = ? AND s.deleted_at IS NOT NULL
+ORDER BY s.deleted_at DESC;
+
+-- name: ListTrashedTopics :many
+SELECT
+    t.id,
+    t.subject_id,
+    t.name,
+    t.deleted_at,
+    (SELECT COUNT(*) FROM exercise_logs el WHERE el.topic_id = t.id) AS exercise_logs
+FROM topics t
+JOIN subjects s ON s.id = t.subject_id
+WHERE s.user_id = ? AND t.deleted_at IS NOT NULL
+ORDER BY t.deleted_at DESC;
+
+-- name: ListTrashedStudyCycles :many
+-- Study cycles have no owner; a user's are those with items on their subjects
+SELECT
+    sc.id,
+    sc.name,
+    sc.deleted_at,
+    (SELECT COUNT(*) FROM cycle_items ci WHERE ci.cycle_id = sc.id) AS cycle_items
+FROM study_cycles sc
+WHERE sc.deleted_at IS NOT NULL
+  AND sc.id IN (
+    SELECT ci.cycle_id
+    FROM cycle_items ci
+    JOIN subjects s ON s.id = ci.subject_id
+    WHERE s.user_id = sqlc.arg(user_id)
+  )
+ORDER BY sc.deleted_at DESC;
+
+-- name: GetTrashedTopic :one
+SELECT t.* FROM topics t
+JOIN subjects s ON s.id = t.subject_id
+WHERE t.id = ? AND s.user_id = ? AND t.deleted_at IS NOT NULL;
+
+-- name: RestoreTrashedSubject :execrows
+UPDATE subjects
+SET deleted_at = NULL, updated_at = datetime('now'), version = version + 1
+WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL;
+
+-- name: RestoreTrashedTopic :execrows
+UPDATE topics
+SET deleted_at = NULL, updated_at = datetime('now'), version = version + 1
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: RestoreTrashedStudyCycle :execrows
+UPDATE study_cycles
+SET deleted_at = NULL, updated_at = datetime('now'), version = version + 1
+WHERE id = sqlc.arg(id)
+  AND deleted_at IS NOT NULL
+  AND id IN (
+    SELECT ci.cycle_id
+    FROM cycle_items ci
+    JOIN subjects s ON s.id = ci.subject_id
+    WHERE s.user_id = sqlc.arg(user_id)
+  );
+
+-- name: ListExpiredTrashedSubjects :many
+SELECT id FROM subjects
+WHERE deleted_at IS NOT NULL AND datetime(deleted_at) <= datetime(CAST(sqlc.arg(cutoff) AS TEXT));
+
+-- name: ListExpiredTrashedTopics :many
+SELECT id FROM topics
+WHERE deleted_at IS NOT NULL AND datetime(deleted_at) <= datetime(CAST(sqlc.arg(cutoff) AS TEXT));
+
+-- name: ListExpiredTrashedStudyCycles :many
+SELECT id FROM study_cycles
+WHERE deleted_at IS NOT NULL AND datetime(deleted_at) <= datetime(CAST(sqlc.arg(cutoff) AS TEXT));
+
+-- name: PurgeSubjectExerciseLogs :exec
+DELETE FROM exercise_logs
+WHERE subject_id = ?;
+
+-- name: PurgeSubjectSessionPauses :exec
+DELETE FROM session_pauses
+WHERE session_id IN (SELECT id FROM study_sessions WHERE subject_id = ?);
+
+-- name: PurgeSubjectStudySessions :exec
+DELETE FROM study_sessions
+WHERE subject_id = ?;
+
+-- name: DetachSubjectCycleItemSessions :exec
+-- Sessions of other subjects can still point at the subject's cycle items
+UPDATE study_sessions
+SET cycle_item_id = NULL, updated_at = datetime('now')
+WHERE cycle_item_id IN (SELECT id FROM cycle_items WHERE subject_id = ?);
+
+-- name: PurgeSubjectCycleItems :exec
+DELETE FROM cycle_items
+WHERE subject_id = ?;
+
+-- name: DetachSubjectTopicExerciseLogs :exec
+-- Logs of other subjects can still point at the subject's topics
+UPDATE exercise_logs
+SET topic_id = NULL
+WHERE topic_id IN (SELECT id FROM topics WHERE subject_id = ?);
+
+-- name: PurgeSubjectTopics :exec
+DELETE FROM topics
+WHERE subject_id = ?;
+
+-- name: PurgeSubjectGoals :exec
+DELETE FROM goals
+WHERE subject_id = ?;
+
+-- name: PurgeTrashedSubject :execrows
+DELETE FROM subjects
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: DetachTopicExerciseLogs :exec
+UPDATE exercise_logs
+SET topic_id = NULL
+WHERE topic_id = ?;
+
+-- name: PurgeTrashedTopic :execrows
+DELETE FROM topics
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: DetachStudyCycleSessions :exec
+UPDATE study_sessions
+SET cycle_item_id = NULL, updated_at = datetime('now')
+WHERE cycle_item_id IN (SELECT id FROM cycle_items WHERE cycle_id = ?);
+
+-- name: PurgeStudyCycleItems :exec
+DELETE FROM cycle_items
+WHERE cycle_id = ?;
+
+-- name: PurgeTrashedStudyCycle :execrows
+DELETE FROM study_cycles
+WHERE id = ? AND deleted_at IS NOT NULL;
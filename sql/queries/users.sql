@@ -7,7 +7,35 @@ RETURNING *;
 SELECT * FROM users
 WHERE email = ? LIMIT 1;
 
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = ? LIMIT 1;
+
+-- name: UpdateUserProfile :exec
+UPDATE users
+SET name = ?, timezone = ?, locale = ?, daily_goal_minutes = ?, week_start_day = ?, exam_date = ?
+WHERE id = ?;
+
 -- name: UpdateUserPassword :exec
 UPDATE users
 SET password_hash = ?
 WHERE id = ?;
+
+-- name: IncrementLoginFailures :one
+-- Counts a failed login in place, so concurrent failures all count
+UPDATE users
+SET failed_login_attempts = failed_login_attempts + 1
+WHERE id = ?
+RETURNING failed_login_attempts;
+
+-- name: LockUser :exec
+-- Only while the failures are still the ones the lock was computed for, so a
+-- slower request can't shorten the lock of a later failure
+UPDATE users
+SET locked_until = ?
+WHERE id = ? AND failed_login_attempts = ?;
+
+-- name: ResetLoginFailures :exec
+UPDATE users
+SET failed_login_attempts = 0, locked_until = NULL
+WHERE id = ?;
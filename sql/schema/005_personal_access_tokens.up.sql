@@ -0,0 +1,16 @@
+-- Personal access tokens for scripts and the CLI (only the SHA-256 hash is stored)
+CREATE TABLE personal_access_tokens (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL, -- First characters, to recognise a token in lists
+    scopes TEXT NOT NULL, -- Space separated (ex: "analytics:read sessions:write")
+    expires_at TEXT,
+    last_used_at TEXT,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    revoked_at TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_pat_user ON personal_access_tokens(user_id);
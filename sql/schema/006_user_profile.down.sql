@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN week_start_day;
+ALTER TABLE users DROP COLUMN daily_goal_minutes;
+ALTER TABLE users DROP COLUMN locale;
+ALTER TABLE users DROP COLUMN timezone;
@@ -0,0 +1,5 @@
+-- Profile settings used by /me and the analytics layer
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC'; -- IANA name (ex: America/Sao_Paulo)
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT 'en-US'; -- BCP 47 tag
+ALTER TABLE users ADD COLUMN daily_goal_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN week_start_day INTEGER NOT NULL DEFAULT 1; -- 0 = Sunday ... 6 = Saturday
@@ -0,0 +1,17 @@
+-- Study goals ("240 net minutes per day", "200 questions per week")
+CREATE TABLE goals (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    subject_id TEXT, -- NULL = all subjects
+    metric TEXT NOT NULL CHECK (metric IN ('net_minutes', 'questions')),
+    period TEXT NOT NULL CHECK (period IN ('day', 'week')),
+    target INTEGER NOT NULL CHECK (target > 0),
+    rest_days INTEGER NOT NULL DEFAULT 0, -- Weekday bitmask (bit 0 = Sunday); daily goals skip these days
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    deleted_at TEXT,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_goals_user ON goals(user_id);
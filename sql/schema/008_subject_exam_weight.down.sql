@@ -0,0 +1 @@
+ALTER TABLE subjects DROP COLUMN exam_weight;
@@ -0,0 +1,3 @@
+-- Relative weight of a subject in the target exam (ex: its number of questions or points).
+-- Only the proportions between a user's subjects matter; NULL means unknown.
+ALTER TABLE subjects ADD COLUMN exam_weight REAL CHECK (exam_weight IS NULL OR exam_weight > 0);
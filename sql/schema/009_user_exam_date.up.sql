@@ -0,0 +1,2 @@
+-- Target exam day for readiness forecasts, as a local date (YYYY-MM-DD); NULL when unset
+ALTER TABLE users ADD COLUMN exam_date TEXT;
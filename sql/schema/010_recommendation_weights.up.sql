@@ -0,0 +1,9 @@
+-- Per-user weights of the "study now" recommendation sources; users without a row get the defaults
+CREATE TABLE recommendation_weights (
+    user_id TEXT PRIMARY KEY,
+    cycle_weight REAL NOT NULL CHECK (cycle_weight >= 0),
+    review_weight REAL NOT NULL CHECK (review_weight >= 0),
+    weakness_weight REAL NOT NULL CHECK (weakness_weight >= 0),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Account deletion requests. A request waits out its grace period (the account can be restored
+-- until purge_after), then every row of the user is purged and the counts are kept here as the
+-- deletion report. No foreign key: the row outlives the user, readable with its receipt token.
+CREATE TABLE account_deletions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    receipt_hash TEXT NOT NULL, -- SHA-256 of the receipt token handed out with the request
+    requested_at TEXT NOT NULL DEFAULT (datetime('now')),
+    purge_after TEXT NOT NULL,
+    purged_at TEXT,
+    deleted_rows TEXT, -- JSON object: rows purged per table
+    verified INTEGER NOT NULL DEFAULT 0 -- 1 when the purge deleted exactly the rows counted and left none behind
+);
+
+-- At most one pending request per user
+CREATE UNIQUE INDEX idx_account_deletions_pending ON account_deletions(user_id) WHERE purged_at IS NULL;
+CREATE INDEX idx_account_deletions_due ON account_deletions(purge_after) WHERE purged_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_study_cycles_trash;
+DROP INDEX IF EXISTS idx_topics_trash;
+DROP INDEX IF EXISTS idx_subjects_trash;
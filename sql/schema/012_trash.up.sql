@@ -0,0 +1,5 @@
+-- Soft-deleted subjects, topics and study cycles sit in the trash until restored or purged.
+-- These indexes serve the trash listing and the retention purge.
+CREATE INDEX idx_subjects_trash ON subjects(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_topics_trash ON topics(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_study_cycles_trash ON study_cycles(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE cycle_items DROP COLUMN version;
+ALTER TABLE study_cycles DROP COLUMN version;
+ALTER TABLE topics DROP COLUMN version;
+ALTER TABLE subjects DROP COLUMN version;
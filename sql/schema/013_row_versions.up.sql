@@ -0,0 +1,6 @@
+-- Row versions for optimistic concurrency. Every update bumps the version; updates sent with
+-- If-Match only apply to the version the client last read, and it's served as the ETag.
+ALTER TABLE subjects ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE topics ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE study_cycles ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE cycle_items ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
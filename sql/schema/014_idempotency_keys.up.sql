@@ -0,0 +1,16 @@
+-- Responses kept for Idempotency-Key retries. A row is claimed before the request runs
+-- (status_code NULL while it does) and holds the response once it's done; retries within
+-- the TTL replay it, as long as they send the same request.
+CREATE TABLE idempotency_keys (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key TEXT NOT NULL,
+    request_hash TEXT NOT NULL, -- SHA-256 of the method, path and body
+    status_code INTEGER,
+    content_type TEXT,
+    response_body BLOB,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    expires_at TEXT NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
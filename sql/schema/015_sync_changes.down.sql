@@ -0,0 +1,26 @@
+DROP TRIGGER IF EXISTS sync_goals_delete;
+DROP TRIGGER IF EXISTS sync_goals_update;
+DROP TRIGGER IF EXISTS sync_goals_insert;
+DROP TRIGGER IF EXISTS sync_exercise_logs_delete;
+DROP TRIGGER IF EXISTS sync_exercise_logs_update;
+DROP TRIGGER IF EXISTS sync_exercise_logs_insert;
+DROP TRIGGER IF EXISTS sync_session_pauses_delete;
+DROP TRIGGER IF EXISTS sync_session_pauses_update;
+DROP TRIGGER IF EXISTS sync_session_pauses_insert;
+DROP TRIGGER IF EXISTS sync_study_sessions_delete;
+DROP TRIGGER IF EXISTS sync_study_sessions_update;
+DROP TRIGGER IF EXISTS sync_study_sessions_insert;
+DROP TRIGGER IF EXISTS sync_cycle_items_delete;
+DROP TRIGGER IF EXISTS sync_cycle_items_update;
+DROP TRIGGER IF EXISTS sync_cycle_items_insert;
+DROP TRIGGER IF EXISTS sync_study_cycles_delete;
+DROP TRIGGER IF EXISTS sync_study_cycles_update;
+DROP TRIGGER IF EXISTS sync_study_cycles_insert;
+DROP TRIGGER IF EXISTS sync_topics_delete;
+DROP TRIGGER IF EXISTS sync_topics_update;
+DROP TRIGGER IF EXISTS sync_topics_insert;
+DROP TRIGGER IF EXISTS sync_subjects_delete;
+DROP TRIGGER IF EXISTS sync_subjects_update;
+DROP TRIGGER IF EXISTS sync_subjects_insert;
+DROP VIEW IF EXISTS sync_entities;
+DROP TABLE IF EXISTS sync_changes;
@@ -0,0 +1,259 @@
+-- Change feed for offline sync. Triggers log every write to a user's study data in commit order:
+-- seq only grows, so a client resumes from the last seq it saw. Soft deletes and hard deletes both
+-- log 'delete' (a tombstone); restoring a soft-deleted row logs 'upsert' again.
+CREATE TABLE sync_changes (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT, -- AUTOINCREMENT: never reused, even after purges
+    user_id TEXT NOT NULL,
+    entity TEXT NOT NULL CHECK (entity IN (
+        'subject', 'topic', 'study_cycle', 'cycle_item', 'study_session', 'session_pause', 'exercise_log', 'goal'
+    )),
+    entity_id TEXT NOT NULL,
+    op TEXT NOT NULL CHECK (op IN ('upsert', 'delete')),
+    changed_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX idx_sync_changes_user_seq ON sync_changes(user_id, seq);
+CREATE INDEX idx_sync_changes_entity ON sync_changes(entity, entity_id, seq);
+
+-- Current state of every synced row as the JSON the feed serves
+CREATE VIEW sync_entities AS
+SELECT 'subject' AS entity, id AS entity_id, json_object(
+    'id', id, 'name', name, 'color_hex', color_hex, 'exam_weight', exam_weight,
+    'created_at', created_at, 'updated_at', updated_at, 'deleted_at', deleted_at, 'version', version
+) AS data FROM subjects
+UNION ALL
+SELECT 'topic', id, json_object(
+    'id', id, 'subject_id', subject_id, 'name', name,
+    'created_at', created_at, 'updated_at', updated_at, 'deleted_at', deleted_at, 'version', version
+) FROM topics
+UNION ALL
+SELECT 'study_cycle', id, json_object(
+    'id', id, 'name', name, 'description', description, 'is_active', json(CASE WHEN is_active = 1 THEN 'true' ELSE 'false' END),
+    'created_at', created_at, 'updated_at', updated_at, 'deleted_at', deleted_at, 'version', version
+) FROM study_cycles
+UNION ALL
+SELECT 'cycle_item', id, json_object(
+    'id', id, 'cycle_id', cycle_id, 'subject_id', subject_id, 'order_index', order_index,
+    'planned_duration_minutes', planned_duration_minutes,
+    'created_at', created_at, 'updated_at', updated_at, 'version', version
+) FROM cycle_items
+UNION ALL
+SELECT 'study_session', id, json_object(
+    'id', id, 'subject_id', subject_id, 'cycle_item_id', cycle_item_id, 'started_at', started_at, 'finished_at', finished_at,
+    'gross_duration_seconds', gross_duration_seconds, 'net_duration_seconds', net_duration_seconds, 'notes', notes,
+    'created_at', created_at, 'updated_at', updated_at
+) FROM study_sessions
+UNION ALL
+SELECT 'session_pause', id, json_object(
+    'id', id, 'session_id', session_id, 'started_at', started_at, 'ended_at', ended_at
+) FROM session_pauses
+UNION ALL
+SELECT 'exercise_log', id, json_object(
+    'id', id, 'session_id', session_id, 'subject_id', subject_id, 'topic_id', topic_id,
+    'questions_count', questions_count, 'correct_count', correct_count, 'created_at', created_at
+) FROM exercise_logs
+UNION ALL
+SELECT 'goal', id, json_object(
+    'id', id, 'subject_id', subject_id, 'metric', metric, 'period', period, 'target', target, 'rest_days', rest_days,
+    'created_at', created_at, 'updated_at', updated_at, 'deleted_at', deleted_at
+) FROM goals;
+
+-- Rows written before the feed existed, parents first, so a full sync from seq 0 sees everything
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT user_id, 'subject', id, CASE WHEN deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+FROM subjects ORDER BY created_at, id;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT s.user_id, 'topic', t.id, CASE WHEN t.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+FROM topics t JOIN subjects s ON s.id = t.subject_id ORDER BY t.created_at, t.id;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT DISTINCT s.user_id, 'study_cycle', sc.id, CASE WHEN sc.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+FROM study_cycles sc JOIN cycle_items ci ON ci.cycle_id = sc.id JOIN subjects s ON s.id = ci.subject_id;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT s.user_id, 'cycle_item', ci.id, 'upsert'
+FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id ORDER BY ci.cycle_id, ci.order_index, ci.id;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT s.user_id, 'study_session', ss.id, 'upsert'
+FROM study_sessions ss JOIN subjects s ON s.id = ss.subject_id ORDER BY ss.started_at, ss.id;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT s.user_id, 'session_pause', sp.id, 'upsert'
+FROM session_pauses sp JOIN study_sessions ss ON ss.id = sp.session_id JOIN subjects s ON s.id = ss.subject_id
+ORDER BY sp.started_at, sp.id;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT s.user_id, 'exercise_log', el.id, 'upsert'
+FROM exercise_logs el JOIN subjects s ON s.id = el.subject_id ORDER BY el.created_at, el.id;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, op)
+SELECT user_id, 'goal', id, CASE WHEN deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+FROM goals ORDER BY created_at, id;
+
+-- Inserts and updates find the owner through the row's subject (goals and subjects hold it).
+-- Deletes take it from the feed itself: when a parent goes first, e.g. in a cascade, the join
+-- finds nothing, but every row has logged at least its insert.
+
+CREATE TRIGGER sync_subjects_insert AFTER INSERT ON subjects
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    VALUES (NEW.user_id, 'subject', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END);
+END;
+
+CREATE TRIGGER sync_subjects_update AFTER UPDATE ON subjects
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    VALUES (NEW.user_id, 'subject', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END);
+END;
+
+CREATE TRIGGER sync_subjects_delete AFTER DELETE ON subjects
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'subject', OLD.id, 'delete' FROM sync_changes WHERE entity = 'subject' AND entity_id = OLD.id;
+END;
+
+CREATE TRIGGER sync_topics_insert AFTER INSERT ON topics
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'topic', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+    FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_topics_update AFTER UPDATE ON topics
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'topic', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+    FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_topics_delete AFTER DELETE ON topics
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'topic', OLD.id, 'delete' FROM sync_changes WHERE entity = 'topic' AND entity_id = OLD.id;
+END;
+
+-- Study cycles have no owner: a cycle is logged for every user with items in it, and first
+-- reaches a user's feed with the item that links it to them.
+CREATE TRIGGER sync_study_cycles_insert AFTER INSERT ON study_cycles
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT s.user_id, 'study_cycle', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+    FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id WHERE ci.cycle_id = NEW.id;
+END;
+
+CREATE TRIGGER sync_study_cycles_update AFTER UPDATE ON study_cycles
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT s.user_id, 'study_cycle', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+    FROM cycle_items ci JOIN subjects s ON s.id = ci.subject_id WHERE ci.cycle_id = NEW.id;
+END;
+
+CREATE TRIGGER sync_study_cycles_delete AFTER DELETE ON study_cycles
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'study_cycle', OLD.id, 'delete' FROM sync_changes WHERE entity = 'study_cycle' AND entity_id = OLD.id;
+END;
+
+CREATE TRIGGER sync_cycle_items_insert AFTER INSERT ON cycle_items
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT s.user_id, 'study_cycle', sc.id, CASE WHEN sc.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+    FROM subjects s, study_cycles sc
+    WHERE s.id = NEW.subject_id AND sc.id = NEW.cycle_id
+      AND NOT EXISTS (SELECT 1 FROM sync_changes WHERE user_id = s.user_id AND entity = 'study_cycle' AND entity_id = sc.id);
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'cycle_item', NEW.id, 'upsert' FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_cycle_items_update AFTER UPDATE ON cycle_items
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT s.user_id, 'study_cycle', sc.id, CASE WHEN sc.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END
+    FROM subjects s, study_cycles sc
+    WHERE s.id = NEW.subject_id AND sc.id = NEW.cycle_id
+      AND NOT EXISTS (SELECT 1 FROM sync_changes WHERE user_id = s.user_id AND entity = 'study_cycle' AND entity_id = sc.id);
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'cycle_item', NEW.id, 'upsert' FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_cycle_items_delete AFTER DELETE ON cycle_items
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'cycle_item', OLD.id, 'delete' FROM sync_changes WHERE entity = 'cycle_item' AND entity_id = OLD.id;
+END;
+
+CREATE TRIGGER sync_study_sessions_insert AFTER INSERT ON study_sessions
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'study_session', NEW.id, 'upsert' FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_study_sessions_update AFTER UPDATE ON study_sessions
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'study_session', NEW.id, 'upsert' FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_study_sessions_delete AFTER DELETE ON study_sessions
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'study_session', OLD.id, 'delete' FROM sync_changes WHERE entity = 'study_session' AND entity_id = OLD.id;
+END;
+
+CREATE TRIGGER sync_session_pauses_insert AFTER INSERT ON session_pauses
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT s.user_id, 'session_pause', NEW.id, 'upsert'
+    FROM study_sessions ss JOIN subjects s ON s.id = ss.subject_id WHERE ss.id = NEW.session_id;
+END;
+
+CREATE TRIGGER sync_session_pauses_update AFTER UPDATE ON session_pauses
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT s.user_id, 'session_pause', NEW.id, 'upsert'
+    FROM study_sessions ss JOIN subjects s ON s.id = ss.subject_id WHERE ss.id = NEW.session_id;
+END;
+
+CREATE TRIGGER sync_session_pauses_delete AFTER DELETE ON session_pauses
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'session_pause', OLD.id, 'delete' FROM sync_changes WHERE entity = 'session_pause' AND entity_id = OLD.id;
+END;
+
+CREATE TRIGGER sync_exercise_logs_insert AFTER INSERT ON exercise_logs
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'exercise_log', NEW.id, 'upsert' FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_exercise_logs_update AFTER UPDATE ON exercise_logs
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT user_id, 'exercise_log', NEW.id, 'upsert' FROM subjects WHERE id = NEW.subject_id;
+END;
+
+CREATE TRIGGER sync_exercise_logs_delete AFTER DELETE ON exercise_logs
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'exercise_log', OLD.id, 'delete' FROM sync_changes WHERE entity = 'exercise_log' AND entity_id = OLD.id;
+END;
+
+CREATE TRIGGER sync_goals_insert AFTER INSERT ON goals
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    VALUES (NEW.user_id, 'goal', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END);
+END;
+
+CREATE TRIGGER sync_goals_update AFTER UPDATE ON goals
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    VALUES (NEW.user_id, 'goal', NEW.id, CASE WHEN NEW.deleted_at IS NULL THEN 'upsert' ELSE 'delete' END);
+END;
+
+CREATE TRIGGER sync_goals_delete AFTER DELETE ON goals
+BEGIN
+    INSERT INTO sync_changes (user_id, entity, entity_id, op)
+    SELECT DISTINCT user_id, 'goal', OLD.id, 'delete' FROM sync_changes WHERE entity = 'goal' AND entity_id = OLD.id;
+END;
@@ -0,0 +1,3 @@
+-- Replayed responses keep the headers their handler set, like ETag and Location. JSON object of
+-- header name to values; NULL for responses stored before, which only kept their content type.
+ALTER TABLE idempotency_keys ADD COLUMN response_headers TEXT;
@@ -5,48 +5,76 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joaoapaenas/my-api/internal/config"
 	"github.com/joaoapaenas/my-api/internal/database"
 	"github.com/joaoapaenas/my-api/internal/handler"
+	customMiddleware "github.com/joaoapaenas/my-api/internal/middleware"
 	"github.com/joaoapaenas/my-api/internal/repository"
 	"github.com/joaoapaenas/my-api/internal/service"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestIntegration_CreateUserFlow(t *testing.T) {
-	// 1. Setup In-Memory DB
-	db, err := sql.Open("sqlite3", ":memory:")
+// newTestDB opens an in-memory database with every schema migration applied
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=on")
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer db.Close()
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
 
-	// 2. Apply Schema
-	_, err = db.Exec(`
-		CREATE TABLE users (
-			id TEXT PRIMARY KEY, 
-			email TEXT, 
-			name TEXT, 
-			password_hash TEXT NOT NULL DEFAULT '',
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
+	files, err := filepath.Glob("../sql/schema/*.sql")
 	if err != nil {
 		t.Fatal(err)
 	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if strings.HasSuffix(file, ".down.sql") {
+			continue
+		}
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("applying %s: %v", file, err)
+		}
+	}
+	return db
+}
+
+// withUser sets the authenticated user the way the JWT middleware does
+func withUser(req *http.Request, userID string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), "userID", userID))
+}
+
+func TestIntegration_CreateUserFlow(t *testing.T) {
+	// 1. Setup In-Memory DB (Schema from migrations)
+	db := newTestDB(t)
+	defer db.Close()
 
-	// 3. Wiring
-	queries := database.New(db)
+	// 2. Wiring
+	queries := database.New(repository.NewContextDB(db))
 	repo := repository.NewSQLUserRepository(queries)
 	svc := service.NewUserManager(repo)
 	h := handler.NewUserHandler(svc)
 
-	// 4. Test Request
+	// 3. Test Request
 	reqBody := handler.CreateUserRequest{
 		Email:    "integration@example.com",
 		Name:     "Integration User",
@@ -57,59 +85,35 @@ func TestIntegration_CreateUserFlow(t *testing.T) {
 	req := httptest.NewRequest("POST", "/users", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	// 5. Execute
+	// 4. Execute
 	r := chi.NewRouter()
 	r.Post("/users", h.CreateUser)
 	r.ServeHTTP(rr, req)
 
-	// 6. Assertions
+	// 5. Assertions
 	assert.Equal(t, http.StatusCreated, rr.Code, "Expected status 201")
 
-	var user database.User
-	err = json.NewDecoder(rr.Body).Decode(&user)
+	assert.NotContains(t, rr.Body.String(), "password_hash", "Password hash must not be exposed")
+
+	var user handler.UserResponse
+	err := json.NewDecoder(rr.Body).Decode(&user)
 	assert.NoError(t, err, "Failed to decode response body")
 	assert.Equal(t, "integration@example.com", user.Email, "Email mismatch in response")
 	assert.NotEmpty(t, user.ID, "User ID should not be empty")
 
-	// 7. Verify DB
+	// 6. Verify DB
 	savedUser, err := repo.GetUserByEmail(context.Background(), "integration@example.com")
 	assert.NoError(t, err, "Failed to get user from DB")
 	assert.Equal(t, user.ID, savedUser.ID, "ID mismatch between response and DB")
 }
 
 func TestIntegration_SubjectFlow(t *testing.T) {
-	// 1. Setup In-Memory DB
-	db, err := sql.Open("sqlite3", ":memory:")
-	if err != nil {
-		t.Fatal(err)
-	}
+	// 1. Setup In-Memory DB (Schema from migrations)
+	db := newTestDB(t)
 	defer db.Close()
 
-	// 2. Apply Schema
-	_, err = db.Exec(`
-		PRAGMA foreign_keys = ON;
-		CREATE TABLE users (
-			id TEXT PRIMARY KEY, 
-			email TEXT, 
-			name TEXT, 
-			password_hash TEXT NOT NULL DEFAULT '',
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-		CREATE TABLE subjects (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			color_hex TEXT,
-			created_at TEXT NOT NULL DEFAULT (datetime('now')),
-			updated_at TEXT NOT NULL DEFAULT (datetime('now')),
-			deleted_at TEXT
-		);
-	`)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	// 3. Wiringgo
-	queries := database.New(db)
+	// 2. Wiring
+	queries := database.New(repository.NewContextDB(db))
 
 	// User Setup
 	userRepo := repository.NewSQLUserRepository(queries)
@@ -120,10 +124,10 @@ func TestIntegration_SubjectFlow(t *testing.T) {
 	subjectHandler := handler.NewSubjectHandler(subjectSvc)
 
 	ctx := context.Background()
-	_, err = userSvc.CreateUser(ctx, "test@example.com", "Tester", "password123")
+	user, err := userSvc.CreateUser(ctx, "test@example.com", "Tester", "password123")
 	assert.NoError(t, err)
 
-	// 4. Test Request (Create Subject)
+	// 3. Test Request (Create Subject)
 	reqBody := handler.CreateSubjectRequest{
 		Name:     "Integration Math",
 		ColorHex: "#FF0000",
@@ -131,19 +135,17 @@ func TestIntegration_SubjectFlow(t *testing.T) {
 	body, _ := json.Marshal(reqBody)
 
 	req := httptest.NewRequest("POST", "/subjects", bytes.NewBuffer(body))
-	// Add Basic Auth Header
-	req.SetBasicAuth("test@example.com", "password123")
+	// Authenticated user, as set by the JWT middleware
+	req = withUser(req, user.ID)
 	rr := httptest.NewRecorder()
 
-	// 5. Execute
+	// 4. Execute
 	r := chi.NewRouter()
-	// We need to import middleware package to use the real one,
-	// or we can just test the handler logic if we trust the middleware unit tests.
-	// Let's just test the handler + DB flow here.
+	// The middleware has its own unit tests; test the handler + DB flow here.
 	r.Post("/subjects", subjectHandler.CreateSubject)
 	r.ServeHTTP(rr, req)
 
-	// 6. Assertions
+	// 5. Assertions
 	assert.Equal(t, http.StatusCreated, rr.Code, "Expected status 201")
 
 	var subject database.Subject
@@ -151,32 +153,18 @@ func TestIntegration_SubjectFlow(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Integration Math", subject.Name)
 
-	// 7. Verify DB
-	savedSubject, err := subjectRepo.GetSubject(ctx, subject.ID)
+	// 6. Verify DB
+	savedSubject, err := subjectRepo.GetSubject(ctx, subject.ID, user.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, subject.Name, savedSubject.Name)
 }
 
 func TestIntegration_TopicFlow(t *testing.T) {
-	// 1. Setup DB
-	db, err := sql.Open("sqlite3", ":memory:")
-	if err != nil {
-		t.Fatal(err)
-	}
+	// 1. Setup DB (Schema from migrations)
+	db := newTestDB(t)
 	defer db.Close()
 
-	// Apply Schema (Users, Subjects, Topics)
-	_, err = db.Exec(`
-		PRAGMA foreign_keys = ON;
-		CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT, name TEXT, password_hash TEXT NOT NULL DEFAULT '', created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
-		CREATE TABLE subjects (id TEXT PRIMARY KEY, name TEXT NOT NULL, color_hex TEXT, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), deleted_at TEXT);
-		CREATE TABLE topics (id TEXT PRIMARY KEY, subject_id TEXT NOT NULL, name TEXT NOT NULL, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), deleted_at TEXT, FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE);
-	`)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	queries := database.New(db)
+	queries := database.New(repository.NewContextDB(db))
 
 	// Services
 	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
@@ -185,8 +173,8 @@ func TestIntegration_TopicFlow(t *testing.T) {
 	topicHandler := handler.NewTopicHandler(topicSvc)
 
 	ctx := context.Background()
-	_, _ = userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
-	subject, _ := subjectSvc.CreateSubject(ctx, "Math", "#000")
+	user, _ := userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
+	subject, _ := subjectSvc.CreateSubject(ctx, user.ID, "Math", "#000", nil)
 
 	// Test Create Topic
 	reqBody := handler.CreateTopicRequest{
@@ -194,7 +182,7 @@ func TestIntegration_TopicFlow(t *testing.T) {
 	}
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest("POST", "/subjects/"+subject.ID+"/topics", bytes.NewBuffer(body))
-	req.SetBasicAuth("test@example.com", "pass")
+	req = withUser(req, user.ID)
 	rr := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -216,32 +204,19 @@ func TestIntegration_TopicFlow(t *testing.T) {
 }
 
 func TestIntegration_StudyCycleFlow(t *testing.T) {
-	// 1. Setup DB
-	db, err := sql.Open("sqlite3", ":memory:")
-	if err != nil {
-		t.Fatal(err)
-	}
+	// 1. Setup DB (Schema from migrations)
+	db := newTestDB(t)
 	defer db.Close()
 
-	// Apply Schema (Users, StudyCycles)
-	_, err = db.Exec(`
-		PRAGMA foreign_keys = ON;
-		CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT, name TEXT, password_hash TEXT NOT NULL DEFAULT '', created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
-		CREATE TABLE study_cycles (id TEXT PRIMARY KEY, name TEXT NOT NULL, description TEXT, is_active INTEGER DEFAULT 0, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), deleted_at TEXT);
-	`)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	queries := database.New(db)
+	queries := database.New(repository.NewContextDB(db))
 
 	// Services
 	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
-	cycleSvc := service.NewStudyCycleManager(repository.NewSQLStudyCycleRepository(queries))
+	cycleSvc := service.NewStudyCycleManager(repository.NewSQLStudyCycleRepository(queries), repository.NewSQLTxManager(db))
 	cycleHandler := handler.NewStudyCycleHandler(cycleSvc)
 
 	ctx := context.Background()
-	_, _ = userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
+	user, _ := userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
 
 	// Test Create Cycle
 	reqBody := handler.CreateStudyCycleRequest{
@@ -250,7 +225,7 @@ func TestIntegration_StudyCycleFlow(t *testing.T) {
 	}
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest("POST", "/study-cycles", bytes.NewBuffer(body))
-	req.SetBasicAuth("test@example.com", "pass")
+	req = withUser(req, user.ID)
 	rr := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -266,40 +241,25 @@ func TestIntegration_StudyCycleFlow(t *testing.T) {
 }
 
 func TestIntegration_CycleItemFlow(t *testing.T) {
-	// 1. Setup DB
-	db, err := sql.Open("sqlite3", ":memory:")
-	if err != nil {
-		t.Fatal(err)
-	}
+	// 1. Setup DB (Schema from migrations)
+	db := newTestDB(t)
 	defer db.Close()
 
-	// Apply Schema
-	_, err = db.Exec(`
-		PRAGMA foreign_keys = ON;
-		CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT, name TEXT, password_hash TEXT NOT NULL DEFAULT '', created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
-		CREATE TABLE subjects (id TEXT PRIMARY KEY, name TEXT NOT NULL, color_hex TEXT, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), deleted_at TEXT);
-		CREATE TABLE study_cycles (id TEXT PRIMARY KEY, name TEXT NOT NULL, description TEXT, is_active INTEGER DEFAULT 0, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), deleted_at TEXT);
-		CREATE TABLE cycle_items (id TEXT PRIMARY KEY, cycle_id TEXT NOT NULL, subject_id TEXT NOT NULL, order_index INTEGER NOT NULL, planned_duration_minutes INTEGER DEFAULT 60, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), FOREIGN KEY (cycle_id) REFERENCES study_cycles(id) ON DELETE CASCADE, FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE);
-	`)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	queries := database.New(db)
+	queries := database.New(repository.NewContextDB(db))
 
 	// Services
 	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
 	subjectSvc := service.NewSubjectManager(repository.NewSQLSubjectRepository(queries))
-	cycleSvc := service.NewStudyCycleManager(repository.NewSQLStudyCycleRepository(queries))
+	cycleSvc := service.NewStudyCycleManager(repository.NewSQLStudyCycleRepository(queries), repository.NewSQLTxManager(db))
 	itemSvc := service.NewCycleItemManager(repository.NewSQLCycleItemRepository(queries))
 	itemHandler := handler.NewCycleItemHandler(itemSvc)
 
 	ctx := context.Background()
-	_, err = userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
+	user, err := userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
 	assert.NoError(t, err)
-	subject, err := subjectSvc.CreateSubject(ctx, "Math", "#000")
+	subject, err := subjectSvc.CreateSubject(ctx, user.ID, "Math", "#000", nil)
 	assert.NoError(t, err)
-	cycle, err := cycleSvc.CreateStudyCycle(ctx, "Cycle 1", "", true)
+	cycle, err := cycleSvc.CreateStudyCycle(ctx, "Cycle 1", "", true, nil)
 	assert.NoError(t, err)
 
 	// Test Create Cycle Item
@@ -310,7 +270,7 @@ func TestIntegration_CycleItemFlow(t *testing.T) {
 	}
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest("POST", "/study-cycles/"+cycle.ID+"/items", bytes.NewBuffer(body))
-	req.SetBasicAuth("test@example.com", "pass")
+	req = withUser(req, user.ID)
 	rr := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -332,37 +292,21 @@ func TestIntegration_CycleItemFlow(t *testing.T) {
 }
 
 func TestIntegration_StudySessionFlow(t *testing.T) {
-	// 1. Setup DB
-	db, err := sql.Open("sqlite3", ":memory:")
-	if err != nil {
-		t.Fatal(err)
-	}
+	// 1. Setup DB (Schema from migrations)
+	db := newTestDB(t)
 	defer db.Close()
 
-	// Apply Schema
-	_, err = db.Exec(`
-		PRAGMA foreign_keys = ON;
-		CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT, name TEXT, password_hash TEXT NOT NULL DEFAULT '', created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
-		CREATE TABLE subjects (id TEXT PRIMARY KEY, name TEXT NOT NULL, color_hex TEXT, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), deleted_at TEXT);
-		CREATE TABLE study_cycles (id TEXT PRIMARY KEY, name TEXT NOT NULL, description TEXT, is_active INTEGER DEFAULT 0, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), deleted_at TEXT);
-		CREATE TABLE cycle_items (id TEXT PRIMARY KEY, cycle_id TEXT NOT NULL, subject_id TEXT NOT NULL, order_index INTEGER NOT NULL, planned_duration_minutes INTEGER DEFAULT 60, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), FOREIGN KEY (cycle_id) REFERENCES study_cycles(id) ON DELETE CASCADE, FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE);
-		CREATE TABLE study_sessions (id TEXT PRIMARY KEY, subject_id TEXT NOT NULL, cycle_item_id TEXT, started_at TEXT NOT NULL, finished_at TEXT, gross_duration_seconds INTEGER DEFAULT 0, net_duration_seconds INTEGER DEFAULT 0, notes TEXT, created_at TEXT NOT NULL DEFAULT (datetime('now')), updated_at TEXT NOT NULL DEFAULT (datetime('now')), FOREIGN KEY (subject_id) REFERENCES subjects(id), FOREIGN KEY (cycle_item_id) REFERENCES cycle_items(id));
-	`)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	queries := database.New(db)
+	queries := database.New(repository.NewContextDB(db))
 
 	// Services
 	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
 	subjectSvc := service.NewSubjectManager(repository.NewSQLSubjectRepository(queries))
-	sessionSvc := service.NewStudySessionManager(repository.NewSQLStudySessionRepository(queries))
+	sessionSvc := service.NewStudySessionManager(repository.NewSQLStudySessionRepository(queries), repository.NewSQLTxManager(db))
 	sessionHandler := handler.NewStudySessionHandler(sessionSvc)
 
 	ctx := context.Background()
-	_, _ = userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
-	subject, _ := subjectSvc.CreateSubject(ctx, "Math", "#000")
+	user, _ := userSvc.CreateUser(ctx, "test@example.com", "Tester", "pass")
+	subject, _ := subjectSvc.CreateSubject(ctx, user.ID, "Math", "#000", nil)
 
 	// Test Start Session
 	reqBody := handler.CreateStudySessionRequest{
@@ -371,7 +315,7 @@ func TestIntegration_StudySessionFlow(t *testing.T) {
 	}
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest("POST", "/study-sessions", bytes.NewBuffer(body))
-	req.SetBasicAuth("test@example.com", "pass")
+	req = withUser(req, user.ID)
 	rr := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -385,3 +329,1034 @@ func TestIntegration_StudySessionFlow(t *testing.T) {
 	assert.Equal(t, subject.ID, session.SubjectID)
 	assert.NotEmpty(t, session.StartedAt)
 }
+
+func TestIntegration_AccountBackupFlow(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	backupHandler := handler.NewBackupHandler(service.NewBackupManager(repository.NewSQLBackupRepository(db, queries)))
+
+	ctx := context.Background()
+	source, _ := userSvc.CreateUser(ctx, "source@example.com", "Source", "pass")
+	target, _ := userSvc.CreateUser(ctx, "target@example.com", "Target", "pass")
+	for _, stmt := range []string{
+		`INSERT INTO subjects (id, user_id, name, exam_weight) VALUES ('math', '` + source.ID + `', 'Math', 2)`,
+		`INSERT INTO topics (id, subject_id, name) VALUES ('algebra', 'math', 'Algebra')`,
+		`INSERT INTO study_cycles (id, name, is_active) VALUES ('cycle', 'Main', 1)`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index) VALUES ('item', 'cycle', 'math', 0)`,
+		`INSERT INTO study_sessions (id, subject_id, cycle_item_id, started_at, finished_at, net_duration_seconds)
+		 VALUES ('session', 'math', 'item', '2024-03-01T10:00:00Z', '2024-03-01T11:00:00Z', 3300)`,
+		`INSERT INTO session_pauses (id, session_id, started_at, ended_at) VALUES ('pause', 'session', '2024-03-01T10:20:00Z', '2024-03-01T10:25:00Z')`,
+		`INSERT INTO exercise_logs (id, session_id, subject_id, topic_id, questions_count, correct_count, created_at)
+		 VALUES ('log', 'session', 'math', 'algebra', 10, 8, '2024-03-01 11:00:00')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Get("/me/export", backupHandler.ExportAccount)
+	r.Post("/me/import", backupHandler.ImportAccount)
+
+	// 1. Export the source account, zipped
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("GET", "/me/export?format=zip", nil), source.ID))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	archive := rr.Body.Bytes()
+
+	countSubjects := func(userID string) int {
+		var n int
+		db.QueryRow(`SELECT COUNT(*) FROM subjects WHERE user_id = ?`, userID).Scan(&n)
+		return n
+	}
+
+	// 2. A dry run reports everything and keeps nothing
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/me/import?dry_run=true", bytes.NewReader(archive)), target.ID))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var report handler.ImportReportResponse
+	json.NewDecoder(rr.Body).Decode(&report)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, handler.ImportCountsResponse{Subjects: 1, Topics: 1, StudyCycles: 1, CycleItems: 1, StudySessions: 1, SessionPauses: 1, ExerciseLogs: 1}, report.Imported)
+	assert.Equal(t, 0, countSubjects(target.ID))
+
+	// 3. The real import writes new rows, linked to each other
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/me/import", bytes.NewReader(archive)), target.ID))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, 1, countSubjects(target.ID))
+
+	var subjectID, topicName string
+	var netSeconds, pauses int
+	err := db.QueryRow(`
+		SELECT s.id, t.name, ss.net_duration_seconds, (SELECT COUNT(*) FROM session_pauses sp WHERE sp.session_id = ss.id)
+		FROM exercise_logs el
+		JOIN subjects s ON s.id = el.subject_id
+		JOIN topics t ON t.id = el.topic_id
+		JOIN study_sessions ss ON ss.id = el.session_id
+		WHERE s.user_id = ?`, target.ID).Scan(&subjectID, &topicName, &netSeconds, &pauses)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "math", subjectID)
+	assert.Equal(t, "Algebra", topicName)
+	assert.Equal(t, 3300, netSeconds)
+	assert.Equal(t, 1, pauses)
+
+	// Cycles have no owner: the imported copy mustn't be a second active one
+	json.NewDecoder(rr.Body).Decode(&report)
+	assert.Contains(t, report.Warnings, "study cycle cycle: imported inactive")
+	var active []string
+	rows, _ := db.Query(`SELECT id FROM study_cycles WHERE is_active = 1`)
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		active = append(active, id)
+	}
+	rows.Close()
+	assert.Equal(t, []string{"cycle"}, active)
+
+	// 4. Dangling required links reject the archive
+	broken := `{"kind": "my-api/account", "version": 1, "topics": [{"id": "t", "subject_id": "nope", "name": "Orphan"}]}`
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/me/import", strings.NewReader(broken)), target.ID))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), `unknown subject \"nope\"`)
+
+	// 5. Archives from a newer version are refused
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/me/import", strings.NewReader(`{"kind": "my-api/account", "version": 99}`)), target.ID))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestIntegration_AccountDeletionFlow(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	deletionSvc := service.NewAccountDeletionManager(repository.NewSQLAccountDeletionRepository(db, queries))
+	deletionHandler := handler.NewAccountDeletionHandler(deletionSvc)
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "leaving@example.com", "Leaving", "pass")
+	other, _ := userSvc.CreateUser(ctx, "staying@example.com", "Staying", "pass")
+	for _, stmt := range []string{
+		`INSERT INTO subjects (id, user_id, name) VALUES ('math', '` + user.ID + `', 'Math')`,
+		`INSERT INTO subjects (id, user_id, name) VALUES ('bio', '` + other.ID + `', 'Bio')`,
+		`INSERT INTO topics (id, subject_id, name) VALUES ('algebra', 'math', 'Algebra')`,
+		`INSERT INTO study_cycles (id, name, is_active) VALUES ('mine', 'Mine', 1)`,
+		`INSERT INTO study_cycles (id, name, is_active) VALUES ('shared', 'Shared', 0)`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index) VALUES ('i1', 'mine', 'math', 0)`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index) VALUES ('i2', 'shared', 'math', 0)`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index) VALUES ('i3', 'shared', 'bio', 1)`,
+		`INSERT INTO study_sessions (id, subject_id, started_at) VALUES ('session', 'math', '2024-03-01T10:00:00Z')`,
+		`INSERT INTO session_pauses (id, session_id, started_at) VALUES ('pause', 'session', '2024-03-01T10:20:00Z')`,
+		`INSERT INTO exercise_logs (id, session_id, subject_id, questions_count, correct_count) VALUES ('log', 'session', 'math', 10, 8)`,
+		`INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, expires_at) VALUES ('` + user.ID + `', 'k1', 'h', '2999-01-01 00:00:00')`,
+		`INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, expires_at) VALUES ('` + other.ID + `', 'k1', 'h', '2999-01-01 00:00:00')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Delete("/me", deletionHandler.DeleteMe)
+	r.Get("/me/deletion", deletionHandler.GetMyDeletion)
+	r.Post("/me/restore", deletionHandler.RestoreMe)
+	r.Get("/account-deletions/{id}", deletionHandler.GetDeletionReport)
+
+	deleteMe := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(httptest.NewRequest("DELETE", "/me", strings.NewReader(body)), user.ID))
+		return rr
+	}
+
+	// 1. The password is checked again
+	assert.Equal(t, http.StatusUnauthorized, deleteMe(`{"password": "wrong"}`).Code)
+
+	// 2. A scheduled deletion can be restored
+	rr := deleteMe(`{"password": "pass"}`)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var scheduled handler.AccountDeletionResponse
+	json.NewDecoder(rr.Body).Decode(&scheduled)
+	assert.Equal(t, "scheduled", scheduled.Status)
+	assert.True(t, scheduled.PurgeAfter.After(time.Now().Add(6*24*time.Hour)))
+	assert.Equal(t, http.StatusConflict, deleteMe(`{"password": "pass"}`).Code)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/me/restore", nil), user.ID))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("GET", "/me/deletion", nil), user.ID))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// 3. Without a grace period the account is purged at once, and only it
+	rr = deleteMe(`{"password": "pass", "grace_days": 0}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var purged handler.AccountDeletionResponse
+	json.NewDecoder(rr.Body).Decode(&purged)
+	assert.Equal(t, "purged", purged.Status)
+	assert.True(t, purged.Verified)
+	assert.Equal(t, map[string]int64{
+		"exercise_logs": 1, "session_pauses": 1, "study_sessions": 1, "cycle_items": 2, "study_cycles": 1, "topics": 1,
+		"goals": 0, "subjects": 1, "personal_access_tokens": 0, "password_reset_tokens": 0, "recommendation_weights": 0,
+		"idempotency_keys": 1, "users": 1,
+	}, purged.DeletedRows)
+
+	var users, cycles, items, keys int
+	db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&users)
+	db.QueryRow(`SELECT COUNT(*) FROM study_cycles`).Scan(&cycles)
+	db.QueryRow(`SELECT COUNT(*) FROM cycle_items`).Scan(&items)
+	db.QueryRow(`SELECT COUNT(*) FROM idempotency_keys`).Scan(&keys)
+	assert.Equal(t, []int{1, 1, 1, 1}, []int{users, cycles, items, keys})
+
+	// 4. The receipt reads the report after the account is gone
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/account-deletions/"+purged.ID, nil)
+	req.Header.Set(handler.DeletionReceiptHeader, purged.Receipt)
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"verified":true`)
+	assert.NotContains(t, rr.Body.String(), purged.Receipt)
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/account-deletions/"+purged.ID, nil)
+	req.Header.Set(handler.DeletionReceiptHeader, "adr_wrong")
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestIntegration_TrashFlow(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	subjectSvc := service.NewSubjectManager(repository.NewSQLSubjectRepository(queries))
+	topicSvc := service.NewTopicManager(repository.NewSQLTopicRepository(queries))
+	cycleSvc := service.NewStudyCycleManager(repository.NewSQLStudyCycleRepository(queries), repository.NewSQLTxManager(db))
+	trashSvc := service.NewTrashManager(repository.NewSQLTrashRepository(db, queries))
+	trashHandler := handler.NewTrashHandler(trashSvc)
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "trash@example.com", "Trash", "pass")
+	for _, stmt := range []string{
+		`INSERT INTO subjects (id, user_id, name) VALUES ('math', '` + user.ID + `', 'Math')`,
+		`INSERT INTO subjects (id, user_id, name) VALUES ('bio', '` + user.ID + `', 'Bio')`,
+		`INSERT INTO topics (id, subject_id, name) VALUES ('algebra', 'math', 'Algebra')`,
+		`INSERT INTO topics (id, subject_id, name) VALUES ('geometry', 'math', 'Geometry')`,
+		`INSERT INTO study_cycles (id, name, is_active) VALUES ('cycle', 'Main', 1)`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index) VALUES ('math-item', 'cycle', 'math', 0)`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index) VALUES ('bio-item', 'cycle', 'bio', 1)`,
+		`INSERT INTO study_sessions (id, subject_id, cycle_item_id, started_at) VALUES ('math-session', 'math', 'math-item', '2024-03-01T10:00:00Z')`,
+		`INSERT INTO study_sessions (id, subject_id, cycle_item_id, started_at) VALUES ('bio-session', 'bio', 'bio-item', '2024-03-01T12:00:00Z')`,
+		`INSERT INTO exercise_logs (id, session_id, subject_id, topic_id, questions_count, correct_count) VALUES ('math-log', 'math-session', 'math', 'algebra', 10, 8)`,
+		`INSERT INTO exercise_logs (id, subject_id, topic_id, questions_count, correct_count) VALUES ('geometry-log', 'math', 'geometry', 5, 5)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Get("/trash", trashHandler.ListTrash)
+	r.Post("/subjects/{id}/restore", trashHandler.RestoreSubject)
+	r.Post("/topics/{id}/restore", trashHandler.RestoreTopic)
+	r.Post("/study-cycles/{id}/restore", trashHandler.RestoreStudyCycle)
+
+	post := func(path string) int {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", path, nil), user.ID))
+		return rr.Code
+	}
+	count := func(query string) int {
+		var n int
+		if err := db.QueryRow(query).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// 1. A topic deleted on its own, then its subject
+	assert.NoError(t, topicSvc.DeleteTopic(ctx, "geometry"))
+	assert.NoError(t, subjectSvc.DeleteSubject(ctx, "math", user.ID))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("GET", "/trash", nil), user.ID))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var trash []handler.TrashItemResponse
+	json.NewDecoder(rr.Body).Decode(&trash)
+	assert.Len(t, trash, 2)
+	for _, item := range trash {
+		assert.True(t, item.PurgeAfter.After(item.DeletedAt))
+		if item.Type == service.TrashSubject {
+			assert.Equal(t, map[string]int64{"topics": 2, "cycle_items": 1, "study_sessions": 1, "exercise_logs": 2, "goals": 0}, item.Dependents)
+		} else {
+			assert.Equal(t, "math", item.ParentID)
+		}
+	}
+
+	// 2. The topic waits for its subject; the subject comes back without it
+	assert.Equal(t, http.StatusConflict, post("/topics/geometry/restore"))
+	assert.Equal(t, http.StatusNoContent, post("/subjects/math/restore"))
+	assert.Equal(t, http.StatusNotFound, post("/subjects/math/restore"))
+	_, err := topicSvc.GetTopic(ctx, "geometry")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, http.StatusNoContent, post("/topics/geometry/restore"))
+
+	// 3. Items past the retention period are purged with their dependents
+	assert.NoError(t, topicSvc.DeleteTopic(ctx, "geometry"))
+	assert.NoError(t, subjectSvc.DeleteSubject(ctx, "bio", user.ID))
+	assert.NoError(t, cycleSvc.DeleteStudyCycle(ctx, "cycle"))
+	if _, err := db.Exec(`UPDATE subjects SET deleted_at = datetime('now', '-31 days') WHERE id = 'bio'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE topics SET deleted_at = datetime('now', '-31 days') WHERE id = 'geometry'`); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := trashSvc.PurgeExpired(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, 0, count(`SELECT COUNT(*) FROM subjects WHERE id = 'bio'`))
+	assert.Equal(t, 0, count(`SELECT COUNT(*) FROM study_sessions WHERE subject_id = 'bio'`))
+	assert.Equal(t, 0, count(`SELECT COUNT(*) FROM cycle_items WHERE subject_id = 'bio'`))
+	assert.Equal(t, 0, count(`SELECT COUNT(*) FROM topics WHERE id = 'geometry'`))
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM exercise_logs WHERE id = 'geometry-log' AND topic_id IS NULL`))
+
+	// 4. The cycle, still within retention, comes back with its remaining item
+	assert.Equal(t, http.StatusNoContent, post("/study-cycles/cycle/restore"))
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM cycle_items WHERE cycle_id = 'cycle'`))
+
+	// 5. A purged cycle takes its items and unlinks their sessions
+	assert.NoError(t, cycleSvc.DeleteStudyCycle(ctx, "cycle"))
+	purged, err = trashSvc.PurgeExpired(ctx, time.Now().Add(service.DefaultTrashRetention+time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, 0, count(`SELECT COUNT(*) FROM cycle_items`))
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM study_sessions WHERE id = 'math-session' AND cycle_item_id IS NULL`))
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM exercise_logs WHERE id = 'math-log'`))
+}
+
+func TestIntegration_MergeFlow(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	mergeHandler := handler.NewMergeHandler(service.NewMergeManager(repository.NewSQLMergeRepository(db, queries)))
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "merge@example.com", "Merge", "pass")
+	other, _ := userSvc.CreateUser(ctx, "other@example.com", "Other", "pass")
+	for _, stmt := range []string{
+		`INSERT INTO subjects (id, user_id, name) VALUES ('pt', '` + user.ID + `', 'Português')`,
+		`INSERT INTO subjects (id, user_id, name) VALUES ('lp', '` + user.ID + `', 'Língua Portuguesa')`,
+		`INSERT INTO subjects (id, user_id, name) VALUES ('theirs', '` + other.ID + `', 'Theirs')`,
+		`INSERT INTO topics (id, subject_id, name) VALUES ('crase', 'pt', 'Crase')`,
+		`INSERT INTO topics (id, subject_id, name) VALUES ('crase-2', 'lp', 'Uso da crase')`,
+		`INSERT INTO study_cycles (id, name) VALUES ('cycle', 'Main')`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index) VALUES ('item', 'cycle', 'lp', 0)`,
+		`INSERT INTO study_sessions (id, subject_id, cycle_item_id, started_at) VALUES ('session', 'lp', 'item', '2024-03-01T10:00:00Z')`,
+		`INSERT INTO exercise_logs (id, session_id, subject_id, topic_id, questions_count, correct_count) VALUES ('log', 'session', 'lp', 'crase-2', 10, 7)`,
+		`INSERT INTO goals (id, user_id, subject_id, metric, period, target) VALUES ('goal', '` + user.ID + `', 'lp', 'questions', 'week', 50)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Post("/subjects/{id}/merge", mergeHandler.MergeSubjects)
+	r.Post("/topics/{id}/merge", mergeHandler.MergeTopics)
+
+	merge := func(path, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", path, strings.NewReader(`{"target_id": "`+target+`"}`)), user.ID))
+		return rr
+	}
+	count := func(query string) int {
+		var n int
+		if err := db.QueryRow(query).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	moved := map[string]int64{"topics": 1, "cycle_items": 1, "study_sessions": 1, "exercise_logs": 1, "goals": 1}
+
+	// 1. Topics of different subjects wait for their subjects' merge
+	assert.Equal(t, http.StatusUnprocessableEntity, merge("/topics/crase-2/merge", "crase").Code)
+	assert.Equal(t, http.StatusNotFound, merge("/subjects/lp/merge", "theirs").Code)
+	assert.Equal(t, http.StatusBadRequest, merge("/subjects/lp/merge", "lp").Code)
+
+	// 2. A dry run reports the counts and changes nothing
+	rr := merge("/subjects/lp/merge?dry_run=true", "pt")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var report handler.MergeReportResponse
+	json.NewDecoder(rr.Body).Decode(&report)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, moved, report.Moved)
+	assert.Equal(t, 0, count(`SELECT COUNT(*) FROM subjects WHERE deleted_at IS NOT NULL`))
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM study_sessions WHERE subject_id = 'lp'`))
+
+	// 3. The merge moves everything and trashes the source
+	rr = merge("/subjects/lp/merge", "pt")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&report)
+	assert.False(t, report.DryRun)
+	assert.Equal(t, moved, report.Moved)
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM subjects WHERE id = 'lp' AND deleted_at IS NOT NULL`))
+	for _, table := range []string{"topics", "cycle_items", "study_sessions", "exercise_logs", "goals"} {
+		assert.Equal(t, 0, count(`SELECT COUNT(*) FROM `+table+` WHERE subject_id = 'lp'`), table)
+	}
+
+	// 4. Now under one subject, the duplicate topic folds into the other
+	rr = merge("/topics/crase-2/merge", "crase")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var topicReport handler.MergeReportResponse
+	json.NewDecoder(rr.Body).Decode(&topicReport)
+	assert.Equal(t, map[string]int64{"exercise_logs": 1}, topicReport.Moved)
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM exercise_logs WHERE topic_id = 'crase' AND subject_id = 'pt'`))
+	assert.Equal(t, 1, count(`SELECT COUNT(*) FROM topics WHERE id = 'crase-2' AND deleted_at IS NOT NULL`))
+}
+
+func TestIntegration_ConditionalRequests(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	subjectHandler := handler.NewSubjectHandler(service.NewSubjectManager(repository.NewSQLSubjectRepository(queries)))
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "etag@example.com", "ETag", "pass")
+	if _, err := db.Exec(`INSERT INTO subjects (id, user_id, name) VALUES ('math', '` + user.ID + `', 'Math')`); err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/subjects/{id}", subjectHandler.GetSubject)
+	r.Put("/subjects/{id}", subjectHandler.UpdateSubject)
+	r.With(customMiddleware.RequireIfMatch(true)).Put("/strict/subjects/{id}", subjectHandler.UpdateSubject)
+
+	do := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		body := strings.NewReader("")
+		if method == "PUT" {
+			body = strings.NewReader(`{"name": "Mathematics"}`)
+		}
+		req := httptest.NewRequest(method, path, body)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(req, user.ID))
+		return rr
+	}
+
+	// 1. Reads carry the row version, and a matching copy isn't sent again
+	rr := do("GET", "/subjects/math", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, do("GET", "/subjects/math", map[string]string{"If-None-Match": `"1"`}).Code)
+
+	// 2. Two devices edit the same version: the second one loses
+	rr = do("PUT", "/subjects/math", map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+	assert.Equal(t, http.StatusPreconditionFailed, do("PUT", "/subjects/math", map[string]string{"If-Match": `"1"`}).Code)
+	assert.Equal(t, http.StatusOK, do("GET", "/subjects/math", map[string]string{"If-None-Match": `"1"`}).Code)
+
+	// 3. Weak or listed tags can't name a row version
+	assert.Equal(t, http.StatusPreconditionFailed, do("PUT", "/subjects/math", map[string]string{"If-Match": `W/"2"`}).Code)
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/subjects/math", map[string]string{"If-Match": `"1", "2"`}).Code)
+	assert.Equal(t, http.StatusNotFound, do("PUT", "/subjects/nope", map[string]string{"If-Match": `"1"`}).Code)
+
+	// 4. Without If-Match the update applies, unless the server requires it
+	assert.Equal(t, http.StatusOK, do("PUT", "/subjects/math", nil).Code)
+	assert.Equal(t, http.StatusPreconditionRequired, do("PUT", "/strict/subjects/math", nil).Code)
+	assert.Equal(t, http.StatusOK, do("PUT", "/strict/subjects/math", map[string]string{"If-Match": `"3"`}).Code)
+}
+
+func TestIntegration_MergePatch(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	cycleHandler := handler.NewStudyCycleHandler(service.NewStudyCycleManager(repository.NewSQLStudyCycleRepository(queries), repository.NewSQLTxManager(db)))
+	itemHandler := handler.NewCycleItemHandler(service.NewCycleItemManager(repository.NewSQLCycleItemRepository(queries)))
+
+	user, _ := service.NewUserManager(repository.NewSQLUserRepository(queries)).CreateUser(context.Background(), "patch@example.com", "Patch", "pass")
+	for _, stmt := range []string{
+		`INSERT INTO study_cycles (id, name, description, is_active) VALUES ('cycle', 'Main', 'Before the exam', 1)`,
+		`INSERT INTO subjects (id, user_id, name) VALUES ('math', '` + user.ID + `', 'Math')`,
+		`INSERT INTO cycle_items (id, cycle_id, subject_id, order_index, planned_duration_minutes) VALUES ('item', 'cycle', 'math', 1, 45)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Patch("/study-cycles/{id}", cycleHandler.PatchStudyCycle)
+	r.Patch("/cycle-items/{id}", itemHandler.PatchCycleItem)
+
+	patch := func(path, body, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 1. Clearing the description leaves the other members alone
+	rr := patch("/study-cycles/cycle", `{"description": null}`, handler.MergePatchContentType)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+	var cycle database.StudyCycle
+	json.NewDecoder(rr.Body).Decode(&cycle)
+	assert.Equal(t, "Main", cycle.Name)
+	assert.False(t, cycle.Description.Valid)
+	assert.Equal(t, sql.NullInt64{Int64: 1, Valid: true}, cycle.IsActive)
+
+	// 2. Zero and null are different values
+	var item database.CycleItem
+	rr = patch("/cycle-items/item", `{"planned_duration_minutes": 0}`, "application/json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	json.NewDecoder(rr.Body).Decode(&item)
+	assert.Equal(t, sql.NullInt64{Int64: 0, Valid: true}, item.PlannedDurationMinutes)
+	assert.Equal(t, int64(1), item.OrderIndex)
+
+	rr = patch("/cycle-items/item", `{"planned_duration_minutes": null}`, handler.MergePatchContentType)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	item = database.CycleItem{}
+	json.NewDecoder(rr.Body).Decode(&item)
+	assert.False(t, item.PlannedDurationMinutes.Valid)
+
+	// 3. An empty patch changes nothing, not even the version
+	rr = patch("/cycle-items/item", `{}`, handler.MergePatchContentType)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+
+	// 4. Required members can't be nulled, values are validated and unknown ones refused
+	assert.Equal(t, http.StatusBadRequest, patch("/cycle-items/item", `{"order_index": null}`, handler.MergePatchContentType).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("/cycle-items/item", `{"order_index": 0}`, handler.MergePatchContentType).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("/cycle-items/item", `{"order": 2}`, handler.MergePatchContentType).Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, patch("/cycle-items/item", `{}`, "text/plain").Code)
+	assert.Equal(t, http.StatusNotFound, patch("/cycle-items/nope", `{"order_index": 2}`, handler.MergePatchContentType).Code)
+}
+
+func TestIntegration_IdempotencyKeys(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	subjectSvc := service.NewSubjectManager(repository.NewSQLSubjectRepository(queries))
+	sessionHandler := handler.NewStudySessionHandler(service.NewStudySessionManager(repository.NewSQLStudySessionRepository(queries), repository.NewSQLTxManager(db)))
+	idempotencySvc := service.NewIdempotencyManager(repository.NewSQLIdempotencyRepository(queries)).WithTTL(time.Hour)
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "retry@example.com", "Retry", "pass")
+	other, _ := userSvc.CreateUser(ctx, "other@example.com", "Other", "pass")
+	subject, _ := subjectSvc.CreateSubject(ctx, user.ID, "Math", "", nil)
+
+	r := chi.NewRouter()
+	r.With(customMiddleware.NewIdempotencyMiddleware(idempotencySvc).Handle).Post("/study-sessions", sessionHandler.CreateStudySession)
+
+	post := func(userID, key, startedAt string) *httptest.ResponseRecorder {
+		body := `{"subject_id": "` + subject.ID + `", "started_at": "` + startedAt + `"}`
+		req := withUser(httptest.NewRequest("POST", "/study-sessions", strings.NewReader(body)), userID)
+		if key != "" {
+			req.Header.Set(customMiddleware.IdempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	sessions := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM study_sessions`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// 1. A retry replays the first response instead of creating another session
+	first := post(user.ID, "abc", "2024-03-01T10:00:00Z")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	retry := post(user.ID, "abc", "2024-03-01T10:00:00Z")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(customMiddleware.IdempotentReplayedHeader))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, 1, sessions())
+
+	// 2. The same key with another body is refused; keys are per user
+	assert.Equal(t, http.StatusUnprocessableEntity, post(user.ID, "abc", "2024-03-02T10:00:00Z").Code)
+	assert.Equal(t, http.StatusCreated, post(other.ID, "abc", "2024-03-01T10:00:00Z").Code)
+	assert.Equal(t, 2, sessions())
+
+	// 3. Without a key every request runs
+	post(user.ID, "", "2024-03-01T10:00:00Z")
+	assert.Equal(t, 3, sessions())
+
+	// 4. Once the TTL ends the key can be used again, and the purge drops it
+	if _, err := db.Exec(`UPDATE idempotency_keys SET expires_at = datetime('now', '-1 minute')`); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, post(user.ID, "abc", "2024-03-02T10:00:00Z").Header().Get(customMiddleware.IdempotentReplayedHeader))
+	assert.Equal(t, 4, sessions())
+	purged, err := idempotencySvc.PurgeExpired(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestIntegration_SyncFlow(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	subjectSvc := service.NewSubjectManager(repository.NewSQLSubjectRepository(queries))
+	syncSvc := service.NewSyncManager(repository.NewSQLSyncRepository(db, queries))
+	syncHandler := handler.NewSyncHandler(syncSvc)
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "offline@example.com", "Offline", "pass")
+	other, _ := userSvc.CreateUser(ctx, "other@example.com", "Other", "pass")
+	subject, _ := subjectSvc.CreateSubject(ctx, user.ID, "Math", "", nil)
+	otherSubject, _ := subjectSvc.CreateSubject(ctx, other.ID, "History", "", nil)
+
+	r := chi.NewRouter()
+	r.Get("/sync/changes", syncHandler.Changes)
+	r.Post("/sync/push", syncHandler.Push)
+
+	pull := func(userID, query string) handler.SyncChangesResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(httptest.NewRequest("GET", "/sync/changes"+query, nil), userID))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("pull %s: %d %s", query, rr.Code, rr.Body.String())
+		}
+		var page handler.SyncChangesResponse
+		json.NewDecoder(rr.Body).Decode(&page)
+		return page
+	}
+	push := func(since, mutations string) []handler.SyncResultResponse {
+		t.Helper()
+		body := `{"since": "` + since + `", "mutations": ` + mutations + `}`
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/sync/push", strings.NewReader(body)), user.ID))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("push: %d %s", rr.Code, rr.Body.String())
+		}
+		var resp handler.SyncPushResponse
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return resp.Results
+	}
+	statuses := func(results []handler.SyncResultResponse) []string {
+		var out []string
+		for _, result := range results {
+			out = append(out, result.Status)
+		}
+		return out
+	}
+
+	// 1. A full sync starts without a cursor and only sees the user's rows
+	page := pull(user.ID, "")
+	if assert.Len(t, page.Changes, 1) {
+		assert.Equal(t, "subject", page.Changes[0].Entity)
+		assert.Equal(t, subject.ID, page.Changes[0].ID)
+		assert.Equal(t, "upsert", page.Changes[0].Op)
+		assert.JSONEq(t, `"Math"`, string(mustField(t, page.Changes[0].Data, "name")))
+	}
+	cursor := page.Cursor
+
+	// 2. Rows created offline keep their client IDs; another user's subject can't be written to
+	topicID, sessionID, logID := "0b6f3e4a-8f5d-4d1e-9b55-0c8f9a1d2e01", "0b6f3e4a-8f5d-4d1e-9b55-0c8f9a1d2e02", "0b6f3e4a-8f5d-4d1e-9b55-0c8f9a1d2e03"
+	results := push(cursor, `[
+		{"entity": "topic", "op": "upsert", "id": "`+topicID+`", "data": {"subject_id": "`+subject.ID+`", "name": "Algebra"}},
+		{"entity": "study_session", "op": "upsert", "id": "`+sessionID+`", "data": {"subject_id": "`+subject.ID+`", "started_at": "2024-03-01T10:00:00Z", "finished_at": "2024-03-01T11:00:00Z", "gross_duration_seconds": 3600, "net_duration_seconds": 3000}},
+		{"entity": "exercise_log", "op": "upsert", "id": "`+logID+`", "data": {"session_id": "`+sessionID+`", "subject_id": "`+subject.ID+`", "topic_id": "`+topicID+`", "questions_count": 10, "correct_count": 7}},
+		{"entity": "subject", "op": "upsert", "id": "`+otherSubject.ID+`", "data": {"name": "Mine now"}},
+		{"entity": "exercise_log", "op": "upsert", "id": "0b6f3e4a-8f5d-4d1e-9b55-0c8f9a1d2e04", "data": {"subject_id": "`+subject.ID+`", "questions_count": 1, "correct_count": 2}},
+		{"entity": "goal", "op": "delete", "id": "0b6f3e4a-8f5d-4d1e-9b55-0c8f9a1d2e05"}
+	]`)
+	assert.Equal(t, []string{"applied", "applied", "applied", "rejected", "rejected", "rejected"}, statuses(results))
+	otherName, _ := subjectSvc.GetSubject(ctx, otherSubject.ID, other.ID)
+	assert.Equal(t, "History", otherName.Name)
+
+	// 3. The next pull returns them in commit order
+	page = pull(user.ID, "?since="+cursor)
+	var entities []string
+	for _, change := range page.Changes {
+		entities = append(entities, change.Entity)
+	}
+	assert.Equal(t, []string{"topic", "study_session", "exercise_log"}, entities)
+	cursor = page.Cursor
+
+	// 4. A row changed on the server after the cursor is a conflict, answered with the server's version
+	if _, err := subjectSvc.UpdateSubject(ctx, subject.ID, user.ID, "Mathematics", "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	results = push(cursor, `[{"entity": "subject", "op": "upsert", "id": "`+subject.ID+`", "data": {"name": "Maths"}}]`)
+	if assert.Equal(t, []string{"conflict"}, statuses(results)) && assert.NotNil(t, results[0].Current) {
+		assert.JSONEq(t, `"Mathematics"`, string(mustField(t, results[0].Current.Data, "name")))
+	}
+
+	// 5. Soft and hard deletes both leave tombstones
+	cursor = pull(user.ID, "?since="+cursor).Cursor
+	assert.Equal(t, []string{"applied"}, statuses(push(cursor, `[{"entity": "topic", "op": "delete", "id": "`+topicID+`"}]`)))
+	if _, err := db.Exec(`DELETE FROM exercise_logs WHERE id = ?`, logID); err != nil {
+		t.Fatal(err)
+	}
+	page = pull(user.ID, "?since="+cursor)
+	if assert.Len(t, page.Changes, 2) {
+		for _, change := range page.Changes {
+			assert.Equal(t, "delete", change.Op)
+			assert.Empty(t, change.Data)
+		}
+	}
+
+	// 6. Pages follow the cursor; the other user's feed has only their subject
+	first := pull(user.ID, "?limit=2")
+	assert.True(t, first.HasMore)
+	assert.Len(t, first.Changes, 2)
+	rest := pull(user.ID, "?since="+first.Cursor)
+	assert.False(t, rest.HasMore)
+	assert.Len(t, rest.Changes, 2) // Subject, session and two tombstones in all
+	assert.Len(t, pull(other.ID, "").Changes, 1)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, withUser(httptest.NewRequest("GET", "/sync/changes?since=abc", nil), user.ID))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// 7. Compaction keeps recent changes, then only the last change of every row; the feed reads the same
+	changes := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM sync_changes`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	full, before := pull(user.ID, ""), changes()
+	compacted, err := syncSvc.CompactChanges(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, compacted)
+	compacted, err = syncSvc.CompactChanges(ctx, time.Now().Add(service.DefaultSyncRetention+time.Hour))
+	assert.NoError(t, err)
+	assert.Positive(t, compacted)
+	assert.Equal(t, before-compacted, changes())
+	assert.Equal(t, 5, changes()) // The user's four rows and the other user's subject
+	assert.Equal(t, full, pull(user.ID, ""))
+	assert.Equal(t, full.Changes[1:], pull(user.ID, fmt.Sprintf("?since=%d", full.Changes[0].Seq)).Changes)
+}
+
+func TestIntegration_BatchFlow(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	subjectSvc := service.NewSubjectManager(repository.NewSQLSubjectRepository(queries))
+	topicHandler := handler.NewTopicHandler(service.NewTopicManager(repository.NewSQLTopicRepository(queries)))
+	logHandler := handler.NewExerciseLogHandler(service.NewExerciseLogManager(repository.NewSQLExerciseLogRepository(queries)))
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "batch@example.com", "Batch", "pass")
+	subject, _ := subjectSvc.CreateSubject(ctx, user.ID, "Math", "", nil)
+
+	r := chi.NewRouter()
+	r.Post("/subjects/{id}/topics", topicHandler.CreateTopic)
+	r.Post("/exercise-logs", logHandler.CreateExerciseLog)
+	r.Post("/batch", handler.NewBatchHandler(r, repository.NewSQLTxManager(db)).Batch)
+
+	batch := func(body string) (int, handler.BatchResponse) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/batch", strings.NewReader(body)), user.ID))
+		var resp handler.BatchResponse
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return rr.Code, resp
+	}
+	logOp := func(subjectID string, questions, correct int) string {
+		return fmt.Sprintf(`{"method": "POST", "path": "/exercise-logs", "body": {"subject_id": %q, "questions_count": %d, "correct_count": %d}}`,
+			subjectID, questions, correct)
+	}
+	logs := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM exercise_logs`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// 1. A day of questions is logged in one request, each answered like its own endpoint
+	code, resp := batch(`{"operations": [
+		{"method": "POST", "path": "/subjects/` + subject.ID + `/topics", "body": {"name": "Algebra"}},
+		` + logOp(subject.ID, 10, 7) + `,
+		` + logOp(subject.ID, 20, 15) + `
+	]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, resp.Committed)
+	if assert.Len(t, resp.Results, 3) {
+		for _, result := range resp.Results {
+			assert.Equal(t, http.StatusCreated, result.Status)
+		}
+		assert.JSONEq(t, `"Algebra"`, string(mustField(t, resp.Results[0].Body, "name")))
+	}
+	assert.Equal(t, 2, logs())
+
+	// 2. An invalid operation fails the batch and rolls back the ones before it
+	code, resp = batch(`{"operations": [
+		` + logOp(subject.ID, 5, 5) + `,
+		{"method": "POST", "path": "/subjects/` + subject.ID + `/topics", "body": {"name": "X"}},
+		` + logOp(subject.ID, 5, 5) + `
+	]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, resp.Committed)
+	if assert.Len(t, resp.Results, 2) {
+		assert.Equal(t, http.StatusCreated, resp.Results[0].Status)
+		assert.Equal(t, http.StatusBadRequest, resp.Results[1].Status)
+	}
+	assert.Equal(t, 2, logs())
+
+	// 3. With continue_on_error the valid operations are kept
+	code, resp = batch(`{"continue_on_error": true, "operations": [` + logOp(subject.ID, 0, 0) + `, ` + logOp(subject.ID, 4, 3) + `]}`)
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, resp.Results, 2) {
+		assert.Equal(t, http.StatusBadRequest, resp.Results[0].Status)
+		assert.Equal(t, http.StatusCreated, resp.Results[1].Status)
+	}
+	assert.Equal(t, 3, logs())
+
+	// 4. Routes outside the batchable writes, /batch included, are refused up front
+	code, _ = batch(`{"operations": [{"method": "POST", "path": "/subjects/` + subject.ID + `/merge", "body": {}}]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = batch(`{"operations": [{"method": "POST", "path": "/batch", "body": {}}]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = batch(`{"operations": []}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestIntegration_UnitOfWork(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	txManager := repository.NewSQLTxManager(db).WithRetries(3, time.Millisecond)
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	subjectSvc := service.NewSubjectManager(repository.NewSQLSubjectRepository(queries))
+	sessionSvc := service.NewStudySessionManager(repository.NewSQLStudySessionRepository(queries), txManager)
+	pauseSvc := service.NewSessionPauseManager(repository.NewSQLSessionPauseRepository(queries))
+	cycleHandler := handler.NewStudyCycleHandler(service.NewStudyCycleManager(repository.NewSQLStudyCycleRepository(queries), txManager))
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "unit@example.com", "Unit", "pass")
+	subject, _ := subjectSvc.CreateSubject(ctx, user.ID, "Math", "", nil)
+	count := func(table string) int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// 1. Stopping a session ends the pause left open
+	session, _ := sessionSvc.CreateStudySession(ctx, subject.ID, "", "2024-03-01T10:00:00Z")
+	pause, _ := pauseSvc.CreateSessionPause(ctx, session.ID, "2024-03-01T10:30:00Z")
+	if err := sessionSvc.UpdateSessionDuration(ctx, session.ID, "2024-03-01T11:00:00Z", 3600, 3000, ""); err != nil {
+		t.Fatal(err)
+	}
+	pause, _ = pauseSvc.GetSessionPause(ctx, pause.ID)
+	assert.Equal(t, "2024-03-01T11:00:00Z", pause.EndedAt.String)
+
+	// 2. A cycle is created with its items, or not at all
+	r := chi.NewRouter()
+	r.Post("/study-cycles", cycleHandler.CreateStudyCycle)
+	create := func(body string) int {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, withUser(httptest.NewRequest("POST", "/study-cycles", strings.NewReader(body)), user.ID))
+		return rr.Code
+	}
+	assert.Equal(t, http.StatusCreated, create(`{"name": "Week", "items": [
+		{"subject_id": "`+subject.ID+`", "order_index": 1, "planned_duration_minutes": 50},
+		{"subject_id": "`+subject.ID+`", "order_index": 2}
+	]}`))
+	assert.Equal(t, 1, count("study_cycles"))
+	assert.Equal(t, 2, count("cycle_items"))
+	assert.Equal(t, http.StatusInternalServerError, create(`{"name": "Broken", "items": [
+		{"subject_id": "`+subject.ID+`", "order_index": 1},
+		{"subject_id": "no-such-subject", "order_index": 2}
+	]}`))
+	assert.Equal(t, 1, count("study_cycles"))
+	assert.Equal(t, 2, count("cycle_items"))
+
+	// 3. Nested calls join the outer transaction, which rolls back all of it
+	errAbort := errors.New("abort")
+	err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := txManager.WithinTx(ctx, func(ctx context.Context) error {
+			_, err := subjectSvc.CreateSubject(ctx, user.ID, "History", "", nil)
+			return err
+		}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+	assert.Equal(t, 1, count("subjects"))
+
+	// 4. A busy database is retried, up to the attempts allowed; other errors aren't
+	attempts := 0
+	err = txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if attempts++; attempts < 3 {
+			return errors.New("database is locked (5) (SQLITE_BUSY)")
+		}
+		_, err := subjectSvc.CreateSubject(ctx, user.ID, "History", "", nil)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, count("subjects"))
+
+	attempts = 0
+	err = txManager.WithinTx(ctx, func(ctx context.Context) error {
+		attempts++
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+	assert.Equal(t, 1, attempts)
+
+	// 5. So is the transaction of a repository's own Tx
+	attempts = 0
+	err = repository.NewSQLMergeRepository(db, queries).Tx(ctx, func(repository.MergeRepository) error {
+		if attempts++; attempts < 3 {
+			return errors.New("database is locked (5) (SQLITE_BUSY)")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+// mustField reads one member of a JSON object
+func mustField(t *testing.T, data json.RawMessage, name string) json.RawMessage {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	return fields[name]
+}
+
+func TestIntegration_TokenScopeEscalation(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	tokenSvc := service.NewPersonalAccessTokenManager(repository.NewSQLPersonalAccessTokenRepository(queries))
+	tokenHandler := handler.NewTokenHandler(tokenSvc)
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "tokens@example.com", "Tokens", "pass")
+	_, plaintext, err := tokenSvc.CreateToken(ctx, user.ID, "ci", []string{"tokens:write", "goals:read"}, time.Time{})
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(customMiddleware.NewJWTAuthMiddleware(&config.Config{}, tokenSvc).Protected, customMiddleware.RequireScope("tokens"))
+	r.Post("/tokens", tokenHandler.CreateToken)
+
+	create := func(scopes string) int {
+		req := httptest.NewRequest("POST", "/tokens", strings.NewReader(`{"name": "minted", "scopes": `+scopes+`}`))
+		req.Header.Set("Authorization", "Bearer "+plaintext)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Nothing wider than the token itself
+	assert.Equal(t, http.StatusForbidden, create(`["all"]`))
+	assert.Equal(t, http.StatusForbidden, create(`["read"]`))
+	assert.Equal(t, http.StatusForbidden, create(`["goals:write"]`))
+	assert.Equal(t, http.StatusForbidden, create(`["tokens:write", "subjects:read"]`))
+
+	assert.Equal(t, http.StatusCreated, create(`["goals:read", "tokens:read"]`))
+}
+
+func TestIntegration_LoginLockout(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries)).
+		WithLockoutPolicy(service.LockoutPolicy{Threshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour})
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "locked@example.com", "Locked", "pass")
+
+	// Failures are counted in the database, one per attempt
+	for i := 0; i < 2; i++ {
+		_, err := userSvc.Authenticate(ctx, user.Email, "wrong")
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	}
+	_, err := userSvc.Authenticate(ctx, user.Email, "wrong")
+	assert.ErrorIs(t, err, service.ErrAccountLocked)
+
+	var attempts int
+	var lockedUntil sql.NullTime
+	db.QueryRow(`SELECT failed_login_attempts, locked_until FROM users WHERE id = ?`, user.ID).Scan(&attempts, &lockedUntil)
+	assert.Equal(t, 3, attempts)
+	assert.True(t, lockedUntil.Valid && lockedUntil.Time.After(time.Now()))
+
+	// A locked account refuses even the right password
+	_, err = userSvc.Authenticate(ctx, user.Email, "pass")
+	assert.ErrorIs(t, err, service.ErrAccountLocked)
+}
+
+func TestIntegration_SessionAfterPurge(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	queries := database.New(repository.NewContextDB(db))
+	userSvc := service.NewUserManager(repository.NewSQLUserRepository(queries))
+	deletionSvc := service.NewAccountDeletionManager(repository.NewSQLAccountDeletionRepository(db, queries))
+	subjectHandler := handler.NewSubjectHandler(service.NewSubjectManager(repository.NewSQLSubjectRepository(queries)))
+	cfg := &config.Config{JWTSecret: "test-secret"}
+
+	ctx := context.Background()
+	user, _ := userSvc.CreateUser(ctx, "gone@example.com", "Gone", "pass")
+	session, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": user.ID, "email": user.Email, "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(cfg.JWTSecret))
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(customMiddleware.NewJWTAuthMiddleware(cfg, nil).WithUsers(userSvc).Protected)
+	r.Post("/subjects", subjectHandler.CreateSubject)
+
+	createSubject := func() int {
+		req := httptest.NewRequest("POST", "/subjects", strings.NewReader(`{"name": "Math"}`))
+		req.Header.Set("Authorization", "Bearer "+session)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, createSubject())
+
+	grace := time.Duration(0)
+	deletion, err := deletionSvc.RequestDeletion(ctx, user.ID, "pass", &grace)
+	assert.NoError(t, err)
+	assert.True(t, deletion.Verified)
+
+	// The JWT hasn't expired, but its account is gone: nothing is written for it
+	assert.Equal(t, http.StatusUnauthorized, createSubject())
+	var subjects int
+	db.QueryRow(`SELECT COUNT(*) FROM subjects`).Scan(&subjects)
+	assert.Equal(t, 0, subjects)
+}